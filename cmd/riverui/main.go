@@ -1,8 +1,9 @@
 package main
 
 import (
+	"database/sql"
+
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/riverqueue/river"
 	"github.com/riverqueue/river/riverdriver/riverpgxv5"
@@ -12,13 +13,40 @@ import (
 	"riverqueue.com/riverui/uiendpoints"
 )
 
+// client is a River client for DATABASE_URL, which is backed by either
+// Postgres or SQLite. Exactly one of its fields is set.
+type client struct {
+	pgx    *river.Client[pgx.Tx]
+	sqlite *river.Client[*sql.Tx]
+}
+
 func main() {
 	riveruicmd.Run(
-		func(dbPool *pgxpool.Pool, opts *riveruicmd.ClientOpts) (*river.Client[pgx.Tx], error) {
-			return river.NewClient(riverpgxv5.New(dbPool), &river.Config{Schema: opts.Schema})
+		func(opts *riveruicmd.ClientOpts) (*client, error) {
+			if opts.SQLiteDB != nil {
+				sqliteClient, err := newSQLiteClient(opts.SQLiteDB, opts.Schema)
+				if err != nil {
+					return nil, err
+				}
+				return &client{sqlite: sqliteClient}, nil
+			}
+
+			pgxClient, err := river.NewClient(riverpgxv5.New(opts.DBPool), &river.Config{Schema: opts.Schema})
+			if err != nil {
+				return nil, err
+			}
+			return &client{pgx: pgxClient}, nil
 		},
-		func(client *river.Client[pgx.Tx]) uiendpoints.Bundle {
-			return riverui.NewEndpoints(client, nil)
+		func(client *client, opts *riveruicmd.BundleOpts) uiendpoints.Bundle {
+			if client.sqlite != nil {
+				return riverui.NewEndpoints(client.sqlite, nil)
+			}
+
+			endpointsOpts := &riverui.EndpointsOpts[pgx.Tx]{KindRegistry: opts.KindRegistry}
+			if opts.ReplicaDBPool != nil {
+				endpointsOpts.ReplicaExecutor = riverpgxv5.New(opts.ReplicaDBPool).GetExecutor()
+			}
+			return riverui.NewEndpoints(client.pgx, endpointsOpts)
 		},
 	)
 }
@@ -0,0 +1,16 @@
+//go:build sqlite
+
+package main
+
+import (
+	"database/sql"
+
+	_ "modernc.org/sqlite" // registers the `sqlite` database/sql driver
+
+	"github.com/riverqueue/river"
+	"github.com/riverqueue/river/riverdriver/riversqlite"
+)
+
+func newSQLiteClient(db *sql.DB, schema string) (*river.Client[*sql.Tx], error) {
+	return river.NewClient(riversqlite.New(db), &river.Config{Schema: schema})
+}
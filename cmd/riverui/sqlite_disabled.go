@@ -0,0 +1,18 @@
+//go:build !sqlite
+
+package main
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/riverqueue/river"
+)
+
+// SQLite support needs a cgo-free SQLite driver that considerably increases
+// the size of the binary, so it's only included when building with `-tags
+// sqlite`. Without it, riveruicmd rejects `sqlite://` URLs before getting
+// here because no `sqlite` database/sql driver is registered.
+func newSQLiteClient(_ *sql.DB, _ string) (*river.Client[*sql.Tx], error) {
+	return nil, errors.New("this build of riverui doesn't support SQLite databases; build it with `-tags sqlite`")
+}
@@ -0,0 +1,113 @@
+package riverui
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"riverqueue.com/riverui/uiauth"
+)
+
+const (
+	csrfCookieName = "river_csrf"
+	csrfHeaderName = "X-CSRF-Token"
+	csrfTokenBytes = 32
+)
+
+// csrfProtection protects state changing requests from cross-site request
+// forgery, which is possible whenever browsers attach credentials to requests
+// automatically, like with cookie sessions from an authenticating proxy or
+// cached basic auth credentials. Two checks are made:
+//
+//   - Requests from browsers are rejected if their `Sec-Fetch-Site` or
+//     `Origin` headers show that they're cross origin, unless the origin is
+//     explicitly trusted. Requests from non-browser clients carry neither
+//     header and pass this check.
+//
+//   - Requests that carry the CSRF cookie (meaning they're from a browser that
+//     loaded the UI) must also carry a matching `X-CSRF-Token` header. The
+//     token is handed to the frontend in the index page's config, and the
+//     cookie is `SameSite=Strict` so it's never sent cross site (double
+//     submit).
+type csrfProtection struct {
+	cookiePath  string
+	crossOrigin *http.CrossOriginProtection
+	logger      *slog.Logger
+}
+
+func newCSRFProtection(logger *slog.Logger, pathPrefix string, trustedOrigins []string) (*csrfProtection, error) {
+	crossOrigin := http.NewCrossOriginProtection()
+	for _, origin := range trustedOrigins {
+		if err := crossOrigin.AddTrustedOrigin(origin); err != nil {
+			return nil, fmt.Errorf("invalid CSRF trusted origin: %w", err)
+		}
+	}
+
+	cookiePath := pathPrefix
+	if cookiePath == "" {
+		cookiePath = "/"
+	}
+
+	return &csrfProtection{
+		cookiePath:  cookiePath,
+		crossOrigin: crossOrigin,
+		logger:      logger,
+	}, nil
+}
+
+func (p *csrfProtection) Middleware(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		if err := p.crossOrigin.Check(r); err != nil {
+			uiauth.NewForbiddenf("Cross-origin request rejected.").Write(r.Context(), p.logger, w)
+			return
+		}
+
+		if cookie, err := r.Cookie(csrfCookieName); err == nil {
+			if subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(r.Header.Get(csrfHeaderName))) != 1 {
+				uiauth.NewForbiddenf("Missing or invalid CSRF token. Reload the page and try again.").Write(r.Context(), p.logger, w)
+				return
+			}
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// ensureToken returns the CSRF token from the request's cookie, or generates
+// a new one and sets it as a cookie if there isn't one.
+func (p *csrfProtection) ensureToken(w http.ResponseWriter, r *http.Request) (string, error) {
+	if cookie, err := r.Cookie(csrfCookieName); err == nil && isValidCSRFToken(cookie.Value) {
+		return cookie.Value, nil
+	}
+
+	tokenBytes := make([]byte, csrfTokenBytes)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", fmt.Errorf("error generating CSRF token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+
+	http.SetCookie(w, &http.Cookie{
+		HttpOnly: true,
+		Name:     csrfCookieName,
+		Path:     p.cookiePath,
+		SameSite: http.SameSiteStrictMode,
+		Secure:   r.TLS != nil,
+		Value:    token,
+	})
+
+	return token, nil
+}
+
+func isValidCSRFToken(token string) bool {
+	decoded, err := base64.RawURLEncoding.DecodeString(token)
+	return err == nil && len(decoded) == csrfTokenBytes
+}
@@ -0,0 +1,107 @@
+package riverui
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSRFProtection(t *testing.T) {
+	t.Parallel()
+
+	const token = "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+
+	setup := func(t *testing.T) http.Handler {
+		t.Helper()
+
+		csrf, err := newCSRFProtection(slog.New(slog.DiscardHandler), "", []string{"https://trusted.example.com"})
+		require.NoError(t, err)
+
+		return csrf.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	}
+
+	serve := func(handler http.Handler, method string, headers map[string]string, cookie string) *httptest.ResponseRecorder {
+		req := httptest.NewRequestWithContext(context.Background(), method, "http://riverui.example.com/api/jobs/cancel", nil)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		if cookie != "" {
+			req.AddCookie(&http.Cookie{Name: csrfCookieName, Value: cookie})
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	t.Run("SafeMethodsPass", func(t *testing.T) {
+		t.Parallel()
+
+		handler := setup(t)
+
+		for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodOptions} {
+			recorder := serve(handler, method, map[string]string{"Sec-Fetch-Site": "cross-site"}, token)
+			require.Equal(t, http.StatusNoContent, recorder.Code, method)
+		}
+	})
+
+	t.Run("NonBrowserRequestPasses", func(t *testing.T) {
+		t.Parallel()
+
+		recorder := serve(setup(t), http.MethodPost, nil, "")
+		require.Equal(t, http.StatusNoContent, recorder.Code)
+	})
+
+	t.Run("CrossOriginRejected", func(t *testing.T) {
+		t.Parallel()
+
+		recorder := serve(setup(t), http.MethodPost, map[string]string{"Sec-Fetch-Site": "cross-site"}, "")
+		require.Equal(t, http.StatusForbidden, recorder.Code)
+		require.Contains(t, recorder.Body.String(), "Cross-origin request rejected.")
+
+		recorder = serve(setup(t), http.MethodPost, map[string]string{"Origin": "https://evil.example.com"}, "")
+		require.Equal(t, http.StatusForbidden, recorder.Code)
+	})
+
+	t.Run("TrustedOriginPasses", func(t *testing.T) {
+		t.Parallel()
+
+		recorder := serve(setup(t), http.MethodPost, map[string]string{"Origin": "https://trusted.example.com", "Sec-Fetch-Site": "cross-site"}, "")
+		require.Equal(t, http.StatusNoContent, recorder.Code)
+	})
+
+	t.Run("MatchingTokenPasses", func(t *testing.T) {
+		t.Parallel()
+
+		recorder := serve(setup(t), http.MethodPost, map[string]string{"Sec-Fetch-Site": "same-origin", csrfHeaderName: token}, token)
+		require.Equal(t, http.StatusNoContent, recorder.Code)
+	})
+
+	t.Run("MissingTokenRejected", func(t *testing.T) {
+		t.Parallel()
+
+		recorder := serve(setup(t), http.MethodPost, map[string]string{"Sec-Fetch-Site": "same-origin"}, token)
+		require.Equal(t, http.StatusForbidden, recorder.Code)
+		require.Contains(t, recorder.Body.String(), "Missing or invalid CSRF token.")
+	})
+
+	t.Run("MismatchedTokenRejected", func(t *testing.T) {
+		t.Parallel()
+
+		recorder := serve(setup(t), http.MethodPost, map[string]string{"Sec-Fetch-Site": "same-origin", csrfHeaderName: "wrong"}, token)
+		require.Equal(t, http.StatusForbidden, recorder.Code)
+	})
+
+	t.Run("InvalidTrustedOrigin", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := newCSRFProtection(slog.New(slog.DiscardHandler), "", []string{"not an origin"})
+		require.ErrorContains(t, err, "invalid CSRF trusted origin")
+	})
+}
@@ -0,0 +1,134 @@
+package riverui
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/riverqueue/apiframe/apierror"
+)
+
+const (
+	// DefaultEnvironment is the name of the environment served by
+	// HandlerOpts.Endpoints when HandlerOpts.Environments configures others.
+	DefaultEnvironment = "default"
+
+	// EnvironmentHeader is the header with which API requests may select an
+	// environment configured with HandlerOpts.Environments, as an alternative
+	// to a path like `/api/environments/{name}/jobs`.
+	EnvironmentHeader = "River-Environment"
+
+	environmentPathPrefix = "/api/environments/"
+)
+
+var environmentNameRE = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+func validateEnvironmentName(name string) error {
+	if name == DefaultEnvironment {
+		return fmt.Errorf("environment name %q is reserved for HandlerOpts.Endpoints", name)
+	}
+	if !environmentNameRE.MatchString(name) {
+		return fmt.Errorf("invalid environment name %q: must start with a lowercase letter and contain only lowercase letters, digits, and underscores", name)
+	}
+	return nil
+}
+
+// routeMatcher finds the handler and pattern that a request routes to. It's
+// implemented by http.ServeMux and environmentMux so that middleware can
+// look up the endpoint a request is for regardless of how many environments
+// are configured.
+type routeMatcher interface {
+	Handler(r *http.Request) (h http.Handler, pattern string)
+}
+
+type environmentContextKey struct{}
+
+// Returns the name of the environment selected for a request by
+// environmentMiddleware, or DefaultEnvironment if none was selected.
+func environmentFromContext(ctx context.Context) string {
+	if name, ok := ctx.Value(environmentContextKey{}).(string); ok {
+		return name
+	}
+	return DefaultEnvironment
+}
+
+// environmentMux routes requests to the mux of the environment selected for
+// them by environmentMiddleware, or to the default environment's mux if none
+// was selected.
+type environmentMux struct {
+	defaultMux *http.ServeMux
+	muxes      map[string]*http.ServeMux
+}
+
+func (m *environmentMux) Handler(r *http.Request) (http.Handler, string) {
+	return m.mux(r).Handler(r)
+}
+
+func (m *environmentMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mux(r).ServeHTTP(w, r)
+}
+
+func (m *environmentMux) mux(r *http.Request) *http.ServeMux {
+	if name, ok := r.Context().Value(environmentContextKey{}).(string); ok {
+		if mux, ok := m.muxes[name]; ok {
+			return mux
+		}
+	}
+	return m.defaultMux
+}
+
+// environmentMiddleware selects the environment for API requests from an
+// `/api/environments/{name}/` path prefix, which it strips, or from the
+// EnvironmentHeader header. It must run before any middleware that looks up
+// the endpoint a request routes to.
+type environmentMiddleware struct {
+	logger *slog.Logger
+	mux    *environmentMux
+}
+
+func (m *environmentMiddleware) Middleware(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		name := r.Header.Get(EnvironmentHeader)
+		if rest, ok := strings.CutPrefix(r.URL.Path, environmentPathPrefix); ok {
+			name, _, _ = strings.Cut(rest, "/")
+			r = withEnvironmentPrefixStripped(r, name)
+		}
+
+		if name == "" || name == DefaultEnvironment {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		if _, ok := m.mux.muxes[name]; !ok {
+			apierror.NewNotFoundf("Environment not found: %s.", name).Write(r.Context(), m.logger, w)
+			return
+		}
+
+		handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), environmentContextKey{}, name)))
+	})
+}
+
+// Returns a copy of the request with an `/api/environments/{name}` prefix
+// removed from its path so that it routes like a request to `/api/...`.
+func withEnvironmentPrefixStripped(r *http.Request, name string) *http.Request {
+	prefix := environmentPathPrefix + name
+
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.URL = new(url.URL)
+	*r2.URL = *r.URL
+	r2.URL.Path = "/api" + strings.TrimPrefix(r.URL.Path, prefix)
+	if r.URL.RawPath != "" {
+		r2.URL.RawPath = "/api" + strings.TrimPrefix(r.URL.RawPath, environmentPathPrefix+url.PathEscape(name))
+	}
+	return r2
+}
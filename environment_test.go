@@ -0,0 +1,236 @@
+package riverui
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/riverqueue/apiframe/apimiddleware"
+	"github.com/riverqueue/river"
+	"github.com/riverqueue/river/riverdbtest"
+	"github.com/riverqueue/river/riverdriver/riverpgxv5"
+	"github.com/riverqueue/river/rivershared/riversharedtest"
+
+	"riverqueue.com/riverui/internal/riverinternaltest/testfactory"
+	"riverqueue.com/riverui/uiaudit"
+	"riverqueue.com/riverui/uiauth"
+	"riverqueue.com/riverui/uiendpoints"
+)
+
+func TestEnvironmentMiddleware(t *testing.T) {
+	t.Parallel()
+
+	type testBundle struct {
+		auditSink            *recordingAuditSink
+		environmentInHandler string
+	}
+
+	setup := func(t *testing.T) (http.Handler, *testBundle) {
+		t.Helper()
+
+		bundle := &testBundle{auditSink: &recordingAuditSink{}}
+
+		newMux := func(environment string) *http.ServeMux {
+			okHandler := func(w http.ResponseWriter, r *http.Request) {
+				bundle.environmentInHandler = environment
+				w.WriteHeader(http.StatusOK)
+			}
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /api/jobs/{id}", okHandler)
+			mux.HandleFunc("GET /robots.txt", okHandler)
+			return mux
+		}
+
+		router := &environmentMux{
+			defaultMux: newMux(DefaultEnvironment),
+			muxes:      map[string]*http.ServeMux{"eu": newMux("eu")},
+		}
+
+		// Only the environment has this endpoint, so authorization must look
+		// it up through the environment's mux.
+		router.muxes["eu"].HandleFunc("POST /api/jobs/cancel", func(w http.ResponseWriter, r *http.Request) {
+			bundle.environmentInHandler = "eu"
+			w.WriteHeader(http.StatusOK)
+		})
+
+		middlewareStack := apimiddleware.NewMiddlewareStack()
+		middlewareStack.Use(&environmentMiddleware{logger: riversharedtest.Logger(t), mux: router})
+		middlewareStack.Use(&authorizationMiddleware{
+			anonymousRole: uiauth.RoleViewer,
+			auditSink:     bundle.auditSink,
+			endpointAccess: map[string]uiauth.Access{
+				"GET /api/jobs/{id}":    uiauth.AccessRead,
+				"POST /api/jobs/cancel": uiauth.AccessMutate,
+			},
+			endpointAuditActions: map[string]string{
+				"POST /api/jobs/cancel": string(uiauth.ActionJobCancel),
+			},
+			logger: riversharedtest.Logger(t),
+			mux:    router,
+		})
+
+		return middlewareStack.Mount(router), bundle
+	}
+
+	serve := func(t *testing.T, handler http.Handler, method, path, environmentHeader string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequestWithContext(t.Context(), method, path, nil)
+		if environmentHeader != "" {
+			req.Header.Set(EnvironmentHeader, environmentHeader)
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	t.Run("DefaultEnvironment", func(t *testing.T) {
+		t.Parallel()
+
+		handler, bundle := setup(t)
+
+		require.Equal(t, http.StatusOK, serve(t, handler, http.MethodGet, "/api/jobs/123", "").Code)
+		require.Equal(t, DefaultEnvironment, bundle.environmentInHandler)
+
+		require.Equal(t, http.StatusOK, serve(t, handler, http.MethodGet, "/api/environments/default/jobs/123", "").Code)
+		require.Equal(t, DefaultEnvironment, bundle.environmentInHandler)
+	})
+
+	t.Run("SelectedByPath", func(t *testing.T) {
+		t.Parallel()
+
+		handler, bundle := setup(t)
+
+		require.Equal(t, http.StatusOK, serve(t, handler, http.MethodGet, "/api/environments/eu/jobs/123", "").Code)
+		require.Equal(t, "eu", bundle.environmentInHandler)
+	})
+
+	t.Run("SelectedByHeader", func(t *testing.T) {
+		t.Parallel()
+
+		handler, bundle := setup(t)
+
+		require.Equal(t, http.StatusOK, serve(t, handler, http.MethodGet, "/api/jobs/123", "eu").Code)
+		require.Equal(t, "eu", bundle.environmentInHandler)
+	})
+
+	t.Run("HeaderIgnoredOutsideAPI", func(t *testing.T) {
+		t.Parallel()
+
+		handler, bundle := setup(t)
+
+		require.Equal(t, http.StatusOK, serve(t, handler, http.MethodGet, "/robots.txt", "eu").Code)
+		require.Equal(t, DefaultEnvironment, bundle.environmentInHandler)
+	})
+
+	t.Run("UnknownEnvironment", func(t *testing.T) {
+		t.Parallel()
+
+		handler, _ := setup(t)
+
+		recorder := serve(t, handler, http.MethodGet, "/api/environments/us/jobs/123", "")
+		require.Equal(t, http.StatusNotFound, recorder.Code)
+		require.JSONEq(t, `{"message":"Environment not found: us."}`, recorder.Body.String())
+
+		require.Equal(t, http.StatusNotFound, serve(t, handler, http.MethodGet, "/api/jobs/123", "us").Code)
+	})
+
+	t.Run("AuthorizesEnvironmentOnlyEndpoints", func(t *testing.T) {
+		t.Parallel()
+
+		handler, bundle := setup(t)
+
+		require.Equal(t, http.StatusForbidden, serve(t, handler, http.MethodPost, "/api/environments/eu/jobs/cancel", "").Code)
+
+		// The denial is audited with the environment it was made in.
+		entries := bundle.auditSink.recorded()
+		require.Len(t, entries, 1)
+		require.Equal(t, "eu", entries[0].Environment)
+		require.Equal(t, uiaudit.OutcomeDenied, entries[0].Outcome)
+	})
+}
+
+func TestValidateEnvironmentName(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, validateEnvironmentName("eu_west_1"))
+	require.EqualError(t, validateEnvironmentName(DefaultEnvironment), `environment name "default" is reserved for HandlerOpts.Endpoints`)
+	require.ErrorContains(t, validateEnvironmentName("EU"), `invalid environment name "EU"`)
+	require.ErrorContains(t, validateEnvironmentName("1eu"), `invalid environment name "1eu"`)
+	require.ErrorContains(t, validateEnvironmentName(""), `invalid environment name ""`)
+}
+
+func TestHandlerEnvironments(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	createBundle := func(t *testing.T) (uiendpoints.Bundle, pgx.Tx) {
+		t.Helper()
+
+		driver := riverpgxv5.New(riversharedtest.DBPool(ctx, t))
+		tx, _ := riverdbtest.TestTxPgxDriver(ctx, t, driver, nil)
+
+		client, err := river.NewClient(driver, &river.Config{Logger: riversharedtest.Logger(t)})
+		require.NoError(t, err)
+
+		return NewEndpoints(client, &EndpointsOpts[pgx.Tx]{Tx: &tx}), tx
+	}
+
+	defaultBundle, _ := createBundle(t)
+	euBundle, euTx := createBundle(t)
+
+	handler, err := NewHandler(&HandlerOpts{
+		DevMode:      true,
+		Endpoints:    defaultBundle,
+		Environments: map[string]uiendpoints.Bundle{"eu": euBundle},
+		LiveFS:       true,
+		Logger:       riversharedtest.Logger(t),
+		projectRoot:  "./",
+	})
+	require.NoError(t, err)
+	require.NoError(t, handler.Start(t.Context()))
+
+	// A job that only exists in the eu environment's transaction.
+	job := testfactory.Job(ctx, t, riverpgxv5.New(nil).UnwrapExecutor(euTx), &testfactory.JobOpts{})
+
+	serve := func(t *testing.T, path string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequestWithContext(t.Context(), http.MethodGet, path, nil))
+		return recorder
+	}
+
+	jobPath := "/jobs/" + strconv.FormatInt(job.ID, 10)
+	require.Equal(t, http.StatusOK, serve(t, "/api/environments/eu"+jobPath).Code)
+	require.Equal(t, http.StatusNotFound, serve(t, "/api"+jobPath).Code)
+
+	var features featuresGetResponse
+
+	recorder := serve(t, "/api/environments/eu/features")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &features))
+	require.Equal(t, "eu", features.Environment)
+	require.Equal(t, []string{DefaultEnvironment, "eu"}, features.Environments)
+
+	recorder = serve(t, "/api/features")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &features))
+	require.Equal(t, DefaultEnvironment, features.Environment)
+
+	_, err = NewHandler(&HandlerOpts{
+		Endpoints:    defaultBundle,
+		Environments: map[string]uiendpoints.Bundle{DefaultEnvironment: euBundle},
+		Logger:       riversharedtest.Logger(t),
+	})
+	require.EqualError(t, err, `environment name "default" is reserved for HandlerOpts.Endpoints`)
+}
@@ -10,13 +10,18 @@ import (
 	"io"
 	"io/fs"
 	"log/slog"
+	"maps"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/riverqueue/apiframe/apiendpoint"
+	"github.com/riverqueue/apiframe/apierror"
 	"github.com/riverqueue/apiframe/apimiddleware"
 	"github.com/riverqueue/apiframe/apitype"
 	"github.com/riverqueue/river"
@@ -25,11 +30,37 @@ import (
 	"github.com/riverqueue/river/rivershared/startstop"
 
 	"riverqueue.com/riverui/internal/apibundle"
+	"riverqueue.com/riverui/uiaudit"
+	"riverqueue.com/riverui/uiauth"
+	"riverqueue.com/riverui/uidecode"
 	"riverqueue.com/riverui/uiendpoints"
+	"riverqueue.com/riverui/uikinds"
+	"riverqueue.com/riverui/uiredact"
+	"riverqueue.com/riverui/uischema"
 )
 
 // EndpointsOpts are the options for creating a new Endpoints bundle.
 type EndpointsOpts[TTx any] struct {
+	// Extensions are optional extensions that mount custom endpoints
+	// alongside the built-in ones and add custom feature flags to
+	// `/api/features`. See uiendpoints.Extension.
+	Extensions []uiendpoints.Extension[TTx]
+
+	// KindRegistry is an optional registry of the job kinds that live clients
+	// have registered workers for, which they report to it with
+	// uikinds.Heartbeat. When it's configured, `/api/kinds` flags kinds that
+	// have pending jobs but no live worker, and registered kinds that haven't
+	// reported recently.
+	KindRegistry uikinds.Registry
+
+	// ReplicaExecutor is an optional executor for a streaming read replica of
+	// the client's database. Read-only endpoints like job and queue lists,
+	// job state counts, and autocompletion query it instead of the primary so
+	// that the UI adds less load to it, while mutations always go to the
+	// primary. It must be from the same driver as the client, like
+	// `riverpgxv5.New(replicaPool).GetExecutor()`.
+	ReplicaExecutor riverdriver.Executor
+
 	// Tx is an optional transaction to wrap all database operations. It's mainly
 	// used for testing.
 	Tx *TTx
@@ -64,10 +95,25 @@ func (e *endpoints[TTx]) Configure(bundleOpts *uiendpoints.BundleOpts) {
 }
 
 func (e *endpoints[TTx]) Extensions(ctx context.Context) (map[string]bool, error) {
-	if e.extensions == nil {
-		return map[string]bool{}, nil
+	extensions := map[string]bool{}
+	for _, extension := range e.opts.Extensions {
+		features, err := extension.Features(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error getting extension features: %w", err)
+		}
+		maps.Copy(extensions, features)
+	}
+
+	// Built-in flags are copied last so that they take precedence.
+	if e.extensions != nil {
+		builtin, err := e.extensions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(extensions, builtin)
 	}
-	return e.extensions(ctx)
+
+	return extensions, nil
 }
 
 // SetExtensionsProvider sets the extensions provider function for this bundle.
@@ -80,6 +126,11 @@ func (e *endpoints[TTx]) Validate() error {
 	if e.client == nil {
 		return errors.New("client is required")
 	}
+	for i, extension := range e.opts.Extensions {
+		if extension == nil {
+			return fmt.Errorf("extension at index %d is nil", i)
+		}
+	}
 	return nil
 }
 
@@ -91,17 +142,35 @@ func (e *endpoints[TTx]) MountEndpoints(archetype *baseservice.Archetype, logger
 	} else {
 		executor = driver.UnwrapExecutor(*e.opts.Tx)
 	}
+	replicaExecutor := e.opts.ReplicaExecutor
+	if e.bundleOpts.StatementTimeout > 0 && driver.DatabaseName() == riverdriver.DatabaseNamePostgres {
+		executor = &apibundle.StatementTimeoutExecutor{Executor: executor, Timeout: e.bundleOpts.StatementTimeout}
+		if replicaExecutor != nil {
+			replicaExecutor = &apibundle.StatementTimeoutExecutor{Executor: replicaExecutor, Timeout: e.bundleOpts.StatementTimeout}
+		}
+	}
 	bundle := apibundle.APIBundle[TTx]{
 		Archetype:                archetype,
+		ArgsDecoder:              e.bundleOpts.ArgsDecoder,
+		ArgsDecoderAccess:        e.bundleOpts.ArgsDecoderAccess,
+		ArgsSchemas:              e.bundleOpts.ArgsSchemas,
+		AuditSink:                e.bundleOpts.AuditSink,
+		AuthorizationPolicy:      e.bundleOpts.AuthorizationPolicy,
 		Client:                   e.client,
 		DB:                       executor,
 		Driver:                   driver,
 		Extensions:               e.Extensions,
 		JobListHideArgsByDefault: e.bundleOpts.JobListHideArgsByDefault,
+		Environment:              e.bundleOpts.Environment,
+		Environments:             e.bundleOpts.Environments,
+		KindRegistry:             e.opts.KindRegistry,
 		Logger:                   logger,
+		Redactor:                 e.bundleOpts.Redactor,
+		ReplicaDB:                replicaExecutor,
 	}
 
-	return []apiendpoint.EndpointInterface{
+	endpoints := []apiendpoint.EndpointInterface{
+		apiendpoint.Mount(mux, newAuditListEndpoint(bundle), mountOpts),
 		apiendpoint.Mount(mux, newAutocompleteListEndpoint(bundle), mountOpts),
 		apiendpoint.Mount(mux, newFeaturesGetEndpoint(bundle), mountOpts),
 		apiendpoint.Mount(mux, newHealthCheckGetEndpoint(bundle), mountOpts),
@@ -110,6 +179,9 @@ func (e *endpoints[TTx]) MountEndpoints(archetype *baseservice.Archetype, logger
 		apiendpoint.Mount(mux, newJobGetEndpoint(bundle), mountOpts),
 		apiendpoint.Mount(mux, newJobListEndpoint(bundle), mountOpts),
 		apiendpoint.Mount(mux, newJobRetryEndpoint(bundle), mountOpts),
+		apiendpoint.Mount(mux, newKindListEndpoint(bundle), mountOpts),
+		apiendpoint.Mount(mux, newKindSchemaGetEndpoint(bundle), mountOpts),
+		apiendpoint.Mount(mux, newKindValidateEndpoint(bundle), mountOpts),
 		apiendpoint.Mount(mux, newQueueGetEndpoint(bundle), mountOpts),
 		apiendpoint.Mount(mux, newQueueListEndpoint(bundle), mountOpts),
 		apiendpoint.Mount(mux, newQueuePauseEndpoint(bundle), mountOpts),
@@ -117,13 +189,73 @@ func (e *endpoints[TTx]) MountEndpoints(archetype *baseservice.Archetype, logger
 		apiendpoint.Mount(mux, newQueueUpdateEndpoint(bundle), mountOpts),
 		apiendpoint.Mount(mux, newStateAndCountGetEndpoint(bundle), mountOpts),
 	}
+
+	for _, extension := range e.opts.Extensions {
+		endpoints = append(endpoints, extension.MountEndpoints(bundle, mux, mountOpts)...)
+	}
+
+	return endpoints
 }
 
 // HandlerOpts are the options for creating a new Handler.
 type HandlerOpts struct {
+	// AnonymousRole is the role granted to requests that don't carry an
+	// identity in their context (see uiauth.WithIdentity). Defaults to
+	// uiauth.RoleAdmin so that requests are unrestricted unless authentication
+	// middleware in front of the handler says otherwise. Set to
+	// uiauth.RoleViewer to serve a read-only UI to unauthenticated users.
+	AnonymousRole uiauth.Role
+	// ArgsDecoder optionally decodes job args that are encrypted or otherwise
+	// encoded at the application layer into a displayable JSON form. Decoded
+	// args are served in job details, job lists, and workflow tasks with
+	// `args_decoded` set, but only to identities with ArgsDecoderAccess, and
+	// others get the raw args. An
+	// error decoding a job's args is served in its `args_decode_error`
+	// instead of failing the request.
+	ArgsDecoder uidecode.ArgsDecoder
+	// ArgsDecoderAccess is the access an identity must have to be served args
+	// decoded by ArgsDecoder. Defaults to uiauth.AccessAdmin.
+	ArgsDecoderAccess uiauth.Access
+	// ArgsSchemas optionally holds JSON Schemas describing the args of job
+	// kinds, either handwritten or derived from Go args types with
+	// uischema.Registry.RegisterArgs. They're served from
+	// `/api/kinds/{kind}/schema` so that the frontend can render forms for
+	// args, and `/api/kinds/{kind}/validate` checks args against them
+	// server-side. Kinds without a schema fall back to raw JSON args.
+	ArgsSchemas *uischema.Registry
+	// AuditSink optionally records an audit entry for every mutating action
+	// taken through the API, like cancelling jobs or pausing queues. If the
+	// sink implements uiaudit.Lister, its entries can be browsed by admins
+	// through `GET /api/audit`.
+	AuditSink uiaudit.Sink
+	// AuthorizationPolicy optionally scopes the actions that identities may
+	// take to particular queues and job kinds, and optionally limits the jobs
+	// and queues that list endpoints return to them.
+	AuthorizationPolicy *uiauth.Policy
+	// CSRFProtection enables protection against cross-site request forgery
+	// for state changing API requests. It should be enabled whenever browsers
+	// authenticate to the UI with credentials they attach automatically, like
+	// cookies set by an authenticating proxy or cached basic auth credentials.
+	// Cross-origin requests from browsers are rejected, and requests from
+	// browsers that loaded the UI must carry the CSRF token injected into its
+	// index page. Requests from non-browser clients like API token holders are
+	// unaffected.
+	CSRFProtection bool
+	// CSRFTrustedOrigins are origins like `https://example.com` whose
+	// cross-origin requests are allowed through CSRF protection.
+	CSRFTrustedOrigins []string
 	// DevMode is whether the server is running in development mode.
-	DevMode                  bool
-	Endpoints                uiendpoints.Bundle
+	DevMode   bool
+	Endpoints uiendpoints.Bundle
+	// Environments optionally serves additional named bundles alongside
+	// Endpoints, like for River databases in other regions or in other
+	// schemas, so that a single UI can manage all of them. API requests select
+	// an environment with a path like `/api/environments/{name}/jobs` or an
+	// EnvironmentHeader header, and requests that don't select one are served
+	// by Endpoints, which is named DefaultEnvironment. Names must start with a
+	// lowercase letter and contain only lowercase letters, digits, and
+	// underscores.
+	Environments             map[string]uiendpoints.Bundle
 	JobListHideArgsByDefault bool
 	// LiveFS is whether to use the live filesystem for the frontend.
 	LiveFS bool
@@ -131,15 +263,55 @@ type HandlerOpts struct {
 	Logger *slog.Logger
 	// Prefix is the path prefix to use for the API and UI HTTP requests.
 	Prefix string
+	// RateLimit optionally limits the rate of API requests made by each
+	// identity and the number of API requests executing concurrently.
+	RateLimit *RateLimitOpts
+	// Redactor optionally transforms or redacts the args, metadata, and
+	// attempt errors of every job served by the API, in job lists and job
+	// details alike, so that personal information and secrets they contain
+	// aren't exposed. Unlike JobListHideArgsByDefault, which only hides args
+	// in the job list until they're revealed, redacted values never leave the
+	// server. See uiredact.JSONPathRedactor for a built-in redactor.
+	Redactor uiredact.Redactor
+	// StatementTimeout optionally limits how long any single database
+	// statement in an API request's transaction may run before Postgres
+	// cancels it, so that slow queries from the UI can't hold connections for
+	// long. Requests that hit the timeout get a 503 response.
+	StatementTimeout time.Duration
 
 	// projectRoot is an optional path to the project root used for testing.
 	projectRoot string
 }
 
 func (opts *HandlerOpts) validate() error {
+	if opts.AnonymousRole == "" {
+		opts.AnonymousRole = uiauth.RoleAdmin
+	}
+	if opts.ArgsDecoderAccess == "" {
+		opts.ArgsDecoderAccess = uiauth.AccessAdmin
+	}
+	switch opts.ArgsDecoderAccess {
+	case uiauth.AccessAdmin, uiauth.AccessMutate, uiauth.AccessRead:
+	default:
+		return fmt.Errorf("invalid args decoder access %q", opts.ArgsDecoderAccess)
+	}
+	if _, err := uiauth.ParseRole(string(opts.AnonymousRole)); err != nil {
+		return fmt.Errorf("invalid anonymous role: %w", err)
+	}
+	if err := opts.AuthorizationPolicy.Validate(); err != nil {
+		return fmt.Errorf("invalid authorization policy: %w", err)
+	}
 	if opts.Logger == nil {
 		return errors.New("logger is required")
 	}
+	if opts.RateLimit != nil {
+		if err := opts.RateLimit.validate(); err != nil {
+			return fmt.Errorf("invalid rate limit: %w", err)
+		}
+	}
+	if opts.StatementTimeout < 0 {
+		return errors.New("statement timeout can't be negative")
+	}
 	opts.Prefix = NormalizePathPrefix(opts.Prefix)
 	return nil
 }
@@ -165,6 +337,7 @@ func NormalizePathPrefix(prefix string) string {
 // automatically stop as soon as the context is done.
 type Handler struct {
 	baseStartStop startstop.BaseStartStop
+	draining      atomic.Bool
 	handler       http.Handler
 	services      []startstop.Service
 }
@@ -177,6 +350,17 @@ func NewHandler(opts *HandlerOpts) (*Handler, error) {
 	if err := opts.Endpoints.Validate(); err != nil {
 		return nil, err
 	}
+	for name, bundle := range opts.Environments {
+		if err := validateEnvironmentName(name); err != nil {
+			return nil, err
+		}
+		if bundle == nil {
+			return nil, fmt.Errorf("endpoints for environment %q are required", name)
+		}
+		if err := bundle.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid endpoints for environment %q: %w", name, err)
+		}
+	}
 
 	if opts == nil {
 		return nil, errors.New("opts is required")
@@ -185,9 +369,27 @@ func NewHandler(opts *HandlerOpts) (*Handler, error) {
 		return nil, err
 	}
 
-	opts.Endpoints.Configure(&uiendpoints.BundleOpts{
-		JobListHideArgsByDefault: opts.JobListHideArgsByDefault,
-	})
+	environmentNames := append([]string{DefaultEnvironment}, slices.Sorted(maps.Keys(opts.Environments))...)
+
+	bundleOpts := func(environment string) *uiendpoints.BundleOpts {
+		return &uiendpoints.BundleOpts{
+			ArgsDecoder:              opts.ArgsDecoder,
+			ArgsDecoderAccess:        opts.ArgsDecoderAccess,
+			ArgsSchemas:              opts.ArgsSchemas,
+			AuditSink:                opts.AuditSink,
+			AuthorizationPolicy:      opts.AuthorizationPolicy,
+			Environment:              environment,
+			Environments:             environmentNames,
+			JobListHideArgsByDefault: opts.JobListHideArgsByDefault,
+			Redactor:                 opts.Redactor,
+			StatementTimeout:         opts.StatementTimeout,
+		}
+	}
+
+	opts.Endpoints.Configure(bundleOpts(DefaultEnvironment))
+	for name, bundle := range opts.Environments {
+		bundle.Configure(bundleOpts(name))
+	}
 
 	prefix := opts.Prefix
 
@@ -231,9 +433,16 @@ func NewHandler(opts *HandlerOpts) (*Handler, error) {
 		return nil, err
 	}
 
+	var csrf *csrfProtection
+	if opts.CSRFProtection {
+		if csrf, err = newCSRFProtection(opts.Logger, prefix, opts.CSRFTrustedOrigins); err != nil {
+			return nil, err
+		}
+	}
+
 	httpFS := http.FS(frontendIndex)
 	fileServer := http.FileServer(httpFS)
-	serveIndex := serveIndexHTML(opts.DevMode, manifest, prefix, httpFS)
+	serveIndex := serveIndexHTML(opts.DevMode, manifest, prefix, httpFS, csrf)
 
 	mux := http.NewServeMux()
 
@@ -244,6 +453,53 @@ func NewHandler(opts *HandlerOpts) (*Handler, error) {
 
 	endpoints := opts.Endpoints.MountEndpoints(baseservice.NewArchetype(opts.Logger), opts.Logger, mux, &mountOpts)
 
+	// Each additional environment's endpoints are mounted on a mux of their
+	// own, which requests that select the environment are routed to.
+	var (
+		router            routeMatcher = mux
+		environmentRouter *environmentMux
+	)
+	if len(opts.Environments) > 0 {
+		environmentRouter = &environmentMux{
+			defaultMux: mux,
+			muxes:      make(map[string]*http.ServeMux, len(opts.Environments)),
+		}
+		for name, bundle := range opts.Environments {
+			environmentMux := http.NewServeMux()
+			endpoints = append(endpoints, bundle.MountEndpoints(baseservice.NewArchetype(opts.Logger), opts.Logger, environmentMux, &mountOpts)...)
+			environmentRouter.muxes[name] = environmentMux
+		}
+		router = environmentRouter
+	}
+
+	// The OpenAPI document describes all endpoints, including itself, so it's
+	// built after everything else is mounted.
+	openAPIEndpoint := newOpenAPIGetEndpoint()
+	endpoints = append(endpoints, apiendpoint.Mount(mux, openAPIEndpoint, &mountOpts))
+
+	openAPIOpts := &openAPIDocumentOpts{Prefix: prefix}
+	if len(opts.Environments) > 0 {
+		openAPIOpts.Environments = environmentNames
+	}
+	if openAPIEndpoint.document, err = buildOpenAPIDocument(endpoints, openAPIOpts); err != nil {
+		return nil, err
+	}
+
+	// Build a map of endpoint patterns to the access each requires so that
+	// requests can be authorized before they reach an endpoint, and another
+	// of the audited actions they take so that denied requests are audited.
+	var (
+		endpointAccess       = make(map[string]uiauth.Access, len(endpoints))
+		endpointAuditActions = make(map[string]string)
+	)
+	for _, endpoint := range endpoints {
+		meta := endpoint.Meta()
+		endpointAccess[meta.Pattern] = endpointRequiredAccess(endpoint, meta)
+		if declarer, ok := endpoint.(uiaudit.ActionDeclarer); ok {
+			endpointAuditActions[meta.Pattern] = declarer.AuditAction()
+		}
+	}
+
 	var services []startstop.Service
 
 	type WithSubServices interface {
@@ -264,16 +520,60 @@ func NewHandler(opts *HandlerOpts) (*Handler, error) {
 	mux.HandleFunc("/api", http.NotFound)
 	mux.Handle("/", intercept404(fileServer, serveIndex))
 
+	handler := &Handler{
+		services: services,
+	}
+
 	middlewareStack := apimiddleware.NewMiddlewareStack()
 
 	if prefix != "" {
 		middlewareStack.Use(&stripPrefixMiddleware{prefix})
 	}
 
-	handler := &Handler{
-		handler:  middlewareStack.Mount(mux),
-		services: services,
+	if environmentRouter != nil {
+		middlewareStack.Use(&environmentMiddleware{
+			logger: opts.Logger,
+			mux:    environmentRouter,
+		})
+	}
+
+	middlewareStack.Use(&drainingMiddleware{
+		draining: &handler.draining,
+		logger:   opts.Logger,
+		mux:      router,
+	})
+
+	if csrf != nil {
+		middlewareStack.Use(csrf)
+	}
+
+	middlewareStack.Use(&authorizationMiddleware{
+		anonymousRole:        opts.AnonymousRole,
+		auditSink:            opts.AuditSink,
+		endpointAccess:       endpointAccess,
+		endpointAuditActions: endpointAuditActions,
+		logger:               opts.Logger,
+		mux:                  router,
+	})
+
+	if opts.RateLimit != nil {
+		// Health checks must keep responding under load, so they're exempt
+		// from rate limits.
+		rateLimitedPatterns := make(map[string]struct{}, len(endpointAccess))
+		for pattern := range endpointAccess {
+			if pattern != healthCheckGetPattern {
+				rateLimitedPatterns[pattern] = struct{}{}
+			}
+		}
+
+		middlewareStack.Use(newRateLimitMiddleware(opts.Logger, router, rateLimitedPatterns, opts.RateLimit))
+	}
+
+	var innerHandler http.Handler = mux
+	if environmentRouter != nil {
+		innerHandler = environmentRouter
 	}
+	handler.handler = middlewareStack.Mount(innerHandler)
 
 	return handler, nil
 }
@@ -313,6 +613,20 @@ func (h *Handler) Start(ctx context.Context) error {
 	return nil
 }
 
+// Drain marks the handler as draining ahead of shutdown. Health checks respond
+// with 503 Service Unavailable from then on so that load balancers stop
+// routing new requests to it, while other requests continue to be served.
+func (h *Handler) Drain() {
+	h.draining.Store(true)
+}
+
+// Stopped returns a channel that's closed once the handler's background
+// services have stopped after the context passed to Start is done. A reference
+// to it must be taken before the context is cancelled.
+func (h *Handler) Stopped() <-chan struct{} {
+	return h.baseStartStop.Stopped()
+}
+
 func readManifest(frontendIndex fs.FS, devMode bool) (map[string]any, error) {
 	if devMode {
 		return map[string]any{}, nil
@@ -379,6 +693,105 @@ func mountStaticFiles(logger *slog.Logger, mux *http.ServeMux) error {
 	})
 }
 
+// Gets the access required by an endpoint, either as declared by the endpoint
+// itself, or inferred from its HTTP method in case it doesn't declare one.
+func endpointRequiredAccess(endpoint apiendpoint.EndpointInterface, meta *apiendpoint.EndpointMeta) uiauth.Access {
+	if declarer, ok := endpoint.(uiauth.AccessDeclarer); ok {
+		return declarer.Access()
+	}
+
+	method, _, _ := strings.Cut(meta.Pattern, " ")
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		return uiauth.AccessRead
+	default:
+		return uiauth.AccessMutate
+	}
+}
+
+// authorizationMiddleware checks the identity in a request's context against
+// the access required by the API endpoint it's routed to, responding with a
+// 403 if the identity doesn't have sufficient access. Requests without an
+// identity are given an anonymous one with the configured anonymous role.
+// Denied requests to endpoints that take an audited action are recorded to
+// the audit sink, if one is configured.
+type authorizationMiddleware struct {
+	anonymousRole        uiauth.Role
+	auditSink            uiaudit.Sink
+	endpointAccess       map[string]uiauth.Access
+	endpointAuditActions map[string]string
+	logger               *slog.Logger
+	mux                  routeMatcher
+}
+
+func (m *authorizationMiddleware) Middleware(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		identity := uiauth.IdentityFromContext(ctx)
+		if identity == nil {
+			identity = &uiauth.Identity{Roles: []uiauth.Role{m.anonymousRole}}
+			ctx = uiauth.WithIdentity(ctx, identity)
+			r = r.WithContext(ctx)
+		}
+
+		_, pattern := m.mux.Handler(r)
+		if access, ok := m.endpointAccess[pattern]; ok && !identity.Allows(access) {
+			forbiddenErr := uiauth.NewForbiddenf("Insufficient permissions: this operation requires %s access.", access)
+			m.recordDenied(ctx, identity, pattern, forbiddenErr)
+			forbiddenErr.Write(ctx, m.logger, w)
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// Records an audit entry for a request that was denied before reaching its
+// endpoint, if the endpoint takes an audited action. Denials by a policy are
+// recorded by the endpoint itself (see uiendpoints.WithAudit).
+func (m *authorizationMiddleware) recordDenied(ctx context.Context, identity *uiauth.Identity, pattern string, err error) {
+	action, ok := m.endpointAuditActions[pattern]
+	if !ok || m.auditSink == nil {
+		return
+	}
+
+	entry := &uiaudit.Entry{
+		Action:      action,
+		Actor:       identity.Name,
+		Endpoint:    pattern,
+		Environment: environmentFromContext(ctx),
+		Error:       err.Error(),
+		OccurredAt:  time.Now().UTC(),
+		Outcome:     uiaudit.OutcomeDenied,
+	}
+	if recordErr := m.auditSink.Record(context.WithoutCancel(ctx), entry); recordErr != nil {
+		m.logger.ErrorContext(ctx, "Error recording audit entry",
+			"action", entry.Action, "actor", entry.Actor, "error", recordErr)
+	}
+}
+
+// drainingMiddleware fails health checks once the handler is draining (see
+// Handler.Drain).
+type drainingMiddleware struct {
+	draining *atomic.Bool
+	logger   *slog.Logger
+	mux      routeMatcher
+}
+
+func (m *drainingMiddleware) Middleware(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.draining.Load() {
+			if _, pattern := m.mux.Handler(r); pattern == healthCheckGetPattern {
+				apierror.NewServiceUnavailable("Server is shutting down.").Write(r.Context(), m.logger, w)
+				return
+			}
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
 // Go's http.StripPrefix can sometimes result in an empty path. For example,
 // when removing a prefix like "/foo" from path "/foo", the result is "".  This
 // does not get handled by the ServeMux correctly (it results in a redirect to
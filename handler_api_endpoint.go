@@ -5,13 +5,16 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
 	"net/http"
 	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgerrcode"
-	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5"
 
 	"github.com/riverqueue/apiframe/apiendpoint"
 	"github.com/riverqueue/apiframe/apierror"
@@ -19,13 +22,19 @@ import (
 	"github.com/riverqueue/river"
 	"github.com/riverqueue/river/riverdriver"
 	"github.com/riverqueue/river/rivershared/startstop"
-	"github.com/riverqueue/river/rivershared/util/dbutil"
 	"github.com/riverqueue/river/rivershared/util/ptrutil"
 	"github.com/riverqueue/river/rivershared/util/sliceutil"
 	"github.com/riverqueue/river/rivertype"
 
 	"riverqueue.com/riverui/internal/apibundle"
+	"riverqueue.com/riverui/internal/pgerror"
 	"riverqueue.com/riverui/internal/querycacher"
+	"riverqueue.com/riverui/uiaudit"
+	"riverqueue.com/riverui/uiauth"
+	"riverqueue.com/riverui/uiendpoints"
+	"riverqueue.com/riverui/uikinds"
+	"riverqueue.com/riverui/uiredact"
+	"riverqueue.com/riverui/uischema"
 )
 
 type listResponse[T any] struct {
@@ -42,6 +51,82 @@ type statusResponse struct {
 
 var statusResponseOK = &statusResponse{Status: "ok"} //nolint:gochecknoglobals
 
+//
+// auditListEndpoint
+//
+
+type auditListEndpoint[TTx any] struct {
+	apibundle.APIBundle[TTx]
+	apiendpoint.Endpoint[auditListRequest, listResponse[AuditEntry]]
+}
+
+func newAuditListEndpoint[TTx any](bundle apibundle.APIBundle[TTx]) *auditListEndpoint[TTx] {
+	return &auditListEndpoint[TTx]{APIBundle: bundle}
+}
+
+func (*auditListEndpoint[TTx]) Meta() *apiendpoint.EndpointMeta {
+	return &apiendpoint.EndpointMeta{
+		Pattern:    "GET /api/audit",
+		StatusCode: http.StatusOK,
+	}
+}
+
+func (*auditListEndpoint[TTx]) Access() uiauth.Access { return uiauth.AccessAdmin }
+
+type auditListRequest struct {
+	Action      string `json:"-" query:"action"`                                          // from ExtractRaw
+	Actor       string `json:"-" query:"actor"`                                           // from ExtractRaw
+	BeforeID    *int64 `json:"-" query:"before_id"   validate:"omitempty,min=1"`          // from ExtractRaw
+	Environment string `json:"-" query:"environment"`                                     // from ExtractRaw
+	Limit       *int   `json:"-" query:"limit"       validate:"omitempty,min=1,max=1000"` // from ExtractRaw
+}
+
+func (req *auditListRequest) ExtractRaw(r *http.Request) error {
+	req.Action = r.URL.Query().Get("action")
+	req.Actor = r.URL.Query().Get("actor")
+	req.Environment = r.URL.Query().Get("environment")
+
+	if beforeIDStr := r.URL.Query().Get("before_id"); beforeIDStr != "" {
+		beforeID, err := strconv.ParseInt(beforeIDStr, 10, 64)
+		if err != nil {
+			return apierror.NewBadRequestf("Couldn't convert `before_id` to integer: %s.", err)
+		}
+
+		req.BeforeID = &beforeID
+	}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil {
+			return apierror.NewBadRequestf("Couldn't convert `limit` to integer: %s.", err)
+		}
+
+		req.Limit = &limit
+	}
+
+	return nil
+}
+
+func (a *auditListEndpoint[TTx]) Execute(ctx context.Context, req *auditListRequest) (*listResponse[AuditEntry], error) {
+	lister, ok := a.AuditSink.(uiaudit.Lister)
+	if !ok {
+		return nil, apierror.NewNotFound("No browsable audit log is configured.")
+	}
+
+	entries, err := lister.List(ctx, &uiaudit.ListParams{
+		Action:      req.Action,
+		Actor:       req.Actor,
+		BeforeID:    ptrutil.ValOrDefault(req.BeforeID, 0),
+		Environment: req.Environment,
+		Limit:       ptrutil.ValOrDefault(req.Limit, 100),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing audit entries: %w", err)
+	}
+
+	return listResponseFrom(sliceutil.Map(entries, auditEntryToSerializableAuditEntry)), nil
+}
+
 //
 // autocompleteListEndpoint
 //
@@ -62,6 +147,8 @@ func (*autocompleteListEndpoint[TTx]) Meta() *apiendpoint.EndpointMeta {
 	}
 }
 
+func (*autocompleteListEndpoint[TTx]) Access() uiauth.Access { return uiauth.AccessRead }
+
 type autocompleteFacet string
 
 const (
@@ -70,10 +157,10 @@ const (
 )
 
 type autocompleteListRequest struct {
-	After   *string           `json:"-"` // from ExtractRaw
-	Exclude []string          `json:"-"` // from ExtractRaw
-	Facet   autocompleteFacet `json:"-"` // from ExtractRaw
-	Match   *string           `json:"-"` // from ExtractRaw
+	After   *string           `json:"-" query:"after"`   // from ExtractRaw
+	Exclude []string          `json:"-" query:"exclude"` // from ExtractRaw
+	Facet   autocompleteFacet `json:"-" query:"facet"`   // from ExtractRaw
+	Match   *string           `json:"-" query:"match"`   // from ExtractRaw
 }
 
 func (req *autocompleteListRequest) ExtractRaw(r *http.Request) error {
@@ -97,7 +184,7 @@ func (req *autocompleteListRequest) ExtractRaw(r *http.Request) error {
 }
 
 func (a *autocompleteListEndpoint[TTx]) Execute(ctx context.Context, req *autocompleteListRequest) (*listResponse[string], error) {
-	return dbutil.WithTxV(ctx, a.DB, func(ctx context.Context, execTx riverdriver.ExecutorTx) (*listResponse[string], error) {
+	return uiendpoints.WithTxV(ctx, a.ReadDB(), func(ctx context.Context, execTx riverdriver.ExecutorTx) (*listResponse[string], error) {
 		tx := a.Driver.UnwrapTx(execTx)
 
 		match := ""
@@ -123,6 +210,11 @@ func (a *autocompleteListEndpoint[TTx]) Execute(ctx context.Context, req *autoco
 				return nil, fmt.Errorf("error listing job kinds: %w", err)
 			}
 
+			identity := uiauth.IdentityFromContext(ctx)
+			kinds = slices.DeleteFunc(kinds, func(kind string) bool {
+				return !a.AuthorizationPolicy.CanSeeKind(identity, kind)
+			})
+
 			kindPtrs := make([]*string, len(kinds))
 			for i, kind := range kinds {
 				kindCopy := kind
@@ -143,6 +235,11 @@ func (a *autocompleteListEndpoint[TTx]) Execute(ctx context.Context, req *autoco
 				return nil, fmt.Errorf("error listing queue names: %w", err)
 			}
 
+			identity := uiauth.IdentityFromContext(ctx)
+			queues = slices.DeleteFunc(queues, func(queue string) bool {
+				return !a.AuthorizationPolicy.CanSeeQueue(identity, queue)
+			})
+
 			queuePtrs := make([]*string, len(queues))
 			for i, queue := range queues {
 				queueCopy := queue
@@ -177,11 +274,41 @@ func (*featuresGetEndpoint[TTx]) Meta() *apiendpoint.EndpointMeta {
 	}
 }
 
+func (*featuresGetEndpoint[TTx]) Access() uiauth.Access { return uiauth.AccessRead }
+
 type featuresGetRequest struct{}
 
 type featuresGetResponse struct {
-	Extensions               map[string]bool `json:"extensions"`
-	JobListHideArgsByDefault bool            `json:"job_list_hide_args_by_default"`
+	Environment              string              `json:"environment"`
+	Environments             []string            `json:"environments"`
+	Extensions               map[string]bool     `json:"extensions"`
+	Identity                 *featuresIdentity   `json:"identity"`
+	JobListHideArgsByDefault bool                `json:"job_list_hide_args_by_default"`
+	Permissions              featuresPermissions `json:"permissions"`
+	Replica                  *featuresReplica    `json:"replica"`
+}
+
+// featuresIdentity is the identity making the request, or nil for anonymous
+// requests.
+type featuresIdentity struct {
+	Name  string        `json:"name"`
+	Roles []uiauth.Role `json:"roles"`
+}
+
+// featuresPermissions tells the frontend which levels of access the current
+// identity has so that it can hide actions that would be rejected.
+type featuresPermissions struct {
+	Admin  bool `json:"admin"`
+	Mutate bool `json:"mutate"`
+	Read   bool `json:"read"`
+}
+
+// featuresReplica describes the read replica that read-only endpoints query,
+// or is nil if none is configured.
+type featuresReplica struct {
+	// LagSeconds is how far the replica's replayed data trails the primary,
+	// or nil if it couldn't be determined.
+	LagSeconds *float64 `json:"lag_seconds"`
 }
 
 func (a *featuresGetEndpoint[TTx]) Execute(ctx context.Context, _ *featuresGetRequest) (*featuresGetResponse, error) {
@@ -190,16 +317,67 @@ func (a *featuresGetEndpoint[TTx]) Execute(ctx context.Context, _ *featuresGetRe
 		return nil, err
 	}
 
+	var replica *featuresReplica
+	if a.ReplicaDB != nil {
+		replica = &featuresReplica{}
+
+		// The replica being unavailable shouldn't break the features that the
+		// rest of the UI depends on, so log the error and report unknown lag.
+		// Lag can only be measured on Postgres.
+		if a.Driver.DatabaseName() == riverdriver.DatabaseNamePostgres {
+			if replica.LagSeconds, err = replicaLagSeconds(ctx, a.ReplicaDB); err != nil {
+				a.Logger.WarnContext(ctx, "Error getting replica lag", slog.String("error", err.Error()))
+			}
+		}
+	}
+
+	identity := uiauth.IdentityFromContext(ctx)
+
+	var respIdentity *featuresIdentity
+	if identity != nil && identity.Name != "" {
+		respIdentity = &featuresIdentity{
+			Name:  identity.Name,
+			Roles: identity.Roles,
+		}
+	}
+
 	return &featuresGetResponse{
+		Environment:              a.Environment,
+		Environments:             a.Environments,
 		Extensions:               extensions,
+		Identity:                 respIdentity,
 		JobListHideArgsByDefault: a.JobListHideArgsByDefault,
+		Permissions: featuresPermissions{
+			Admin:  identity.Allows(uiauth.AccessAdmin),
+			Mutate: identity.Allows(uiauth.AccessMutate),
+			Read:   identity.Allows(uiauth.AccessRead),
+		},
+		Replica: replica,
 	}, nil
 }
 
+// Gets how long ago the last transaction replayed on a replica was committed
+// on the primary, or nil if the database isn't a replica or hasn't replayed
+// anything yet. Note that a primary with no write traffic causes lag to appear
+// to grow even though the replica is caught up.
+func replicaLagSeconds(ctx context.Context, exec riverdriver.Executor) (*float64, error) {
+	var lagSeconds *float64
+	if err := exec.QueryRow(ctx, `
+		SELECT CASE WHEN pg_is_in_recovery()
+			THEN greatest(extract(epoch FROM now() - pg_last_xact_replay_timestamp()), 0)::float8
+		END
+	`).Scan(&lagSeconds); err != nil {
+		return nil, fmt.Errorf("error querying replica lag: %w", err)
+	}
+	return lagSeconds, nil
+}
+
 //
 // healthCheckGetEndpoint
 //
 
+const healthCheckGetPattern = "GET /api/health-checks/{name}"
+
 type healthCheckGetEndpoint[TTx any] struct {
 	apibundle.APIBundle[TTx]
 	apiendpoint.Endpoint[healthCheckGetRequest, statusResponse]
@@ -211,11 +389,13 @@ func newHealthCheckGetEndpoint[TTx any](bundle apibundle.APIBundle[TTx]) *health
 
 func (*healthCheckGetEndpoint[TTx]) Meta() *apiendpoint.EndpointMeta {
 	return &apiendpoint.EndpointMeta{
-		Pattern:    "GET /api/health-checks/{name}",
+		Pattern:    healthCheckGetPattern,
 		StatusCode: http.StatusOK,
 	}
 }
 
+func (*healthCheckGetEndpoint[TTx]) Access() uiauth.Access { return uiauth.AccessRead }
+
 type healthCheckName string
 
 const (
@@ -224,7 +404,7 @@ const (
 )
 
 type healthCheckGetRequest struct {
-	Name healthCheckName `json:"-"` // from ExtractRaw
+	Name healthCheckName `json:"-" path:"name"` // from ExtractRaw
 }
 
 func (req *healthCheckGetRequest) ExtractRaw(r *http.Request) error {
@@ -272,29 +452,46 @@ func (*jobCancelEndpoint[TTx]) Meta() *apiendpoint.EndpointMeta {
 	}
 }
 
+func (*jobCancelEndpoint[TTx]) Access() uiauth.Access { return uiauth.AccessMutate }
+func (*jobCancelEndpoint[TTx]) AuditAction() string   { return string(uiauth.ActionJobCancel) }
+
 type jobCancelRequest struct {
 	JobIDs []int64String `json:"ids" validate:"required,min=1,max=1000"`
 }
 
 func (a *jobCancelEndpoint[TTx]) Execute(ctx context.Context, req *jobCancelRequest) (*statusResponse, error) {
-	return dbutil.WithTxV(ctx, a.DB, func(ctx context.Context, execTx riverdriver.ExecutorTx) (*statusResponse, error) {
-		tx := a.Driver.UnwrapTx(execTx)
+	jobIDs := int64StringsToInt64s(req.JobIDs)
 
-		updatedJobs := make(map[int64]*rivertype.JobRow)
-		for _, jobID := range req.JobIDs {
-			jobID := int64(jobID)
-			job, err := a.Client.JobCancelTx(ctx, tx, jobID)
-			if err != nil {
-				if errors.Is(err, river.ErrNotFound) {
-					return nil, NewNotFoundJob(jobID)
-				}
+	auditEntry := &uiaudit.Entry{
+		Action:   a.AuditAction(),
+		Endpoint: a.Meta().Pattern,
+		JobIDs:   jobIDs,
+	}
+
+	return uiendpoints.WithAudit(ctx, a.APIBundle, auditEntry, req, func() (*statusResponse, error) {
+		return uiendpoints.WithTxV(ctx, a.DB, func(ctx context.Context, execTx riverdriver.ExecutorTx) (*statusResponse, error) {
+			tx := a.Driver.UnwrapTx(execTx)
+
+			if err := authorizeJobAction(ctx, a.APIBundle, tx, uiauth.ActionJobCancel, jobIDs); err != nil {
 				return nil, err
 			}
-			updatedJobs[jobID] = job
-		}
 
-		// TODO: return jobs in response, use in frontend instead of invalidating
-		return statusResponseOK, nil
+			updatedJobs := make(map[int64]*rivertype.JobRow)
+			for _, jobID := range req.JobIDs {
+				jobID := int64(jobID)
+				job, err := a.Client.JobCancelTx(ctx, tx, jobID)
+				if err != nil {
+					if errors.Is(err, river.ErrNotFound) {
+						return nil, NewNotFoundJob(jobID)
+					}
+					return nil, err
+				}
+				updatedJobs[jobID] = job
+			}
+
+			// TODO: return jobs in response, use in frontend instead of invalidating
+			return statusResponseOK, nil
+		})
 	})
 }
 
@@ -318,29 +515,46 @@ func (*jobDeleteEndpoint[TTx]) Meta() *apiendpoint.EndpointMeta {
 	}
 }
 
+func (*jobDeleteEndpoint[TTx]) Access() uiauth.Access { return uiauth.AccessMutate }
+func (*jobDeleteEndpoint[TTx]) AuditAction() string   { return string(uiauth.ActionJobDelete) }
+
 type jobDeleteRequest struct {
 	JobIDs []int64String `json:"ids" validate:"required,min=1,max=1000"`
 }
 
 func (a *jobDeleteEndpoint[TTx]) Execute(ctx context.Context, req *jobDeleteRequest) (*statusResponse, error) {
-	return dbutil.WithTxV(ctx, a.DB, func(ctx context.Context, execTx riverdriver.ExecutorTx) (*statusResponse, error) {
-		tx := a.Driver.UnwrapTx(execTx)
+	jobIDs := int64StringsToInt64s(req.JobIDs)
 
-		for _, jobID := range req.JobIDs {
-			jobID := int64(jobID)
-			_, err := a.Client.JobDeleteTx(ctx, tx, jobID)
-			if err != nil {
-				if errors.Is(err, rivertype.ErrJobRunning) {
-					return nil, apierror.NewBadRequestf("Job %d is running and can't be deleted until it finishes.", jobID)
-				}
-				if errors.Is(err, river.ErrNotFound) {
-					return nil, NewNotFoundJob(jobID)
-				}
+	auditEntry := &uiaudit.Entry{
+		Action:   a.AuditAction(),
+		Endpoint: a.Meta().Pattern,
+		JobIDs:   jobIDs,
+	}
+
+	return uiendpoints.WithAudit(ctx, a.APIBundle, auditEntry, req, func() (*statusResponse, error) {
+		return uiendpoints.WithTxV(ctx, a.DB, func(ctx context.Context, execTx riverdriver.ExecutorTx) (*statusResponse, error) {
+			tx := a.Driver.UnwrapTx(execTx)
+
+			if err := authorizeJobAction(ctx, a.APIBundle, tx, uiauth.ActionJobDelete, jobIDs); err != nil {
 				return nil, err
 			}
-		}
 
-		return statusResponseOK, nil
+			for _, jobID := range req.JobIDs {
+				jobID := int64(jobID)
+				_, err := a.Client.JobDeleteTx(ctx, tx, jobID)
+				if err != nil {
+					if errors.Is(err, rivertype.ErrJobRunning) {
+						return nil, apierror.NewBadRequestf("Job %d is running and can't be deleted until it finishes.", jobID)
+					}
+					if errors.Is(err, river.ErrNotFound) {
+						return nil, NewNotFoundJob(jobID)
+					}
+					return nil, err
+				}
+			}
+
+			return statusResponseOK, nil
+		})
 	})
 }
 
@@ -364,8 +578,10 @@ func (*jobGetEndpoint[TTx]) Meta() *apiendpoint.EndpointMeta {
 	}
 }
 
+func (*jobGetEndpoint[TTx]) Access() uiauth.Access { return uiauth.AccessRead }
+
 type jobGetRequest struct {
-	JobID int64 `json:"-" validate:"required"` // from ExtractRaw
+	JobID int64 `json:"-" path:"job_id" validate:"required"` // from ExtractRaw
 }
 
 func (req *jobGetRequest) ExtractRaw(r *http.Request) error {
@@ -382,7 +598,7 @@ func (req *jobGetRequest) ExtractRaw(r *http.Request) error {
 }
 
 func (a *jobGetEndpoint[TTx]) Execute(ctx context.Context, req *jobGetRequest) (*RiverJob, error) {
-	return dbutil.WithTxV(ctx, a.DB, func(ctx context.Context, execTx riverdriver.ExecutorTx) (*RiverJob, error) {
+	return uiendpoints.WithTxV(ctx, a.DB, func(ctx context.Context, execTx riverdriver.ExecutorTx) (*RiverJob, error) {
 		tx := a.Driver.UnwrapTx(execTx)
 
 		job, err := a.Client.JobGetTx(ctx, tx, req.JobID)
@@ -392,7 +608,22 @@ func (a *jobGetEndpoint[TTx]) Execute(ctx context.Context, req *jobGetRequest) (
 			}
 			return nil, fmt.Errorf("error getting job: %w", err)
 		}
-		return riverJobToSerializableJob(job), nil
+
+		if !a.AuthorizationPolicy.CanSeeJob(uiauth.IdentityFromContext(ctx), job.Queue, job.Kind) {
+			return nil, NewNotFoundJob(req.JobID)
+		}
+
+		// Args are decoded before they're redacted so that redaction applies to
+		// the decoded form.
+		job, argsDecoded, argsDecodeErr := a.DecodeJobArgs(ctx, job)
+
+		if job, err = uiredact.Apply(ctx, a.Redactor, job); err != nil {
+			return nil, fmt.Errorf("error redacting job: %w", err)
+		}
+
+		serializableJob := riverJobToSerializableJob(job)
+		serializableJob.setArgsDecoded(argsDecoded, argsDecodeErr)
+		return serializableJob, nil
 	})
 }
 
@@ -416,14 +647,16 @@ func (*jobListEndpoint[TTx]) Meta() *apiendpoint.EndpointMeta {
 	}
 }
 
+func (*jobListEndpoint[TTx]) Access() uiauth.Access { return uiauth.AccessRead }
+
 type jobListRequest struct {
-	IDs        []int64             `json:"-" validate:"omitempty,min=1,max=1000"`                                                                    // from ExtractRaw
-	Kinds      []string            `json:"-" validate:"omitempty,max=100"`                                                                           // from ExtractRaw
-	Limit      *int                `json:"-" validate:"omitempty,min=0,max=1000"`                                                                    // from ExtractRaw
-	Priorities []int16             `json:"-" validate:"omitempty,min=0,max=10"`                                                                      // from ExtractRaw
-	Queues     []string            `json:"-" validate:"omitempty,max=100"`                                                                           // from ExtractRaw
-	State      *rivertype.JobState `json:"-" validate:"omitempty,oneof=available cancelled completed discarded pending retryable running scheduled"` // from ExtractRaw
-	Tags       []string            `json:"-" validate:"omitempty,max=100"`                                                                           // from ExtractRaw
+	IDs        []int64             `json:"-" query:"ids"        validate:"omitempty,min=1,max=1000"`                                                                    // from ExtractRaw
+	Kinds      []string            `json:"-" query:"kinds"      validate:"omitempty,max=100"`                                                                           // from ExtractRaw
+	Limit      *int                `json:"-" query:"limit"      validate:"omitempty,min=0,max=1000"`                                                                    // from ExtractRaw
+	Priorities []int16             `json:"-" query:"priorities" validate:"omitempty,min=0,max=10"`                                                                      // from ExtractRaw
+	Queues     []string            `json:"-" query:"queues"     validate:"omitempty,max=100"`                                                                           // from ExtractRaw
+	State      *rivertype.JobState `json:"-" query:"state"      validate:"omitempty,oneof=available cancelled completed discarded pending retryable running scheduled"` // from ExtractRaw
+	Tags       []string            `json:"-" query:"tags"       validate:"omitempty,max=100"`                                                                           // from ExtractRaw
 }
 
 func (req *jobListRequest) ExtractRaw(r *http.Request) error {
@@ -476,7 +709,7 @@ func (req *jobListRequest) ExtractRaw(r *http.Request) error {
 }
 
 func (a *jobListEndpoint[TTx]) Execute(ctx context.Context, req *jobListRequest) (*listResponse[RiverJobMinimal], error) {
-	return dbutil.WithTxV(ctx, a.DB, func(ctx context.Context, execTx riverdriver.ExecutorTx) (*listResponse[RiverJobMinimal], error) {
+	return uiendpoints.WithTxV(ctx, a.ReadDB(), func(ctx context.Context, execTx riverdriver.ExecutorTx) (*listResponse[RiverJobMinimal], error) {
 		tx := a.Driver.UnwrapTx(execTx)
 
 		params := river.NewJobListParams().First(ptrutil.ValOrDefault(req.Limit, 20))
@@ -501,6 +734,12 @@ func (a *jobListEndpoint[TTx]) Execute(ctx context.Context, req *jobListRequest)
 			params = params.TagsAny(req.Tags...)
 		}
 
+		if identity := uiauth.IdentityFromContext(ctx); a.AuthorizationPolicy.FiltersLists(identity) {
+			if predicate, namedArgs := jobVisibilityPredicate(a.AuthorizationPolicy.RulesFor(identity)); predicate != "" {
+				params = params.Where(predicate, namedArgs)
+			}
+		}
+
 		if req.State == nil {
 			params = params.States(rivertype.JobStateRunning).OrderBy(river.JobListOrderByTime, river.SortOrderAsc)
 		} else {
@@ -517,7 +756,12 @@ func (a *jobListEndpoint[TTx]) Execute(ctx context.Context, req *jobListRequest)
 			return nil, fmt.Errorf("error listing jobs: %w", err)
 		}
 
-		return listResponseFrom(sliceutil.Map(result.Jobs, riverJobToSerializableJobMinimal)), nil
+		jobs, err := riverJobsToSerializableJobsMinimal(ctx, a.APIBundle, result.Jobs)
+		if err != nil {
+			return nil, err
+		}
+
+		return listResponseFrom(jobs), nil
 	})
 }
 
@@ -541,29 +785,46 @@ func (*jobRetryEndpoint[TTx]) Meta() *apiendpoint.EndpointMeta {
 	}
 }
 
+func (*jobRetryEndpoint[TTx]) Access() uiauth.Access { return uiauth.AccessMutate }
+func (*jobRetryEndpoint[TTx]) AuditAction() string   { return string(uiauth.ActionJobRetry) }
+
 type jobRetryRequest struct {
 	JobIDs []int64String `json:"ids" validate:"required,min=1,max=1000"`
 }
 
 func (a *jobRetryEndpoint[TTx]) Execute(ctx context.Context, req *jobRetryRequest) (*statusResponse, error) {
-	return dbutil.WithTxV(ctx, a.DB, func(ctx context.Context, execTx riverdriver.ExecutorTx) (*statusResponse, error) {
-		tx := a.Driver.UnwrapTx(execTx)
+	jobIDs := int64StringsToInt64s(req.JobIDs)
 
-		for _, jobID := range req.JobIDs {
-			jobID := int64(jobID)
-			_, err := a.Client.JobRetryTx(ctx, tx, jobID)
-			if err != nil {
-				if errors.Is(err, river.ErrNotFound) {
-					return nil, NewNotFoundJob(jobID)
-				}
-				if isJobRetryUniqueConflict(err) {
-					return nil, newJobRetryUniqueConflictError(err)
-				}
+	auditEntry := &uiaudit.Entry{
+		Action:   a.AuditAction(),
+		Endpoint: a.Meta().Pattern,
+		JobIDs:   jobIDs,
+	}
+
+	return uiendpoints.WithAudit(ctx, a.APIBundle, auditEntry, req, func() (*statusResponse, error) {
+		return uiendpoints.WithTxV(ctx, a.DB, func(ctx context.Context, execTx riverdriver.ExecutorTx) (*statusResponse, error) {
+			tx := a.Driver.UnwrapTx(execTx)
+
+			if err := authorizeJobAction(ctx, a.APIBundle, tx, uiauth.ActionJobRetry, jobIDs); err != nil {
 				return nil, err
 			}
-		}
 
-		return statusResponseOK, nil
+			for _, jobID := range req.JobIDs {
+				jobID := int64(jobID)
+				_, err := a.Client.JobRetryTx(ctx, tx, jobID)
+				if err != nil {
+					if errors.Is(err, river.ErrNotFound) {
+						return nil, NewNotFoundJob(jobID)
+					}
+					if isJobRetryUniqueConflict(err) {
+						return nil, newJobRetryUniqueConflictError(err)
+					}
+					return nil, err
+				}
+			}
+
+			return statusResponseOK, nil
+		})
 	})
 }
 
@@ -586,11 +847,295 @@ func newJobRetryUniqueConflictError(internalErr error) *jobRetryUniqueConflictEr
 	}
 }
 
+// SQLite drivers don't report the name of a violated index, but describe
+// unique violations by the columns involved, and river_job's only unique
+// index other than its primary key is on unique_key.
+const jobRetryUniqueSQLiteMessage = "UNIQUE constraint failed: river_job.unique_key"
+
 func isJobRetryUniqueConflict(err error) bool {
-	var pgErr *pgconn.PgError
-	return errors.As(err, &pgErr) &&
-		pgErr.Code == pgerrcode.UniqueViolation &&
-		pgErr.ConstraintName == jobRetryUniqueConstraint
+	if pgErr, ok := pgerror.As(err); ok {
+		return pgErr.Code == pgerrcode.UniqueViolation && pgErr.ConstraintName == jobRetryUniqueConstraint
+	}
+	return strings.Contains(err.Error(), jobRetryUniqueSQLiteMessage)
+}
+
+//
+// kindListEndpoint
+//
+
+type kindListEndpoint[TTx any] struct {
+	apibundle.APIBundle[TTx]
+	apiendpoint.Endpoint[kindListRequest, kindListResponse]
+}
+
+func newKindListEndpoint[TTx any](bundle apibundle.APIBundle[TTx]) *kindListEndpoint[TTx] {
+	return &kindListEndpoint[TTx]{APIBundle: bundle}
+}
+
+func (*kindListEndpoint[TTx]) Meta() *apiendpoint.EndpointMeta {
+	return &apiendpoint.EndpointMeta{
+		Pattern:    "GET /api/kinds",
+		StatusCode: http.StatusOK,
+	}
+}
+
+func (*kindListEndpoint[TTx]) Access() uiauth.Access { return uiauth.AccessRead }
+
+type kindListRequest struct {
+	StaleAfter *time.Duration `json:"-" query:"stale_after"` // from ExtractRaw
+}
+
+func (req *kindListRequest) ExtractRaw(r *http.Request) error {
+	if staleAfterStr := r.URL.Query().Get("stale_after"); staleAfterStr != "" {
+		staleAfter, err := time.ParseDuration(staleAfterStr)
+		if err != nil {
+			return apierror.NewBadRequestf("Couldn't convert `stale_after` to duration: %s.", err)
+		}
+		if staleAfter <= 0 {
+			return apierror.NewBadRequest("`stale_after` must be positive.")
+		}
+
+		req.StaleAfter = &staleAfter
+	}
+
+	return nil
+}
+
+type kindListResponse struct {
+	Data []*RiverKind `json:"data"`
+
+	// RegistryConfigured is whether a kind registry is configured. Without
+	// one, no kinds are registered and none are flagged as unhandled or stale.
+	RegistryConfigured bool `json:"registry_configured"`
+}
+
+// kindListMax is the maximum number of kinds listed from the database.
+const kindListMax = 1_000
+
+func (a *kindListEndpoint[TTx]) Execute(ctx context.Context, req *kindListRequest) (*kindListResponse, error) {
+	var registrations []*uikinds.Registration
+	if a.KindRegistry != nil {
+		var err error
+		registrations, err = a.KindRegistry.List(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error listing kind registrations: %w", err)
+		}
+	}
+
+	return uiendpoints.WithTxV(ctx, a.ReadDB(), func(ctx context.Context, execTx riverdriver.ExecutorTx) (*kindListResponse, error) {
+		tx := a.Driver.UnwrapTx(execTx)
+
+		jobKinds, err := a.Driver.UnwrapExecutor(tx).JobKindList(ctx, &riverdriver.JobKindListParams{
+			Max:    kindListMax,
+			Schema: a.Client.Schema(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error listing job kinds: %w", err)
+		}
+
+		kinds := kindsFromRegistrations(jobKinds, registrations, a.Archetype.Time.Now().UTC(), ptrutil.ValOrDefault(req.StaleAfter, uikinds.DefaultStaleAfter))
+
+		identity := uiauth.IdentityFromContext(ctx)
+		kinds = slices.DeleteFunc(kinds, func(kind *RiverKind) bool {
+			return !a.AuthorizationPolicy.CanSeeKind(identity, kind.Kind)
+		})
+
+		// Without a registry there's no telling which kinds are handled, so
+		// only look for pending jobs of kinds missing a live registration when
+		// one is configured.
+		if a.KindRegistry != nil {
+			var unregisteredKinds []string
+			for _, kind := range kinds {
+				if !kind.Registered {
+					unregisteredKinds = append(unregisteredKinds, kind.Kind)
+				}
+			}
+
+			if len(unregisteredKinds) > 0 {
+				oldestPendingAtByKind, err := kindOldestPendingAt(ctx, execTx, a.Driver.DatabaseName(), a.Client.Schema(), unregisteredKinds)
+				if err != nil {
+					return nil, err
+				}
+
+				for _, kind := range kinds {
+					if oldestPendingAt, ok := oldestPendingAtByKind[kind.Kind]; ok {
+						oldestPendingAt = oldestPendingAt.UTC()
+						kind.OldestPendingAt = &oldestPendingAt
+						kind.Unhandled = true
+					}
+				}
+			}
+		}
+
+		return &kindListResponse{
+			Data:               kinds,
+			RegistryConfigured: a.KindRegistry != nil,
+		}, nil
+	})
+}
+
+// Gets the scheduled time of the oldest pending (available, retryable, or
+// scheduled) job of each of the given kinds, omitting kinds without any. It's
+// one grouped query rather than a job list per kind because up to
+// kindListMax kinds may be missing a registration. The executor only returns
+// single rows, so results are aggregated into a JSON object, and kinds are
+// passed as a JSON array so that it works the same with every driver.
+func kindOldestPendingAt(ctx context.Context, exec riverdriver.Executor, databaseName, schema string, kinds []string) (map[string]time.Time, error) {
+	table := pgx.Identifier{"river_job"}
+	if schema != "" {
+		table = pgx.Identifier{schema, "river_job"}
+	}
+
+	kindsJSON, err := json.Marshal(kinds)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding kinds: %w", err)
+	}
+
+	var query string
+	if databaseName == riverdriver.DatabaseNamePostgres {
+		query = `
+			SELECT coalesce(json_object_agg(kind, oldest_pending_at), '{}')::text
+			FROM (
+				SELECT kind, min(scheduled_at) AS oldest_pending_at
+				FROM %s
+				WHERE kind IN (SELECT jsonb_array_elements_text($1::text::jsonb))
+					AND state IN ('available', 'retryable', 'scheduled')
+				GROUP BY kind
+			) AS pending`
+	} else {
+		// SQLite stores timestamps as text, so format them as RFC 3339.
+		query = `
+			SELECT coalesce(json_group_object(kind, oldest_pending_at), '{}')
+			FROM (
+				SELECT kind, strftime('%%Y-%%m-%%dT%%H:%%M:%%fZ', min(scheduled_at)) AS oldest_pending_at
+				FROM %s
+				WHERE kind IN (SELECT value FROM json_each($1))
+					AND state IN ('available', 'retryable', 'scheduled')
+				GROUP BY kind
+			) AS pending`
+	}
+
+	var oldestPendingAtJSON string
+	if err := exec.QueryRow(ctx, fmt.Sprintf(query, table.Sanitize()), string(kindsJSON)).Scan(&oldestPendingAtJSON); err != nil {
+		return nil, fmt.Errorf("error listing oldest pending jobs by kind: %w", err)
+	}
+
+	var oldestPendingAtByKind map[string]time.Time
+	if err := json.Unmarshal([]byte(oldestPendingAtJSON), &oldestPendingAtByKind); err != nil {
+		return nil, fmt.Errorf("error decoding oldest pending jobs by kind: %w", err)
+	}
+	return oldestPendingAtByKind, nil
+}
+
+//
+// kindSchemaGetEndpoint
+//
+
+type kindSchemaGetEndpoint[TTx any] struct {
+	apibundle.APIBundle[TTx]
+	apiendpoint.Endpoint[kindSchemaGetRequest, kindSchemaGetResponse]
+}
+
+func newKindSchemaGetEndpoint[TTx any](bundle apibundle.APIBundle[TTx]) *kindSchemaGetEndpoint[TTx] {
+	return &kindSchemaGetEndpoint[TTx]{APIBundle: bundle}
+}
+
+func (*kindSchemaGetEndpoint[TTx]) Meta() *apiendpoint.EndpointMeta {
+	return &apiendpoint.EndpointMeta{
+		Pattern:    "GET /api/kinds/{kind}/schema",
+		StatusCode: http.StatusOK,
+	}
+}
+
+func (*kindSchemaGetEndpoint[TTx]) Access() uiauth.Access { return uiauth.AccessRead }
+
+type kindSchemaGetRequest struct {
+	Kind string `json:"-" path:"kind" validate:"required"` // from ExtractRaw
+}
+
+func (req *kindSchemaGetRequest) ExtractRaw(r *http.Request) error {
+	req.Kind = r.PathValue("kind")
+	return nil
+}
+
+type kindSchemaGetResponse struct {
+	Kind string `json:"kind"`
+
+	// Schema is the JSON Schema of the kind's args, or null if it doesn't have
+	// one, in which case its args should be edited as raw JSON.
+	Schema json.RawMessage `json:"schema"`
+}
+
+func (a *kindSchemaGetEndpoint[TTx]) Execute(ctx context.Context, req *kindSchemaGetRequest) (*kindSchemaGetResponse, error) {
+	if !a.AuthorizationPolicy.CanSeeKind(uiauth.IdentityFromContext(ctx), req.Kind) {
+		return nil, NewNotFoundKind(req.Kind)
+	}
+
+	resp := &kindSchemaGetResponse{Kind: req.Kind}
+	if schema := a.ArgsSchemas.Schema(req.Kind); schema != nil {
+		var err error
+		if resp.Schema, err = json.Marshal(schema); err != nil {
+			return nil, fmt.Errorf("error marshaling schema: %w", err)
+		}
+	}
+
+	return resp, nil
+}
+
+//
+// kindValidateEndpoint
+//
+
+type kindValidateEndpoint[TTx any] struct {
+	apibundle.APIBundle[TTx]
+	apiendpoint.Endpoint[kindValidateRequest, kindValidateResponse]
+}
+
+func newKindValidateEndpoint[TTx any](bundle apibundle.APIBundle[TTx]) *kindValidateEndpoint[TTx] {
+	return &kindValidateEndpoint[TTx]{APIBundle: bundle}
+}
+
+func (*kindValidateEndpoint[TTx]) Meta() *apiendpoint.EndpointMeta {
+	return &apiendpoint.EndpointMeta{
+		Pattern:    "POST /api/kinds/{kind}/validate",
+		StatusCode: http.StatusOK,
+	}
+}
+
+func (*kindValidateEndpoint[TTx]) Access() uiauth.Access { return uiauth.AccessRead }
+
+type kindValidateRequest struct {
+	Args json.RawMessage `json:"args" validate:"required"`
+	Kind string          `json:"-"    path:"kind"         validate:"required"` // from ExtractRaw
+}
+
+func (req *kindValidateRequest) ExtractRaw(r *http.Request) error {
+	req.Kind = r.PathValue("kind")
+	return nil
+}
+
+type kindValidateResponse struct {
+	// Errors are the ways in which args don't match the kind's schema, or
+	// empty if they're valid.
+	Errors []*uischema.FieldError `json:"errors"`
+
+	Valid bool `json:"valid"`
+}
+
+func (a *kindValidateEndpoint[TTx]) Execute(ctx context.Context, req *kindValidateRequest) (*kindValidateResponse, error) {
+	if !a.AuthorizationPolicy.CanSeeKind(uiauth.IdentityFromContext(ctx), req.Kind) {
+		return nil, NewNotFoundKind(req.Kind)
+	}
+
+	if err := a.ArgsSchemas.Validate(req.Kind, req.Args); err != nil {
+		var validationErr *uischema.ValidationError
+		if !errors.As(err, &validationErr) {
+			return nil, fmt.Errorf("error validating args: %w", err)
+		}
+		return &kindValidateResponse{Errors: validationErr.Errors, Valid: false}, nil
+	}
+
+	return &kindValidateResponse{Errors: []*uischema.FieldError{}, Valid: true}, nil
 }
 
 //
@@ -613,8 +1158,10 @@ func (*queueGetEndpoint[TTx]) Meta() *apiendpoint.EndpointMeta {
 	}
 }
 
+func (*queueGetEndpoint[TTx]) Access() uiauth.Access { return uiauth.AccessRead }
+
 type queueGetRequest struct {
-	Name string `json:"-" validate:"required"` // from ExtractRaw
+	Name string `json:"-" path:"name" validate:"required"` // from ExtractRaw
 }
 
 func (req *queueGetRequest) ExtractRaw(r *http.Request) error {
@@ -623,7 +1170,11 @@ func (req *queueGetRequest) ExtractRaw(r *http.Request) error {
 }
 
 func (a *queueGetEndpoint[TTx]) Execute(ctx context.Context, req *queueGetRequest) (*RiverQueue, error) {
-	return dbutil.WithTxV(ctx, a.DB, func(ctx context.Context, execTx riverdriver.ExecutorTx) (*RiverQueue, error) {
+	if !a.AuthorizationPolicy.CanSeeQueue(uiauth.IdentityFromContext(ctx), req.Name) {
+		return nil, NewNotFoundQueue(req.Name)
+	}
+
+	return uiendpoints.WithTxV(ctx, a.DB, func(ctx context.Context, execTx riverdriver.ExecutorTx) (*RiverQueue, error) {
 		tx := a.Driver.UnwrapTx(execTx)
 
 		queue, err := a.Client.QueueGetTx(ctx, tx, req.Name)
@@ -666,8 +1217,14 @@ func (*queueListEndpoint[TTx]) Meta() *apiendpoint.EndpointMeta {
 	}
 }
 
+func (*queueListEndpoint[TTx]) Access() uiauth.Access { return uiauth.AccessRead }
+
+// Maximum number of queues fetched while filling a page of queues visible to
+// the current identity, which is the most River lists at once.
+const queueListFetchMax = 10_000
+
 type queueListRequest struct {
-	Limit *int `json:"-" validate:"omitempty,min=0,max=1000"` // from ExtractRaw
+	Limit *int `json:"-" query:"limit" validate:"omitempty,min=0,max=1000"` // from ExtractRaw
 }
 
 func (req *queueListRequest) ExtractRaw(r *http.Request) error {
@@ -684,15 +1241,34 @@ func (req *queueListRequest) ExtractRaw(r *http.Request) error {
 }
 
 func (a *queueListEndpoint[TTx]) Execute(ctx context.Context, req *queueListRequest) (*listResponse[RiverQueue], error) {
-	return dbutil.WithTxV(ctx, a.DB, func(ctx context.Context, execTx riverdriver.ExecutorTx) (*listResponse[RiverQueue], error) {
+	return uiendpoints.WithTxV(ctx, a.ReadDB(), func(ctx context.Context, execTx riverdriver.ExecutorTx) (*listResponse[RiverQueue], error) {
 		tx := a.Driver.UnwrapTx(execTx)
 
-		result, err := a.Client.QueueListTx(ctx, tx, river.NewQueueListParams().First(ptrutil.ValOrDefault(req.Limit, 100)))
-		if err != nil {
-			return nil, fmt.Errorf("error listing queues: %w", err)
+		var (
+			identity = uiauth.IdentityFromContext(ctx)
+			limit    = ptrutil.ValOrDefault(req.Limit, 100)
+			queues   []*rivertype.Queue
+		)
+
+		// Queues hidden by the authorization policy are filtered out after
+		// they're listed, so fetch progressively more of them until there are
+		// enough visible ones to fill the page or there are no more to fetch.
+		for fetch := limit; ; fetch = min(fetch*4, queueListFetchMax) {
+			result, err := a.Client.QueueListTx(ctx, tx, river.NewQueueListParams().First(fetch))
+			if err != nil {
+				return nil, fmt.Errorf("error listing queues: %w", err)
+			}
+
+			queues = slices.DeleteFunc(result.Queues, func(queue *rivertype.Queue) bool {
+				return !a.AuthorizationPolicy.CanSeeQueue(identity, queue.Name)
+			})
+			if len(queues) >= limit || len(result.Queues) < fetch || fetch >= queueListFetchMax {
+				break
+			}
 		}
+		queues = queues[:min(len(queues), limit)]
 
-		queueNames := sliceutil.Map(result.Queues, func(q *rivertype.Queue) string { return q.Name })
+		queueNames := sliceutil.Map(queues, func(q *rivertype.Queue) string { return q.Name })
 
 		countRows, err := a.Driver.UnwrapExecutor(tx).JobCountByQueueAndState(ctx, &riverdriver.JobCountByQueueAndStateParams{
 			QueueNames: queueNames,
@@ -702,7 +1278,7 @@ func (a *queueListEndpoint[TTx]) Execute(ctx context.Context, req *queueListRequ
 			return nil, fmt.Errorf("error getting queue counts: %w", err)
 		}
 
-		return riverQueuesToSerializableQueues(result.Queues, countRows), nil
+		return riverQueuesToSerializableQueues(queues, countRows), nil
 	})
 }
 
@@ -726,8 +1302,11 @@ func (*queuePauseEndpoint[TTx]) Meta() *apiendpoint.EndpointMeta {
 	}
 }
 
+func (*queuePauseEndpoint[TTx]) Access() uiauth.Access { return uiauth.AccessMutate }
+func (*queuePauseEndpoint[TTx]) AuditAction() string   { return string(uiauth.ActionQueuePause) }
+
 type queuePauseRequest struct {
-	Name string `json:"-" validate:"required"` // from ExtractRaw
+	Name string `json:"-" path:"name" validate:"required"` // from ExtractRaw
 }
 
 func (req *queuePauseRequest) ExtractRaw(r *http.Request) error {
@@ -736,18 +1315,30 @@ func (req *queuePauseRequest) ExtractRaw(r *http.Request) error {
 }
 
 func (a *queuePauseEndpoint[TTx]) Execute(ctx context.Context, req *queuePauseRequest) (*statusResponse, error) {
-	return dbutil.WithTxV(ctx, a.DB, func(ctx context.Context, execTx riverdriver.ExecutorTx) (*statusResponse, error) {
-		tx := a.Driver.UnwrapTx(execTx)
+	auditEntry := &uiaudit.Entry{
+		Action:   a.AuditAction(),
+		Endpoint: a.Meta().Pattern,
+		Queue:    req.Name,
+	}
 
-		err := a.Client.QueuePauseTx(ctx, tx, req.Name, nil)
-		if err != nil {
-			if errors.Is(err, river.ErrNotFound) {
-				return nil, NewNotFoundQueue(req.Name)
-			}
-			return nil, fmt.Errorf("error pausing queue: %w", err)
+	return uiendpoints.WithAudit(ctx, a.APIBundle, auditEntry, req, func() (*statusResponse, error) {
+		if err := authorizeQueueAction(ctx, a.APIBundle, uiauth.ActionQueuePause, req.Name); err != nil {
+			return nil, err
 		}
 
-		return statusResponseOK, nil
+		return uiendpoints.WithTxV(ctx, a.DB, func(ctx context.Context, execTx riverdriver.ExecutorTx) (*statusResponse, error) {
+			tx := a.Driver.UnwrapTx(execTx)
+
+			err := a.Client.QueuePauseTx(ctx, tx, req.Name, nil)
+			if err != nil {
+				if errors.Is(err, river.ErrNotFound) {
+					return nil, NewNotFoundQueue(req.Name)
+				}
+				return nil, fmt.Errorf("error pausing queue: %w", err)
+			}
+
+			return statusResponseOK, nil
+		})
 	})
 }
 
@@ -771,8 +1362,11 @@ func (*queueResumeEndpoint[TTx]) Meta() *apiendpoint.EndpointMeta {
 	}
 }
 
+func (*queueResumeEndpoint[TTx]) Access() uiauth.Access { return uiauth.AccessMutate }
+func (*queueResumeEndpoint[TTx]) AuditAction() string   { return string(uiauth.ActionQueueResume) }
+
 type queueResumeRequest struct {
-	Name string `json:"-" validate:"required"` // from ExtractRaw
+	Name string `json:"-" path:"name" validate:"required"` // from ExtractRaw
 }
 
 func (req *queueResumeRequest) ExtractRaw(r *http.Request) error {
@@ -781,18 +1375,30 @@ func (req *queueResumeRequest) ExtractRaw(r *http.Request) error {
 }
 
 func (a *queueResumeEndpoint[TTx]) Execute(ctx context.Context, req *queueResumeRequest) (*statusResponse, error) {
-	return dbutil.WithTxV(ctx, a.DB, func(ctx context.Context, execTx riverdriver.ExecutorTx) (*statusResponse, error) {
-		tx := a.Driver.UnwrapTx(execTx)
+	auditEntry := &uiaudit.Entry{
+		Action:   a.AuditAction(),
+		Endpoint: a.Meta().Pattern,
+		Queue:    req.Name,
+	}
 
-		err := a.Client.QueueResumeTx(ctx, tx, req.Name, nil)
-		if err != nil {
-			if errors.Is(err, river.ErrNotFound) {
-				return nil, NewNotFoundQueue(req.Name)
-			}
-			return nil, fmt.Errorf("error resuming queue: %w", err)
+	return uiendpoints.WithAudit(ctx, a.APIBundle, auditEntry, req, func() (*statusResponse, error) {
+		if err := authorizeQueueAction(ctx, a.APIBundle, uiauth.ActionQueueResume, req.Name); err != nil {
+			return nil, err
 		}
 
-		return statusResponseOK, nil
+		return uiendpoints.WithTxV(ctx, a.DB, func(ctx context.Context, execTx riverdriver.ExecutorTx) (*statusResponse, error) {
+			tx := a.Driver.UnwrapTx(execTx)
+
+			err := a.Client.QueueResumeTx(ctx, tx, req.Name, nil)
+			if err != nil {
+				if errors.Is(err, river.ErrNotFound) {
+					return nil, NewNotFoundQueue(req.Name)
+				}
+				return nil, fmt.Errorf("error resuming queue: %w", err)
+			}
+
+			return statusResponseOK, nil
+		})
 	})
 }
 
@@ -812,9 +1418,12 @@ func (*queueUpdateEndpoint[TTx]) Meta() *apiendpoint.EndpointMeta {
 	}
 }
 
+func (*queueUpdateEndpoint[TTx]) Access() uiauth.Access { return uiauth.AccessMutate }
+func (*queueUpdateEndpoint[TTx]) AuditAction() string   { return string(uiauth.ActionQueueUpdate) }
+
 type queueUpdateRequest struct {
 	Concurrency apitype.ExplicitNullable[ConcurrencyConfig] `json:"concurrency"`
-	Name        string                                      `json:"-"           validate:"required"` // from ExtractRaw
+	Name        string                                      `json:"-"           path:"name" validate:"required"` // from ExtractRaw
 }
 
 func (req *queueUpdateRequest) ExtractRaw(r *http.Request) error {
@@ -823,50 +1432,62 @@ func (req *queueUpdateRequest) ExtractRaw(r *http.Request) error {
 }
 
 func (a *queueUpdateEndpoint[TTx]) Execute(ctx context.Context, req *queueUpdateRequest) (*RiverQueue, error) {
-	return dbutil.WithTxV(ctx, a.DB, func(ctx context.Context, execTx riverdriver.ExecutorTx) (*RiverQueue, error) {
-		tx := a.Driver.UnwrapTx(execTx)
+	auditEntry := &uiaudit.Entry{
+		Action:   a.AuditAction(),
+		Endpoint: a.Meta().Pattern,
+		Queue:    req.Name,
+	}
+
+	return uiendpoints.WithAudit(ctx, a.APIBundle, auditEntry, req, func() (*RiverQueue, error) {
+		if err := authorizeQueueAction(ctx, a.APIBundle, uiauth.ActionQueueUpdate, req.Name); err != nil {
+			return nil, err
+		}
 
-		// Construct metadata based on concurrency field
-		var metadata json.RawMessage
-		if req.Concurrency.Set {
-			if req.Concurrency.Value == nil {
-				// If concurrency is nil, clear the metadata
-				metadata = []byte("{}")
-			} else {
-				// Ensure consistent sorting of ByArgs:
-				slices.Sort(req.Concurrency.Value.Partition.ByArgs)
-
-				// Otherwise, construct metadata with the concurrency config
-				metadataStruct := map[string]any{
-					"concurrency": req.Concurrency.Value,
+		return uiendpoints.WithTxV(ctx, a.DB, func(ctx context.Context, execTx riverdriver.ExecutorTx) (*RiverQueue, error) {
+			tx := a.Driver.UnwrapTx(execTx)
+
+			// Construct metadata based on concurrency field
+			var metadata json.RawMessage
+			if req.Concurrency.Set {
+				if req.Concurrency.Value == nil {
+					// If concurrency is nil, clear the metadata
+					metadata = []byte("{}")
+				} else {
+					// Ensure consistent sorting of ByArgs:
+					slices.Sort(req.Concurrency.Value.Partition.ByArgs)
+
+					// Otherwise, construct metadata with the concurrency config
+					metadataStruct := map[string]any{
+						"concurrency": req.Concurrency.Value,
+					}
+					var err error
+					metadata, err = json.Marshal(metadataStruct)
+					if err != nil {
+						return nil, fmt.Errorf("error marshaling metadata: %w", err)
+					}
 				}
-				var err error
-				metadata, err = json.Marshal(metadataStruct)
-				if err != nil {
-					return nil, fmt.Errorf("error marshaling metadata: %w", err)
+			}
+
+			queue, err := a.Client.QueueUpdateTx(ctx, tx, req.Name, &river.QueueUpdateParams{
+				Metadata: metadata,
+			})
+			if err != nil {
+				if errors.Is(err, river.ErrNotFound) {
+					return nil, NewNotFoundQueue(req.Name)
 				}
+				return nil, fmt.Errorf("error updating queue metadata: %w", err)
 			}
-		}
 
-		queue, err := a.Client.QueueUpdateTx(ctx, tx, req.Name, &river.QueueUpdateParams{
-			Metadata: metadata,
-		})
-		if err != nil {
-			if errors.Is(err, river.ErrNotFound) {
-				return nil, NewNotFoundQueue(req.Name)
+			countRows, err := a.Driver.UnwrapExecutor(tx).JobCountByQueueAndState(ctx, &riverdriver.JobCountByQueueAndStateParams{
+				QueueNames: []string{req.Name},
+				Schema:     a.Client.Schema(),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("error getting queue counts: %w", err)
 			}
-			return nil, fmt.Errorf("error updating queue metadata: %w", err)
-		}
 
-		countRows, err := a.Driver.UnwrapExecutor(tx).JobCountByQueueAndState(ctx, &riverdriver.JobCountByQueueAndStateParams{
-			QueueNames: []string{req.Name},
-			Schema:     a.Client.Schema(),
+			return riverQueueToSerializableQueue(*queue, countRows[0]), nil
 		})
-		if err != nil {
-			return nil, fmt.Errorf("error getting queue counts: %w", err)
-		}
-
-		return riverQueueToSerializableQueue(*queue, countRows[0]), nil
 	})
 }
 
@@ -884,7 +1505,7 @@ type stateAndCountGetEndpoint[TTx any] struct {
 
 func newStateAndCountGetEndpoint[TTx any](bundle apibundle.APIBundle[TTx]) *stateAndCountGetEndpoint[TTx] {
 	runQuery := func(ctx context.Context) (map[rivertype.JobState]int, error) {
-		return dbutil.WithTxV(ctx, bundle.DB, func(ctx context.Context, execTx riverdriver.ExecutorTx) (map[rivertype.JobState]int, error) {
+		return uiendpoints.WithTxV(ctx, bundle.ReadDB(), func(ctx context.Context, execTx riverdriver.ExecutorTx) (map[rivertype.JobState]int, error) {
 			tx := bundle.Driver.UnwrapTx(execTx)
 
 			return bundle.Driver.UnwrapExecutor(tx).JobCountByAllStates(ctx, &riverdriver.JobCountByAllStatesParams{Schema: bundle.Client.Schema()})
@@ -904,6 +1525,8 @@ func (*stateAndCountGetEndpoint[TTx]) Meta() *apiendpoint.EndpointMeta {
 	}
 }
 
+func (*stateAndCountGetEndpoint[TTx]) Access() uiauth.Access { return uiauth.AccessRead }
+
 func (a *stateAndCountGetEndpoint[TTx]) SubServices() []startstop.Service {
 	return []startstop.Service{a.queryCacher}
 }
@@ -940,7 +1563,7 @@ func (a *stateAndCountGetEndpoint[TTx]) Execute(ctx context.Context, _ *stateAnd
 	stateAndCountRes, ok := a.queryCacher.CachedRes()
 	if !ok || totalJobs(stateAndCountRes) < a.queryCacheSkipThreshold {
 		var err error
-		stateAndCountRes, err = dbutil.WithTxV(ctx, a.DB, func(ctx context.Context, execTx riverdriver.ExecutorTx) (map[rivertype.JobState]int, error) {
+		stateAndCountRes, err = uiendpoints.WithTxV(ctx, a.ReadDB(), func(ctx context.Context, execTx riverdriver.ExecutorTx) (map[rivertype.JobState]int, error) {
 			tx := a.Driver.UnwrapTx(execTx)
 
 			return a.Driver.UnwrapExecutor(tx).JobCountByAllStates(ctx, &riverdriver.JobCountByAllStatesParams{Schema: a.Client.Schema()})
@@ -966,6 +1589,10 @@ func NewNotFoundJob(jobID int64) *apierror.NotFound {
 	return apierror.NewNotFoundf("Job not found: %d.", jobID)
 }
 
+func NewNotFoundKind(kind string) *apierror.NotFound {
+	return apierror.NewNotFoundf("Kind not found: %s.", kind)
+}
+
 func NewNotFoundQueue(name string) *apierror.NotFound {
 	return apierror.NewNotFoundf("Queue not found: %s.", name)
 }
@@ -974,6 +1601,43 @@ func NewNotFoundWorkflow(id string) *apierror.NotFound {
 	return apierror.NewNotFoundf("Workflow not found: %s.", id)
 }
 
+type AuditEntry struct {
+	ID          int64           `json:"id"`
+	Action      string          `json:"action"`
+	Actor       string          `json:"actor"`
+	Endpoint    string          `json:"endpoint"`
+	Environment string          `json:"environment,omitempty"`
+	Error       string          `json:"error,omitempty"`
+	JobIDs      []int64         `json:"job_ids"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Outcome     string          `json:"outcome"`
+	Params      json.RawMessage `json:"params"`
+	Queue       string          `json:"queue,omitempty"`
+	WorkflowID  string          `json:"workflow_id,omitempty"`
+}
+
+func auditEntryToSerializableAuditEntry(entry *uiaudit.Entry) *AuditEntry {
+	jobIDs := entry.JobIDs
+	if jobIDs == nil {
+		jobIDs = []int64{}
+	}
+
+	return &AuditEntry{
+		ID:          entry.ID,
+		Action:      entry.Action,
+		Actor:       entry.Actor,
+		Endpoint:    entry.Endpoint,
+		Environment: entry.Environment,
+		Error:       entry.Error,
+		JobIDs:      jobIDs,
+		OccurredAt:  entry.OccurredAt.UTC(),
+		Outcome:     string(entry.Outcome),
+		Params:      entry.Params,
+		Queue:       entry.Queue,
+		WorkflowID:  entry.WorkflowID,
+	}
+}
+
 type ConcurrencyConfig struct {
 	GlobalLimit int32           `json:"global_limit"`
 	LocalLimit  int32           `json:"local_limit"`
@@ -986,8 +1650,17 @@ type PartitionConfig struct {
 }
 
 type RiverJobMinimal struct {
-	ID          int64      `json:"id"`
-	Args        string     `json:"args"`
+	ID   int64  `json:"id"`
+	Args string `json:"args"`
+
+	// ArgsDecodeError is the error that occurred decoding the job's args with
+	// the handler's args decoder, in which case Args are the raw args.
+	ArgsDecodeError string `json:"args_decode_error,omitempty"`
+
+	// ArgsDecoded is whether Args are a form decoded by the handler's args
+	// decoder rather than the raw args stored with the job.
+	ArgsDecoded bool `json:"args_decoded"`
+
 	Attempt     int        `json:"attempt"`
 	AttemptedAt *time.Time `json:"attempted_at"`
 	AttemptedBy []string   `json:"attempted_by"`
@@ -1023,6 +1696,31 @@ func riverJobToSerializableJob(riverJob *rivertype.JobRow) *RiverJob {
 	}
 }
 
+// Decodes the args of jobs and redacts them, then serializes them along with
+// whether each one's args were decoded and any error decoding them.
+func riverJobsToSerializableJobsMinimal[TTx any](ctx context.Context, bundle apibundle.APIBundle[TTx], jobs []*rivertype.JobRow) ([]*RiverJobMinimal, error) {
+	var (
+		argsDecoded    = make([]bool, len(jobs))
+		argsDecodeErrs = make([]error, len(jobs))
+		decodedJobs    = make([]*rivertype.JobRow, len(jobs))
+	)
+	for i, job := range jobs {
+		decodedJobs[i], argsDecoded[i], argsDecodeErrs[i] = bundle.DecodeJobArgs(ctx, job)
+	}
+
+	redactedJobs, err := uiredact.ApplyAll(ctx, bundle.Redactor, decodedJobs)
+	if err != nil {
+		return nil, fmt.Errorf("error redacting jobs: %w", err)
+	}
+
+	serializableJobs := make([]*RiverJobMinimal, len(redactedJobs))
+	for i, job := range redactedJobs {
+		serializableJobs[i] = riverJobToSerializableJobMinimal(job)
+		serializableJobs[i].setArgsDecoded(argsDecoded[i], argsDecodeErrs[i])
+	}
+	return serializableJobs, nil
+}
+
 func riverJobToSerializableJobMinimal(riverJob *rivertype.JobRow) *RiverJobMinimal {
 	attemptedBy := riverJob.AttemptedBy
 	if attemptedBy == nil {
@@ -1047,6 +1745,85 @@ func riverJobToSerializableJobMinimal(riverJob *rivertype.JobRow) *RiverJobMinim
 	}
 }
 
+func (j *RiverJobMinimal) setArgsDecoded(decoded bool, decodeErr error) {
+	j.ArgsDecoded = decoded
+	if decodeErr != nil {
+		j.ArgsDecodeError = decodeErr.Error()
+	}
+}
+
+// RiverKind is a job kind seen either in the database or in the kind
+// registry.
+type RiverKind struct {
+	// ClientIDs are the clients with a live registration for the kind.
+	ClientIDs []string `json:"client_ids"`
+
+	Kind string `json:"kind"`
+
+	// LastSeenAt is when any client last registered the kind, or nil if none
+	// ever has.
+	LastSeenAt *time.Time `json:"last_seen_at"`
+
+	// OldestPendingAt is the earliest scheduled time of the kind's available,
+	// retryable, or scheduled jobs. It's only looked up for kinds that aren't
+	// registered.
+	OldestPendingAt *time.Time `json:"oldest_pending_at"`
+
+	// Registered is whether any client has registered the kind recently.
+	Registered bool `json:"registered"`
+
+	// Stale is whether the kind was registered at some point, but no client
+	// has registered it recently.
+	Stale bool `json:"stale"`
+
+	// Unhandled is whether the kind has pending jobs, but no client has
+	// registered it recently, so they may never be worked.
+	Unhandled bool `json:"unhandled"`
+}
+
+// Merges the kinds found in the database with kind registrations, marking a
+// kind as registered if any registration for it was seen within staleAfter of
+// now, and as stale if it has registrations but none are that recent. Kinds
+// are returned sorted by name.
+func kindsFromRegistrations(jobKinds []string, registrations []*uikinds.Registration, now time.Time, staleAfter time.Duration) []*RiverKind {
+	kindsByName := make(map[string]*RiverKind, len(jobKinds))
+	kindFor := func(name string) *RiverKind {
+		kind, ok := kindsByName[name]
+		if !ok {
+			kind = &RiverKind{ClientIDs: []string{}, Kind: name}
+			kindsByName[name] = kind
+		}
+		return kind
+	}
+
+	for _, name := range jobKinds {
+		kindFor(name)
+	}
+
+	for _, registration := range registrations {
+		kind := kindFor(registration.Kind)
+
+		seenAt := registration.SeenAt.UTC()
+		if kind.LastSeenAt == nil || seenAt.After(*kind.LastSeenAt) {
+			kind.LastSeenAt = &seenAt
+		}
+
+		if now.Sub(seenAt) <= staleAfter {
+			kind.ClientIDs = append(kind.ClientIDs, registration.ClientID)
+			kind.Registered = true
+		}
+	}
+
+	kinds := slices.SortedFunc(maps.Values(kindsByName), func(a, b *RiverKind) int {
+		return strings.Compare(a.Kind, b.Kind)
+	})
+	for _, kind := range kinds {
+		slices.Sort(kind.ClientIDs)
+		kind.Stale = kind.LastSeenAt != nil && !kind.Registered
+	}
+	return kinds
+}
+
 type RiverQueue struct {
 	CountAvailable int                `json:"count_available"`
 	CountRunning   int                `json:"count_running"`
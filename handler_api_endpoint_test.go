@@ -3,13 +3,17 @@ package riverui
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/require"
 
 	"github.com/riverqueue/apiframe/apiendpoint"
@@ -24,11 +28,18 @@ import (
 	"github.com/riverqueue/river/rivershared/startstop"
 	"github.com/riverqueue/river/rivershared/uniquestates"
 	"github.com/riverqueue/river/rivershared/util/ptrutil"
+	"github.com/riverqueue/river/rivershared/util/sliceutil"
 	"github.com/riverqueue/river/rivertype"
 
 	"riverqueue.com/riverui/internal/apibundle"
 	"riverqueue.com/riverui/internal/riverinternaltest/testfactory"
 	"riverqueue.com/riverui/internal/uicommontest"
+	"riverqueue.com/riverui/uiaudit"
+	"riverqueue.com/riverui/uiauth"
+	"riverqueue.com/riverui/uidecode"
+	"riverqueue.com/riverui/uikinds"
+	"riverqueue.com/riverui/uiredact"
+	"riverqueue.com/riverui/uischema"
 )
 
 type setupEndpointTestBundle struct {
@@ -233,6 +244,93 @@ func (f autocompleteFacet) baseString() string {
 	}
 }
 
+func TestAPIHandlerAuditList(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (*auditListEndpoint[pgx.Tx], *uiaudit.PostgresSink) {
+		t.Helper()
+
+		endpoint, bundle := setupEndpoint(ctx, t, newAuditListEndpoint)
+
+		sink := uiaudit.NewPostgresSink(bundle.exec, nil)
+		require.NoError(t, sink.Migrate(ctx))
+		endpoint.AuditSink = sink
+
+		return endpoint, sink
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		t.Parallel()
+
+		endpoint, sink := setup(t)
+
+		entry1 := &uiaudit.Entry{Action: "job:cancel", Actor: "alice", Endpoint: "POST /api/jobs/cancel", Environment: "default", JobIDs: []int64{1, 2}, OccurredAt: time.Now(), Outcome: uiaudit.OutcomeSuccess}
+		require.NoError(t, sink.Record(ctx, entry1))
+		entry2 := &uiaudit.Entry{Action: "queue:pause", Actor: "bob", Endpoint: "PUT /api/queues/{name}/pause", Environment: "eu", OccurredAt: time.Now(), Outcome: uiaudit.OutcomeDenied, Queue: "default"}
+		require.NoError(t, sink.Record(ctx, entry2))
+
+		resp, err := apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &auditListRequest{})
+		require.NoError(t, err)
+		require.Len(t, resp.Data, 2)
+		require.Equal(t, entry2.ID, resp.Data[0].ID)
+		require.Equal(t, "eu", resp.Data[0].Environment)
+		require.Equal(t, "default", resp.Data[0].Queue)
+		require.Equal(t, entry1.ID, resp.Data[1].ID)
+		require.Equal(t, "default", resp.Data[1].Environment)
+		require.Equal(t, []int64{1, 2}, resp.Data[1].JobIDs)
+
+		resp, err = apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &auditListRequest{Actor: "alice"})
+		require.NoError(t, err)
+		require.Len(t, resp.Data, 1)
+		require.Equal(t, entry1.ID, resp.Data[0].ID)
+
+		resp, err = apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &auditListRequest{Environment: "eu"})
+		require.NoError(t, err)
+		require.Len(t, resp.Data, 1)
+		require.Equal(t, entry2.ID, resp.Data[0].ID)
+
+		resp, err = apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &auditListRequest{BeforeID: &entry2.ID})
+		require.NoError(t, err)
+		require.Len(t, resp.Data, 1)
+		require.Equal(t, entry1.ID, resp.Data[0].ID)
+	})
+
+	t.Run("RecordedByMutatingEndpoint", func(t *testing.T) {
+		t.Parallel()
+
+		endpoint, sink := setup(t)
+
+		cancelEndpoint := newJobCancelEndpoint(endpoint.APIBundle)
+		job := testfactory.Job(ctx, t, endpoint.DB, &testfactory.JobOpts{})
+
+		ctx := uiauth.WithIdentity(ctx, &uiauth.Identity{Name: "alice", Roles: []uiauth.Role{uiauth.RoleOperator}})
+
+		_, err := apitest.InvokeHandler(ctx, cancelEndpoint.Execute, testMountOpts(t), &jobCancelRequest{JobIDs: []int64String{int64String(job.ID)}})
+		require.NoError(t, err)
+
+		entries, err := sink.List(ctx, &uiaudit.ListParams{Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.Equal(t, "job:cancel", entries[0].Action)
+		require.Equal(t, "alice", entries[0].Actor)
+		require.Equal(t, "POST /api/jobs/cancel", entries[0].Endpoint)
+		require.Equal(t, []int64{job.ID}, entries[0].JobIDs)
+		require.Equal(t, uiaudit.OutcomeSuccess, entries[0].Outcome)
+	})
+
+	t.Run("SinkNotListable", func(t *testing.T) {
+		t.Parallel()
+
+		endpoint, bundle := setupEndpoint(ctx, t, newAuditListEndpoint)
+		endpoint.AuditSink = uiaudit.NewSlogSink(bundle.logger)
+
+		_, err := apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &auditListRequest{})
+		uicommontest.RequireAPIError(t, apierror.NewNotFound("No browsable audit log is configured."), err)
+	})
+}
+
 func TestAPIHandlerAutocompleteList(t *testing.T) {
 	t.Parallel()
 
@@ -384,6 +482,49 @@ func TestAPIHandlerFeaturesGet(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, map[string]bool{"test_1": true, "test_2": false}, resp.Extensions)
 	})
+
+	t.Run("WithIdentity", func(t *testing.T) {
+		t.Parallel()
+
+		endpoint, _ := setupEndpoint(ctx, t, newFeaturesGetEndpoint)
+
+		ctx := uiauth.WithIdentity(ctx, &uiauth.Identity{Name: "alice", Roles: []uiauth.Role{uiauth.RoleOperator}})
+
+		resp, err := apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &featuresGetRequest{})
+		require.NoError(t, err)
+		require.Equal(t, &featuresIdentity{Name: "alice", Roles: []uiauth.Role{uiauth.RoleOperator}}, resp.Identity)
+		require.Equal(t, featuresPermissions{Admin: false, Mutate: true, Read: true}, resp.Permissions)
+	})
+
+	t.Run("WithAnonymousIdentity", func(t *testing.T) {
+		t.Parallel()
+
+		endpoint, _ := setupEndpoint(ctx, t, newFeaturesGetEndpoint)
+
+		ctx := uiauth.WithIdentity(ctx, &uiauth.Identity{Roles: []uiauth.Role{uiauth.RoleViewer}})
+
+		resp, err := apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &featuresGetRequest{})
+		require.NoError(t, err)
+		require.Nil(t, resp.Identity)
+		require.Equal(t, featuresPermissions{Admin: false, Mutate: false, Read: true}, resp.Permissions)
+	})
+
+	t.Run("WithReplica", func(t *testing.T) {
+		t.Parallel()
+
+		endpoint, bundle := setupEndpoint(ctx, t, newFeaturesGetEndpoint)
+
+		resp, err := apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &featuresGetRequest{})
+		require.NoError(t, err)
+		require.Nil(t, resp.Replica)
+
+		// The test database isn't in recovery, so lag is unknown.
+		endpoint.ReplicaDB = bundle.exec
+
+		resp, err = apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &featuresGetRequest{})
+		require.NoError(t, err)
+		require.Equal(t, &featuresReplica{LagSeconds: nil}, resp.Replica)
+	})
 }
 
 func TestAPIHandlerHealthCheckGet(t *testing.T) {
@@ -470,6 +611,25 @@ func TestAPIHandlerJobCancel(t *testing.T) {
 		_, err := apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &jobCancelRequest{JobIDs: []int64String{123}})
 		uicommontest.RequireAPIError(t, NewNotFoundJob(123), err)
 	})
+
+	t.Run("ScopedByPolicy", func(t *testing.T) {
+		t.Parallel()
+
+		endpoint, bundle := setupEndpoint(ctx, t, newJobCancelEndpoint)
+		endpoint.AuthorizationPolicy = testBillingPolicy(false)
+
+		billingJob := testfactory.Job(ctx, t, bundle.exec, &testfactory.JobOpts{Kind: ptrutil.Ptr("invoice.send"), Queue: ptrutil.Ptr("billing_high")})
+		otherJob := testfactory.Job(ctx, t, bundle.exec, &testfactory.JobOpts{Kind: ptrutil.Ptr("email.send"), Queue: ptrutil.Ptr("mailers")})
+
+		ctx := uiauth.WithIdentity(ctx, testBillingIdentity())
+
+		_, err := apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &jobCancelRequest{JobIDs: []int64String{int64String(billingJob.ID), int64String(otherJob.ID)}})
+		uicommontest.RequireAPIError(t, uiauth.NewForbiddenf("Not permitted to cancel job %d (queue %q, kind %q).", otherJob.ID, "mailers", "email.send"), err)
+
+		resp, err := apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &jobCancelRequest{JobIDs: []int64String{int64String(billingJob.ID)}})
+		require.NoError(t, err)
+		require.Equal(t, statusResponseOK, resp)
+	})
 }
 
 func TestAPIHandlerJobDelete(t *testing.T) {
@@ -537,6 +697,86 @@ func TestAPIHandlerJobGet(t *testing.T) {
 		require.True(t, json.Valid([]byte(wireResp.Args)))
 	})
 
+	t.Run("Redacted", func(t *testing.T) {
+		t.Parallel()
+
+		endpoint, bundle := setupEndpoint(ctx, t, newJobGetEndpoint)
+
+		redactor, err := uiredact.NewJSONPathRedactor(&uiredact.JSONPathRedactorOpts{
+			JSONPathRules: uiredact.JSONPathRules{Args: []string{"$.email"}, Errors: true, Metadata: []string{"$.tenant"}},
+		})
+		require.NoError(t, err)
+		endpoint.Redactor = redactor
+
+		job := testfactory.Job(ctx, t, bundle.exec, &testfactory.JobOpts{
+			EncodedArgs: []byte(`{"email":"a@example.com","id":123}`),
+			Errors:      [][]byte{[]byte(`{"at":"2025-01-01T00:00:00Z","attempt":1,"error":"bad address a@example.com","trace":""}`)},
+			Metadata:    []byte(`{"tenant":"acme"}`),
+		})
+
+		resp, err := apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &jobGetRequest{JobID: job.ID})
+		require.NoError(t, err)
+		require.JSONEq(t, `{"email":"[REDACTED]","id":123}`, resp.Args)
+		require.JSONEq(t, `{"tenant":"[REDACTED]"}`, string(resp.Metadata))
+		require.Equal(t, "[REDACTED]", resp.Errors[0].Error)
+	})
+
+	t.Run("DecodedArgs", func(t *testing.T) {
+		t.Parallel()
+
+		endpoint, bundle := setupEndpoint(ctx, t, newJobGetEndpoint)
+		endpoint.ArgsDecoder = uidecode.ArgsDecoderFunc(func(_ context.Context, kind string, args []byte) ([]byte, error) {
+			return []byte(`{"email":"a@example.com"}`), nil
+		})
+		endpoint.ArgsDecoderAccess = uiauth.AccessAdmin
+
+		redactor, err := uiredact.NewJSONPathRedactor(&uiredact.JSONPathRedactorOpts{
+			JSONPathRules: uiredact.JSONPathRules{Args: []string{"$.email"}},
+		})
+		require.NoError(t, err)
+
+		job := testfactory.Job(ctx, t, bundle.exec, &testfactory.JobOpts{EncodedArgs: []byte(`"ciphertext"`)})
+
+		adminCtx := uiauth.WithIdentity(ctx, &uiauth.Identity{Name: "admin", Roles: []uiauth.Role{uiauth.RoleAdmin}})
+		resp, err := apitest.InvokeHandler(adminCtx, endpoint.Execute, testMountOpts(t), &jobGetRequest{JobID: job.ID})
+		require.NoError(t, err)
+		require.True(t, resp.ArgsDecoded)
+		require.JSONEq(t, `{"email":"a@example.com"}`, resp.Args)
+
+		// Redaction applies to decoded args.
+		endpoint.Redactor = redactor
+		resp, err = apitest.InvokeHandler(adminCtx, endpoint.Execute, testMountOpts(t), &jobGetRequest{JobID: job.ID})
+		require.NoError(t, err)
+		require.True(t, resp.ArgsDecoded)
+		require.JSONEq(t, `{"email":"[REDACTED]"}`, resp.Args)
+
+		// Identities without the required access get raw args.
+		viewerCtx := uiauth.WithIdentity(ctx, &uiauth.Identity{Name: "viewer", Roles: []uiauth.Role{uiauth.RoleViewer}})
+		resp, err = apitest.InvokeHandler(viewerCtx, endpoint.Execute, testMountOpts(t), &jobGetRequest{JobID: job.ID})
+		require.NoError(t, err)
+		require.False(t, resp.ArgsDecoded)
+		require.Equal(t, `"ciphertext"`, resp.Args)
+	})
+
+	t.Run("DecodeArgsError", func(t *testing.T) {
+		t.Parallel()
+
+		endpoint, bundle := setupEndpoint(ctx, t, newJobGetEndpoint)
+		endpoint.ArgsDecoder = uidecode.ArgsDecoderFunc(func(_ context.Context, kind string, args []byte) ([]byte, error) {
+			return nil, errors.New("key not found")
+		})
+		endpoint.ArgsDecoderAccess = uiauth.AccessRead
+
+		job := testfactory.Job(ctx, t, bundle.exec, &testfactory.JobOpts{EncodedArgs: []byte(`"ciphertext"`)})
+
+		viewerCtx := uiauth.WithIdentity(ctx, &uiauth.Identity{Name: "viewer", Roles: []uiauth.Role{uiauth.RoleViewer}})
+		resp, err := apitest.InvokeHandler(viewerCtx, endpoint.Execute, testMountOpts(t), &jobGetRequest{JobID: job.ID})
+		require.NoError(t, err)
+		require.False(t, resp.ArgsDecoded)
+		require.Equal(t, "key not found", resp.ArgsDecodeError)
+		require.Equal(t, `"ciphertext"`, resp.Args)
+	})
+
 	t.Run("NotFound", func(t *testing.T) {
 		t.Parallel()
 
@@ -587,6 +827,108 @@ func TestAPIHandlerJobList(t *testing.T) {
 		require.Equal(t, expectedArgs, wireResp.Data[0].Args)
 	})
 
+	t.Run("Redacted", func(t *testing.T) {
+		t.Parallel()
+
+		endpoint, bundle := setupEndpoint(ctx, t, newJobListEndpoint)
+		endpoint.Redactor = uiredact.ByKind(map[string]uiredact.Redactor{
+			"kind1": uiredact.RedactorFunc(func(_ context.Context, job *rivertype.JobRow) error {
+				job.EncodedArgs = []byte(`{}`)
+				return nil
+			}),
+		})
+
+		job1 := testfactory.Job(ctx, t, bundle.exec, &testfactory.JobOpts{
+			EncodedArgs: []byte(`{"secret":"a"}`),
+			Kind:        ptrutil.Ptr("kind1"),
+			State:       ptrutil.Ptr(rivertype.JobStateRunning),
+		})
+		job2 := testfactory.Job(ctx, t, bundle.exec, &testfactory.JobOpts{
+			EncodedArgs: []byte(`{"public":"b"}`),
+			Kind:        ptrutil.Ptr("kind2"),
+			State:       ptrutil.Ptr(rivertype.JobStateRunning),
+		})
+
+		resp, err := apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &jobListRequest{})
+		require.NoError(t, err)
+		require.Len(t, resp.Data, 2)
+		require.Equal(t, job1.ID, resp.Data[0].ID)
+		require.JSONEq(t, `{}`, resp.Data[0].Args)
+		require.Equal(t, job2.ID, resp.Data[1].ID)
+		require.JSONEq(t, `{"public":"b"}`, resp.Data[1].Args)
+	})
+
+	t.Run("DecodedArgs", func(t *testing.T) {
+		t.Parallel()
+
+		endpoint, bundle := setupEndpoint(ctx, t, newJobListEndpoint)
+		endpoint.ArgsDecoder = uidecode.ArgsDecoderFunc(func(_ context.Context, kind string, args []byte) ([]byte, error) {
+			if kind == "undecodable" {
+				return nil, errors.New("key not found")
+			}
+			return []byte(`{"email":"a@example.com"}`), nil
+		})
+		endpoint.ArgsDecoderAccess = uiauth.AccessAdmin
+
+		job1 := testfactory.Job(ctx, t, bundle.exec, &testfactory.JobOpts{
+			EncodedArgs: []byte(`"ciphertext1"`),
+			Kind:        ptrutil.Ptr("decodable"),
+			State:       ptrutil.Ptr(rivertype.JobStateRunning),
+		})
+		job2 := testfactory.Job(ctx, t, bundle.exec, &testfactory.JobOpts{
+			EncodedArgs: []byte(`"ciphertext2"`),
+			Kind:        ptrutil.Ptr("undecodable"),
+			State:       ptrutil.Ptr(rivertype.JobStateRunning),
+		})
+
+		adminCtx := uiauth.WithIdentity(ctx, &uiauth.Identity{Name: "admin", Roles: []uiauth.Role{uiauth.RoleAdmin}})
+		resp, err := apitest.InvokeHandler(adminCtx, endpoint.Execute, testMountOpts(t), &jobListRequest{})
+		require.NoError(t, err)
+		require.Len(t, resp.Data, 2)
+
+		require.Equal(t, job1.ID, resp.Data[0].ID)
+		require.True(t, resp.Data[0].ArgsDecoded)
+		require.Empty(t, resp.Data[0].ArgsDecodeError)
+		require.JSONEq(t, `{"email":"a@example.com"}`, resp.Data[0].Args)
+
+		require.Equal(t, job2.ID, resp.Data[1].ID)
+		require.False(t, resp.Data[1].ArgsDecoded)
+		require.Equal(t, "key not found", resp.Data[1].ArgsDecodeError)
+		require.Equal(t, `"ciphertext2"`, resp.Data[1].Args)
+
+		// Identities without the required access get raw args.
+		viewerCtx := uiauth.WithIdentity(ctx, &uiauth.Identity{Name: "viewer", Roles: []uiauth.Role{uiauth.RoleViewer}})
+		resp, err = apitest.InvokeHandler(viewerCtx, endpoint.Execute, testMountOpts(t), &jobListRequest{})
+		require.NoError(t, err)
+		require.Len(t, resp.Data, 2)
+		require.False(t, resp.Data[0].ArgsDecoded)
+		require.Equal(t, `"ciphertext1"`, resp.Data[0].Args)
+	})
+
+	t.Run("UsesReplica", func(t *testing.T) {
+		t.Parallel()
+
+		endpoint, bundle := setupEndpoint(ctx, t, newJobListEndpoint)
+
+		_ = testfactory.Job(ctx, t, bundle.exec, &testfactory.JobOpts{State: ptrutil.Ptr(rivertype.JobStateRunning)})
+
+		// Stand in for a replica with a separate test transaction that can't
+		// see the job inserted above.
+		var (
+			driver       = riverpgxv5.New(riversharedtest.DBPool(ctx, t))
+			replicaTx, _ = riverdbtest.TestTxPgxDriver(ctx, t, driver, nil)
+			replicaExec  = driver.UnwrapExecutor(replicaTx)
+		)
+		endpoint.ReplicaDB = replicaExec
+
+		replicaJob := testfactory.Job(ctx, t, replicaExec, &testfactory.JobOpts{State: ptrutil.Ptr(rivertype.JobStateRunning)})
+
+		resp, err := apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &jobListRequest{})
+		require.NoError(t, err)
+		require.Len(t, resp.Data, 1)
+		require.Equal(t, replicaJob.ID, resp.Data[0].ID)
+	})
+
 	t.Run("FilterByIDs", func(t *testing.T) {
 		t.Parallel()
 
@@ -739,6 +1081,39 @@ func TestAPIHandlerJobList(t *testing.T) {
 		require.Len(t, resp.Data, 1)
 		require.Equal(t, job.ID, resp.Data[0].ID)
 	})
+
+	t.Run("FilteredByPolicy", func(t *testing.T) {
+		t.Parallel()
+
+		endpoint, bundle := setupEndpoint(ctx, t, newJobListEndpoint)
+		endpoint.AuthorizationPolicy = testBillingPolicy(true)
+
+		billingJob := testfactory.Job(ctx, t, bundle.exec, &testfactory.JobOpts{
+			Kind:  ptrutil.Ptr("invoice.send"),
+			Queue: ptrutil.Ptr("billing_high"),
+			State: ptrutil.Ptr(rivertype.JobStateRunning),
+		})
+		_ = testfactory.Job(ctx, t, bundle.exec, &testfactory.JobOpts{
+			Kind:  ptrutil.Ptr("invoice.send"),
+			Queue: ptrutil.Ptr("billingxhigh"), // underscore in pattern must not act as a LIKE wildcard
+			State: ptrutil.Ptr(rivertype.JobStateRunning),
+		})
+		_ = testfactory.Job(ctx, t, bundle.exec, &testfactory.JobOpts{
+			Kind:  ptrutil.Ptr("email.send"),
+			Queue: ptrutil.Ptr("billing_high"),
+			State: ptrutil.Ptr(rivertype.JobStateRunning),
+		})
+
+		resp, err := apitest.InvokeHandler(uiauth.WithIdentity(ctx, testBillingIdentity()), endpoint.Execute, testMountOpts(t), &jobListRequest{})
+		require.NoError(t, err)
+		require.Len(t, resp.Data, 1)
+		require.Equal(t, billingJob.ID, resp.Data[0].ID)
+
+		// Admins see everything.
+		resp, err = apitest.InvokeHandler(uiauth.WithIdentity(ctx, &uiauth.Identity{Roles: []uiauth.Role{uiauth.RoleAdmin}}), endpoint.Execute, testMountOpts(t), &jobListRequest{})
+		require.NoError(t, err)
+		require.Len(t, resp.Data, 3)
+	})
 }
 
 func TestAPIHandlerJobListCustomSchema(t *testing.T) {
@@ -851,6 +1226,353 @@ func TestAPIHandlerJobRetry(t *testing.T) {
 	})
 }
 
+// testPQError mimics lib/pq's *pq.Error, whose fields are accessed by their
+// protocol codes.
+type testPQError map[byte]string
+
+func (e testPQError) Error() string     { return "pq: " + e['M'] }
+func (e testPQError) Get(k byte) string { return e[k] }
+
+func TestIsJobRetryUniqueConflict(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, isJobRetryUniqueConflict(&pgconn.PgError{
+		Code:           pgerrcode.UniqueViolation,
+		ConstraintName: jobRetryUniqueConstraint,
+	}))
+	require.False(t, isJobRetryUniqueConflict(&pgconn.PgError{
+		Code:           pgerrcode.UniqueViolation,
+		ConstraintName: "river_job_pkey",
+	}))
+
+	// lib/pq's errors, as returned through riverdatabasesql.
+	require.True(t, isJobRetryUniqueConflict(testPQError{
+		'C': pgerrcode.UniqueViolation,
+		'n': jobRetryUniqueConstraint,
+	}))
+
+	// SQLite drivers' errors, which are only distinguishable by message.
+	require.True(t, isJobRetryUniqueConflict(errors.New("constraint failed: UNIQUE constraint failed: river_job.unique_key (2067)")))
+	require.False(t, isJobRetryUniqueConflict(errors.New("constraint failed: UNIQUE constraint failed: river_job.id (1555)")))
+}
+
+func TestAPIHandlerKindList(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (*kindListEndpoint[pgx.Tx], *setupEndpointTestBundle, *uikinds.MemoryRegistry) {
+		t.Helper()
+
+		endpoint, bundle := setupEndpoint(ctx, t, newKindListEndpoint)
+
+		registry := uikinds.NewMemoryRegistry()
+		endpoint.KindRegistry = registry
+
+		return endpoint, bundle, registry
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		t.Parallel()
+
+		endpoint, bundle, registry := setup(t)
+
+		var (
+			now             = time.Now().UTC()
+			oldestPendingAt = now.Add(-time.Hour)
+		)
+		_ = testfactory.Job(ctx, t, bundle.exec, &testfactory.JobOpts{Kind: ptrutil.Ptr("handled"), ScheduledAt: &oldestPendingAt})
+		_ = testfactory.Job(ctx, t, bundle.exec, &testfactory.JobOpts{Kind: ptrutil.Ptr("removed"), ScheduledAt: &oldestPendingAt})
+		_ = testfactory.Job(ctx, t, bundle.exec, &testfactory.JobOpts{Kind: ptrutil.Ptr("removed"), ScheduledAt: &now})
+		_ = testfactory.Job(ctx, t, bundle.exec, &testfactory.JobOpts{Kind: ptrutil.Ptr("finished"), State: ptrutil.Ptr(rivertype.JobStateCompleted), FinalizedAt: &now})
+
+		require.NoError(t, registry.Register(ctx, "client_1", []string{"handled", "idle"}))
+
+		resp, err := apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &kindListRequest{})
+		require.NoError(t, err)
+		require.True(t, resp.RegistryConfigured)
+		require.Len(t, resp.Data, 4)
+
+		require.Equal(t, "finished", resp.Data[0].Kind)
+		require.False(t, resp.Data[0].Registered)
+		require.Nil(t, resp.Data[0].OldestPendingAt)
+		require.False(t, resp.Data[0].Unhandled)
+
+		require.Equal(t, "handled", resp.Data[1].Kind)
+		require.True(t, resp.Data[1].Registered)
+		require.Equal(t, []string{"client_1"}, resp.Data[1].ClientIDs)
+		require.False(t, resp.Data[1].Unhandled)
+
+		require.Equal(t, "idle", resp.Data[2].Kind)
+		require.True(t, resp.Data[2].Registered)
+
+		require.Equal(t, "removed", resp.Data[3].Kind)
+		require.False(t, resp.Data[3].Registered)
+		require.False(t, resp.Data[3].Stale)
+		require.True(t, resp.Data[3].Unhandled)
+		require.NotNil(t, resp.Data[3].OldestPendingAt)
+		require.WithinDuration(t, oldestPendingAt, *resp.Data[3].OldestPendingAt, time.Millisecond)
+	})
+
+	t.Run("Stale", func(t *testing.T) {
+		t.Parallel()
+
+		endpoint, bundle, registry := setup(t)
+
+		_ = testfactory.Job(ctx, t, bundle.exec, &testfactory.JobOpts{Kind: ptrutil.Ptr("handled")})
+
+		require.NoError(t, registry.Register(ctx, "client_1", []string{"handled"}))
+
+		resp, err := apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &kindListRequest{StaleAfter: ptrutil.Ptr(time.Nanosecond)})
+		require.NoError(t, err)
+		require.Len(t, resp.Data, 1)
+		require.False(t, resp.Data[0].Registered)
+		require.True(t, resp.Data[0].Stale)
+		require.True(t, resp.Data[0].Unhandled)
+	})
+
+	t.Run("ManyKinds", func(t *testing.T) {
+		t.Parallel()
+
+		endpoint, bundle, registry := setup(t)
+
+		const numKinds = 200
+
+		var (
+			now                   = time.Now().UTC()
+			oldestPendingAtByKind = make(map[string]time.Time, numKinds)
+		)
+		for i := range numKinds {
+			kind := fmt.Sprintf("kind_%03d", i)
+
+			switch i % 3 {
+			case 0: // registered
+				require.NoError(t, registry.Register(ctx, "client_1", []string{kind}))
+				_ = testfactory.Job(ctx, t, bundle.exec, &testfactory.JobOpts{Kind: &kind})
+			case 1: // unregistered with pending jobs
+				oldestPendingAt := now.Add(-time.Duration(i) * time.Minute)
+				oldestPendingAtByKind[kind] = oldestPendingAt
+				_ = testfactory.Job(ctx, t, bundle.exec, &testfactory.JobOpts{Kind: &kind, ScheduledAt: &oldestPendingAt})
+				_ = testfactory.Job(ctx, t, bundle.exec, &testfactory.JobOpts{Kind: &kind, ScheduledAt: &now, State: ptrutil.Ptr(rivertype.JobStateScheduled)})
+			case 2: // unregistered with only finished jobs
+				_ = testfactory.Job(ctx, t, bundle.exec, &testfactory.JobOpts{Kind: &kind, State: ptrutil.Ptr(rivertype.JobStateCompleted), FinalizedAt: &now})
+			}
+		}
+
+		resp, err := apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &kindListRequest{})
+		require.NoError(t, err)
+		require.Len(t, resp.Data, numKinds)
+
+		for _, kind := range resp.Data {
+			oldestPendingAt, ok := oldestPendingAtByKind[kind.Kind]
+			require.Equal(t, ok, kind.Unhandled, kind.Kind)
+			if ok {
+				require.NotNil(t, kind.OldestPendingAt, kind.Kind)
+				require.WithinDuration(t, oldestPendingAt, *kind.OldestPendingAt, time.Millisecond, kind.Kind)
+			} else {
+				require.Nil(t, kind.OldestPendingAt, kind.Kind)
+			}
+		}
+	})
+
+	t.Run("NoRegistry", func(t *testing.T) {
+		t.Parallel()
+
+		endpoint, bundle := setupEndpoint(ctx, t, newKindListEndpoint)
+
+		_ = testfactory.Job(ctx, t, bundle.exec, &testfactory.JobOpts{Kind: ptrutil.Ptr("removed")})
+
+		resp, err := apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &kindListRequest{})
+		require.NoError(t, err)
+		require.False(t, resp.RegistryConfigured)
+		require.Len(t, resp.Data, 1)
+		require.Equal(t, "removed", resp.Data[0].Kind)
+		require.False(t, resp.Data[0].Unhandled)
+	})
+}
+
+func TestKindsFromRegistrations(t *testing.T) {
+	t.Parallel()
+
+	var (
+		now        = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+		staleAfter = 5 * time.Minute
+	)
+
+	kinds := kindsFromRegistrations([]string{"pending_only", "shared"}, []*uikinds.Registration{
+		{ClientID: "client_2", Kind: "shared", SeenAt: now.Add(-time.Minute)},
+		{ClientID: "client_1", Kind: "shared", SeenAt: now.Add(-2 * time.Minute)},
+		{ClientID: "client_3", Kind: "shared", SeenAt: now.Add(-time.Hour)},
+		{ClientID: "client_1", Kind: "gone", SeenAt: now.Add(-time.Hour)},
+	}, now, staleAfter)
+
+	require.Len(t, kinds, 3)
+
+	require.Equal(t, "gone", kinds[0].Kind)
+	require.Empty(t, kinds[0].ClientIDs)
+	require.Equal(t, now.Add(-time.Hour), *kinds[0].LastSeenAt)
+	require.False(t, kinds[0].Registered)
+	require.True(t, kinds[0].Stale)
+
+	require.Equal(t, "pending_only", kinds[1].Kind)
+	require.Empty(t, kinds[1].ClientIDs)
+	require.Nil(t, kinds[1].LastSeenAt)
+	require.False(t, kinds[1].Registered)
+	require.False(t, kinds[1].Stale)
+
+	require.Equal(t, "shared", kinds[2].Kind)
+	require.Equal(t, []string{"client_1", "client_2"}, kinds[2].ClientIDs)
+	require.Equal(t, now.Add(-time.Minute), *kinds[2].LastSeenAt)
+	require.True(t, kinds[2].Registered)
+	require.False(t, kinds[2].Stale)
+}
+
+func TestKindListRequestExtractRaw(t *testing.T) {
+	t.Parallel()
+
+	extract := func(query string) (*kindListRequest, error) {
+		req := &kindListRequest{}
+		return req, req.ExtractRaw(httptest.NewRequest(http.MethodGet, "/api/kinds?"+query, nil))
+	}
+
+	req, err := extract("stale_after=10m")
+	require.NoError(t, err)
+	require.Equal(t, 10*time.Minute, *req.StaleAfter)
+
+	req, err = extract("")
+	require.NoError(t, err)
+	require.Nil(t, req.StaleAfter)
+
+	_, err = extract("stale_after=soon")
+	uicommontest.RequireAPIError(t, apierror.NewBadRequest("Couldn't convert `stale_after` to duration: time: invalid duration \"soon\"."), err)
+
+	_, err = extract("stale_after=-1m")
+	uicommontest.RequireAPIError(t, apierror.NewBadRequest("`stale_after` must be positive."), err)
+}
+
+type testSchemaArgs struct {
+	InvoiceID int64 `json:"invoice_id"`
+}
+
+func (testSchemaArgs) Kind() string { return "invoice.send" }
+
+func testArgsSchemas(t *testing.T) *uischema.Registry {
+	t.Helper()
+
+	registry := uischema.NewRegistry()
+	require.NoError(t, registry.RegisterArgs(testSchemaArgs{}))
+	return registry
+}
+
+func TestAPIHandlerKindSchemaGet(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		t.Parallel()
+
+		endpoint := newKindSchemaGetEndpoint(apibundle.APIBundle[pgx.Tx]{ArgsSchemas: testArgsSchemas(t)})
+
+		resp, err := apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &kindSchemaGetRequest{Kind: "invoice.send"})
+		require.NoError(t, err)
+		require.Equal(t, "invoice.send", resp.Kind)
+		require.JSONEq(t, `{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"title": "invoice.send",
+			"type": "object",
+			"additionalProperties": false,
+			"properties": {"invoice_id": {"type": "integer"}},
+			"required": ["invoice_id"]
+		}`, string(resp.Schema))
+	})
+
+	t.Run("NoSchema", func(t *testing.T) {
+		t.Parallel()
+
+		for _, argsSchemas := range []*uischema.Registry{nil, testArgsSchemas(t)} {
+			endpoint := newKindSchemaGetEndpoint(apibundle.APIBundle[pgx.Tx]{ArgsSchemas: argsSchemas})
+
+			resp, err := apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &kindSchemaGetRequest{Kind: "other"})
+			require.NoError(t, err)
+
+			respJSON, err := json.Marshal(resp)
+			require.NoError(t, err)
+			require.JSONEq(t, `{"kind": "other", "schema": null}`, string(respJSON))
+		}
+	})
+
+	t.Run("KindNotVisible", func(t *testing.T) {
+		t.Parallel()
+
+		endpoint := newKindSchemaGetEndpoint(apibundle.APIBundle[pgx.Tx]{ArgsSchemas: testArgsSchemas(t), AuthorizationPolicy: testBillingPolicy(true)})
+
+		ctx := uiauth.WithIdentity(ctx, &uiauth.Identity{Name: "bob", Roles: []uiauth.Role{uiauth.RoleOperator}})
+		_, err := apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &kindSchemaGetRequest{Kind: "invoice.send"})
+		uicommontest.RequireAPIError(t, NewNotFoundKind("invoice.send"), err)
+
+		ctx = uiauth.WithIdentity(ctx, testBillingIdentity())
+		_, err = apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &kindSchemaGetRequest{Kind: "invoice.send"})
+		require.NoError(t, err)
+	})
+}
+
+func TestAPIHandlerKindValidate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("Valid", func(t *testing.T) {
+		t.Parallel()
+
+		endpoint := newKindValidateEndpoint(apibundle.APIBundle[pgx.Tx]{ArgsSchemas: testArgsSchemas(t)})
+
+		resp, err := apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &kindValidateRequest{Args: json.RawMessage(`{"invoice_id": 123}`), Kind: "invoice.send"})
+		require.NoError(t, err)
+		require.Equal(t, &kindValidateResponse{Errors: []*uischema.FieldError{}, Valid: true}, resp)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+
+		endpoint := newKindValidateEndpoint(apibundle.APIBundle[pgx.Tx]{ArgsSchemas: testArgsSchemas(t)})
+
+		resp, err := apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &kindValidateRequest{Args: json.RawMessage(`{"invoice_id": "123", "extra": true}`), Kind: "invoice.send"})
+		require.NoError(t, err)
+		require.Equal(t, &kindValidateResponse{
+			Errors: []*uischema.FieldError{
+				{Path: "$.extra", Message: "isn't a known property"},
+				{Path: "$.invoice_id", Message: "must be of type integer"},
+			},
+			Valid: false,
+		}, resp)
+	})
+
+	t.Run("RawJSONFallback", func(t *testing.T) {
+		t.Parallel()
+
+		endpoint := newKindValidateEndpoint(apibundle.APIBundle[pgx.Tx]{})
+
+		resp, err := apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &kindValidateRequest{Args: json.RawMessage(`{"anything": 1}`), Kind: "other"})
+		require.NoError(t, err)
+		require.True(t, resp.Valid)
+
+		resp, err = apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &kindValidateRequest{Args: json.RawMessage(`[1]`), Kind: "other"})
+		require.NoError(t, err)
+		require.Equal(t, &kindValidateResponse{Errors: []*uischema.FieldError{{Path: "$", Message: "must be a JSON object"}}, Valid: false}, resp)
+	})
+
+	t.Run("KindNotVisible", func(t *testing.T) {
+		t.Parallel()
+
+		endpoint := newKindValidateEndpoint(apibundle.APIBundle[pgx.Tx]{ArgsSchemas: testArgsSchemas(t), AuthorizationPolicy: testBillingPolicy(true)})
+
+		ctx := uiauth.WithIdentity(ctx, &uiauth.Identity{Name: "bob", Roles: []uiauth.Role{uiauth.RoleOperator}})
+		_, err := apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &kindValidateRequest{Args: json.RawMessage(`{}`), Kind: "invoice.send"})
+		uicommontest.RequireAPIError(t, NewNotFoundKind("invoice.send"), err)
+	})
+}
+
 func TestAPIHandlerQueueGet(t *testing.T) {
 	t.Parallel()
 
@@ -920,6 +1642,29 @@ func TestAPIHandlerQueueList(t *testing.T) {
 		require.Len(t, resp.Data, 1)
 		require.Equal(t, queue1.Name, resp.Data[0].Name)
 	})
+
+	t.Run("FilteredByPolicy", func(t *testing.T) {
+		t.Parallel()
+
+		endpoint, bundle := setupEndpoint(ctx, t, newQueueListEndpoint)
+		endpoint.AuthorizationPolicy = testBillingPolicy(true)
+
+		// Hidden queues sort before visible ones so that a page filled only
+		// from the first queues listed would be empty.
+		for _, name := range []string{"analytics_1", "analytics_2", "analytics_3", "billing_high", "billing_low", "billing_medium"} {
+			_ = testfactory.Queue(ctx, t, bundle.exec, &testfactory.QueueOpts{Name: ptrutil.Ptr(name)})
+		}
+
+		ctx := uiauth.WithIdentity(ctx, testBillingIdentity())
+
+		resp, err := apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &queueListRequest{Limit: ptrutil.Ptr(2)})
+		require.NoError(t, err)
+		require.Equal(t, []string{"billing_high", "billing_low"}, sliceutil.Map(resp.Data, func(q *RiverQueue) string { return q.Name }))
+
+		resp, err = apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &queueListRequest{})
+		require.NoError(t, err)
+		require.Equal(t, []string{"billing_high", "billing_low", "billing_medium"}, sliceutil.Map(resp.Data, func(q *RiverQueue) string { return q.Name }))
+	})
 }
 
 func TestAPIHandlerQueuePause(t *testing.T) {
@@ -947,6 +1692,25 @@ func TestAPIHandlerQueuePause(t *testing.T) {
 		_, err := apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &queuePauseRequest{Name: "does_not_exist"})
 		uicommontest.RequireAPIError(t, NewNotFoundQueue("does_not_exist"), err)
 	})
+
+	t.Run("ScopedByPolicy", func(t *testing.T) {
+		t.Parallel()
+
+		endpoint, bundle := setupEndpoint(ctx, t, newQueuePauseEndpoint)
+		endpoint.AuthorizationPolicy = testBillingPolicy(false)
+
+		billingQueue := testfactory.Queue(ctx, t, bundle.exec, &testfactory.QueueOpts{Name: ptrutil.Ptr("billing_high")})
+		otherQueue := testfactory.Queue(ctx, t, bundle.exec, &testfactory.QueueOpts{Name: ptrutil.Ptr("mailers")})
+
+		ctx := uiauth.WithIdentity(ctx, testBillingIdentity())
+
+		_, err := apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &queuePauseRequest{Name: otherQueue.Name})
+		uicommontest.RequireAPIError(t, uiauth.NewForbiddenf("Not permitted to pause queue %q.", otherQueue.Name), err)
+
+		resp, err := apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &queuePauseRequest{Name: billingQueue.Name})
+		require.NoError(t, err)
+		require.Equal(t, statusResponseOK, resp)
+	})
 }
 
 func TestAPIHandlerQueueResume(t *testing.T) {
@@ -1144,3 +1908,24 @@ func TestStateAndCountGetEndpoint(t *testing.T) {
 		}, resp)
 	})
 }
+
+// Identity and policy used for testing authorization scoped to queues and
+// kinds, where members of team-billing may cancel and retry billing jobs and
+// pause and resume billing queues.
+func testBillingIdentity() *uiauth.Identity {
+	return &uiauth.Identity{Groups: []string{"team-billing"}, Name: "alice", Roles: []uiauth.Role{uiauth.RoleOperator}}
+}
+
+func testBillingPolicy(filterLists bool) *uiauth.Policy {
+	return &uiauth.Policy{
+		FilterLists: filterLists,
+		Rules: []*uiauth.Rule{
+			{
+				Actions:  []uiauth.Action{uiauth.ActionJobCancel, uiauth.ActionJobRetry, uiauth.ActionQueuePause, uiauth.ActionQueueResume},
+				Kinds:    []string{"invoice.*"},
+				Queues:   []string{"billing_*"},
+				Subjects: []string{"team-billing"},
+			},
+		},
+	}
+}
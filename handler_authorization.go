@@ -0,0 +1,113 @@
+package riverui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/riverqueue/river"
+
+	"riverqueue.com/riverui/internal/apibundle"
+	"riverqueue.com/riverui/uiauth"
+)
+
+// Checks that the identity in context may take the given action on each of
+// the given jobs according to the bundle's authorization policy, returning a
+// forbidden API error for the first job that it may not. Jobs that don't exist
+// are ignored so that the caller can return its usual not found error.
+func authorizeJobAction[TTx any](ctx context.Context, bundle apibundle.APIBundle[TTx], tx TTx, action uiauth.Action, jobIDs []int64) error {
+	var (
+		identity = uiauth.IdentityFromContext(ctx)
+		policy   = bundle.AuthorizationPolicy
+	)
+
+	if !policy.Restricts(identity) || len(jobIDs) < 1 {
+		return nil
+	}
+
+	result, err := bundle.Client.JobListTx(ctx, tx, river.NewJobListParams().IDs(jobIDs...).First(len(jobIDs)))
+	if err != nil {
+		return fmt.Errorf("error listing jobs for authorization: %w", err)
+	}
+
+	for _, job := range result.Jobs {
+		if !policy.AllowsJobAction(identity, action, job.Queue, job.Kind) {
+			return uiauth.NewForbiddenf("Not permitted to %s job %d (queue %q, kind %q).", actionVerb(action), job.ID, job.Queue, job.Kind)
+		}
+	}
+
+	return nil
+}
+
+// Checks that the identity in context may take the given action on a queue
+// according to the bundle's authorization policy.
+func authorizeQueueAction[TTx any](ctx context.Context, bundle apibundle.APIBundle[TTx], action uiauth.Action, queueName string) error {
+	if !bundle.AuthorizationPolicy.AllowsQueueAction(uiauth.IdentityFromContext(ctx), action, queueName) {
+		return uiauth.NewForbiddenf("Not permitted to %s queue %q.", actionVerb(action), queueName)
+	}
+	return nil
+}
+
+// Gets a verb for an action like "cancel" for use in error messages.
+func actionVerb(action uiauth.Action) string {
+	_, verb, _ := strings.Cut(string(action), ":")
+	return verb
+}
+
+// Returns a SQL predicate and named arguments for use with JobListParams.Where
+// that limit a job list to jobs in queues and of kinds matched by the given
+// rules. Returns an empty predicate if the rules don't restrict anything.
+func jobVisibilityPredicate(rules []*uiauth.Rule) (string, river.NamedArgs) {
+	if len(rules) < 1 {
+		return "1 = 0", nil
+	}
+
+	var (
+		namedArgs  = river.NamedArgs{}
+		predicates = make([]string, 0, len(rules))
+	)
+
+	// Builds a predicate like `(column LIKE @arg0 ESCAPE '\' OR ...)`.
+	likeAny := func(column string, patterns []string, argPrefix string) string {
+		likes := make([]string, len(patterns))
+		for i, pattern := range patterns {
+			argName := fmt.Sprintf("%s_%d", argPrefix, i)
+			namedArgs[argName] = globToLikePattern(pattern)
+			likes[i] = fmt.Sprintf(`%s LIKE @%s ESCAPE '\'`, column, argName)
+		}
+		return "(" + strings.Join(likes, " OR ") + ")"
+	}
+
+	for i, rule := range rules {
+		var conditions []string
+		if len(rule.Queues) > 0 {
+			conditions = append(conditions, likeAny("queue", rule.Queues, fmt.Sprintf("authz_rule%d_queue", i)))
+		}
+		if len(rule.Kinds) > 0 {
+			conditions = append(conditions, likeAny("kind", rule.Kinds, fmt.Sprintf("authz_rule%d_kind", i)))
+		}
+
+		// A rule without patterns matches every job, so no predicate is
+		// needed at all.
+		if len(conditions) < 1 {
+			return "", nil
+		}
+
+		predicates = append(predicates, "("+strings.Join(conditions, " AND ")+")")
+	}
+
+	return "(" + strings.Join(predicates, " OR ") + ")", namedArgs
+}
+
+var likeEscaper = strings.NewReplacer( //nolint:gochecknoglobals
+	`\`, `\\`,
+	`%`, `\%`,
+	`_`, `\_`,
+	`*`, `%`,
+)
+
+// Converts a pattern where `*` is a wildcard to an equivalent SQL LIKE pattern
+// for use with `ESCAPE '\'`.
+func globToLikePattern(pattern string) string {
+	return likeEscaper.Replace(pattern)
+}
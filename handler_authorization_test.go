@@ -0,0 +1,60 @@
+package riverui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/riverqueue/river"
+
+	"riverqueue.com/riverui/uiauth"
+)
+
+func TestGlobToLikePattern(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "billing%", globToLikePattern("billing*"))
+	require.Equal(t, `billing\_%`, globToLikePattern("billing_*"))
+	require.Equal(t, `100\%\\%`, globToLikePattern(`100%\*`))
+}
+
+func TestJobVisibilityPredicate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("NoRules", func(t *testing.T) {
+		t.Parallel()
+
+		predicate, namedArgs := jobVisibilityPredicate(nil)
+		require.Equal(t, "1 = 0", predicate)
+		require.Nil(t, namedArgs)
+	})
+
+	t.Run("UnrestrictedRule", func(t *testing.T) {
+		t.Parallel()
+
+		predicate, _ := jobVisibilityPredicate([]*uiauth.Rule{
+			{Queues: []string{"billing_*"}},
+			{},
+		})
+		require.Empty(t, predicate)
+	})
+
+	t.Run("QueuesAndKinds", func(t *testing.T) {
+		t.Parallel()
+
+		predicate, namedArgs := jobVisibilityPredicate([]*uiauth.Rule{
+			{Kinds: []string{"invoice.*"}, Queues: []string{"billing_*", "payments"}},
+			{Kinds: []string{"email.send"}},
+		})
+		require.Equal(t,
+			`(((queue LIKE @authz_rule0_queue_0 ESCAPE '\' OR queue LIKE @authz_rule0_queue_1 ESCAPE '\') AND (kind LIKE @authz_rule0_kind_0 ESCAPE '\')) OR ((kind LIKE @authz_rule1_kind_0 ESCAPE '\')))`,
+			predicate,
+		)
+		require.Equal(t, river.NamedArgs{
+			"authz_rule0_kind_0":  "invoice.%",
+			"authz_rule0_queue_0": `billing\_%`,
+			"authz_rule0_queue_1": "payments",
+			"authz_rule1_kind_0":  "email.send",
+		}, namedArgs)
+	})
+}
@@ -0,0 +1,152 @@
+//go:build databasesql
+
+// These tests run the handler on River's database/sql driver backed by
+// lib/pq, whose errors look different from pgx's. Like SQLite support, the
+// drivers they need aren't dependencies of this module, so the tests are only
+// built with the `databasesql` tag after adding them:
+//
+//	go get github.com/lib/pq github.com/riverqueue/river/riverdriver/riverdatabasesql
+//	go test -tags databasesql -run DatabaseSQL .
+
+package riverui
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+
+	"github.com/riverqueue/apiframe/apierror"
+	"github.com/riverqueue/apiframe/apitest"
+	"github.com/riverqueue/river"
+	"github.com/riverqueue/river/riverdbtest"
+	"github.com/riverqueue/river/riverdriver"
+	"github.com/riverqueue/river/riverdriver/riverdatabasesql"
+	"github.com/riverqueue/river/rivershared/riversharedtest"
+	"github.com/riverqueue/river/rivershared/uniquestates"
+	"github.com/riverqueue/river/rivershared/util/ptrutil"
+	"github.com/riverqueue/river/rivertype"
+
+	"riverqueue.com/riverui/internal/apibundle"
+	"riverqueue.com/riverui/internal/handlertest"
+	"riverqueue.com/riverui/internal/riverinternaltest/testfactory"
+	"riverqueue.com/riverui/uiendpoints"
+)
+
+// Opens a database/sql pool on lib/pq for the test database.
+func databaseSQLPool(tb testing.TB) *sql.DB {
+	tb.Helper()
+
+	db, err := sql.Open("postgres", riversharedtest.TestDatabaseURL())
+	require.NoError(tb, err)
+	tb.Cleanup(func() { require.NoError(tb, db.Close()) })
+	return db
+}
+
+func TestNewHandlerIntegrationDatabaseSQL(t *testing.T) {
+	t.Parallel()
+
+	createClient := func(ctx context.Context, tb testing.TB, logger *slog.Logger) (*river.Client[*sql.Tx], riverdriver.Driver[*sql.Tx], *sql.Tx) {
+		tb.Helper()
+
+		driver := riverdatabasesql.New(databaseSQLPool(tb))
+		tx, _ := riverdbtest.TestTx(ctx, tb, driver, nil)
+
+		client, err := river.NewClient(driver, &river.Config{
+			Logger: logger,
+		})
+		require.NoError(tb, err)
+
+		return client, driver, tx
+	}
+
+	createBundle := func(client *river.Client[*sql.Tx], tx *sql.Tx) uiendpoints.Bundle {
+		return NewEndpoints(client, &EndpointsOpts[*sql.Tx]{
+			Tx: &tx,
+		})
+	}
+
+	handlertest.RunIntegrationTest(t, createClient, createBundle, newIntegrationTestHandler, integrationTestRunner[*sql.Tx](t))
+}
+
+func TestAPIHandlerDatabaseSQL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (apibundle.APIBundle[*sql.Tx], riverdriver.Executor) {
+		t.Helper()
+
+		var (
+			logger = riversharedtest.Logger(t)
+			driver = riverdatabasesql.New(databaseSQLPool(t))
+			tx, _  = riverdbtest.TestTx(ctx, t, driver, nil)
+			exec   = driver.UnwrapExecutor(tx)
+		)
+
+		client, err := river.NewClient(driver, &river.Config{
+			Logger: logger,
+		})
+		require.NoError(t, err)
+
+		return apibundle.APIBundle[*sql.Tx]{
+			Archetype:  riversharedtest.BaseServiceArchetype(t),
+			Client:     client,
+			DB:         exec,
+			Driver:     driver,
+			Extensions: func(_ context.Context) (map[string]bool, error) { return map[string]bool{}, nil },
+			Logger:     logger,
+		}, exec
+	}
+
+	t.Run("JobRetryUniqueConflict", func(t *testing.T) {
+		t.Parallel()
+
+		bundle, exec := setup(t)
+		endpoint := newJobRetryEndpoint(bundle)
+
+		uniqueKey := []byte("job-retry-unique-conflict")
+		uniqueStates := uniquestates.UniqueStatesToBitmask([]rivertype.JobState{rivertype.JobStateAvailable})
+
+		discardedParams := testfactory.Job_Build(t, &testfactory.JobOpts{
+			FinalizedAt: ptrutil.Ptr(time.Now()),
+			State:       ptrutil.Ptr(rivertype.JobStateDiscarded),
+		})
+		discardedParams.UniqueKey = uniqueKey
+		discardedParams.UniqueStates = uniqueStates
+		discardedJob, err := exec.JobInsertFull(ctx, discardedParams)
+		require.NoError(t, err)
+
+		activeParams := testfactory.Job_Build(t, nil)
+		activeParams.UniqueKey = uniqueKey
+		activeParams.UniqueStates = uniqueStates
+		_, err = exec.JobInsertFull(ctx, activeParams)
+		require.NoError(t, err)
+
+		_, err = apitest.InvokeHandler(ctx, endpoint.Execute, testMountOpts(t), &jobRetryRequest{JobIDs: []int64String{int64String(discardedJob.ID)}})
+
+		var apiErr *jobRetryUniqueConflictError
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusConflict, apiErr.StatusCode)
+		require.Equal(t, jobRetryUniqueMessage, apiErr.Message)
+	})
+
+	t.Run("StatementTimeout", func(t *testing.T) {
+		t.Parallel()
+
+		_, exec := setup(t)
+
+		_, err := uiendpoints.WithTxV(ctx, &apibundle.StatementTimeoutExecutor{Executor: exec, Timeout: 10 * time.Millisecond}, func(ctx context.Context, execTx riverdriver.ExecutorTx) (struct{}, error) {
+			return struct{}{}, execTx.Exec(ctx, "SELECT pg_sleep(1)")
+		})
+
+		var apiErr *apierror.ServiceUnavailable
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, uiendpoints.StatementTimeoutMessage, apiErr.Message)
+	})
+}
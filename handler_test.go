@@ -2,15 +2,23 @@ package riverui
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/stretchr/testify/require"
 
+	"github.com/riverqueue/apiframe/apiendpoint"
+	"github.com/riverqueue/apiframe/apierror"
 	"github.com/riverqueue/apiframe/apitype"
 	"github.com/riverqueue/river"
 	"github.com/riverqueue/river/riverdbtest"
@@ -18,9 +26,12 @@ import (
 	"github.com/riverqueue/river/riverdriver/riverpgxv5"
 	"github.com/riverqueue/river/rivershared/riversharedtest"
 
+	"riverqueue.com/riverui/internal/apibundle"
 	"riverqueue.com/riverui/internal/handlertest"
 	"riverqueue.com/riverui/internal/riverinternaltest/testfactory"
 	"riverqueue.com/riverui/internal/uicommontest"
+	"riverqueue.com/riverui/uiaudit"
+	"riverqueue.com/riverui/uiauth"
 	"riverqueue.com/riverui/uiendpoints"
 )
 
@@ -47,22 +58,33 @@ func TestNewHandlerIntegration(t *testing.T) {
 		})
 	}
 
-	createHandler := func(t *testing.T, bundle uiendpoints.Bundle) http.Handler {
-		t.Helper()
+	handlertest.RunIntegrationTest(t, createClient, createBundle, newIntegrationTestHandler, integrationTestRunner[pgx.Tx](t))
+}
 
-		logger := riversharedtest.Logger(t)
-		server, err := NewHandler(&HandlerOpts{
-			DevMode:     true,
-			Endpoints:   bundle,
-			LiveFS:      true,
-			Logger:      logger,
-			projectRoot: "./",
-		})
-		require.NoError(t, err)
-		return server
-	}
+// newIntegrationTestHandler is a handlertest.RunIntegrationTest createHandler
+// shared by the integration tests of each driver.
+func newIntegrationTestHandler(t *testing.T, bundle uiendpoints.Bundle) http.Handler {
+	t.Helper()
+
+	logger := riversharedtest.Logger(t)
+	server, err := NewHandler(&HandlerOpts{
+		DevMode:     true,
+		Endpoints:   bundle,
+		LiveFS:      true,
+		Logger:      logger,
+		projectRoot: "./",
+	})
+	require.NoError(t, err)
+	return server
+}
+
+// integrationTestRunner returns a handlertest.RunIntegrationTest testRunner
+// that calls each built-in API endpoint, shared by the integration tests of
+// each driver.
+func integrationTestRunner[TTx any](t *testing.T) func(exec riverdriver.Executor, _ riverdriver.Driver[TTx], makeAPICall handlertest.APICallFunc) {
+	t.Helper()
 
-	testRunner := func(exec riverdriver.Executor, _ riverdriver.Driver[pgx.Tx], makeAPICall handlertest.APICallFunc) {
+	return func(exec riverdriver.Executor, _ riverdriver.Driver[TTx], makeAPICall handlertest.APICallFunc) {
 		ctx := context.Background()
 
 		makeURL := fmt.Sprintf
@@ -112,8 +134,6 @@ func TestNewHandlerIntegration(t *testing.T) {
 
 		makeAPICall(t, "RobotsTxt", http.MethodGet, makeURL("/robots.txt"), nil)
 	}
-
-	handlertest.RunIntegrationTest(t, createClient, createBundle, createHandler, testRunner)
 }
 
 func TestMountStaticFiles(t *testing.T) {
@@ -163,3 +183,415 @@ func TestNormalizePathPrefix(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthorizationMiddleware(t *testing.T) {
+	t.Parallel()
+
+	type testBundle struct {
+		auditSink         *recordingAuditSink
+		identityInHandler *uiauth.Identity
+	}
+
+	setup := func(t *testing.T, anonymousRole uiauth.Role) (http.Handler, *testBundle) {
+		t.Helper()
+
+		var (
+			bundle = &testBundle{auditSink: &recordingAuditSink{}}
+			mux    = http.NewServeMux()
+		)
+
+		okHandler := func(w http.ResponseWriter, r *http.Request) {
+			bundle.identityInHandler = uiauth.IdentityFromContext(r.Context())
+			w.WriteHeader(http.StatusOK)
+		}
+		mux.HandleFunc("GET /api/jobs", okHandler)
+		mux.HandleFunc("POST /api/jobs/cancel", okHandler)
+		mux.HandleFunc("GET /robots.txt", okHandler)
+
+		middleware := &authorizationMiddleware{
+			anonymousRole: anonymousRole,
+			auditSink:     bundle.auditSink,
+			endpointAccess: map[string]uiauth.Access{
+				"GET /api/jobs":         uiauth.AccessRead,
+				"POST /api/jobs/cancel": uiauth.AccessMutate,
+			},
+			endpointAuditActions: map[string]string{
+				"POST /api/jobs/cancel": string(uiauth.ActionJobCancel),
+			},
+			logger: riversharedtest.Logger(t),
+			mux:    mux,
+		}
+
+		return middleware.Middleware(mux), bundle
+	}
+
+	serve := func(t *testing.T, handler http.Handler, method, path string, identity *uiauth.Identity) *httptest.ResponseRecorder {
+		t.Helper()
+
+		ctx := t.Context()
+		if identity != nil {
+			ctx = uiauth.WithIdentity(ctx, identity)
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequestWithContext(ctx, method, path, nil))
+		return recorder
+	}
+
+	t.Run("AnonymousGetsAnonymousRole", func(t *testing.T) {
+		t.Parallel()
+
+		handler, bundle := setup(t, uiauth.RoleViewer)
+
+		recorder := serve(t, handler, http.MethodGet, "/api/jobs", nil)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, &uiauth.Identity{Roles: []uiauth.Role{uiauth.RoleViewer}}, bundle.identityInHandler)
+
+		recorder = serve(t, handler, http.MethodPost, "/api/jobs/cancel", nil)
+		require.Equal(t, http.StatusForbidden, recorder.Code)
+		require.JSONEq(t, `{"message":"Insufficient permissions: this operation requires mutate access."}`, recorder.Body.String())
+	})
+
+	t.Run("ViewerCannotMutate", func(t *testing.T) {
+		t.Parallel()
+
+		handler, bundle := setup(t, uiauth.RoleAdmin)
+		identity := &uiauth.Identity{Name: "viewer", Roles: []uiauth.Role{uiauth.RoleViewer}}
+
+		require.Equal(t, http.StatusOK, serve(t, handler, http.MethodGet, "/api/jobs", identity).Code)
+		require.Equal(t, http.StatusForbidden, serve(t, handler, http.MethodPost, "/api/jobs/cancel", identity).Code)
+
+		entries := bundle.auditSink.recorded()
+		require.Len(t, entries, 1)
+		require.Equal(t, string(uiauth.ActionJobCancel), entries[0].Action)
+		require.Equal(t, "viewer", entries[0].Actor)
+		require.Equal(t, "POST /api/jobs/cancel", entries[0].Endpoint)
+		require.Equal(t, DefaultEnvironment, entries[0].Environment)
+		require.Equal(t, "Insufficient permissions: this operation requires mutate access.", entries[0].Error)
+		require.Equal(t, uiaudit.OutcomeDenied, entries[0].Outcome)
+		require.WithinDuration(t, time.Now(), entries[0].OccurredAt, time.Minute)
+	})
+
+	t.Run("OperatorCanMutate", func(t *testing.T) {
+		t.Parallel()
+
+		handler, bundle := setup(t, uiauth.RoleViewer)
+		identity := &uiauth.Identity{Name: "operator", Roles: []uiauth.Role{uiauth.RoleOperator}}
+
+		require.Equal(t, http.StatusOK, serve(t, handler, http.MethodPost, "/api/jobs/cancel", identity).Code)
+		require.Equal(t, identity, bundle.identityInHandler)
+
+		// Allowed requests are audited by their endpoints, not the middleware.
+		require.Empty(t, bundle.auditSink.recorded())
+	})
+
+	t.Run("IdentityWithoutRolesRejected", func(t *testing.T) {
+		t.Parallel()
+
+		handler, _ := setup(t, uiauth.RoleAdmin)
+
+		require.Equal(t, http.StatusForbidden, serve(t, handler, http.MethodGet, "/api/jobs", &uiauth.Identity{Name: "nobody"}).Code)
+	})
+
+	t.Run("NonEndpointRoutesPassThrough", func(t *testing.T) {
+		t.Parallel()
+
+		handler, _ := setup(t, uiauth.RoleViewer)
+
+		require.Equal(t, http.StatusOK, serve(t, handler, http.MethodGet, "/robots.txt", &uiauth.Identity{Name: "nobody"}).Code)
+	})
+}
+
+// recordingAuditSink is an audit sink that keeps the entries recorded to it.
+type recordingAuditSink struct {
+	entries []*uiaudit.Entry
+	mu      sync.Mutex
+}
+
+func (s *recordingAuditSink) Record(ctx context.Context, entry *uiaudit.Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func (s *recordingAuditSink) recorded() []*uiaudit.Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.entries)
+}
+
+func TestDrainingMiddleware(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	okHandler := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	mux.HandleFunc(healthCheckGetPattern, okHandler)
+	mux.HandleFunc("GET /api/jobs", okHandler)
+
+	var draining atomic.Bool
+	handler := (&drainingMiddleware{
+		draining: &draining,
+		logger:   riversharedtest.Logger(t),
+		mux:      mux,
+	}).Middleware(mux)
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequestWithContext(t.Context(), http.MethodGet, path, nil))
+		return recorder
+	}
+
+	require.Equal(t, http.StatusOK, serve("/api/health-checks/minimal").Code)
+
+	draining.Store(true)
+
+	recorder := serve("/api/health-checks/minimal")
+	require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	require.JSONEq(t, `{"message":"Server is shutting down."}`, recorder.Body.String())
+
+	require.Equal(t, http.StatusOK, serve("/api/jobs").Code)
+}
+
+type accessDeclaringEndpoint struct {
+	apiendpoint.Endpoint[struct{}, struct{}]
+}
+
+func (*accessDeclaringEndpoint) Access() uiauth.Access { return uiauth.AccessAdmin }
+func (*accessDeclaringEndpoint) Meta() *apiendpoint.EndpointMeta {
+	return &apiendpoint.EndpointMeta{Pattern: "GET /api/admin-thing", StatusCode: http.StatusOK}
+}
+
+type nonDeclaringEndpoint struct {
+	apiendpoint.Endpoint[struct{}, struct{}]
+}
+
+func (*nonDeclaringEndpoint) Meta() *apiendpoint.EndpointMeta {
+	return &apiendpoint.EndpointMeta{Pattern: "GET /api/thing", StatusCode: http.StatusOK}
+}
+
+func TestEndpointRequiredAccess(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Declared", func(t *testing.T) {
+		t.Parallel()
+
+		endpoint := &accessDeclaringEndpoint{}
+		require.Equal(t, uiauth.AccessAdmin, endpointRequiredAccess(endpoint, endpoint.Meta()))
+	})
+
+	t.Run("InferredFromMethod", func(t *testing.T) {
+		t.Parallel()
+
+		endpoint := &nonDeclaringEndpoint{}
+		require.Equal(t, uiauth.AccessRead, endpointRequiredAccess(endpoint, &apiendpoint.EndpointMeta{Pattern: "GET /api/thing"}))
+		require.Equal(t, uiauth.AccessRead, endpointRequiredAccess(endpoint, &apiendpoint.EndpointMeta{Pattern: "HEAD /api/thing"}))
+		require.Equal(t, uiauth.AccessMutate, endpointRequiredAccess(endpoint, &apiendpoint.EndpointMeta{Pattern: "POST /api/thing"}))
+		require.Equal(t, uiauth.AccessMutate, endpointRequiredAccess(endpoint, &apiendpoint.EndpointMeta{Pattern: "DELETE /api/thing"}))
+	})
+}
+
+type testExtension struct{}
+
+func (*testExtension) Features(_ context.Context) (map[string]bool, error) {
+	return map[string]bool{"dead_letter_queue": true, "producer_queries": true}, nil
+}
+
+func (*testExtension) MountEndpoints(bundle uiendpoints.APIBundle[pgx.Tx], mux *http.ServeMux, mountOpts *apiendpoint.MountOpts) []apiendpoint.EndpointInterface {
+	return []apiendpoint.EndpointInterface{
+		apiendpoint.Mount(mux, &testExtensionEndpoint{APIBundle: bundle}, mountOpts),
+		apiendpoint.Mount(mux, &testExtensionSlowEndpoint{APIBundle: bundle}, mountOpts),
+	}
+}
+
+type testExtensionEndpoint struct {
+	uiendpoints.APIBundle[pgx.Tx]
+	apiendpoint.Endpoint[testExtensionRequest, testExtensionResponse]
+}
+
+func (*testExtensionEndpoint) Meta() *apiendpoint.EndpointMeta {
+	return &apiendpoint.EndpointMeta{Pattern: "POST /api/jobs/{job_id}/redrive", StatusCode: http.StatusOK}
+}
+
+type testExtensionRequest struct {
+	JobID int64 `json:"-" path:"job_id"` // from ExtractRaw
+}
+
+func (req *testExtensionRequest) ExtractRaw(r *http.Request) error {
+	jobID, err := strconv.ParseInt(r.PathValue("job_id"), 10, 64)
+	if err != nil {
+		return apierror.NewBadRequestf("Couldn't convert job ID to int64: %s.", err)
+	}
+	req.JobID = jobID
+	return nil
+}
+
+type testExtensionResponse struct {
+	Environment string `json:"environment"`
+	JobID       int64  `json:"job_id"`
+}
+
+func (a *testExtensionEndpoint) Execute(_ context.Context, req *testExtensionRequest) (*testExtensionResponse, error) {
+	return &testExtensionResponse{Environment: a.Environment, JobID: req.JobID}, nil
+}
+
+// testExtensionSlowEndpoint is an extension endpoint that takes an audited
+// action with a query that's slower than any statement timeout in tests.
+type testExtensionSlowEndpoint struct {
+	uiendpoints.APIBundle[pgx.Tx]
+	apiendpoint.Endpoint[testExtensionRequest, statusResponse]
+}
+
+func (*testExtensionSlowEndpoint) AuditAction() string { return "job:reprocess" }
+
+func (*testExtensionSlowEndpoint) Meta() *apiendpoint.EndpointMeta {
+	return &apiendpoint.EndpointMeta{Pattern: "POST /api/jobs/{job_id}/reprocess", StatusCode: http.StatusOK}
+}
+
+func (a *testExtensionSlowEndpoint) Execute(ctx context.Context, req *testExtensionRequest) (*statusResponse, error) {
+	auditEntry := &uiaudit.Entry{
+		Action:   a.AuditAction(),
+		Endpoint: a.Meta().Pattern,
+		JobIDs:   []int64{req.JobID},
+	}
+
+	return uiendpoints.WithAudit(ctx, a.APIBundle, auditEntry, req, func() (*statusResponse, error) {
+		return uiendpoints.WithTxV(ctx, a.DB, func(ctx context.Context, execTx riverdriver.ExecutorTx) (*statusResponse, error) {
+			if err := execTx.Exec(ctx, "SELECT pg_sleep(1)"); err != nil {
+				return nil, err
+			}
+			return statusResponseOK, nil
+		})
+	})
+}
+
+func TestEndpointsExtensions(t *testing.T) {
+	t.Parallel()
+
+	// Extensions are mostly exercised without a database, so the client's
+	// driver has no pool.
+	setup := func(t *testing.T, extensions ...uiendpoints.Extension[pgx.Tx]) uiendpoints.Bundle {
+		t.Helper()
+
+		client, err := river.NewClient(riverpgxv5.New(nil), &river.Config{})
+		require.NoError(t, err)
+
+		return NewEndpoints(client, &EndpointsOpts[pgx.Tx]{Extensions: extensions})
+	}
+
+	newHandler := func(t *testing.T, endpoints uiendpoints.Bundle) *Handler {
+		t.Helper()
+
+		handler, err := NewHandler(&HandlerOpts{
+			AnonymousRole: uiauth.RoleViewer,
+			DevMode:       true,
+			Endpoints:     endpoints,
+			LiveFS:        true,
+			Logger:        riversharedtest.Logger(t),
+			projectRoot:   "./",
+		})
+		require.NoError(t, err)
+		return handler
+	}
+
+	serve := func(t *testing.T, handler http.Handler, method, path string, identity *uiauth.Identity) *httptest.ResponseRecorder {
+		t.Helper()
+
+		ctx := t.Context()
+		if identity != nil {
+			ctx = uiauth.WithIdentity(ctx, identity)
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequestWithContext(ctx, method, path, nil))
+		return recorder
+	}
+
+	t.Run("MountsAuthorizedEndpoints", func(t *testing.T) {
+		t.Parallel()
+
+		handler := newHandler(t, setup(t, &testExtension{}))
+
+		recorder := serve(t, handler, http.MethodPost, "/api/jobs/123/redrive", nil)
+		require.Equal(t, http.StatusForbidden, recorder.Code)
+
+		recorder = serve(t, handler, http.MethodPost, "/api/jobs/123/redrive", &uiauth.Identity{Name: "operator", Roles: []uiauth.Role{uiauth.RoleOperator}})
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.JSONEq(t, `{"environment":"default","job_id":123}`, recorder.Body.String())
+	})
+
+	t.Run("DescribedInOpenAPIDocument", func(t *testing.T) {
+		t.Parallel()
+
+		handler := newHandler(t, setup(t, &testExtension{}))
+
+		recorder := serve(t, handler, http.MethodGet, "/api/openapi.json", nil)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Contains(t, recorder.Body.String(), `"/api/jobs/{job_id}/redrive"`)
+	})
+
+	t.Run("FeaturesMerged", func(t *testing.T) {
+		t.Parallel()
+
+		endpoints := setup(t, &testExtension{})
+
+		// Built-in flags, like those of riverproui, take precedence.
+		endpoints.(apibundle.APIExtensionsProviderSetter).SetExtensionsProvider(func(_ context.Context) (map[string]bool, error) { //nolint:forcetypeassert
+			return map[string]bool{"producer_queries": false}, nil
+		})
+
+		handler := newHandler(t, endpoints)
+
+		recorder := serve(t, handler, http.MethodGet, "/api/features", nil)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var resp featuresGetResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+		require.Equal(t, map[string]bool{"dead_letter_queue": true, "producer_queries": false}, resp.Extensions)
+	})
+
+	t.Run("NilExtension", func(t *testing.T) {
+		t.Parallel()
+
+		require.EqualError(t, setup(t, &testExtension{}, nil).Validate(), "extension at index 1 is nil")
+	})
+
+	t.Run("StatementTimeoutAndAudit", func(t *testing.T) {
+		t.Parallel()
+
+		// Unlike the others, this test needs a database for the extension
+		// endpoint's query to time out.
+		var (
+			ctx    = t.Context()
+			driver = riverpgxv5.New(riversharedtest.DBPool(ctx, t))
+			tx, _  = riverdbtest.TestTxPgxDriver(ctx, t, driver, nil)
+		)
+
+		client, err := river.NewClient(driver, &river.Config{})
+		require.NoError(t, err)
+
+		auditSink := &recordingAuditSink{}
+		handler, err := NewHandler(&HandlerOpts{
+			AnonymousRole:    uiauth.RoleOperator,
+			AuditSink:        auditSink,
+			DevMode:          true,
+			Endpoints:        NewEndpoints(client, &EndpointsOpts[pgx.Tx]{Extensions: []uiendpoints.Extension[pgx.Tx]{&testExtension{}}, Tx: &tx}),
+			LiveFS:           true,
+			Logger:           riversharedtest.Logger(t),
+			StatementTimeout: 10 * time.Millisecond,
+			projectRoot:      "./",
+		})
+		require.NoError(t, err)
+
+		recorder := serve(t, handler, http.MethodPost, "/api/jobs/123/reprocess", nil)
+		require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+		require.Contains(t, recorder.Body.String(), uiendpoints.StatementTimeoutMessage)
+
+		entries := auditSink.recorded()
+		require.Len(t, entries, 1)
+		require.Equal(t, "job:reprocess", entries[0].Action)
+		require.Equal(t, "POST /api/jobs/{job_id}/reprocess", entries[0].Endpoint)
+		require.Equal(t, []int64{123}, entries[0].JobIDs)
+		require.Equal(t, uiaudit.OutcomeFailure, entries[0].Outcome)
+	})
+}
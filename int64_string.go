@@ -33,3 +33,11 @@ func (i *int64String) UnmarshalJSON(data []byte) error {
 	*i = int64String(parsedInt)
 	return nil
 }
+
+func int64StringsToInt64s(values []int64String) []int64 {
+	ints := make([]int64, len(values))
+	for i, value := range values {
+		ints[i] = int64(value)
+	}
+	return ints
+}
@@ -2,23 +2,14 @@ package apibundle
 
 import (
 	"context"
-	"log/slog"
 
-	"github.com/riverqueue/river"
-	"github.com/riverqueue/river/riverdriver"
-	"github.com/riverqueue/river/rivershared/baseservice"
+	"riverqueue.com/riverui/uiendpoints"
 )
 
-// APIBundle is a bundle of common types needed for many API endpoints.
-type APIBundle[TTx any] struct {
-	Archetype                *baseservice.Archetype
-	Client                   *river.Client[TTx]
-	DB                       riverdriver.Executor
-	Driver                   riverdriver.Driver[TTx]
-	Extensions               func(ctx context.Context) (map[string]bool, error)
-	JobListHideArgsByDefault bool
-	Logger                   *slog.Logger
-}
+// APIBundle is a bundle of common types needed for many API endpoints. It's an
+// alias of the public uiendpoints.APIBundle so that the endpoints of
+// extensions share the same bundle as built-in ones.
+type APIBundle[TTx any] = uiendpoints.APIBundle[TTx]
 
 // APIExtensionsProviderSetter is an interface to allow setting the extensions
 // provider for the feature flag API.
@@ -0,0 +1,44 @@
+package apibundle
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/riverqueue/river/riverdriver"
+	"github.com/riverqueue/river/rivershared/util/dbutil"
+)
+
+// StatementTimeoutExecutor wraps an executor so that every transaction it
+// begins has a Postgres statement timeout. See BeginWithStatementTimeout.
+type StatementTimeoutExecutor struct {
+	riverdriver.Executor
+
+	Timeout time.Duration
+}
+
+func (e *StatementTimeoutExecutor) Begin(ctx context.Context) (riverdriver.ExecutorTx, error) {
+	return BeginWithStatementTimeout(ctx, e.Executor, e.Timeout)
+}
+
+// BeginWithStatementTimeout begins a transaction on exec with a Postgres
+// statement timeout, which is set with `SET LOCAL` so that it doesn't leak
+// onto the pooled connection after the transaction ends. It's exported so
+// that executors with more methods than riverdriver.Executor, like River
+// Pro's, can be wrapped the same way as StatementTimeoutExecutor.
+func BeginWithStatementTimeout(ctx context.Context, exec riverdriver.Executor, timeout time.Duration) (riverdriver.ExecutorTx, error) {
+	execTx, err := exec.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// `SET` doesn't take parameters, but the value is an integer formatted
+	// here so there's nothing to escape. A timeout of zero disables it, so
+	// round sub-millisecond timeouts up.
+	if err := execTx.Exec(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", max(timeout.Milliseconds(), 1))); err != nil {
+		_ = dbutil.RollbackWithoutCancel(ctx, execTx)
+		return nil, fmt.Errorf("error setting statement timeout: %w", err)
+	}
+
+	return execTx, nil
+}
@@ -0,0 +1,66 @@
+package apibundle
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/riverqueue/apiframe/apierror"
+	"github.com/riverqueue/river/riverdbtest"
+	"github.com/riverqueue/river/riverdriver"
+	"github.com/riverqueue/river/riverdriver/riverpgxv5"
+	"github.com/riverqueue/river/rivershared/riversharedtest"
+
+	"riverqueue.com/riverui/uiendpoints"
+)
+
+func TestStatementTimeoutExecutor(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	setup := func(t *testing.T, timeout time.Duration) riverdriver.Executor {
+		t.Helper()
+
+		var (
+			driver = riverpgxv5.New(riversharedtest.DBPool(ctx, t))
+			tx, _  = riverdbtest.TestTxPgxDriver(ctx, t, driver, nil)
+		)
+
+		return &StatementTimeoutExecutor{Executor: driver.UnwrapExecutor(tx), Timeout: timeout}
+	}
+
+	t.Run("SetsTimeout", func(t *testing.T) {
+		t.Parallel()
+
+		exec := setup(t, 1500*time.Millisecond)
+
+		timeout, err := uiendpoints.WithTxV(ctx, exec, func(ctx context.Context, execTx riverdriver.ExecutorTx) (string, error) {
+			var timeout string
+			if err := execTx.QueryRow(ctx, "SHOW statement_timeout").Scan(&timeout); err != nil {
+				return "", err
+			}
+			return timeout, nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, "1500ms", timeout)
+	})
+
+	t.Run("TimeoutIsServiceUnavailable", func(t *testing.T) {
+		t.Parallel()
+
+		exec := setup(t, 10*time.Millisecond)
+
+		_, err := uiendpoints.WithTxV(ctx, exec, func(ctx context.Context, execTx riverdriver.ExecutorTx) (struct{}, error) {
+			return struct{}{}, execTx.Exec(ctx, "SELECT pg_sleep(1)")
+		})
+
+		var apiErr *apierror.ServiceUnavailable
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+		require.Equal(t, uiendpoints.StatementTimeoutMessage, apiErr.Message)
+	})
+}
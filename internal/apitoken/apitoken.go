@@ -0,0 +1,257 @@
+// Package apitoken manages bearer tokens used for programmatic access to River
+// UI's API. Tokens are random secrets of which only a SHA-256 hash is stored.
+// Each is granted a single role and may expire.
+package apitoken
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"riverqueue.com/riverui/uiauth"
+)
+
+// Table is the name of the table tokens are stored in.
+const Table = "river_ui_api_token"
+
+// Prefix is prepended to all generated tokens so that they're easily
+// recognizable, like by secret scanners.
+const Prefix = "riverui_"
+
+// lastUsedInterval is the minimum amount of time between updates of a token's
+// last used timestamp so that busy tokens don't cause a write on every
+// request.
+const lastUsedInterval = 1 * time.Minute
+
+// ErrNotFound is returned when a token doesn't exist or is already revoked.
+var ErrNotFound = errors.New("token not found")
+
+// DBTX is a database connection or transaction, like *pgxpool.Pool or pgx.Tx.
+type DBTX interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Token is a stored API token. Its secret is only available when it's created.
+type Token struct {
+	ID         int64
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	Name       string
+	RevokedAt  *time.Time
+	Role       uiauth.Role
+}
+
+// CreateParams are parameters for Store.Create.
+type CreateParams struct {
+	// ExpiresAt is when the token stops being accepted. Never expires if nil.
+	ExpiresAt *time.Time
+
+	// Name describes the token, like the runbook or script using it.
+	Name string
+
+	// Role is the role granted to requests authenticated with the token.
+	Role uiauth.Role
+}
+
+// Store creates, lists, revokes, and authenticates tokens in Postgres.
+type Store struct {
+	db    DBTX
+	table string
+}
+
+// NewStore returns a new Store using the given database. Tokens are stored in
+// the given schema, or in the connection's search path if it's empty.
+func NewStore(db DBTX, schema string) *Store {
+	identifier := pgx.Identifier{Table}
+	if schema != "" {
+		identifier = pgx.Identifier{schema, Table}
+	}
+	return &Store{db: db, table: identifier.Sanitize()}
+}
+
+// Migrate creates the token table if it doesn't already exist.
+func (s *Store) Migrate(ctx context.Context) error {
+	if _, err := s.db.Exec(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id bigserial PRIMARY KEY,
+			created_at timestamptz NOT NULL DEFAULT now(),
+			expires_at timestamptz,
+			last_used_at timestamptz,
+			name text NOT NULL,
+			revoked_at timestamptz,
+			role text NOT NULL,
+			token_hash text NOT NULL UNIQUE
+		)`, s.table)); err != nil {
+		return fmt.Errorf("error migrating API token table: %w", err)
+	}
+	return nil
+}
+
+// Create generates and stores a new token, returning it along with its secret.
+// The secret can't be retrieved again.
+func (s *Store) Create(ctx context.Context, params *CreateParams) (*Token, string, error) {
+	if params.Name == "" {
+		return nil, "", errors.New("token name is required")
+	}
+	if _, err := uiauth.ParseRole(string(params.Role)); err != nil {
+		return nil, "", err
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, "", err
+	}
+
+	rows, err := s.db.Query(ctx, fmt.Sprintf(`
+		INSERT INTO %s (expires_at, name, role, token_hash)
+		VALUES ($1, $2, $3, $4)
+		RETURNING %s`, s.table, tokenColumns),
+		params.ExpiresAt, params.Name, string(params.Role), hashSecret(secret))
+	if err != nil {
+		return nil, "", fmt.Errorf("error creating token: %w", err)
+	}
+
+	token, err := pgx.CollectExactlyOneRow(rows, scanToken)
+	if err != nil {
+		return nil, "", fmt.Errorf("error creating token: %w", err)
+	}
+
+	return token, secret, nil
+}
+
+// List returns all tokens, including expired and revoked ones, newest first.
+func (s *Store) List(ctx context.Context) ([]*Token, error) {
+	rows, err := s.db.Query(ctx, fmt.Sprintf(`
+		SELECT %s
+		FROM %s
+		ORDER BY id DESC`, tokenColumns, s.table))
+	if err != nil {
+		return nil, fmt.Errorf("error listing tokens: %w", err)
+	}
+
+	tokens, err := pgx.CollectRows(rows, scanToken)
+	if err != nil {
+		return nil, fmt.Errorf("error listing tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// Revoke revokes the token with the given ID so that it's no longer accepted.
+// Returns ErrNotFound if the token doesn't exist or was already revoked.
+func (s *Store) Revoke(ctx context.Context, id int64) (*Token, error) {
+	rows, err := s.db.Query(ctx, fmt.Sprintf(`
+		UPDATE %s
+		SET revoked_at = now()
+		WHERE id = $1
+			AND revoked_at IS NULL
+		RETURNING %s`, s.table, tokenColumns), id)
+	if err != nil {
+		return nil, fmt.Errorf("error revoking token: %w", err)
+	}
+
+	token, err := pgx.CollectExactlyOneRow(rows, scanToken)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("error revoking token: %w", err)
+	}
+
+	return token, nil
+}
+
+// Authenticate returns the token matching the given secret if it's neither
+// expired nor revoked, and records that it was used. Returns ErrNotFound
+// otherwise.
+func (s *Store) Authenticate(ctx context.Context, secret string) (*Token, error) {
+	if !strings.HasPrefix(secret, Prefix) {
+		return nil, ErrNotFound
+	}
+
+	rows, err := s.db.Query(ctx, fmt.Sprintf(`
+		SELECT %s
+		FROM %s
+		WHERE token_hash = $1
+			AND revoked_at IS NULL
+			AND (expires_at IS NULL OR expires_at > now())`, tokenColumns, s.table),
+		hashSecret(secret))
+	if err != nil {
+		return nil, fmt.Errorf("error authenticating token: %w", err)
+	}
+
+	token, err := pgx.CollectExactlyOneRow(rows, scanToken)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("error authenticating token: %w", err)
+	}
+
+	if token.LastUsedAt == nil || time.Since(*token.LastUsedAt) > lastUsedInterval {
+		if _, err := s.db.Exec(ctx, fmt.Sprintf(`UPDATE %s SET last_used_at = now() WHERE id = $1`, s.table), token.ID); err != nil {
+			return nil, fmt.Errorf("error updating token last used: %w", err)
+		}
+	}
+
+	return token, nil
+}
+
+// AuthenticateToken authenticates a bearer token, returning an identity for
+// it or nil if the token isn't valid. It implements
+// authmiddleware.TokenAuthenticator.
+func (s *Store) AuthenticateToken(ctx context.Context, secret string) (*uiauth.Identity, error) {
+	token, err := s.Authenticate(ctx, secret)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil //nolint:nilnil
+		}
+		return nil, err
+	}
+
+	return &uiauth.Identity{
+		Name:  "token:" + token.Name,
+		Roles: []uiauth.Role{token.Role},
+	}, nil
+}
+
+const tokenColumns = "id, created_at, expires_at, last_used_at, name, revoked_at, role"
+
+func scanToken(row pgx.CollectableRow) (*Token, error) {
+	var (
+		token Token
+		role  string
+	)
+	if err := row.Scan(&token.ID, &token.CreatedAt, &token.ExpiresAt, &token.LastUsedAt, &token.Name, &token.RevokedAt, &role); err != nil {
+		return nil, err
+	}
+	token.Role = uiauth.Role(role)
+	return &token, nil
+}
+
+func generateSecret() (string, error) {
+	randomBytes := make([]byte, 32)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", fmt.Errorf("error generating token: %w", err)
+	}
+	return Prefix + base64.RawURLEncoding.EncodeToString(randomBytes), nil
+}
+
+// Tokens carry 256 bits of entropy, so a fast unsalted hash is sufficient to
+// protect them in storage.
+func hashSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,135 @@
+package apitoken
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/riverqueue/river/riverdbtest"
+	"github.com/riverqueue/river/riverdriver/riverpgxv5"
+	"github.com/riverqueue/river/rivershared/riversharedtest"
+	"github.com/riverqueue/river/rivershared/util/ptrutil"
+
+	"riverqueue.com/riverui/uiauth"
+)
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	setup := func(t *testing.T) *Store {
+		t.Helper()
+
+		driver := riverpgxv5.New(riversharedtest.DBPool(ctx, t))
+		tx, _ := riverdbtest.TestTxPgxDriver(ctx, t, driver, nil)
+
+		store := NewStore(tx, "")
+		require.NoError(t, store.Migrate(ctx))
+		return store
+	}
+
+	t.Run("CreateAndAuthenticate", func(t *testing.T) {
+		t.Parallel()
+
+		store := setup(t)
+
+		token, secret, err := store.Create(ctx, &CreateParams{Name: "runbook", Role: uiauth.RoleOperator})
+		require.NoError(t, err)
+		require.Equal(t, "runbook", token.Name)
+		require.Equal(t, uiauth.RoleOperator, token.Role)
+		require.Nil(t, token.LastUsedAt)
+		require.True(t, strings.HasPrefix(secret, Prefix))
+
+		authenticated, err := store.Authenticate(ctx, secret)
+		require.NoError(t, err)
+		require.Equal(t, token.ID, authenticated.ID)
+
+		tokens, err := store.List(ctx)
+		require.NoError(t, err)
+		require.Len(t, tokens, 1)
+		require.NotNil(t, tokens[0].LastUsedAt)
+
+		identity, err := store.AuthenticateToken(ctx, secret)
+		require.NoError(t, err)
+		require.Equal(t, &uiauth.Identity{Name: "token:runbook", Roles: []uiauth.Role{uiauth.RoleOperator}}, identity)
+	})
+
+	t.Run("UnknownToken", func(t *testing.T) {
+		t.Parallel()
+
+		store := setup(t)
+
+		_, err := store.Authenticate(ctx, Prefix+"unknown")
+		require.ErrorIs(t, err, ErrNotFound)
+
+		_, err = store.Authenticate(ctx, "no-prefix")
+		require.ErrorIs(t, err, ErrNotFound)
+
+		identity, err := store.AuthenticateToken(ctx, Prefix+"unknown")
+		require.NoError(t, err)
+		require.Nil(t, identity)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		t.Parallel()
+
+		store := setup(t)
+
+		_, secret, err := store.Create(ctx, &CreateParams{ExpiresAt: ptrutil.Ptr(time.Now().Add(-time.Minute)), Name: "expired", Role: uiauth.RoleViewer})
+		require.NoError(t, err)
+
+		_, err = store.Authenticate(ctx, secret)
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		t.Parallel()
+
+		store := setup(t)
+
+		token, secret, err := store.Create(ctx, &CreateParams{Name: "revoked", Role: uiauth.RoleViewer})
+		require.NoError(t, err)
+
+		revoked, err := store.Revoke(ctx, token.ID)
+		require.NoError(t, err)
+		require.NotNil(t, revoked.RevokedAt)
+
+		_, err = store.Authenticate(ctx, secret)
+		require.ErrorIs(t, err, ErrNotFound)
+
+		_, err = store.Revoke(ctx, token.ID)
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("CreateValidatesParams", func(t *testing.T) {
+		t.Parallel()
+
+		store := setup(t)
+
+		_, _, err := store.Create(ctx, &CreateParams{Role: uiauth.RoleViewer})
+		require.EqualError(t, err, "token name is required")
+
+		_, _, err = store.Create(ctx, &CreateParams{Name: "runbook", Role: "superuser"})
+		require.EqualError(t, err, `unknown role "superuser"; must be one of: viewer, operator, admin`)
+	})
+}
+
+func TestGenerateSecret(t *testing.T) {
+	t.Parallel()
+
+	secret1, err := generateSecret()
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(secret1, Prefix))
+
+	secret2, err := generateSecret()
+	require.NoError(t, err)
+	require.NotEqual(t, secret1, secret2)
+
+	require.Len(t, hashSecret(secret1), 64)
+	require.Equal(t, hashSecret(secret1), hashSecret(secret1))
+	require.NotEqual(t, hashSecret(secret1), hashSecret(secret2))
+}
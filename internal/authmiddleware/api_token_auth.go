@@ -0,0 +1,71 @@
+package authmiddleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"riverqueue.com/riverui/uiauth"
+)
+
+// TokenAuthenticator authenticates bearer tokens. AuthenticateToken returns nil
+// and no error for a token that isn't valid, and an error only if the token
+// couldn't be checked.
+type TokenAuthenticator interface {
+	AuthenticateToken(ctx context.Context, token string) (*uiauth.Identity, error)
+}
+
+// APITokenAuth authenticates requests carrying an `Authorization: Bearer`
+// header. Requests without one are passed through untouched so that they may
+// be authenticated by middleware further down the stack like BasicAuth, which
+// accepts requests that were already authenticated by a token.
+//
+// When Required is set, requests without a bearer token are rejected instead,
+// which is necessary when tokens are the only authentication configured and
+// there's no other middleware to reject them.
+type APITokenAuth struct {
+	Authenticator TokenAuthenticator
+	Logger        *slog.Logger
+	Required      bool
+}
+
+func (m *APITokenAuth) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		token, ok := bearerToken(req)
+		if !ok {
+			if m.Required && !isHealthCheck(req) {
+				res.Header().Set("WWW-Authenticate", "Bearer realm=\"riverui\"")
+				http.Error(res, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(res, req)
+			return
+		}
+
+		identity, err := m.Authenticator.AuthenticateToken(req.Context(), token)
+		if err != nil {
+			m.Logger.ErrorContext(req.Context(), "Error authenticating API token", slog.String("error", err.Error()))
+			http.Error(res, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		if identity != nil {
+			req = req.WithContext(uiauth.WithIdentity(req.Context(), identity))
+		} else if !isHealthCheck(req) {
+			res.Header().Set("WWW-Authenticate", "Bearer realm=\"riverui\"")
+			http.Error(res, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(res, req)
+	})
+}
+
+func bearerToken(req *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(req.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
@@ -0,0 +1,135 @@
+package authmiddleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/riverqueue/river/rivershared/riversharedtest"
+
+	"riverqueue.com/riverui/uiauth"
+)
+
+type staticTokenAuthenticator struct {
+	err    error
+	tokens map[string]*uiauth.Identity
+}
+
+func (a *staticTokenAuthenticator) AuthenticateToken(ctx context.Context, token string) (*uiauth.Identity, error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+	return a.tokens[token], nil
+}
+
+func TestAPITokenAuth(t *testing.T) {
+	t.Parallel()
+
+	scriptIdentity := &uiauth.Identity{Name: "token:runbook", Roles: []uiauth.Role{uiauth.RoleOperator}}
+
+	setup := func(t *testing.T, authenticator *staticTokenAuthenticator, next http.Handler) http.Handler {
+		t.Helper()
+
+		if authenticator == nil {
+			authenticator = &staticTokenAuthenticator{tokens: map[string]*uiauth.Identity{"riverui_valid": scriptIdentity}}
+		}
+		auth := &APITokenAuth{Authenticator: authenticator, Logger: riversharedtest.Logger(t)}
+		return auth.Middleware(next)
+	}
+
+	serve := func(t *testing.T, path, authorization string) (*httptest.ResponseRecorder, *uiauth.Identity) {
+		t.Helper()
+
+		var identity *uiauth.Identity
+		handler := setup(t, nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity = uiauth.IdentityFromContext(r.Context())
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, path, nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder, identity
+	}
+
+	t.Run("ValidToken", func(t *testing.T) {
+		t.Parallel()
+
+		recorder, identity := serve(t, "/api/jobs", "Bearer riverui_valid")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, scriptIdentity, identity)
+	})
+
+	t.Run("SchemeCaseInsensitive", func(t *testing.T) {
+		t.Parallel()
+
+		recorder, identity := serve(t, "/api/jobs", "bearer riverui_valid")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, scriptIdentity, identity)
+	})
+
+	t.Run("InvalidToken", func(t *testing.T) {
+		t.Parallel()
+
+		recorder, identity := serve(t, "/api/jobs", "Bearer riverui_invalid")
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+		require.Equal(t, `Bearer realm="riverui"`, recorder.Header().Get("WWW-Authenticate"))
+		require.Nil(t, identity)
+	})
+
+	t.Run("InvalidTokenOnHealthCheck", func(t *testing.T) {
+		t.Parallel()
+
+		recorder, identity := serve(t, "/api/health-checks/minimal", "Bearer riverui_invalid")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Nil(t, identity)
+	})
+
+	t.Run("NoBearerToken", func(t *testing.T) {
+		t.Parallel()
+
+		recorder, identity := serve(t, "/api/jobs", "Basic dXNlcjpwYXNz")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Nil(t, identity)
+	})
+
+	t.Run("AuthenticatorError", func(t *testing.T) {
+		t.Parallel()
+
+		handler := setup(t, &staticTokenAuthenticator{err: errors.New("database unavailable")}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/api/jobs", nil)
+		req.Header.Set("Authorization", "Bearer riverui_valid")
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		require.Equal(t, http.StatusInternalServerError, recorder.Code)
+	})
+
+	t.Run("AcceptedByBasicAuth", func(t *testing.T) {
+		t.Parallel()
+
+		basicAuth := &BasicAuth{Username: "user", Password: "pass"}
+		handler := setup(t, nil, basicAuth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, scriptIdentity, uiauth.IdentityFromContext(r.Context()))
+			w.WriteHeader(http.StatusOK)
+		})))
+
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/api/jobs", nil)
+		req.Header.Set("Authorization", "Bearer riverui_valid")
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		require.Equal(t, http.StatusOK, recorder.Code)
+	})
+}
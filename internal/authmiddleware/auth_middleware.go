@@ -4,15 +4,40 @@ import (
 	"crypto/subtle"
 	"net/http"
 	"strings"
+
+	"riverqueue.com/riverui/uiauth"
 )
 
 type BasicAuth struct {
 	Username string
 	Password string
+
+	// Role is the role granted to the authenticated user. Defaults to
+	// uiauth.RoleAdmin.
+	Role uiauth.Role
 }
 
 func (m *BasicAuth) Middleware(next http.Handler) http.Handler {
+	role := m.Role
+	if role == "" {
+		role = uiauth.RoleAdmin
+	}
+
 	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		// Already authenticated by middleware earlier in the stack, like
+		// APITokenAuth.
+		if uiauth.IdentityFromContext(req.Context()) != nil {
+			next.ServeHTTP(res, req)
+			return
+		}
+
+		if isValidAuth(req, m.Username, m.Password) {
+			req = req.WithContext(uiauth.WithIdentity(req.Context(), &uiauth.Identity{
+				Name:  m.Username,
+				Roles: []uiauth.Role{role},
+			}))
+		}
+
 		if isReqAuthorized(req, m.Username, m.Password) {
 			next.ServeHTTP(res, req)
 			return
@@ -23,13 +48,18 @@ func (m *BasicAuth) Middleware(next http.Handler) http.Handler {
 	})
 }
 
+func isHealthCheck(req *http.Request) bool {
+	return strings.Contains(req.URL.Path, "/api/health-checks/")
+}
+
 func isReqAuthorized(req *http.Request, username, password string) bool {
+	return isHealthCheck(req) || isValidAuth(req, username, password)
+}
+
+func isValidAuth(req *http.Request, username, password string) bool {
 	reqUsername, reqPassword, ok := req.BasicAuth()
 
-	isHealthCheck := strings.Contains(req.URL.Path, "/api/health-checks/")
-	isValidAuth := ok &&
+	return ok &&
 		subtle.ConstantTimeCompare([]byte(reqUsername), []byte(username)) == 1 &&
 		subtle.ConstantTimeCompare([]byte(reqPassword), []byte(password)) == 1
-
-	return isHealthCheck || isValidAuth
 }
@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"riverqueue.com/riverui/uiauth"
 )
 
 func TestBasicAuth_Middleware(t *testing.T) {
@@ -122,3 +124,56 @@ func Test_isReqAuthorized(t *testing.T) {
 		})
 	}
 }
+
+func TestBasicAuth_Identity(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T, auth *BasicAuth) (http.Handler, **uiauth.Identity) {
+		t.Helper()
+
+		var identity *uiauth.Identity
+		handler := auth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity = uiauth.IdentityFromContext(r.Context())
+			w.WriteHeader(http.StatusOK)
+		}))
+		return handler, &identity
+	}
+
+	t.Run("DefaultsToAdmin", func(t *testing.T) {
+		t.Parallel()
+
+		handler, identity := setup(t, &BasicAuth{Username: "user", Password: "pass"})
+
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/", nil)
+		req.SetBasicAuth("user", "pass")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		require.Equal(t, &uiauth.Identity{Name: "user", Roles: []uiauth.Role{uiauth.RoleAdmin}}, *identity)
+	})
+
+	t.Run("ConfiguredRole", func(t *testing.T) {
+		t.Parallel()
+
+		handler, identity := setup(t, &BasicAuth{Username: "user", Password: "pass", Role: uiauth.RoleViewer})
+
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/", nil)
+		req.SetBasicAuth("user", "pass")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		require.Equal(t, &uiauth.Identity{Name: "user", Roles: []uiauth.Role{uiauth.RoleViewer}}, *identity)
+	})
+
+	t.Run("HealthCheckWithBadCredentialsHasNoIdentity", func(t *testing.T) {
+		t.Parallel()
+
+		handler, identity := setup(t, &BasicAuth{Username: "user", Password: "pass"})
+
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/api/health-checks/minimal", nil)
+		req.SetBasicAuth("user", "wrong")
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Nil(t, *identity)
+	})
+}
@@ -0,0 +1,54 @@
+package authmiddleware
+
+import (
+	"cmp"
+	"crypto/x509"
+	"net/http"
+
+	"riverqueue.com/riverui/uiauth"
+)
+
+// ClientCertAuth authenticates requests by the TLS client certificate they
+// were made with. The server's TLS config must verify client certificates
+// against a trusted CA (e.g. with tls.VerifyClientCertIfGiven) because only
+// verified certificates are accepted. Requests without one are rejected,
+// except for health checks so that probes don't need a certificate.
+type ClientCertAuth struct {
+	// Role is the role granted to authenticated clients. Defaults to
+	// uiauth.RoleAdmin.
+	Role uiauth.Role
+}
+
+func (m *ClientCertAuth) Middleware(next http.Handler) http.Handler {
+	role := cmp.Or(m.Role, uiauth.RoleAdmin)
+
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		// Already authenticated by middleware earlier in the stack, like
+		// APITokenAuth.
+		if uiauth.IdentityFromContext(req.Context()) != nil || isHealthCheck(req) {
+			next.ServeHTTP(res, req)
+			return
+		}
+
+		if req.TLS == nil || len(req.TLS.VerifiedChains) < 1 || len(req.TLS.VerifiedChains[0]) < 1 {
+			http.Error(res, "Unauthorized: a verified client certificate is required", http.StatusUnauthorized)
+			return
+		}
+
+		identity := ClientCertIdentity(req.TLS.VerifiedChains[0][0])
+		identity.Roles = []uiauth.Role{role}
+
+		next.ServeHTTP(res, req.WithContext(uiauth.WithIdentity(req.Context(), identity)))
+	})
+}
+
+// ClientCertIdentity returns an identity for a client certificate. Its name is
+// the certificate subject's common name, or the full subject if there's no
+// common name, and its groups are the subject's organizational units so that
+// they can be matched by authorization policy rules.
+func ClientCertIdentity(cert *x509.Certificate) *uiauth.Identity {
+	return &uiauth.Identity{
+		Groups: cert.Subject.OrganizationalUnit,
+		Name:   cmp.Or(cert.Subject.CommonName, cert.Subject.String()),
+	}
+}
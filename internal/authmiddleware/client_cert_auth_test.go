@@ -0,0 +1,100 @@
+package authmiddleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"riverqueue.com/riverui/uiauth"
+)
+
+func TestClientCertAuth(t *testing.T) {
+	t.Parallel()
+
+	serve := func(t *testing.T, auth *ClientCertAuth, path string, connState *tls.ConnectionState, identity *uiauth.Identity) (*httptest.ResponseRecorder, *uiauth.Identity) {
+		t.Helper()
+
+		var identityInHandler *uiauth.Identity
+		handler := auth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identityInHandler = uiauth.IdentityFromContext(r.Context())
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		ctx := t.Context()
+		if identity != nil {
+			ctx = uiauth.WithIdentity(ctx, identity)
+		}
+
+		req := httptest.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+		req.TLS = connState
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder, identityInHandler
+	}
+
+	verifiedState := func(subject pkix.Name) *tls.ConnectionState {
+		return &tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{{Subject: subject}}},
+		}
+	}
+
+	t.Run("VerifiedCertificate", func(t *testing.T) {
+		t.Parallel()
+
+		recorder, identity := serve(t, &ClientCertAuth{Role: uiauth.RoleOperator}, "/api/jobs", verifiedState(pkix.Name{
+			CommonName:         "deploy-bot",
+			OrganizationalUnit: []string{"platform", "oncall"},
+		}), nil)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, &uiauth.Identity{
+			Groups: []string{"platform", "oncall"},
+			Name:   "deploy-bot",
+			Roles:  []uiauth.Role{uiauth.RoleOperator},
+		}, identity)
+	})
+
+	t.Run("SubjectWithoutCommonName", func(t *testing.T) {
+		t.Parallel()
+
+		recorder, identity := serve(t, &ClientCertAuth{}, "/api/jobs", verifiedState(pkix.Name{
+			Organization: []string{"Example"},
+		}), nil)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, "O=Example", identity.Name)
+		require.Equal(t, []uiauth.Role{uiauth.RoleAdmin}, identity.Roles)
+	})
+
+	t.Run("NoCertificate", func(t *testing.T) {
+		t.Parallel()
+
+		recorder, _ := serve(t, &ClientCertAuth{}, "/api/jobs", &tls.ConnectionState{}, nil)
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+		recorder, _ = serve(t, &ClientCertAuth{}, "/api/jobs", nil, nil)
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	})
+
+	t.Run("HealthCheckWithoutCertificate", func(t *testing.T) {
+		t.Parallel()
+
+		recorder, identity := serve(t, &ClientCertAuth{}, "/api/health-checks/minimal", nil, nil)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Nil(t, identity)
+	})
+
+	t.Run("AlreadyAuthenticated", func(t *testing.T) {
+		t.Parallel()
+
+		tokenIdentity := &uiauth.Identity{Name: "token:ci", Roles: []uiauth.Role{uiauth.RoleViewer}}
+
+		recorder, identity := serve(t, &ClientCertAuth{}, "/api/jobs", nil, tokenIdentity)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, tokenIdentity, identity)
+	})
+}
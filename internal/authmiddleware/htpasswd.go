@@ -0,0 +1,275 @@
+package authmiddleware
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"riverqueue.com/riverui/uiauth"
+)
+
+const defaultHtpasswdPollInterval = 2 * time.Second
+
+// htpasswdDummyHash is compared against when a username isn't found so that
+// unknown users take as long to reject as known ones, which would otherwise
+// reveal which usernames exist. Its cost matches bcrypt.DefaultCost, which is
+// also what `htpasswd -B` uses by default.
+var htpasswdDummyHash = []byte("$2a$10$4MjOCoJW6cOTMOZpUB10R.9vX3tKzrjqGP8VgfCLmpaPDgZqqux9u") //nolint:gochecknoglobals
+
+// HtpasswdFileOpts are options for LoadHtpasswdFile.
+type HtpasswdFileOpts struct {
+	// DefaultRole is the role granted to users without a role annotation.
+	// Defaults to uiauth.RoleAdmin.
+	DefaultRole uiauth.Role
+
+	// Logger is used to log errors reloading the file.
+	Logger *slog.Logger
+
+	// PollInterval is how often Watch checks the file for changes. Defaults
+	// to two seconds.
+	PollInterval time.Duration
+}
+
+// HtpasswdFile is a set of users loaded from an htpasswd-style file. Each
+// line contains a username and a bcrypt password hash like those generated by
+// `htpasswd -B`, optionally followed by a role:
+//
+//	alice:$2y$10$...:admin
+//	bob:$2y$10$...:viewer
+//
+// Blank lines and lines starting with `#` are ignored.
+type HtpasswdFile struct {
+	defaultRole  uiauth.Role
+	logger       *slog.Logger
+	path         string
+	pollInterval time.Duration
+
+	mu      sync.RWMutex
+	modTime time.Time
+	size    int64
+	users   map[string]*htpasswdUser
+}
+
+type htpasswdUser struct {
+	hash []byte
+	role uiauth.Role
+
+	// SHA-256 of the password that last matched hash. Checking bcrypt hashes
+	// is deliberately slow, so this avoids doing so on every request made by
+	// a logged in user. Reset when the file is reloaded.
+	verifiedMu       sync.Mutex
+	verifiedPassword []byte
+}
+
+// LoadHtpasswdFile loads users from the htpasswd-style file at path.
+func LoadHtpasswdFile(path string, opts *HtpasswdFileOpts) (*HtpasswdFile, error) {
+	if opts == nil {
+		opts = &HtpasswdFileOpts{}
+	}
+
+	file := &HtpasswdFile{
+		defaultRole:  cmp.Or(opts.DefaultRole, uiauth.RoleAdmin),
+		logger:       cmp.Or(opts.Logger, slog.Default()),
+		path:         path,
+		pollInterval: cmp.Or(opts.PollInterval, defaultHtpasswdPollInterval),
+	}
+	if err := file.Reload(); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// Reload reloads users from the file. If the file can't be read or parsed,
+// an error is returned and the previously loaded users are kept.
+func (f *HtpasswdFile) Reload() error {
+	stat, err := os.Stat(f.path)
+	if err != nil {
+		return fmt.Errorf("error reading htpasswd file: %w", err)
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return fmt.Errorf("error reading htpasswd file: %w", err)
+	}
+
+	users, err := parseHtpasswd(data, f.defaultRole)
+	if err != nil {
+		return fmt.Errorf("error parsing htpasswd file %q: %w", f.path, err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.modTime = stat.ModTime()
+	f.size = stat.Size()
+	f.users = users
+	return nil
+}
+
+// Watch reloads the file whenever it changes on disk or a value is received
+// on reloadSignal (e.g. SIGHUP), until ctx is done. Errors reloading are
+// logged and the previously loaded users are kept.
+func (f *HtpasswdFile) Watch(ctx context.Context, reloadSignal <-chan os.Signal) {
+	ticker := time.NewTicker(f.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-reloadSignal:
+			f.reloadAndLog(ctx)
+
+		case <-ticker.C:
+			stat, err := os.Stat(f.path)
+			if err != nil {
+				f.logger.ErrorContext(ctx, "Error checking htpasswd file for changes", slog.String("error", err.Error()))
+				continue
+			}
+
+			f.mu.RLock()
+			changed := !stat.ModTime().Equal(f.modTime) || stat.Size() != f.size
+			f.mu.RUnlock()
+
+			if changed {
+				f.reloadAndLog(ctx)
+			}
+		}
+	}
+}
+
+func (f *HtpasswdFile) reloadAndLog(ctx context.Context) {
+	if err := f.Reload(); err != nil {
+		f.logger.ErrorContext(ctx, "Error reloading htpasswd file; keeping previously loaded users", slog.String("error", err.Error()))
+		return
+	}
+	f.logger.InfoContext(ctx, "Reloaded htpasswd file", slog.String("path", f.path))
+}
+
+// Authenticate returns an identity for the given credentials, or nil if
+// they're invalid.
+func (f *HtpasswdFile) Authenticate(username, password string) *uiauth.Identity {
+	f.mu.RLock()
+	user := f.users[username]
+	f.mu.RUnlock()
+
+	if user == nil {
+		_ = bcrypt.CompareHashAndPassword(htpasswdDummyHash, []byte(password))
+		return nil
+	}
+
+	passwordHash := sha256.Sum256([]byte(password))
+
+	// Only hold the lock while reading and writing the verified password so
+	// that concurrent requests for the same user don't queue behind a slow
+	// bcrypt comparison.
+	user.verifiedMu.Lock()
+	verified := user.verifiedPassword != nil && subtle.ConstantTimeCompare(user.verifiedPassword, passwordHash[:]) == 1
+	user.verifiedMu.Unlock()
+
+	if !verified {
+		if err := bcrypt.CompareHashAndPassword(user.hash, []byte(password)); err != nil {
+			return nil
+		}
+
+		user.verifiedMu.Lock()
+		user.verifiedPassword = passwordHash[:]
+		user.verifiedMu.Unlock()
+	}
+
+	return &uiauth.Identity{
+		Name:  username,
+		Roles: []uiauth.Role{user.role},
+	}
+}
+
+func parseHtpasswd(data []byte, defaultRole uiauth.Role) (map[string]*htpasswdUser, error) {
+	users := make(map[string]*htpasswdUser)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.Split(line, ":")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == "" {
+			return nil, fmt.Errorf("line %d: expected `username:hash` or `username:hash:role`", lineNum)
+		}
+
+		username, hash := parts[0], parts[1]
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			return nil, fmt.Errorf("line %d: password hash for %q isn't bcrypt (generate one with `htpasswd -B`)", lineNum, username)
+		}
+
+		role := defaultRole
+		if len(parts) == 3 {
+			var err error
+			if role, err = uiauth.ParseRole(parts[2]); err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+		}
+
+		if _, ok := users[username]; ok {
+			return nil, fmt.Errorf("line %d: duplicate user %q", lineNum, username)
+		}
+
+		users[username] = &htpasswdUser{hash: []byte(hash), role: role}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(users) < 1 {
+		return nil, errors.New("no users found")
+	}
+
+	return users, nil
+}
+
+// HtpasswdAuth authenticates requests with HTTP basic auth against users
+// loaded from an htpasswd-style file.
+type HtpasswdAuth struct {
+	File *HtpasswdFile
+}
+
+func (m *HtpasswdAuth) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		// Already authenticated by middleware earlier in the stack, like
+		// APITokenAuth.
+		if uiauth.IdentityFromContext(req.Context()) != nil {
+			next.ServeHTTP(res, req)
+			return
+		}
+
+		if username, password, ok := req.BasicAuth(); ok {
+			if identity := m.File.Authenticate(username, password); identity != nil {
+				next.ServeHTTP(res, req.WithContext(uiauth.WithIdentity(req.Context(), identity)))
+				return
+			}
+		}
+
+		if isHealthCheck(req) {
+			next.ServeHTTP(res, req)
+			return
+		}
+
+		res.Header().Set("WWW-Authenticate", "Basic realm=\"riverui\"")
+		http.Error(res, "Unauthorized", http.StatusUnauthorized)
+	})
+}
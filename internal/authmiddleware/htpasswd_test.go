@@ -0,0 +1,239 @@
+package authmiddleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/riverqueue/river/rivershared/riversharedtest"
+
+	"riverqueue.com/riverui/uiauth"
+)
+
+func testBcryptHash(t *testing.T, password string) string {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+	return string(hash)
+}
+
+func writeHtpasswdFile(t *testing.T, path, contents string) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+}
+
+func TestHtpasswdFile(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T, contents string) (*HtpasswdFile, string) {
+		t.Helper()
+
+		path := filepath.Join(t.TempDir(), "htpasswd")
+		writeHtpasswdFile(t, path, contents)
+
+		file, err := LoadHtpasswdFile(path, &HtpasswdFileOpts{
+			Logger:       riversharedtest.Logger(t),
+			PollInterval: 10 * time.Millisecond,
+		})
+		require.NoError(t, err)
+		return file, path
+	}
+
+	t.Run("Authenticate", func(t *testing.T) {
+		t.Parallel()
+
+		file, _ := setup(t, "# team credentials\n\nalice:"+testBcryptHash(t, "alice-pass")+"\nbob:"+testBcryptHash(t, "bob-pass")+":viewer\n")
+
+		require.Equal(t, &uiauth.Identity{Name: "alice", Roles: []uiauth.Role{uiauth.RoleAdmin}}, file.Authenticate("alice", "alice-pass"))
+		require.Equal(t, &uiauth.Identity{Name: "bob", Roles: []uiauth.Role{uiauth.RoleViewer}}, file.Authenticate("bob", "bob-pass"))
+
+		// Again to exercise the cache of verified passwords.
+		require.NotNil(t, file.Authenticate("alice", "alice-pass"))
+
+		require.Nil(t, file.Authenticate("alice", "bob-pass"))
+		require.Nil(t, file.Authenticate("carol", "alice-pass"))
+	})
+
+	t.Run("AuthenticateConcurrently", func(t *testing.T) {
+		t.Parallel()
+
+		file, _ := setup(t, "alice:"+testBcryptHash(t, "alice-pass")+"\n")
+
+		var (
+			identities = make([]*uiauth.Identity, 10)
+			wg         sync.WaitGroup
+		)
+		for i := range identities {
+			password := "alice-pass"
+			if i%2 == 1 {
+				password = "wrong-pass"
+			}
+			wg.Go(func() { identities[i] = file.Authenticate("alice", password) })
+		}
+		wg.Wait()
+
+		for i, identity := range identities {
+			if i%2 == 1 {
+				require.Nil(t, identity)
+			} else {
+				require.NotNil(t, identity)
+			}
+		}
+	})
+
+	t.Run("DummyHash", func(t *testing.T) {
+		t.Parallel()
+
+		// Unknown users are compared against the dummy hash, so it must be a
+		// valid bcrypt hash at the same cost as typical htpasswd files.
+		cost, err := bcrypt.Cost(htpasswdDummyHash)
+		require.NoError(t, err)
+		require.Equal(t, bcrypt.DefaultCost, cost)
+	})
+
+	t.Run("DefaultRole", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "htpasswd")
+		writeHtpasswdFile(t, path, "alice:"+testBcryptHash(t, "alice-pass")+"\n")
+
+		file, err := LoadHtpasswdFile(path, &HtpasswdFileOpts{DefaultRole: uiauth.RoleOperator})
+		require.NoError(t, err)
+		require.Equal(t, []uiauth.Role{uiauth.RoleOperator}, file.Authenticate("alice", "alice-pass").Roles)
+	})
+
+	t.Run("ParseErrors", func(t *testing.T) {
+		t.Parallel()
+
+		hash := testBcryptHash(t, "pass")
+
+		tests := []struct {
+			name     string
+			contents string
+			wantErr  string
+		}{
+			{"Empty", "# nobody\n", "no users found"},
+			{"MissingHash", "alice\n", "line 1: expected `username:hash` or `username:hash:role`"},
+			{"NotBcrypt", "alice:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=\n", "line 1: password hash for \"alice\" isn't bcrypt (generate one with `htpasswd -B`)"},
+			{"UnknownRole", "alice:" + hash + ":superuser\n", `line 1: unknown role "superuser"; must be one of: viewer, operator, admin`},
+			{"Duplicate", "alice:" + hash + "\nalice:" + hash + "\n", `line 2: duplicate user "alice"`},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				path := filepath.Join(t.TempDir(), "htpasswd")
+				writeHtpasswdFile(t, path, tt.contents)
+
+				_, err := LoadHtpasswdFile(path, nil)
+				require.ErrorContains(t, err, tt.wantErr)
+			})
+		}
+	})
+
+	t.Run("WatchReloadsOnChange", func(t *testing.T) {
+		t.Parallel()
+
+		file, path := setup(t, "alice:"+testBcryptHash(t, "old-pass")+"\n")
+
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+		go file.Watch(ctx, nil)
+
+		writeHtpasswdFile(t, path, "alice:"+testBcryptHash(t, "new-pass")+":viewer\n")
+
+		require.Eventually(t, func() bool { return file.Authenticate("alice", "new-pass") != nil }, 5*time.Second, 10*time.Millisecond)
+		require.Nil(t, file.Authenticate("alice", "old-pass"))
+	})
+
+	t.Run("WatchReloadsOnSignal", func(t *testing.T) {
+		t.Parallel()
+
+		file, path := setup(t, "alice:"+testBcryptHash(t, "old-pass")+"\n")
+		file.pollInterval = time.Hour
+
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+		reloadSignal := make(chan os.Signal, 1)
+		go file.Watch(ctx, reloadSignal)
+
+		writeHtpasswdFile(t, path, "alice:"+testBcryptHash(t, "new-pass")+"\n")
+		reloadSignal <- syscall.SIGHUP
+
+		require.Eventually(t, func() bool { return file.Authenticate("alice", "new-pass") != nil }, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("InvalidReloadKeepsUsers", func(t *testing.T) {
+		t.Parallel()
+
+		file, path := setup(t, "alice:"+testBcryptHash(t, "alice-pass")+"\n")
+
+		writeHtpasswdFile(t, path, "alice\n")
+		require.Error(t, file.Reload())
+		require.NotNil(t, file.Authenticate("alice", "alice-pass"))
+	})
+}
+
+func TestHtpasswdAuth(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "htpasswd")
+	writeHtpasswdFile(t, path, "alice:"+testBcryptHash(t, "alice-pass")+":operator\n")
+
+	file, err := LoadHtpasswdFile(path, nil)
+	require.NoError(t, err)
+
+	serve := func(t *testing.T, path string, setupRequest func(req *http.Request)) (*httptest.ResponseRecorder, *uiauth.Identity) {
+		t.Helper()
+
+		var identity *uiauth.Identity
+		handler := (&HtpasswdAuth{File: file}).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity = uiauth.IdentityFromContext(r.Context())
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, path, nil)
+		setupRequest(req)
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder, identity
+	}
+
+	t.Run("Authorized", func(t *testing.T) {
+		t.Parallel()
+
+		recorder, identity := serve(t, "/api/jobs", func(req *http.Request) { req.SetBasicAuth("alice", "alice-pass") })
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, &uiauth.Identity{Name: "alice", Roles: []uiauth.Role{uiauth.RoleOperator}}, identity)
+	})
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		t.Parallel()
+
+		recorder, identity := serve(t, "/api/jobs", func(req *http.Request) { req.SetBasicAuth("alice", "wrong") })
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+		require.Equal(t, `Basic realm="riverui"`, recorder.Header().Get("WWW-Authenticate"))
+		require.Nil(t, identity)
+	})
+
+	t.Run("HealthCheck", func(t *testing.T) {
+		t.Parallel()
+
+		recorder, identity := serve(t, "/api/health-checks/minimal", func(req *http.Request) {})
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Nil(t, identity)
+	})
+}
@@ -0,0 +1,121 @@
+package authmiddleware
+
+import (
+	"cmp"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"riverqueue.com/riverui/uiauth"
+)
+
+const (
+	DefaultProxyAuthEmailHeader = "X-Forwarded-Email"
+	DefaultProxyAuthUserHeader  = "X-Forwarded-User"
+)
+
+// ProxyAuth trusts the identity asserted in headers set by an authenticating
+// reverse proxy like oauth2-proxy. Headers are only trusted on requests whose
+// remote address is in TrustedProxies, or that arrive on a Unix socket when
+// TrustUnixSocket is set. Requests from anywhere else, or that don't carry a
+// user or email header, are rejected.
+type ProxyAuth struct {
+	// EmailHeader is the header containing the user's email address, used as
+	// the identity's name when the user header is absent. Defaults to
+	// DefaultProxyAuthEmailHeader.
+	EmailHeader string
+
+	// GroupsHeader is an optional header containing a comma-separated list of
+	// groups the user belongs to, like `X-Forwarded-Groups`. Groups are matched
+	// against the subjects of authorization policy rules.
+	GroupsHeader string
+
+	// Role is the role granted to authenticated users. Defaults to
+	// uiauth.RoleAdmin.
+	Role uiauth.Role
+
+	// TrustedProxies are the networks that requests must come from for their
+	// identity headers to be trusted. Unless TrustUnixSocket is set, all
+	// requests are rejected if empty.
+	TrustedProxies []netip.Prefix
+
+	// TrustUnixSocket trusts the identity headers of requests on connections
+	// accepted on a Unix socket. Their peers don't have a network address to
+	// check, so who can connect must be restricted by the socket's file mode
+	// instead.
+	TrustUnixSocket bool
+
+	// UserHeader is the header containing the user's name. Defaults to
+	// DefaultProxyAuthUserHeader.
+	UserHeader string
+}
+
+func (m *ProxyAuth) Middleware(next http.Handler) http.Handler {
+	var (
+		emailHeader = cmp.Or(m.EmailHeader, DefaultProxyAuthEmailHeader)
+		role        = cmp.Or(m.Role, uiauth.RoleAdmin)
+		userHeader  = cmp.Or(m.UserHeader, DefaultProxyAuthUserHeader)
+	)
+
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		// Already authenticated by middleware earlier in the stack, like
+		// APITokenAuth.
+		if uiauth.IdentityFromContext(req.Context()) != nil || isHealthCheck(req) {
+			next.ServeHTTP(res, req)
+			return
+		}
+
+		if !m.isTrustedProxy(req) {
+			http.Error(res, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		name := cmp.Or(strings.TrimSpace(req.Header.Get(userHeader)), strings.TrimSpace(req.Header.Get(emailHeader)))
+		if name == "" {
+			http.Error(res, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		identity := &uiauth.Identity{
+			Name:  name,
+			Roles: []uiauth.Role{role},
+		}
+		if m.GroupsHeader != "" {
+			identity.Groups = parseGroups(req.Header.Get(m.GroupsHeader))
+		}
+
+		next.ServeHTTP(res, req.WithContext(uiauth.WithIdentity(req.Context(), identity)))
+	})
+}
+
+// Checks the request's remote address, which is that of the immediate peer
+// rather than anything asserted in forwarding headers.
+func (m *ProxyAuth) isTrustedProxy(req *http.Request) bool {
+	// Peers on a Unix socket have no address, so the socket is identified by
+	// the local address of the connection that the server accepted instead.
+	if localAddr, ok := req.Context().Value(http.LocalAddrContextKey).(net.Addr); ok && localAddr.Network() == "unix" {
+		return m.TrustUnixSocket
+	}
+
+	addrPort, err := netip.ParseAddrPort(req.RemoteAddr)
+	if err != nil {
+		return false
+	}
+
+	addr := addrPort.Addr().Unmap()
+	return slices.ContainsFunc(m.TrustedProxies, func(prefix netip.Prefix) bool {
+		return prefix.Contains(addr)
+	})
+}
+
+func parseGroups(header string) []string {
+	var groups []string
+	for group := range strings.SplitSeq(header, ",") {
+		if group = strings.TrimSpace(group); group != "" {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
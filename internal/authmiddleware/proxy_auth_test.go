@@ -0,0 +1,178 @@
+package authmiddleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"riverqueue.com/riverui/uiauth"
+)
+
+func TestProxyAuth(t *testing.T) {
+	t.Parallel()
+
+	trustedProxies := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("::1/128")}
+
+	serve := func(t *testing.T, auth *ProxyAuth, path, remoteAddr string, headers map[string]string) (*httptest.ResponseRecorder, *uiauth.Identity) {
+		t.Helper()
+
+		var identity *uiauth.Identity
+		handler := auth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity = uiauth.IdentityFromContext(r.Context())
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder, identity
+	}
+
+	t.Run("TrustedUserHeader", func(t *testing.T) {
+		t.Parallel()
+
+		recorder, identity := serve(t, &ProxyAuth{TrustedProxies: trustedProxies}, "/api/jobs", "10.1.2.3:51234", map[string]string{
+			"X-Forwarded-User":  "alice",
+			"X-Forwarded-Email": "alice@example.com",
+		})
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, &uiauth.Identity{Name: "alice", Roles: []uiauth.Role{uiauth.RoleAdmin}}, identity)
+	})
+
+	t.Run("FallsBackToEmailHeader", func(t *testing.T) {
+		t.Parallel()
+
+		recorder, identity := serve(t, &ProxyAuth{TrustedProxies: trustedProxies}, "/api/jobs", "[::1]:51234", map[string]string{
+			"X-Forwarded-Email": "alice@example.com",
+		})
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, "alice@example.com", identity.Name)
+	})
+
+	t.Run("CustomHeadersGroupsAndRole", func(t *testing.T) {
+		t.Parallel()
+
+		auth := &ProxyAuth{
+			GroupsHeader:   "X-Forwarded-Groups",
+			Role:           uiauth.RoleOperator,
+			TrustedProxies: trustedProxies,
+			UserHeader:     "X-Auth-Request-User",
+		}
+		recorder, identity := serve(t, auth, "/api/jobs", "10.1.2.3:51234", map[string]string{
+			"X-Auth-Request-User": "alice",
+			"X-Forwarded-Groups":  "team-billing, team-ops,,",
+		})
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, &uiauth.Identity{Groups: []string{"team-billing", "team-ops"}, Name: "alice", Roles: []uiauth.Role{uiauth.RoleOperator}}, identity)
+	})
+
+	t.Run("IPv4MappedAddress", func(t *testing.T) {
+		t.Parallel()
+
+		recorder, identity := serve(t, &ProxyAuth{TrustedProxies: trustedProxies}, "/api/jobs", "[::ffff:10.1.2.3]:51234", map[string]string{
+			"X-Forwarded-User": "alice",
+		})
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, "alice", identity.Name)
+	})
+
+	t.Run("UntrustedSource", func(t *testing.T) {
+		t.Parallel()
+
+		recorder, identity := serve(t, &ProxyAuth{TrustedProxies: trustedProxies}, "/api/jobs", "192.168.1.1:51234", map[string]string{
+			"X-Forwarded-User": "alice",
+		})
+		require.Equal(t, http.StatusForbidden, recorder.Code)
+		require.Nil(t, identity)
+	})
+
+	t.Run("NoTrustedProxiesRejectsAll", func(t *testing.T) {
+		t.Parallel()
+
+		recorder, _ := serve(t, &ProxyAuth{}, "/api/jobs", "10.1.2.3:51234", map[string]string{
+			"X-Forwarded-User": "alice",
+		})
+		require.Equal(t, http.StatusForbidden, recorder.Code)
+	})
+
+	t.Run("MissingHeader", func(t *testing.T) {
+		t.Parallel()
+
+		recorder, identity := serve(t, &ProxyAuth{TrustedProxies: trustedProxies}, "/api/jobs", "10.1.2.3:51234", nil)
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+		require.Nil(t, identity)
+	})
+
+	t.Run("UnixSocket", func(t *testing.T) {
+		t.Parallel()
+
+		serveUnix := func(t *testing.T, auth *ProxyAuth) (*http.Response, *uiauth.Identity) {
+			t.Helper()
+
+			socketPath := filepath.Join(t.TempDir(), "riverui.sock")
+			listener, err := net.Listen("unix", socketPath)
+			require.NoError(t, err)
+
+			var identity *uiauth.Identity
+			server := &http.Server{ //nolint:gosec
+				Handler: auth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					identity = uiauth.IdentityFromContext(r.Context())
+					w.WriteHeader(http.StatusOK)
+				})),
+			}
+			go func() { _ = server.Serve(listener) }()
+			t.Cleanup(func() { require.NoError(t, server.Shutdown(context.WithoutCancel(t.Context()))) })
+
+			client := &http.Client{Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var dialer net.Dialer
+					return dialer.DialContext(ctx, "unix", socketPath)
+				},
+			}}
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://riverui/api/jobs", nil)
+			require.NoError(t, err)
+			req.Header.Set("X-Forwarded-User", "alice")
+
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			t.Cleanup(func() { resp.Body.Close() })
+			return resp, identity
+		}
+
+		t.Run("Trusted", func(t *testing.T) {
+			t.Parallel()
+
+			resp, identity := serveUnix(t, &ProxyAuth{TrustUnixSocket: true})
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			require.Equal(t, &uiauth.Identity{Name: "alice", Roles: []uiauth.Role{uiauth.RoleAdmin}}, identity)
+		})
+
+		t.Run("NotTrusted", func(t *testing.T) {
+			t.Parallel()
+
+			resp, identity := serveUnix(t, &ProxyAuth{TrustedProxies: trustedProxies})
+			require.Equal(t, http.StatusForbidden, resp.StatusCode)
+			require.Nil(t, identity)
+		})
+	})
+
+	t.Run("HealthCheckFromUntrustedSource", func(t *testing.T) {
+		t.Parallel()
+
+		recorder, identity := serve(t, &ProxyAuth{TrustedProxies: trustedProxies}, "/api/health-checks/minimal", "192.168.1.1:51234", nil)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Nil(t, identity)
+	})
+}
@@ -9,7 +9,6 @@ import (
 	"net/http/httptest"
 	"testing"
 
-	"github.com/jackc/pgx/v5"
 	"github.com/stretchr/testify/require"
 
 	"github.com/riverqueue/river/riverdriver"
@@ -20,7 +19,10 @@ import (
 
 type APICallFunc = func(t *testing.T, testCaseName, method, path string, payload []byte)
 
-func RunIntegrationTest[TClient any](t *testing.T, createClient func(ctx context.Context, tb testing.TB, logger *slog.Logger) (TClient, riverdriver.Driver[pgx.Tx], pgx.Tx), createBundle func(client TClient, tx pgx.Tx) uiendpoints.Bundle, createHandler func(t *testing.T, bundle uiendpoints.Bundle) http.Handler, testRunner func(exec riverdriver.Executor, dbDriver riverdriver.Driver[pgx.Tx], makeAPICall APICallFunc)) {
+// RunIntegrationTest makes API calls against a handler built from a bundle
+// for a test transaction. It's generic over the transaction type so that it
+// works with any River driver, like riverpgxv5 or riverdatabasesql.
+func RunIntegrationTest[TClient, TTx any](t *testing.T, createClient func(ctx context.Context, tb testing.TB, logger *slog.Logger) (TClient, riverdriver.Driver[TTx], TTx), createBundle func(client TClient, tx TTx) uiendpoints.Bundle, createHandler func(t *testing.T, bundle uiendpoints.Bundle) http.Handler, testRunner func(exec riverdriver.Executor, dbDriver riverdriver.Driver[TTx], makeAPICall APICallFunc)) {
 	t.Helper()
 
 	var (
@@ -35,12 +37,13 @@ func RunIntegrationTest[TClient any](t *testing.T, createClient func(ctx context
 
 		t.Run(testCaseName, func(t *testing.T) {
 			// Start a new savepoint so that the state of our test data stays
-			// pristine between API calls.
-			tx, err := tx.Begin(ctx)
+			// pristine between API calls. Going through the driver's executor
+			// instead of the transaction itself works for any driver.
+			execTx, err := exec.Begin(ctx)
 			require.NoError(t, err)
-			t.Cleanup(func() { tx.Rollback(ctx) })
+			t.Cleanup(func() { execTx.Rollback(ctx) })
 
-			bundle := createBundle(client, tx)
+			bundle := createBundle(client, driver.UnwrapTx(execTx))
 			handler := createHandler(t, bundle)
 
 			var body io.Reader
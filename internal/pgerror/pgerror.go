@@ -0,0 +1,58 @@
+// Package pgerror extracts errors from Postgres regardless of the driver they
+// came through so that the API can respond to them with more specific errors.
+package pgerror
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Error is the subset of an error from Postgres that the API
+// inspects to produce more specific API errors.
+type Error struct {
+	Code           string
+	ConstraintName string
+	Message        string
+}
+
+// pqError is implemented by lib/pq's *pq.Error, which is what Postgres errors
+// look like with riverdatabasesql when database/sql is backed by lib/pq. It's
+// matched by method so that lib/pq doesn't need to be a dependency.
+type pqError interface {
+	error
+
+	// Get returns the value of an error field by its protocol code, like 'C'
+	// for the SQLSTATE code.
+	Get(k byte) string
+}
+
+// As extracts a Postgres error from err, regardless of whether it
+// came through pgx (riverpgxv5, or database/sql backed by pgx's stdlib) or
+// lib/pq. Returns false if err isn't from Postgres.
+func As(err error) (*Error, bool) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return &Error{Code: pgErr.Code, ConstraintName: pgErr.ConstraintName, Message: pgErr.Message}, true
+	}
+
+	var pqErr pqError
+	if errors.As(err, &pqErr) {
+		return &Error{Code: pqErr.Get('C'), ConstraintName: pqErr.Get('n'), Message: pqErr.Get('M')}, true
+	}
+
+	return nil, false
+}
+
+// IsStatementTimeout returns true if the error is from a statement cancelled
+// by Postgres because it exceeded statement_timeout. Statements cancelled
+// because their context was cancelled have the same error code, but a
+// different message.
+func IsStatementTimeout(err error) bool {
+	pgErr, ok := As(err)
+	return ok &&
+		pgErr.Code == pgerrcode.QueryCanceled &&
+		strings.Contains(pgErr.Message, "statement timeout")
+}
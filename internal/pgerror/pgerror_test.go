@@ -0,0 +1,80 @@
+package pgerror
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+// testPQError mimics lib/pq's *pq.Error, whose fields are accessed by their
+// protocol codes.
+type testPQError map[byte]string
+
+func (e testPQError) Error() string     { return "pq: " + e['M'] }
+func (e testPQError) Get(k byte) string { return e[k] }
+
+func TestAs(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Pgx", func(t *testing.T) {
+		t.Parallel()
+
+		pgErr, ok := As(fmt.Errorf("wrapped: %w", &pgconn.PgError{
+			Code:           pgerrcode.UniqueViolation,
+			ConstraintName: "river_job_unique_idx",
+			Message:        "duplicate key value violates unique constraint",
+		}))
+		require.True(t, ok)
+		require.Equal(t, &Error{
+			Code:           pgerrcode.UniqueViolation,
+			ConstraintName: "river_job_unique_idx",
+			Message:        "duplicate key value violates unique constraint",
+		}, pgErr)
+	})
+
+	t.Run("PQ", func(t *testing.T) {
+		t.Parallel()
+
+		pgErr, ok := As(fmt.Errorf("wrapped: %w", testPQError{
+			'C': pgerrcode.UniqueViolation,
+			'M': "duplicate key value violates unique constraint",
+			'n': "river_job_unique_idx",
+		}))
+		require.True(t, ok)
+		require.Equal(t, &Error{
+			Code:           pgerrcode.UniqueViolation,
+			ConstraintName: "river_job_unique_idx",
+			Message:        "duplicate key value violates unique constraint",
+		}, pgErr)
+	})
+
+	t.Run("NotPostgres", func(t *testing.T) {
+		t.Parallel()
+
+		_, ok := As(errors.New("connection refused"))
+		require.False(t, ok)
+	})
+}
+
+func TestIsStatementTimeout(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, IsStatementTimeout(&pgconn.PgError{
+		Code:    pgerrcode.QueryCanceled,
+		Message: "canceling statement due to statement timeout",
+	}))
+	require.False(t, IsStatementTimeout(&pgconn.PgError{
+		Code:    pgerrcode.QueryCanceled,
+		Message: "canceling statement due to user request",
+	}))
+	require.False(t, IsStatementTimeout(&pgconn.PgError{Code: pgerrcode.UniqueViolation}))
+	require.True(t, IsStatementTimeout(testPQError{
+		'C': pgerrcode.QueryCanceled,
+		'M': "canceling statement due to statement timeout",
+	}))
+	require.False(t, IsStatementTimeout(errors.New("statement timeout")))
+}
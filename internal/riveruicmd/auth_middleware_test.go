@@ -9,7 +9,6 @@ import (
 	"testing"
 
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/require"
 
 	"github.com/riverqueue/river"
@@ -17,6 +16,8 @@ import (
 	"github.com/riverqueue/river/rivershared/riversharedtest"
 
 	"riverqueue.com/riverui"
+	"riverqueue.com/riverui/internal/apitoken"
+	"riverqueue.com/riverui/uiauth"
 	"riverqueue.com/riverui/uiendpoints"
 )
 
@@ -41,15 +42,15 @@ func TestAuthMiddleware(t *testing.T) { //nolint:tparallel
 				logger:     riversharedtest.Logger(t),
 				pathPrefix: prefix,
 			},
-			func(dbPool *pgxpool.Pool, opts *ClientOpts) (*river.Client[pgx.Tx], error) {
-				return river.NewClient(riverpgxv5.New(dbPool), &river.Config{Schema: opts.Schema})
+			func(opts *ClientOpts) (*river.Client[pgx.Tx], error) {
+				return river.NewClient(riverpgxv5.New(opts.DBPool), &river.Config{Schema: opts.Schema})
 			},
-			func(client *river.Client[pgx.Tx]) uiendpoints.Bundle {
+			func(client *river.Client[pgx.Tx], _ *BundleOpts) uiendpoints.Bundle {
 				return riverui.NewEndpoints(client, nil)
 			},
 		)
 		require.NoError(t, err)
-		t.Cleanup(initRes.dbPool.Close)
+		t.Cleanup(initRes.db.Close)
 
 		return initRes.httpServer.Handler
 	}
@@ -104,3 +105,86 @@ func TestAuthMiddleware(t *testing.T) { //nolint:tparallel
 		require.Equal(t, http.StatusOK, recorder.Code)
 	})
 }
+
+func TestAuthMiddlewareAPITokensOnly(t *testing.T) { //nolint:tparallel
+	// Cannot be parallelized because of Setenv calls.
+	var (
+		ctx         = context.Background()
+		databaseURL = cmp.Or(os.Getenv("TEST_DATABASE_URL"), "postgres://localhost/river_test")
+	)
+
+	t.Setenv("DEV", "true")
+	t.Setenv("DATABASE_URL", databaseURL)
+	t.Setenv("RIVER_API_TOKENS_ENABLED", "true")
+
+	setup := func(t *testing.T) (http.Handler, *initServerResult) {
+		t.Helper()
+		initRes, err := initServer(ctx,
+			&initServerOpts{
+				logger:     riversharedtest.Logger(t),
+				pathPrefix: "/",
+			},
+			func(opts *ClientOpts) (*river.Client[pgx.Tx], error) {
+				return river.NewClient(riverpgxv5.New(opts.DBPool), &river.Config{Schema: opts.Schema})
+			},
+			func(client *river.Client[pgx.Tx], _ *BundleOpts) uiendpoints.Bundle {
+				return riverui.NewEndpoints(client, nil)
+			},
+		)
+		require.NoError(t, err)
+		t.Cleanup(initRes.db.Close)
+
+		return initRes.httpServer.Handler, initRes
+	}
+
+	serve := func(t *testing.T, handler http.Handler, path, token string) int {
+		t.Helper()
+
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	t.Run("RequiresToken", func(t *testing.T) {
+		handler, initRes := setup(t)
+
+		require.Equal(t, http.StatusUnauthorized, serve(t, handler, "/api/jobs", ""))
+		require.Equal(t, http.StatusOK, serve(t, handler, "/api/health-checks/complete", ""))
+
+		_, secret, err := apitoken.NewStore(initRes.db.pool, "").Create(ctx, &apitoken.CreateParams{
+			Name: "auth_middleware_test",
+			Role: uiauth.RoleViewer,
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, serve(t, handler, "/api/jobs", secret))
+	})
+
+	t.Run("ExplicitAnonymousRole", func(t *testing.T) {
+		// Cannot be parallelized because of Setenv calls.
+		t.Setenv("RIVER_ANONYMOUS_ROLE", "viewer")
+
+		handler, _ := setup(t)
+
+		require.Equal(t, http.StatusOK, serve(t, handler, "/api/jobs", ""))
+	})
+
+	t.Run("WithOtherAuthentication", func(t *testing.T) {
+		// Cannot be parallelized because of Setenv calls.
+		t.Setenv("RIVER_BASIC_AUTH_USER", "test_auth_user")
+		t.Setenv("RIVER_BASIC_AUTH_PASS", "test_auth_pass")
+
+		handler, _ := setup(t)
+
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/api/jobs", nil)
+		req.SetBasicAuth("test_auth_user", "test_auth_pass")
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		require.Equal(t, http.StatusOK, recorder.Code)
+	})
+}
@@ -0,0 +1,422 @@
+package riveruicmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// configSetting is a setting of the riverui server or its subcommands. Every
+// setting can be set with an environment variable or in a config file given
+// with `-config`, and some with a command line flag. Flags take precedence over environment
+// variables, which take precedence over the config file.
+type configSetting struct {
+	// Env is the setting's environment variable. Its key in a config file is
+	// the same name in lowercase without any `RIVER_` prefix, so
+	// `RIVER_BASIC_AUTH_USER` is `basic_auth_user`.
+	Env string
+
+	// Flag is the command line flag that sets the setting, if any.
+	Flag string
+
+	// IsBool is whether the setting is a boolean, which must be `true`,
+	// `false`, `1`, or `0`.
+	IsBool bool
+
+	// IsList is whether the setting is a comma-separated list. In a config
+	// file, it may also be given as an array.
+	IsList bool
+
+	// IsSecret is whether the setting's value is redacted in the summary of
+	// the configuration logged at startup.
+	IsSecret bool
+}
+
+// FileKey returns the setting's key in a config file.
+func (s *configSetting) FileKey() string {
+	return strings.ToLower(strings.TrimPrefix(s.Env, "RIVER_"))
+}
+
+// configSettings are all the settings of the riverui server, sorted by
+// environment variable. RIVER_API_TOKEN and RIVER_URL are only used by the
+// `jobs`, `queues`, and `states` subcommands.
+var configSettings = []*configSetting{ //nolint:gochecknoglobals
+	{Env: "CORS_ORIGINS", IsList: true},
+	{Env: "DATABASE_REPLICA_URL", IsSecret: true},
+	{Env: "DATABASE_URL", IsSecret: true},
+	{Env: "DEV", IsBool: true},
+	{Env: "LIVE_FS", IsBool: true},
+	{Env: "OTEL_ENABLED", IsBool: true},
+	{Env: "PATH_PREFIX", Flag: "prefix"},
+	{Env: "PORT"},
+	{Env: "RIVER_ANONYMOUS_ROLE"},
+	{Env: "RIVER_API_TOKEN", IsSecret: true},
+	{Env: "RIVER_API_TOKENS_ENABLED", IsBool: true},
+	{Env: "RIVER_ARGS_SCHEMA_FILE"},
+	{Env: "RIVER_AUDIT_SINK"},
+	{Env: "RIVER_AUTHORIZATION_POLICY_FILE"},
+	{Env: "RIVER_BASIC_AUTH_FILE"},
+	{Env: "RIVER_BASIC_AUTH_PASS", IsSecret: true},
+	{Env: "RIVER_BASIC_AUTH_ROLE"},
+	{Env: "RIVER_BASIC_AUTH_USER"},
+	{Env: "RIVER_CSRF_PROTECTION", IsBool: true},
+	{Env: "RIVER_DB_APPLICATION_NAME"},
+	{Env: "RIVER_DB_MAX_CONN_LIFETIME"},
+	{Env: "RIVER_DB_MAX_CONNS"},
+	{Env: "RIVER_DB_MIN_CONNS"},
+	{Env: "RIVER_DB_STATEMENT_TIMEOUT"},
+	{Env: "RIVER_DEBUG", IsBool: true},
+	{Env: "RIVER_ENVIRONMENTS", IsList: true},
+	{Env: "RIVER_HOST"},
+	{Env: "RIVER_JOB_LIST_HIDE_ARGS_BY_DEFAULT", IsBool: true},
+	{Env: "RIVER_KIND_REGISTRY_ENABLED", IsBool: true},
+	{Env: "RIVER_LISTEN_SOCKET"},
+	{Env: "RIVER_LISTEN_SOCKET_MODE"},
+	{Env: "RIVER_LISTEN_SYSTEMD", IsBool: true},
+	{Env: "RIVER_LOG_FORMAT"},
+	{Env: "RIVER_LOG_LEVEL"},
+	{Env: "RIVER_PROXY_AUTH_EMAIL_HEADER"},
+	{Env: "RIVER_PROXY_AUTH_GROUPS_HEADER"},
+	{Env: "RIVER_PROXY_AUTH_ROLE"},
+	{Env: "RIVER_PROXY_AUTH_TRUSTED_CIDRS", IsList: true},
+	{Env: "RIVER_PROXY_AUTH_TRUST_UNIX_SOCKET", IsBool: true},
+	{Env: "RIVER_PROXY_AUTH_USER_HEADER"},
+	{Env: "RIVER_RATE_LIMIT_BURST"},
+	{Env: "RIVER_RATE_LIMIT_MAX_CONCURRENT"},
+	{Env: "RIVER_RATE_LIMIT_RPS"},
+	{Env: "RIVER_REDACTION_FILE"},
+	{Env: "RIVER_SCHEMA", Flag: "schema"},
+	{Env: "RIVER_SHUTDOWN_DELAY"},
+	{Env: "RIVER_SHUTDOWN_TIMEOUT"},
+	{Env: "RIVER_SILENT_HEALTHCHECKS", Flag: "silent-healthchecks", IsBool: true},
+	{Env: "RIVER_TLS_CERT"},
+	{Env: "RIVER_TLS_CLIENT_CA"},
+	{Env: "RIVER_TLS_CLIENT_ROLE"},
+	{Env: "RIVER_TLS_KEY"},
+	{Env: "RIVER_URL"},
+}
+
+// Returns the settings of an additional environment named in
+// RIVER_ENVIRONMENTS, like `RIVER_ENVIRONMENT_EU_DATABASE_URL` for an
+// environment named `eu`. In a config file, they're given in a map under
+// `environments` instead, like `environments: {eu: {database_url: ...}}`.
+func environmentSettings(name string) []*configSetting {
+	prefix := "RIVER_ENVIRONMENT_" + strings.ToUpper(name) + "_"
+	return []*configSetting{
+		{Env: prefix + "DATABASE_REPLICA_URL", IsSecret: true},
+		{Env: prefix + "DATABASE_URL", IsSecret: true},
+		{Env: prefix + "SCHEMA"},
+	}
+}
+
+type configSource string
+
+const (
+	configSourceEnv  configSource = "env"
+	configSourceFile configSource = "file"
+	configSourceFlag configSource = "flag"
+)
+
+// serverConfig is the configuration in effect for the riverui server, merged
+// from a config file, environment variables, and command line flags.
+type serverConfig struct {
+	sources map[string]configSource
+	values  map[string]string
+}
+
+// Loads configuration from the config file at path (if non-empty), then
+// environment variables from lookupEnv, then flags, which map flag names to
+// values for flags that were set explicitly. Settings with empty values are
+// treated as unset.
+func loadServerConfig(path string, lookupEnv func(string) (string, bool), flags map[string]string) (*serverConfig, error) {
+	config := &serverConfig{
+		sources: make(map[string]configSource),
+		values:  make(map[string]string),
+	}
+
+	var fileValues map[string]string
+	if path != "" {
+		var err error
+		if fileValues, err = readConfigFile(path); err != nil {
+			return nil, err
+		}
+		for _, setting := range configSettings {
+			config.set(setting.Env, fileValues[setting.FileKey()], configSourceFile)
+		}
+	}
+
+	for _, setting := range configSettings {
+		if val, ok := lookupEnv(setting.Env); ok {
+			config.set(setting.Env, val, configSourceEnv)
+		}
+	}
+
+	for name, val := range flags {
+		index := slices.IndexFunc(configSettings, func(setting *configSetting) bool { return setting.Flag == name })
+		if index < 0 {
+			return nil, fmt.Errorf("flag -%s doesn't correspond to a setting", name)
+		}
+		config.set(configSettings[index].Env, val, configSourceFlag)
+	}
+
+	// Settings of additional environments depend on which environments are
+	// configured, so they can only be loaded once that's known.
+	for _, name := range config.Environments() {
+		for _, setting := range environmentSettings(name) {
+			config.set(setting.Env, fileValues[setting.FileKey()], configSourceFile)
+			if val, ok := lookupEnv(setting.Env); ok {
+				config.set(setting.Env, val, configSourceEnv)
+			}
+		}
+	}
+
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// Loads configuration for a subcommand like `tokens` from the config file at
+// path, or in RIVER_CONFIG if path is empty, and environment variables. A
+// schema given with the subcommand's -schema flag takes precedence over both.
+func loadCommandConfig(path string, lookupEnv func(string) (string, bool), schema string) (*serverConfig, error) {
+	if path == "" {
+		path, _ = lookupEnv("RIVER_CONFIG")
+	}
+	return loadServerConfig(path, lookupEnv, map[string]string{"schema": schema})
+}
+
+// Get returns the value of the setting with the given environment variable
+// name, or an empty string if it's unset. It's a drop-in replacement for
+// os.Getenv.
+func (c *serverConfig) Get(env string) string {
+	return c.values[env]
+}
+
+// Environments returns the names of additional environments configured in
+// RIVER_ENVIRONMENTS.
+func (c *serverConfig) Environments() []string {
+	var names []string
+	for name := range strings.SplitSeq(c.values["RIVER_ENVIRONMENTS"], ",") {
+		if name = strings.TrimSpace(name); name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (c *serverConfig) set(env, val string, source configSource) {
+	if val == "" {
+		return
+	}
+	c.sources[env] = source
+	c.values[env] = val
+}
+
+// Returns all settings, including those of any additional environments.
+func (c *serverConfig) settings() []*configSetting {
+	settings := slices.Clone(configSettings)
+	for _, name := range c.Environments() {
+		settings = append(settings, environmentSettings(name)...)
+	}
+	return settings
+}
+
+func (c *serverConfig) validate() error {
+	var errs []error
+	for _, setting := range configSettings {
+		val := c.values[setting.Env]
+		if val == "" {
+			continue
+		}
+
+		if setting.IsBool && !slices.Contains([]string{"0", "1", "false", "true"}, val) {
+			errs = append(errs, fmt.Errorf("invalid %s %q: must be true or false", setting.Env, val))
+		}
+	}
+
+	if logFormat := strings.ToLower(c.values["RIVER_LOG_FORMAT"]); logFormat != "" && !slices.Contains([]string{"json", "text"}, logFormat) {
+		errs = append(errs, fmt.Errorf("invalid RIVER_LOG_FORMAT %q: must be json or text", c.values["RIVER_LOG_FORMAT"]))
+	}
+	if logLevel := strings.ToLower(c.values["RIVER_LOG_LEVEL"]); logLevel != "" && !slices.Contains([]string{"debug", "error", "info", "warn"}, logLevel) {
+		errs = append(errs, fmt.Errorf("invalid RIVER_LOG_LEVEL %q: must be one of debug, info, warn, or error", c.values["RIVER_LOG_LEVEL"]))
+	}
+
+	return errors.Join(errs...)
+}
+
+// SummaryAttrs returns attributes summarizing the settings that are set and
+// where they came from, suitable for logging. Secrets are redacted.
+func (c *serverConfig) SummaryAttrs() []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(c.values))
+	for _, setting := range c.settings() {
+		val, ok := c.values[setting.Env]
+		if !ok {
+			continue
+		}
+
+		if setting.IsSecret {
+			val = redactConfigValue(val)
+		}
+
+		attrs = append(attrs, slog.String(setting.Env, fmt.Sprintf("%s (%s)", val, c.sources[setting.Env])))
+	}
+	return attrs
+}
+
+// Redacts a secret. Database URLs keep everything but their password so that
+// the host and database in use remain reviewable.
+func redactConfigValue(val string) string {
+	if parsedURL, err := url.Parse(val); err == nil && parsedURL.Scheme != "" && parsedURL.Host != "" {
+		if _, hasPassword := parsedURL.User.Password(); hasPassword {
+			return parsedURL.Redacted()
+		}
+		if parsedURL.Query().Has("password") {
+			query := parsedURL.Query()
+			query.Set("password", "xxxxx")
+			parsedURL.RawQuery = query.Encode()
+		}
+		return parsedURL.String()
+	}
+	return "[redacted]"
+}
+
+// Reads a YAML or TOML config file, determined by its extension, returning
+// its values as strings keyed by file key.
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+
+	var raw map[string]any
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".toml":
+		if _, err := toml.NewDecoder(bytes.NewReader(data)).Decode(&raw); err != nil {
+			return nil, fmt.Errorf("error parsing config file %q: %w", path, err)
+		}
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("error parsing config file %q: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("config file %q must have a .toml, .yaml, or .yml extension", path)
+	}
+
+	values := make(map[string]string, len(raw))
+	var errs []error
+	for key, rawVal := range raw {
+		if environments, ok := rawVal.(map[string]any); ok && key == "environments" {
+			if err := readConfigFileEnvironments(environments, values); err != nil {
+				errs = append(errs, fmt.Errorf("setting %q: %w", key, err))
+			}
+			continue
+		}
+
+		index := slices.IndexFunc(configSettings, func(setting *configSetting) bool { return setting.FileKey() == key })
+		if index < 0 {
+			errs = append(errs, fmt.Errorf("unknown setting %q", key))
+			continue
+		}
+
+		val, err := configFileValueString(configSettings[index], rawVal)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("setting %q: %w", key, err))
+			continue
+		}
+		values[key] = val
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid config file %q: %w", path, err)
+	}
+
+	return values, nil
+}
+
+// Reads the map of additional environments in a config file, like:
+//
+//	environments:
+//	  eu:
+//	    database_url: postgres://db.eu.example.com/river
+//	    schema: river
+//
+// Their names are set in values as `environments`, and their settings under
+// the file keys of environmentSettings.
+func readConfigFileEnvironments(environments map[string]any, values map[string]string) error {
+	names := slices.Sorted(maps.Keys(environments))
+	values["environments"] = strings.Join(names, ",")
+
+	var errs []error
+	for _, name := range names {
+		rawSettings, ok := environments[name].(map[string]any)
+		if !ok {
+			errs = append(errs, fmt.Errorf("environment %q must be a map of settings", name))
+			continue
+		}
+
+		settings := environmentSettings(name)
+		for key, rawVal := range rawSettings {
+			prefix := "environment_" + strings.ToLower(name) + "_"
+			index := slices.IndexFunc(settings, func(setting *configSetting) bool { return setting.FileKey() == prefix+key })
+			if index < 0 {
+				errs = append(errs, fmt.Errorf("unknown setting %q for environment %q", key, name))
+				continue
+			}
+
+			val, err := configFileValueString(settings[index], rawVal)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("setting %q for environment %q: %w", key, name, err))
+				continue
+			}
+			values[settings[index].FileKey()] = val
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Converts a value decoded from a config file to the string form it would
+// have in an environment variable.
+func configFileValueString(setting *configSetting, rawVal any) (string, error) {
+	switch val := rawVal.(type) {
+	case bool:
+		return strconv.FormatBool(val), nil
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64), nil
+	case int:
+		return strconv.Itoa(val), nil
+	case int64:
+		return strconv.FormatInt(val, 10), nil
+	case string:
+		return val, nil
+	case []any:
+		if !setting.IsList {
+			return "", errors.New("must be a single value, not a list")
+		}
+
+		items := make([]string, len(val))
+		for i, item := range val {
+			itemString, ok := item.(string)
+			if !ok {
+				return "", fmt.Errorf("list item %v must be a string", item)
+			}
+			items[i] = itemString
+		}
+		return strings.Join(items, ","), nil
+	case nil:
+		return "", nil
+	default:
+		return "", fmt.Errorf("unsupported value %v", val)
+	}
+}
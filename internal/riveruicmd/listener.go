@@ -0,0 +1,151 @@
+package riveruicmd
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// listenOpts configures what the server listens on. By default that's a
+// TCP address from RIVER_HOST and PORT, but it may instead be a Unix socket
+// or a listener passed by systemd socket activation.
+type listenOpts struct {
+	// socketMode is the file mode applied to a Unix socket created at
+	// socketPath, or zero to leave the mode determined by the umask.
+	socketMode fs.FileMode
+
+	// socketPath is the path of a Unix socket to listen on. With systemd,
+	// it's only used by `-healthcheck` to connect to the activated socket.
+	socketPath string
+
+	// systemd is whether to listen on a socket passed by systemd socket
+	// activation rather than opening one.
+	systemd bool
+}
+
+// Parses listener settings from their environment variables.
+func parseListenOpts(socketPath, socketMode, systemd string) (*listenOpts, error) {
+	opts := &listenOpts{
+		socketPath: socketPath,
+		systemd:    envBooleanTrue(systemd),
+	}
+
+	if socketMode != "" {
+		if socketPath == "" || opts.systemd {
+			return nil, errors.New("RIVER_LISTEN_SOCKET_MODE requires RIVER_LISTEN_SOCKET, and can't be used with RIVER_LISTEN_SYSTEMD")
+		}
+
+		mode, err := strconv.ParseUint(socketMode, 8, 32)
+		if err != nil || mode > 0o777 {
+			return nil, fmt.Errorf("invalid RIVER_LISTEN_SOCKET_MODE %q: must be an octal file mode like 0660", socketMode)
+		}
+		opts.socketMode = fs.FileMode(mode)
+	}
+
+	return opts, nil
+}
+
+// Opens the listener that the server should accept connections on. addr is
+// the TCP address to listen on if neither a Unix socket nor systemd socket
+// activation is configured.
+func (o *listenOpts) listen(ctx context.Context, addr string) (net.Listener, error) {
+	switch {
+	case o.systemd:
+		return systemdListener()
+	case o.socketPath != "":
+		return listenUnixSocket(ctx, o.socketPath, o.socketMode)
+	default:
+		var netListenConfig net.ListenConfig
+		return netListenConfig.Listen(ctx, "tcp", addr)
+	}
+}
+
+// Listens on a Unix socket at path, replacing a socket left behind by a
+// previous process that didn't shut down cleanly.
+func listenUnixSocket(ctx context.Context, path string, mode fs.FileMode) (net.Listener, error) {
+	if info, err := os.Stat(path); err == nil {
+		if info.Mode().Type() != fs.ModeSocket {
+			return nil, fmt.Errorf("RIVER_LISTEN_SOCKET %q exists and isn't a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("error removing stale socket: %w", err)
+		}
+	}
+
+	var netListenConfig net.ListenConfig
+	listener, err := netListenConfig.Listen(ctx, "unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if mode != 0 {
+		if err := os.Chmod(path, mode); err != nil {
+			_ = listener.Close()
+			return nil, fmt.Errorf("error setting socket mode: %w", err)
+		}
+	}
+
+	return listener, nil
+}
+
+// File descriptor of the first socket passed by systemd socket activation,
+// after stdin, stdout, and stderr.
+const systemdListenFDsStart = 3
+
+// Gets the listener passed by systemd socket activation, as described by
+// sd_listen_fds(3). Only one socket is supported.
+func systemdListener() (net.Listener, error) {
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil, errors.New("RIVER_LISTEN_SYSTEMD is set, but no sockets were passed by systemd for this process (LISTEN_PID doesn't match)")
+	}
+
+	numFDs, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || numFDs < 1 {
+		return nil, errors.New("RIVER_LISTEN_SYSTEMD is set, but no sockets were passed by systemd (LISTEN_FDS is empty)")
+	}
+	if numFDs > 1 {
+		return nil, fmt.Errorf("systemd passed %d sockets, but only one is supported", numFDs)
+	}
+
+	// Unset the variables so they aren't inherited by child processes, which
+	// would otherwise think the sockets were meant for them.
+	name, _, _ := strings.Cut(os.Getenv("LISTEN_FDNAMES"), ":")
+	for _, env := range []string{"LISTEN_FDNAMES", "LISTEN_FDS", "LISTEN_PID"} {
+		_ = os.Unsetenv(env)
+	}
+
+	file := os.NewFile(systemdListenFDsStart, cmp.Or(name, "systemd-socket"))
+	defer file.Close()
+
+	// FileListener duplicates the descriptor, so the file can be closed.
+	listener, err := net.FileListener(file)
+	if err != nil {
+		return nil, fmt.Errorf("error using socket passed by systemd: %w", err)
+	}
+	return listener, nil
+}
+
+// Returns a copy of client whose connections go to the Unix socket at
+// socketPath regardless of the host in request URLs.
+func withUnixSocketDialer(client *http.Client, socketPath string) *http.Client {
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok || transport == nil {
+		transport = http.DefaultTransport.(*http.Transport) //nolint:forcetypeassert
+	}
+	transport = transport.Clone()
+	transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, "unix", socketPath)
+	}
+
+	clientCopy := *client
+	clientCopy.Transport = transport
+	return &clientCopy
+}
@@ -0,0 +1,125 @@
+package riveruicmd
+
+import (
+	"context"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseListenOpts(t *testing.T) {
+	t.Parallel()
+
+	opts, err := parseListenOpts("", "", "")
+	require.NoError(t, err)
+	require.Equal(t, &listenOpts{}, opts)
+
+	opts, err = parseListenOpts("/run/riverui.sock", "0660", "")
+	require.NoError(t, err)
+	require.Equal(t, &listenOpts{socketMode: 0o660, socketPath: "/run/riverui.sock"}, opts)
+
+	opts, err = parseListenOpts("", "", "true")
+	require.NoError(t, err)
+	require.Equal(t, &listenOpts{systemd: true}, opts)
+
+	_, err = parseListenOpts("/run/riverui.sock", "rw-rw----", "")
+	require.EqualError(t, err, `invalid RIVER_LISTEN_SOCKET_MODE "rw-rw----": must be an octal file mode like 0660`)
+
+	_, err = parseListenOpts("/run/riverui.sock", "01777", "")
+	require.EqualError(t, err, `invalid RIVER_LISTEN_SOCKET_MODE "01777": must be an octal file mode like 0660`)
+
+	_, err = parseListenOpts("", "0660", "")
+	require.EqualError(t, err, "RIVER_LISTEN_SOCKET_MODE requires RIVER_LISTEN_SOCKET, and can't be used with RIVER_LISTEN_SYSTEMD")
+}
+
+func TestListenUnixSocket(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+
+	t.Run("SetsMode", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "riverui.sock")
+
+		listener, err := listenUnixSocket(ctx, path, 0o600)
+		require.NoError(t, err)
+		t.Cleanup(func() { listener.Close() })
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Equal(t, fs.ModeSocket, info.Mode().Type())
+		require.Equal(t, fs.FileMode(0o600), info.Mode().Perm())
+	})
+
+	t.Run("ReplacesStaleSocket", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "riverui.sock")
+
+		// Leave a socket file behind like a process that was killed would.
+		staleListener, err := net.Listen("unix", path)
+		require.NoError(t, err)
+		staleListener.(*net.UnixListener).SetUnlinkOnClose(false) //nolint:forcetypeassert
+		require.NoError(t, staleListener.Close())
+
+		listener, err := listenUnixSocket(ctx, path, 0)
+		require.NoError(t, err)
+		require.NoError(t, listener.Close())
+	})
+
+	t.Run("RefusesToReplaceOtherFiles", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "riverui.sock")
+		require.NoError(t, os.WriteFile(path, []byte("data"), 0o600))
+
+		_, err := listenUnixSocket(ctx, path, 0)
+		require.ErrorContains(t, err, "exists and isn't a socket")
+	})
+}
+
+func TestSystemdListenerNotActivated(t *testing.T) {
+	// Cannot be parallelized because of Setenv calls.
+	t.Setenv("LISTEN_FDS", "1")
+	t.Setenv("LISTEN_PID", "1")
+
+	_, err := systemdListener()
+	require.ErrorContains(t, err, "no sockets were passed by systemd for this process")
+}
+
+func TestCheckHealthUnixSocket(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+
+	path := filepath.Join(t.TempDir(), "riverui.sock")
+	listener, err := listenUnixSocket(ctx, path, 0)
+	require.NoError(t, err)
+
+	server := &http.Server{ //nolint:gosec
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/river/api/health-checks/minimal" {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}),
+	}
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() { require.NoError(t, server.Shutdown(context.WithoutCancel(ctx))) })
+
+	config, err := loadServerConfig("", func(key string) (string, bool) {
+		if key == "RIVER_LISTEN_SOCKET" {
+			return path, true
+		}
+		return "", false
+	}, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, checkHealth(ctx, config, "/river", "minimal"))
+	require.EqualError(t, checkHealth(ctx, config, "/", "minimal"), "health endpoint returned status code 404 instead of 200")
+}
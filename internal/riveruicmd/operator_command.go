@@ -0,0 +1,534 @@
+package riveruicmd
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"riverqueue.com/riverui"
+	"riverqueue.com/riverui/uiauth"
+	"riverqueue.com/riverui/uiendpoints"
+)
+
+const operatorUsage = `usage: riverui <jobs|queues|states> [command] [flags]
+
+Inspect and act on jobs and queues from a shell. Commands run the same API
+endpoints as the UI, either in-process against DATABASE_URL, or over HTTP
+against a running River UI given with -url.
+
+commands:
+  jobs list [-state STATE] [-kind KIND]... [-queue QUEUE]... [-priority N]... [-tag TAG]... [-id ID]... [-limit N]
+  jobs get ID
+  jobs cancel ID...
+  jobs delete ID...
+  jobs retry ID...
+  queues list [-limit N]
+  queues pause NAME
+  queues resume NAME
+  states
+
+All commands accept:
+  -config  YAML or TOML config file to read settings like DATABASE_URL and
+           RIVER_URL from (defaults to RIVER_CONFIG)
+  -json    print API responses as JSON instead of tables
+  -schema  non-default database schema where River tables are located
+  -token   API token for -url (defaults to RIVER_API_TOKEN)
+  -url     URL of a running River UI including any path prefix, like
+           https://river.example.com/ui (defaults to RIVER_URL)`
+
+// newLocalHandlerFunc builds a handler that serves the API against the
+// database in the configuration's DATABASE_URL, returning a function that
+// releases its resources.
+type newLocalHandlerFunc func(ctx context.Context, config *serverConfig) (http.Handler, func(), error)
+
+// operatorRequest is an API request made by an operator command, and how to
+// print its response as a table.
+type operatorRequest struct {
+	body   any
+	method string
+	path   string
+	print  func(out io.Writer, respBody []byte) error
+	query  url.Values
+}
+
+// Runs the `jobs`, `queues`, or `states` subcommand with args starting at the
+// subcommand's name, writing output to out. Settings are read from lookupEnv
+// and any config file.
+func runOperatorCommand(ctx context.Context, args []string, out io.Writer, lookupEnv func(string) (string, bool), newLocalHandler newLocalHandlerFunc) error {
+	if len(args) < 1 {
+		return errors.New(operatorUsage)
+	}
+
+	resource, command := args[0], ""
+	args = args[1:]
+	if resource != "states" {
+		if len(args) < 1 {
+			return errors.New(operatorUsage)
+		}
+		command, args = args[0], args[1:]
+	}
+
+	flagSet := flag.NewFlagSet(strings.TrimSpace("riverui "+resource+" "+command), flag.ContinueOnError)
+	flagSet.SetOutput(out)
+
+	var (
+		configPath string
+		jsonOutput bool
+		schema     string
+		serverURL  string
+		token      string
+	)
+	flagSet.StringVar(&configPath, "config", "", "path to a YAML or TOML config file (defaults to RIVER_CONFIG)")
+	flagSet.BoolVar(&jsonOutput, "json", false, "print API responses as JSON instead of tables")
+	flagSet.StringVar(&schema, "schema", "", "name of non-default database schema where River tables are located (defaults to RIVER_SCHEMA)")
+	flagSet.StringVar(&token, "token", "", "API token sent as a bearer token with -url (defaults to RIVER_API_TOKEN)")
+	flagSet.StringVar(&serverURL, "url", "", "URL of a running River UI to call instead of connecting to DATABASE_URL (defaults to RIVER_URL)")
+
+	var req *operatorRequest
+
+	switch strings.TrimSpace(resource + " " + command) {
+	case "jobs list":
+		var (
+			ids        stringListFlag
+			kinds      stringListFlag
+			limit      int
+			priorities stringListFlag
+			queues     stringListFlag
+			state      string
+			tags       stringListFlag
+		)
+		flagSet.Var(&ids, "id", "only list the job with this ID (repeatable)")
+		flagSet.Var(&kinds, "kind", "only list jobs of this kind (repeatable)")
+		flagSet.IntVar(&limit, "limit", 0, "maximum number of jobs to list (defaults to 20)")
+		flagSet.Var(&priorities, "priority", "only list jobs with this priority (repeatable)")
+		flagSet.Var(&queues, "queue", "only list jobs in this queue (repeatable)")
+		flagSet.StringVar(&state, "state", "", "only list jobs in this state, like available or retryable")
+		flagSet.Var(&tags, "tag", "only list jobs with this tag (repeatable)")
+		if err := parseOperatorFlags(flagSet, args, 0, ""); err != nil {
+			return err
+		}
+
+		// Query parameters are the same that the UI uses with `GET /api/jobs`.
+		query := url.Values{}
+		for key, vals := range map[string][]string{"ids": ids, "kinds": kinds, "priorities": priorities, "queues": queues, "tags": tags} {
+			if len(vals) > 0 {
+				query[key] = vals
+			}
+		}
+		if limit > 0 {
+			query.Set("limit", strconv.Itoa(limit))
+		}
+		if state != "" {
+			query.Set("state", state)
+		}
+
+		req = &operatorRequest{method: http.MethodGet, path: "/api/jobs", print: printJobList, query: query}
+
+	case "jobs get":
+		if err := parseOperatorFlags(flagSet, args, 1, "usage: riverui jobs get [flags] ID"); err != nil {
+			return err
+		}
+		jobIDs, err := parseJobIDs(flagSet.Args())
+		if err != nil {
+			return err
+		}
+
+		req = &operatorRequest{method: http.MethodGet, path: "/api/jobs/" + jobIDs[0], print: printJob}
+
+	case "jobs cancel", "jobs delete", "jobs retry":
+		if err := parseOperatorFlags(flagSet, args, -1, fmt.Sprintf("usage: riverui jobs %s [flags] ID...", command)); err != nil {
+			return err
+		}
+		jobIDs, err := parseJobIDs(flagSet.Args())
+		if err != nil {
+			return err
+		}
+
+		past := map[string]string{"cancel": "Cancelled", "delete": "Deleted", "retry": "Retried"}[command]
+		req = &operatorRequest{
+			body:   map[string][]string{"ids": jobIDs},
+			method: http.MethodPost,
+			path:   "/api/jobs/" + command,
+			print: func(out io.Writer, _ []byte) error {
+				_, err := fmt.Fprintf(out, "%s %s %s.\n", past, pluralize(len(jobIDs), "job", "jobs"), strings.Join(jobIDs, ", "))
+				return err
+			},
+		}
+
+	case "queues list":
+		var limit int
+		flagSet.IntVar(&limit, "limit", 0, "maximum number of queues to list (defaults to 100)")
+		if err := parseOperatorFlags(flagSet, args, 0, ""); err != nil {
+			return err
+		}
+
+		query := url.Values{}
+		if limit > 0 {
+			query.Set("limit", strconv.Itoa(limit))
+		}
+
+		req = &operatorRequest{method: http.MethodGet, path: "/api/queues", print: printQueueList, query: query}
+
+	case "queues pause", "queues resume":
+		if err := parseOperatorFlags(flagSet, args, 1, fmt.Sprintf("usage: riverui queues %s [flags] NAME", command)); err != nil {
+			return err
+		}
+		name := flagSet.Arg(0)
+
+		past := map[string]string{"pause": "Paused", "resume": "Resumed"}[command]
+		req = &operatorRequest{
+			method: http.MethodPut,
+			path:   "/api/queues/" + url.PathEscape(name) + "/" + command,
+			print: func(out io.Writer, _ []byte) error {
+				_, err := fmt.Fprintf(out, "%s queue %s.\n", past, name)
+				return err
+			},
+		}
+
+	case "states":
+		if err := parseOperatorFlags(flagSet, args, 0, ""); err != nil {
+			return err
+		}
+
+		req = &operatorRequest{method: http.MethodGet, path: "/api/states", print: printStates}
+
+	default:
+		return fmt.Errorf("unknown %s command %q\n\n%s", resource, command, operatorUsage)
+	}
+
+	config, err := loadCommandConfig(configPath, lookupEnv, schema)
+	if err != nil {
+		return err
+	}
+	serverURL = cmp.Or(serverURL, config.Get("RIVER_URL"))
+	token = cmp.Or(token, config.Get("RIVER_API_TOKEN"))
+
+	client, baseURL := http.DefaultClient, strings.TrimSuffix(serverURL, "/")
+	if serverURL == "" {
+		handler, cleanup, err := newLocalHandler(ctx, config)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		client, baseURL = &http.Client{Transport: handlerTransport{handler: handler}}, "http://riverui"
+	}
+
+	respBody, err := doOperatorRequest(ctx, client, baseURL, token, req)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, respBody, "", "  "); err != nil {
+			return fmt.Errorf("error formatting response: %w", err)
+		}
+		indented.WriteByte('\n')
+		_, err := indented.WriteTo(out)
+		return err
+	}
+
+	return req.print(out, respBody)
+}
+
+// Parses flags and checks that the right number of positional arguments
+// remain. numArgs of -1 means at least one.
+func parseOperatorFlags(flagSet *flag.FlagSet, args []string, numArgs int, usage string) error {
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	switch {
+	case numArgs == -1 && flagSet.NArg() < 1,
+		numArgs >= 0 && flagSet.NArg() != numArgs:
+		if usage == "" {
+			return fmt.Errorf("unexpected arguments: %s", strings.Join(flagSet.Args(), " "))
+		}
+		return errors.New(usage)
+	}
+
+	return nil
+}
+
+func parseJobIDs(args []string) ([]string, error) {
+	for _, arg := range args {
+		if _, err := strconv.ParseInt(arg, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid job ID %q: %w", arg, err)
+		}
+	}
+	return args, nil
+}
+
+// Makes an API request, returning the response body, or an error with the
+// API's error message if it doesn't succeed.
+func doOperatorRequest(ctx context.Context, client *http.Client, baseURL, token string, req *operatorRequest) ([]byte, error) {
+	var body io.Reader
+	if req.body != nil {
+		data, err := json.Marshal(req.body)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	requestURL := baseURL + req.path
+	if len(req.query) > 0 {
+		requestURL += "?" + req.query.Encode()
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, requestURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing request: %w", err)
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	//nolint:gosec // The URL is given by the operator running the command.
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting %s %s: %w", req.method, req.path, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(respBody, &apiErr); err != nil || apiErr.Message == "" {
+			return nil, fmt.Errorf("%s %s returned status code %d", req.method, req.path, resp.StatusCode)
+		}
+		return nil, fmt.Errorf("%s (status code %d)", apiErr.Message, resp.StatusCode)
+	}
+
+	return respBody, nil
+}
+
+// handlerTransport is an http.RoundTripper that serves requests with a
+// handler in-process, so that operator commands go through exactly the same
+// API endpoints whether they run locally or against a remote instance.
+type handlerTransport struct {
+	handler http.Handler
+}
+
+func (t handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Outgoing requests may have a nil body, but handlers expect an incoming
+	// request's body to always be non-nil.
+	serverReq := req.Clone(req.Context())
+	if serverReq.Body == nil {
+		serverReq.Body = http.NoBody
+	}
+	serverReq.RequestURI = req.URL.RequestURI()
+
+	recorder := httptest.NewRecorder()
+	t.handler.ServeHTTP(recorder, serverReq)
+	return recorder.Result(), nil
+}
+
+// Returns a newLocalHandlerFunc that builds a handler with createClient and
+// createBundle against DATABASE_URL, and DATABASE_REPLICA_URL if it's set.
+func newOperatorLocalHandler[TClient any](createClient func(*ClientOpts) (TClient, error), createBundle func(TClient, *BundleOpts) uiendpoints.Bundle) newLocalHandlerFunc {
+	return func(ctx context.Context, config *serverConfig) (http.Handler, func(), error) {
+		db, err := openDB(ctx, config.Get("DATABASE_URL"), &dbPoolOpts{})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var replicaDBPool *pgxpool.Pool
+		if databaseReplicaURL := config.Get("DATABASE_REPLICA_URL"); databaseReplicaURL != "" {
+			if db.pool == nil {
+				db.Close()
+				return nil, nil, errors.New("DATABASE_REPLICA_URL requires a Postgres database")
+			}
+			if replicaDBPool, err = connectDB(ctx, databaseReplicaURL, &dbPoolOpts{}); err != nil {
+				db.Close()
+				return nil, nil, fmt.Errorf("error connecting to DATABASE_REPLICA_URL: %w", err)
+			}
+		}
+		closeDBs := func() {
+			db.Close()
+			if replicaDBPool != nil {
+				replicaDBPool.Close()
+			}
+		}
+
+		client, err := createClient(db.clientOpts(config.Get("RIVER_SCHEMA")))
+		if err != nil {
+			closeDBs()
+			return nil, nil, err
+		}
+
+		// Request logs would be mixed in with the command's output, so only
+		// log problems, and to stderr.
+		logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+		// Actions taken from the command line are audited like those taken
+		// through the server. The slog sink writes to stderr at info level
+		// regardless of the level of other logs.
+		auditLogger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+		auditSink, err := newAuditSink(ctx, config.Get("RIVER_AUDIT_SINK"), db, config.Get("RIVER_SCHEMA"), auditLogger)
+		if err != nil {
+			closeDBs()
+			return nil, nil, err
+		}
+
+		handler, err := riverui.NewHandler(&riverui.HandlerOpts{
+			AuditSink: auditSink,
+			Endpoints: createBundle(client, &BundleOpts{ReplicaDBPool: replicaDBPool}),
+			Logger:    logger,
+		})
+		if err != nil {
+			closeDBs()
+			return nil, nil, err
+		}
+
+		handlerCtx, cancelHandler := context.WithCancel(ctx)
+		if err := handler.Start(handlerCtx); err != nil {
+			cancelHandler()
+			closeDBs()
+			return nil, nil, err
+		}
+
+		// Requests are attributed to the user running the command so that
+		// audit entries identify who took an action.
+		identity := &uiauth.Identity{Name: operatorActor(), Roles: []uiauth.Role{uiauth.RoleAdmin}}
+		identifiedHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler.ServeHTTP(w, r.WithContext(uiauth.WithIdentity(r.Context(), identity)))
+		})
+
+		return identifiedHandler, func() {
+			cancelHandler()
+			<-handler.Stopped()
+			closeDBs()
+		}, nil
+	}
+}
+
+// Returns the name that actions taken by operator commands run in-process are
+// attributed to, like `cli:alice` for the OS user alice.
+func operatorActor() string {
+	if currentUser, err := user.Current(); err == nil && currentUser.Username != "" {
+		return "cli:" + currentUser.Username
+	}
+	return "cli"
+}
+
+func printJobList(out io.Writer, respBody []byte) error {
+	var resp struct {
+		Data []*riverui.RiverJobMinimal `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return fmt.Errorf("error decoding jobs: %w", err)
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "ID\tKIND\tQUEUE\tSTATE\tATTEMPT\tPRIORITY\tSCHEDULED")
+	for _, job := range resp.Data {
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%s\t%d/%d\t%d\t%s\n",
+			job.ID, job.Kind, job.Queue, job.State, job.Attempt, job.MaxAttempts, job.Priority, formatTime(&job.ScheduledAt))
+	}
+	return writer.Flush()
+}
+
+func printJob(out io.Writer, respBody []byte) error {
+	var job riverui.RiverJob
+	if err := json.Unmarshal(respBody, &job); err != nil {
+		return fmt.Errorf("error decoding job: %w", err)
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(writer, "ID:\t%d\n", job.ID)
+	fmt.Fprintf(writer, "Kind:\t%s\n", job.Kind)
+	fmt.Fprintf(writer, "Queue:\t%s\n", job.Queue)
+	fmt.Fprintf(writer, "State:\t%s\n", job.State)
+	fmt.Fprintf(writer, "Attempt:\t%d/%d\n", job.Attempt, job.MaxAttempts)
+	fmt.Fprintf(writer, "Priority:\t%d\n", job.Priority)
+	fmt.Fprintf(writer, "Tags:\t%s\n", cmp.Or(strings.Join(job.Tags, ", "), "-"))
+	fmt.Fprintf(writer, "Created:\t%s\n", formatTime(&job.CreatedAt))
+	fmt.Fprintf(writer, "Scheduled:\t%s\n", formatTime(&job.ScheduledAt))
+	fmt.Fprintf(writer, "Attempted:\t%s\n", formatTime(job.AttemptedAt))
+	fmt.Fprintf(writer, "Finalized:\t%s\n", formatTime(job.FinalizedAt))
+	fmt.Fprintf(writer, "Args:\t%s\n", job.Args)
+	fmt.Fprintf(writer, "Metadata:\t%s\n", job.Metadata)
+	for _, attemptErr := range job.Errors {
+		fmt.Fprintf(writer, "Error (attempt %d):\t%s %s\n", attemptErr.Attempt, formatTime(&attemptErr.At), attemptErr.Error)
+	}
+	return writer.Flush()
+}
+
+func printQueueList(out io.Writer, respBody []byte) error {
+	var resp struct {
+		Data []*riverui.RiverQueue `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return fmt.Errorf("error decoding queues: %w", err)
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tAVAILABLE\tRUNNING\tPAUSED\tCREATED")
+	for _, queue := range resp.Data {
+		fmt.Fprintf(writer, "%s\t%d\t%d\t%s\t%s\n",
+			queue.Name, queue.CountAvailable, queue.CountRunning, formatTime(queue.PausedAt), formatTime(&queue.CreatedAt))
+	}
+	return writer.Flush()
+}
+
+// Job states in the order they're printed by `riverui states`, which is
+// roughly the order that jobs move through them.
+var operatorJobStates = []string{"scheduled", "pending", "available", "running", "retryable", "completed", "cancelled", "discarded"} //nolint:gochecknoglobals
+
+func printStates(out io.Writer, respBody []byte) error {
+	var counts map[string]int
+	if err := json.Unmarshal(respBody, &counts); err != nil {
+		return fmt.Errorf("error decoding states: %w", err)
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "STATE\tCOUNT")
+	for _, state := range operatorJobStates {
+		fmt.Fprintf(writer, "%s\t%d\n", state, counts[state])
+	}
+	return writer.Flush()
+}
+
+func pluralize(count int, singular, plural string) string {
+	if count == 1 {
+		return singular
+	}
+	return plural
+}
+
+// stringListFlag is a flag that may be given multiple times to build a list.
+type stringListFlag []string
+
+func (f *stringListFlag) Set(val string) error {
+	*f = append(*f, val)
+	return nil
+}
+
+func (f *stringListFlag) String() string { return strings.Join(*f, ",") }
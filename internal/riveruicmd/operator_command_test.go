@@ -0,0 +1,284 @@
+package riveruicmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunOperatorCommand(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	type testBundle struct {
+		requestAuthorization string
+		requestBody          string
+		requestURI           string
+	}
+
+	// Stands in for the API with canned responses for each endpoint.
+	newAPIHandler := func(bundle *testBundle) http.Handler {
+		mux := http.NewServeMux()
+		record := func(next http.HandlerFunc) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				bundle.requestAuthorization = r.Header.Get("Authorization")
+				bundle.requestBody = string(body)
+				bundle.requestURI = r.URL.RequestURI()
+				next(w, r)
+			}
+		}
+		respond := func(body string) http.HandlerFunc {
+			return record(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(body))
+			})
+		}
+
+		mux.HandleFunc("GET /api/jobs", respond(`{"data":[{"id":123,"kind":"email","queue":"default","state":"available","attempt":1,"max_attempts":25,"priority":1,"scheduled_at":"2026-01-02T03:04:05Z"}]}`))
+		mux.HandleFunc("GET /api/jobs/{job_id}", respond(`{"id":123,"args":"{\"to\":\"a@example.com\"}","kind":"email","queue":"default","state":"retryable","attempt":1,"max_attempts":25,"priority":1,"tags":["a","b"],"created_at":"2026-01-02T03:04:05Z","scheduled_at":"2026-01-02T03:04:05Z","errors":[{"at":"2026-01-02T03:04:06Z","attempt":1,"error":"boom","trace":""}],"metadata":{}}`))
+		mux.HandleFunc("POST /api/jobs/retry", respond(`{"status":"ok"}`))
+		mux.HandleFunc("POST /api/jobs/cancel", record(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message":"Identity isn't allowed to cancel jobs."}`))
+		}))
+		mux.HandleFunc("GET /api/queues", respond(`{"data":[{"name":"default","count_available":3,"count_running":1,"created_at":"2026-01-02T03:04:05Z","paused_at":null}]}`))
+		mux.HandleFunc("PUT /api/queues/{name}/pause", respond(`{"status":"ok"}`))
+		mux.HandleFunc("GET /api/states", respond(`{"available":3,"cancelled":0,"completed":10,"discarded":1,"pending":0,"retryable":2,"running":1,"scheduled":0}`))
+		return mux
+	}
+
+	setup := func(t *testing.T) (newLocalHandlerFunc, *testBundle) {
+		t.Helper()
+
+		bundle := &testBundle{}
+		return func(ctx context.Context, config *serverConfig) (http.Handler, func(), error) {
+			return newAPIHandler(bundle), func() {}, nil
+		}, bundle
+	}
+
+	runWithEnv := func(t *testing.T, env map[string]string, newLocalHandler newLocalHandlerFunc, args ...string) (string, error) {
+		t.Helper()
+
+		lookupEnv := func(key string) (string, bool) {
+			val, ok := env[key]
+			return val, ok
+		}
+
+		var out bytes.Buffer
+		err := runOperatorCommand(ctx, args, &out, lookupEnv, newLocalHandler)
+		return out.String(), err
+	}
+
+	run := func(t *testing.T, newLocalHandler newLocalHandlerFunc, args ...string) (string, error) {
+		t.Helper()
+
+		return runWithEnv(t, nil, newLocalHandler, args...)
+	}
+
+	t.Run("InvalidArguments", func(t *testing.T) {
+		t.Parallel()
+
+		// Arguments are validated before connecting to anything.
+		newLocalHandler := func(ctx context.Context, config *serverConfig) (http.Handler, func(), error) {
+			require.FailNow(t, "Handler shouldn't be built")
+			return nil, nil, nil
+		}
+
+		tests := []struct {
+			name    string
+			args    []string
+			wantErr string
+		}{
+			{"NoCommand", []string{"jobs"}, operatorUsage},
+			{"UnknownCommand", []string{"jobs", "requeue"}, "unknown jobs command \"requeue\"\n\n" + operatorUsage},
+			{"GetWithoutID", []string{"jobs", "get"}, "usage: riverui jobs get [flags] ID"},
+			{"GetWithInvalidID", []string{"jobs", "get", "abc"}, `invalid job ID "abc": strconv.ParseInt: parsing "abc": invalid syntax`},
+			{"RetryWithoutIDs", []string{"jobs", "retry"}, "usage: riverui jobs retry [flags] ID..."},
+			{"ListWithExtraArgs", []string{"jobs", "list", "extra"}, "unexpected arguments: extra"},
+			{"PauseWithoutName", []string{"queues", "pause"}, "usage: riverui queues pause [flags] NAME"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				_, err := run(t, newLocalHandler, tt.args...)
+				require.EqualError(t, err, tt.wantErr)
+			})
+		}
+	})
+
+	t.Run("JobsList", func(t *testing.T) {
+		t.Parallel()
+
+		newLocalHandler, bundle := setup(t)
+
+		out, err := run(t, newLocalHandler, "jobs", "list", "-state", "available", "-kind", "email", "-kind", "sms", "-queue", "default", "-limit", "5")
+		require.NoError(t, err)
+		require.Equal(t, "/api/jobs?kinds=email&kinds=sms&limit=5&queues=default&state=available", bundle.requestURI)
+		require.Equal(t, ""+
+			"ID   KIND   QUEUE    STATE      ATTEMPT  PRIORITY  SCHEDULED\n"+
+			"123  email  default  available  1/25     1         2026-01-02T03:04:05Z\n", out)
+	})
+
+	t.Run("JobsGet", func(t *testing.T) {
+		t.Parallel()
+
+		newLocalHandler, bundle := setup(t)
+
+		out, err := run(t, newLocalHandler, "jobs", "get", "123")
+		require.NoError(t, err)
+		require.Equal(t, "/api/jobs/123", bundle.requestURI)
+		require.Contains(t, out, "State:              retryable\n")
+		require.Contains(t, out, "Tags:               a, b\n")
+		require.Contains(t, out, "Error (attempt 1):  2026-01-02T03:04:06Z boom\n")
+	})
+
+	t.Run("JobsRetry", func(t *testing.T) {
+		t.Parallel()
+
+		newLocalHandler, bundle := setup(t)
+
+		out, err := run(t, newLocalHandler, "jobs", "retry", "123", "124")
+		require.NoError(t, err)
+		require.JSONEq(t, `{"ids":["123","124"]}`, bundle.requestBody)
+		require.Equal(t, "Retried jobs 123, 124.\n", out)
+	})
+
+	t.Run("APIError", func(t *testing.T) {
+		t.Parallel()
+
+		newLocalHandler, _ := setup(t)
+
+		_, err := run(t, newLocalHandler, "jobs", "cancel", "123")
+		require.EqualError(t, err, "Identity isn't allowed to cancel jobs. (status code 403)")
+	})
+
+	t.Run("QueuesList", func(t *testing.T) {
+		t.Parallel()
+
+		newLocalHandler, _ := setup(t)
+
+		out, err := run(t, newLocalHandler, "queues", "list")
+		require.NoError(t, err)
+		require.Equal(t, ""+
+			"NAME     AVAILABLE  RUNNING  PAUSED  CREATED\n"+
+			"default  3          1        -       2026-01-02T03:04:05Z\n", out)
+	})
+
+	t.Run("QueuesPause", func(t *testing.T) {
+		t.Parallel()
+
+		newLocalHandler, bundle := setup(t)
+
+		out, err := run(t, newLocalHandler, "queues", "pause", "default")
+		require.NoError(t, err)
+		require.Equal(t, "/api/queues/default/pause", bundle.requestURI)
+		require.Equal(t, "Paused queue default.\n", out)
+	})
+
+	t.Run("States", func(t *testing.T) {
+		t.Parallel()
+
+		newLocalHandler, _ := setup(t)
+
+		out, err := run(t, newLocalHandler, "states")
+		require.NoError(t, err)
+		require.Contains(t, out, "STATE      COUNT\nscheduled  0\n")
+		require.Contains(t, out, "completed  10\n")
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		t.Parallel()
+
+		newLocalHandler, _ := setup(t)
+
+		out, err := run(t, newLocalHandler, "states", "-json")
+		require.NoError(t, err)
+		require.JSONEq(t, `{"available":3,"cancelled":0,"completed":10,"discarded":1,"pending":0,"retryable":2,"running":1,"scheduled":0}`, out)
+	})
+
+	t.Run("RemoteURL", func(t *testing.T) {
+		t.Parallel()
+
+		bundle := &testBundle{}
+		server := httptest.NewServer(http.StripPrefix("/river", newAPIHandler(bundle)))
+		t.Cleanup(server.Close)
+
+		newLocalHandler := func(ctx context.Context, config *serverConfig) (http.Handler, func(), error) {
+			require.FailNow(t, "Handler shouldn't be built when -url is given")
+			return nil, nil, nil
+		}
+
+		out, err := run(t, newLocalHandler, "queues", "pause", "-url", server.URL+"/river/", "-token", "rvr_secret", "default")
+		require.NoError(t, err)
+		require.Equal(t, "Bearer rvr_secret", bundle.requestAuthorization)
+		require.Equal(t, "Paused queue default.\n", out)
+	})
+
+	t.Run("ConfigFile", func(t *testing.T) {
+		t.Parallel()
+
+		bundle := &testBundle{}
+		server := httptest.NewServer(newAPIHandler(bundle))
+		t.Cleanup(server.Close)
+
+		configPath := filepath.Join(t.TempDir(), "riverui.yaml")
+		require.NoError(t, os.WriteFile(configPath, []byte("api_token: rvr_from_file\nurl: "+server.URL+"\n"), 0o600))
+
+		newLocalHandler := func(ctx context.Context, config *serverConfig) (http.Handler, func(), error) {
+			require.FailNow(t, "Handler shouldn't be built when RIVER_URL is configured")
+			return nil, nil, nil
+		}
+
+		out, err := runWithEnv(t, map[string]string{"RIVER_CONFIG": configPath}, newLocalHandler, "queues", "pause", "default")
+		require.NoError(t, err)
+		require.Equal(t, "Bearer rvr_from_file", bundle.requestAuthorization)
+		require.Equal(t, "Paused queue default.\n", out)
+
+		// Flags take precedence over the config file.
+		_, err = runWithEnv(t, map[string]string{"RIVER_CONFIG": configPath}, newLocalHandler, "queues", "pause", "-token", "rvr_from_flag", "default")
+		require.NoError(t, err)
+		require.Equal(t, "Bearer rvr_from_flag", bundle.requestAuthorization)
+	})
+
+	t.Run("LocalHandlerConfig", func(t *testing.T) {
+		t.Parallel()
+
+		configPath := filepath.Join(t.TempDir(), "riverui.toml")
+		require.NoError(t, os.WriteFile(configPath, []byte("database_url = \"postgres://localhost/river\"\nschema = \"from_file\"\n"), 0o600))
+
+		var localConfig *serverConfig
+		newLocalHandler := func(ctx context.Context, config *serverConfig) (http.Handler, func(), error) {
+			localConfig = config
+			return newAPIHandler(&testBundle{}), func() {}, nil
+		}
+
+		_, err := runWithEnv(t, nil, newLocalHandler, "states", "-config", configPath)
+		require.NoError(t, err)
+		require.Equal(t, "postgres://localhost/river", localConfig.Get("DATABASE_URL"))
+		require.Equal(t, "from_file", localConfig.Get("RIVER_SCHEMA"))
+
+		_, err = runWithEnv(t, nil, newLocalHandler, "states", "-config", configPath, "-schema", "from_flag")
+		require.NoError(t, err)
+		require.Equal(t, "from_flag", localConfig.Get("RIVER_SCHEMA"))
+	})
+
+	t.Run("InvalidConfigFile", func(t *testing.T) {
+		t.Parallel()
+
+		newLocalHandler, _ := setup(t)
+
+		_, err := run(t, newLocalHandler, "states", "-config", filepath.Join(t.TempDir(), "riverui.yaml"))
+		require.ErrorContains(t, err, "error reading config file")
+	})
+}
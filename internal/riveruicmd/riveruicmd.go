@@ -1,16 +1,24 @@
 package riveruicmd
 
 import (
+	"bytes"
 	"cmp"
 	"context"
+	"crypto/tls"
+	"database/sql"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"log/slog"
 	"net"
 	"net/http"
+	"net/netip"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -18,72 +26,157 @@ import (
 	sloghttp "github.com/samber/slog-http"
 
 	"github.com/riverqueue/apiframe/apimiddleware"
+	"github.com/riverqueue/river/riverdriver/riverpgxv5"
 
 	"riverqueue.com/riverui"
+	"riverqueue.com/riverui/internal/apitoken"
 	"riverqueue.com/riverui/internal/authmiddleware"
+	"riverqueue.com/riverui/uiaudit"
+	"riverqueue.com/riverui/uiauth"
 	"riverqueue.com/riverui/uiendpoints"
+	"riverqueue.com/riverui/uikinds"
+	"riverqueue.com/riverui/uiredact"
+	"riverqueue.com/riverui/uischema"
 )
 
 type BundleOpts struct {
 	JobListHideArgsByDefault bool
+
+	// KindRegistry is a registry in the primary database that clients report
+	// their job kinds to if RIVER_KIND_REGISTRY_ENABLED is set, or nil
+	// otherwise. Bundles should pass it to riverui.EndpointsOpts.KindRegistry.
+	KindRegistry uikinds.Registry
+
+	// ReplicaDBPool is a pool connected to the read replica in
+	// DATABASE_REPLICA_URL, or nil if it's not set. Bundles should query it
+	// from read-only endpoints through riverui.EndpointsOpts.ReplicaExecutor.
+	ReplicaDBPool *pgxpool.Pool
 }
 
+// ClientOpts are passed to Run's createClient with the database to build a
+// River client for. Exactly one of DBPool or SQLiteDB is set depending on
+// whether DATABASE_URL is a Postgres URL or a `sqlite://` one.
 type ClientOpts struct {
+	// DBPool is a pool connected to a Postgres database.
+	DBPool *pgxpool.Pool
+
 	Schema string
+
+	// SQLiteDB is an opened SQLite database. Opening one requires that the
+	// binary registers a database/sql driver for SQLite named `sqlite`.
+	SQLiteDB *sql.DB
 }
 
-func Run[TClient any](createClient func(*pgxpool.Pool, *ClientOpts) (TClient, error), createBundle func(TClient) uiendpoints.Bundle) {
+func Run[TClient any](createClient func(*ClientOpts) (TClient, error), createBundle func(TClient, *BundleOpts) uiendpoints.Bundle) {
 	ctx := context.Background()
 
-	logger := slog.New(getLogHandler(&slog.HandlerOptions{
-		Level: getLogLevel(),
+	logger := slog.New(getLogHandler(os.Getenv, &slog.HandlerOptions{
+		Level: getLogLevel(os.Getenv),
 	}))
 
-	var pathPrefix string
-	flag.StringVar(&pathPrefix, "prefix", "/", "path prefix for API and UI routes (must start with '/', use '/' for no prefix)")
+	if len(os.Args) > 1 && os.Args[1] == "tokens" {
+		if err := runTokensCommand(ctx, os.Args[2:], os.Stdout, os.LookupEnv); err != nil {
+			logger.ErrorContext(ctx, "Error running tokens command", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if len(os.Args) > 1 && (os.Args[1] == "jobs" || os.Args[1] == "queues" || os.Args[1] == "states") {
+		if err := runOperatorCommand(ctx, os.Args[1:], os.Stdout, os.LookupEnv, newOperatorLocalHandler(createClient, createBundle)); err != nil {
+			logger.ErrorContext(ctx, "Error running "+os.Args[1]+" command", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	var configPath string
+	flag.StringVar(&configPath, "config", os.Getenv("RIVER_CONFIG"), "path to a YAML or TOML config file; flags and env vars take precedence over its settings")
 
-	var schema string
-	flag.StringVar(&schema, "schema", os.Getenv("RIVER_SCHEMA"), "name of non-default database schema where River tables are located")
+	flag.String("prefix", "/", "path prefix for API and UI routes (must start with '/', use '/' for no prefix)")
+
+	flag.String("schema", "", "name of non-default database schema where River tables are located")
 
 	var healthCheckName string
 	flag.StringVar(&healthCheckName, "healthcheck", "", "the name of the health checks: minimal or complete")
 
-	var silentHealthChecks bool
-	flag.BoolVar(&silentHealthChecks, "silent-healthchecks", false, "silence request logs for health check routes")
+	flag.Bool("silent-healthchecks", false, "silence request logs for health check routes")
 
 	flag.Parse()
 
+	// Only flags set explicitly override env vars and the config file.
+	flagValues := make(map[string]string)
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name != "config" && f.Name != "healthcheck" {
+			flagValues[f.Name] = f.Value.String()
+		}
+	})
+
+	config, err := loadServerConfig(configPath, os.LookupEnv, flagValues)
+	if err != nil {
+		logger.ErrorContext(ctx, "Error loading configuration", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	logger = slog.New(getLogHandler(config.Get, &slog.HandlerOptions{
+		Level: getLogLevel(config.Get),
+	}))
+
+	pathPrefix := cmp.Or(config.Get("PATH_PREFIX"), "/")
+
 	if healthCheckName != "" {
-		if err := checkHealth(ctx, pathPrefix, healthCheckName); err != nil {
+		if err := checkHealth(ctx, config, pathPrefix, healthCheckName); err != nil {
 			logger.ErrorContext(ctx, "Error checking for server health", slog.String("error", err.Error()))
 			os.Exit(1)
 		}
 		os.Exit(0)
 	}
 
+	logger.LogAttrs(ctx, slog.LevelInfo, "Loaded configuration", config.SummaryAttrs()...)
+
 	initRes, err := initServer(ctx, &initServerOpts{
+		config:             config,
 		logger:             logger,
 		pathPrefix:         pathPrefix,
-		schema:             schema,
-		silentHealthChecks: silentHealthChecks,
+		schema:             config.Get("RIVER_SCHEMA"),
+		silentHealthChecks: envBooleanTrue(config.Get("RIVER_SILENT_HEALTHCHECKS")),
 	}, createClient, createBundle)
 	if err != nil {
 		logger.ErrorContext(ctx, "Error initializing server", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
 
-	if err := startAndListen(ctx, logger, initRes); err != nil {
+	shutdownSignal := make(chan os.Signal, 1)
+	signal.Notify(shutdownSignal, os.Interrupt, syscall.SIGTERM)
+
+	if err := startAndListen(ctx, logger, initRes, shutdownSignal); err != nil {
 		logger.ErrorContext(ctx, "Error starting server", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
 }
 
-func checkHealth(ctx context.Context, pathPrefix string, healthCheckName string) error {
-	host := cmp.Or(os.Getenv("RIVER_HOST"), "localhost")
-	port := cmp.Or(os.Getenv("PORT"), "8080")
+func checkHealth(ctx context.Context, config *serverConfig, pathPrefix string, healthCheckName string) error {
+	host := cmp.Or(config.Get("RIVER_HOST"), "localhost")
+	port := cmp.Or(config.Get("PORT"), "8080")
 	pathPrefix = riverui.NormalizePathPrefix(pathPrefix)
 	hostname := net.JoinHostPort(host, port)
-	url := fmt.Sprintf("http://%s%s/api/health-checks/%s", hostname, pathPrefix, healthCheckName)
+
+	client, scheme := http.DefaultClient, "http"
+	if certPath := config.Get("RIVER_TLS_CERT"); certPath != "" {
+		var err error
+		if client, err = newHealthCheckTLSClient(certPath); err != nil {
+			return err
+		}
+		scheme = "https"
+	}
+
+	// The host is still used for the request's Host header and to verify a
+	// TLS certificate, but connections go to the socket.
+	if socketPath := config.Get("RIVER_LISTEN_SOCKET"); socketPath != "" {
+		client = withUnixSocketDialer(client, socketPath)
+	}
+
+	url := fmt.Sprintf("%s://%s%s/api/health-checks/%s", scheme, hostname, pathPrefix, healthCheckName)
 
 	//nolint:gosec // `-healthcheck` is an operator-invoked probe of the running River UI server's own HTTP health endpoint.
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
@@ -91,7 +184,7 @@ func checkHealth(ctx context.Context, pathPrefix string, healthCheckName string)
 		return fmt.Errorf("error constructing request to health endpoint: %w", err)
 	}
 	//nolint:gosec // `-healthcheck` intentionally reuses the configured River UI endpoint and isn't treated as a security boundary here.
-	response, err := http.DefaultClient.Do(req)
+	response, err := client.Do(req)
 	if err != nil {
 		return fmt.Errorf("error requesting health endpoint: %w", err)
 	}
@@ -107,13 +200,91 @@ func checkHealth(ctx context.Context, pathPrefix string, healthCheckName string)
 	return nil
 }
 
+// Connects to the database configured by DATABASE_URL or standard PG* env
+// vars, applying any pool settings from poolOpts on top.
+func connectDB(ctx context.Context, databaseURL string, poolOpts *dbPoolOpts) (*pgxpool.Pool, error) {
+	if databaseURL == "" && os.Getenv("PGDATABASE") == "" {
+		return nil, errors.New("expect to have DATABASE_URL or database configuration in standard PG* env vars like PGDATABASE/PGHOST/PGPORT/PGUSER/PGPASSWORD")
+	}
+
+	poolConfig, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing db config: %w", err)
+	}
+
+	if poolOpts.maxConns > 0 {
+		poolConfig.MaxConns = poolOpts.maxConns
+	}
+	if poolOpts.minConns > 0 {
+		poolConfig.MinConns = poolOpts.minConns
+	}
+	if poolOpts.maxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = poolOpts.maxConnLifetime
+	}
+	if poolConfig.MinConns > poolConfig.MaxConns {
+		return nil, fmt.Errorf("minimum connections (%d) can't be greater than maximum connections (%d)", poolConfig.MinConns, poolConfig.MaxConns)
+	}
+
+	// Identify the UI's connections in pg_stat_activity unless an application
+	// name was already set in DATABASE_URL or PGAPPNAME.
+	if poolOpts.applicationName != "" {
+		poolConfig.ConnConfig.RuntimeParams["application_name"] = poolOpts.applicationName
+	} else if _, ok := poolConfig.ConnConfig.RuntimeParams["application_name"]; !ok {
+		poolConfig.ConnConfig.RuntimeParams["application_name"] = defaultApplicationName
+	}
+
+	dbPool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to db: %w", err)
+	}
+
+	return dbPool, nil
+}
+
+// dbConn is a connection to the database of DATABASE_URL or an environment,
+// which is either a Postgres pool or a SQLite database.
+type dbConn struct {
+	pool   *pgxpool.Pool
+	sqlite *sql.DB
+}
+
+// Connects to Postgres or opens a SQLite database depending on databaseURL.
+func openDB(ctx context.Context, databaseURL string, poolOpts *dbPoolOpts) (*dbConn, error) {
+	if path, ok := sqlitePath(databaseURL); ok {
+		sqliteDB, err := openSQLite(ctx, path, poolOpts)
+		if err != nil {
+			return nil, err
+		}
+		return &dbConn{sqlite: sqliteDB}, nil
+	}
+
+	dbPool, err := connectDB(ctx, databaseURL, poolOpts)
+	if err != nil {
+		return nil, err
+	}
+	return &dbConn{pool: dbPool}, nil
+}
+
+func (c *dbConn) Close() {
+	if c.pool != nil {
+		c.pool.Close()
+	}
+	if c.sqlite != nil {
+		_ = c.sqlite.Close()
+	}
+}
+
+func (c *dbConn) clientOpts(schema string) *ClientOpts {
+	return &ClientOpts{DBPool: c.pool, Schema: schema, SQLiteDB: c.sqlite}
+}
+
 // Translates either a "1" or "true" from env to a Go boolean.
 func envBooleanTrue(val string) bool {
 	return val == "1" || val == "true"
 }
 
-func getLogHandler(opts *slog.HandlerOptions) slog.Handler {
-	logFormat := strings.ToLower(os.Getenv("RIVER_LOG_FORMAT"))
+func getLogHandler(getenv func(string) string, opts *slog.HandlerOptions) slog.Handler {
+	logFormat := strings.ToLower(getenv("RIVER_LOG_FORMAT"))
 	switch logFormat {
 	case "json":
 		return slog.NewJSONHandler(os.Stdout, opts)
@@ -122,12 +293,12 @@ func getLogHandler(opts *slog.HandlerOptions) slog.Handler {
 	}
 }
 
-func getLogLevel() slog.Level {
-	if envBooleanTrue(os.Getenv("RIVER_DEBUG")) {
+func getLogLevel(getenv func(string) string) slog.Level {
+	if envBooleanTrue(getenv("RIVER_DEBUG")) {
 		return slog.LevelDebug
 	}
 
-	switch strings.ToLower(os.Getenv("RIVER_LOG_LEVEL")) {
+	switch strings.ToLower(getenv("RIVER_LOG_LEVEL")) {
 	case "debug":
 		return slog.LevelDebug
 	case "warn":
@@ -139,21 +310,262 @@ func getLogLevel() slog.Level {
 	}
 }
 
+// Loads an authorization policy scoping actions to particular queues and
+// kinds from a JSON file.
+func loadAuthorizationPolicy(path string) (*uiauth.Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading authorization policy file: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var policy uiauth.Policy
+	if err := decoder.Decode(&policy); err != nil {
+		return nil, fmt.Errorf("error parsing authorization policy file %q: %w", path, err)
+	}
+
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid authorization policy file %q: %w", path, err)
+	}
+
+	return &policy, nil
+}
+
+// Loads a redactor that replaces values at JSON paths in jobs' args and
+// metadata from a JSON file.
+func loadRedactor(path string) (*uiredact.JSONPathRedactor, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading redaction file: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var redactorOpts uiredact.JSONPathRedactorOpts
+	if err := decoder.Decode(&redactorOpts); err != nil {
+		return nil, fmt.Errorf("error parsing redaction file %q: %w", path, err)
+	}
+
+	redactor, err := uiredact.NewJSONPathRedactor(&redactorOpts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redaction file %q: %w", path, err)
+	}
+
+	return redactor, nil
+}
+
+// Loads JSON Schemas for job args from a JSON file containing an object that
+// maps job kinds to their schemas.
+func loadArgsSchemas(path string) (*uischema.Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading args schema file: %w", err)
+	}
+
+	var schemas map[string]json.RawMessage
+	if err := json.Unmarshal(data, &schemas); err != nil {
+		return nil, fmt.Errorf("error parsing args schema file %q: %w", path, err)
+	}
+
+	registry := uischema.NewRegistry()
+	for kind, schema := range schemas {
+		if err := registry.Register(kind, schema); err != nil {
+			return nil, fmt.Errorf("invalid args schema file %q: %w", path, err)
+		}
+	}
+
+	return registry, nil
+}
+
+const (
+	auditSinkPostgres = "postgres"
+	auditSinkSlog     = "slog"
+)
+
+// Builds the audit sink named by RIVER_AUDIT_SINK, migrating its table in the
+// given schema for a Postgres sink. Returns a nil sink if name is empty.
+func newAuditSink(ctx context.Context, name string, db *dbConn, schema string, logger *slog.Logger) (uiaudit.Sink, error) {
+	var auditSink uiaudit.Sink
+	switch name {
+	case "":
+	case auditSinkPostgres:
+		if db.pool == nil {
+			return nil, fmt.Errorf("RIVER_AUDIT_SINK=%s requires a Postgres database", auditSinkPostgres)
+		}
+		postgresSink := uiaudit.NewPostgresSink(riverpgxv5.New(db.pool).GetExecutor(), &uiaudit.PostgresSinkOpts{Schema: schema})
+		if err := postgresSink.Migrate(ctx); err != nil {
+			return nil, err
+		}
+		auditSink = postgresSink
+	case auditSinkSlog:
+		auditSink = uiaudit.NewSlogSink(logger)
+	default:
+		return nil, fmt.Errorf("invalid RIVER_AUDIT_SINK %q; must be one of: %s, %s", name, auditSinkPostgres, auditSinkSlog)
+	}
+	return auditSink, nil
+}
+
+// Default maximum time to wait for in-flight requests to finish on shutdown.
+// Kept under the 30 second grace period Kubernetes gives pods by default.
+const defaultShutdownTimeout = 25 * time.Second
+
+// Parses a duration like "10s" from the environment variable named name,
+// returning defaultVal if it's unset.
+func parseNonNegativeDuration(name, val string, defaultVal time.Duration) (time.Duration, error) {
+	if val == "" {
+		return defaultVal, nil
+	}
+
+	duration, err := time.ParseDuration(val)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a non-negative duration like 10s", name, val)
+	}
+	return duration, nil
+}
+
+// Application name reported to Postgres if one isn't configured.
+const defaultApplicationName = "riverui"
+
+// dbPoolOpts are database pool settings that override those from
+// DATABASE_URL. Zero values leave the pool's setting as is.
+type dbPoolOpts struct {
+	applicationName string
+	maxConnLifetime time.Duration
+	maxConns        int32
+	minConns        int32
+}
+
+// Parses database pool settings from their environment variables.
+func parseDBPoolOpts(maxConns, minConns, maxConnLifetime, applicationName string) (*dbPoolOpts, error) {
+	poolOpts := &dbPoolOpts{applicationName: applicationName}
+
+	if maxConns != "" {
+		val, err := strconv.ParseInt(maxConns, 10, 32)
+		if err != nil || val < 1 {
+			return nil, fmt.Errorf("invalid RIVER_DB_MAX_CONNS %q: must be a positive integer", maxConns)
+		}
+		poolOpts.maxConns = int32(val)
+	}
+	if minConns != "" {
+		val, err := strconv.ParseInt(minConns, 10, 32)
+		if err != nil || val < 0 {
+			return nil, fmt.Errorf("invalid RIVER_DB_MIN_CONNS %q: must be a non-negative integer", minConns)
+		}
+		poolOpts.minConns = int32(val)
+	}
+	if poolOpts.maxConns > 0 && poolOpts.minConns > poolOpts.maxConns {
+		return nil, fmt.Errorf("RIVER_DB_MIN_CONNS (%d) can't be greater than RIVER_DB_MAX_CONNS (%d)", poolOpts.minConns, poolOpts.maxConns)
+	}
+
+	var err error
+	if poolOpts.maxConnLifetime, err = parseNonNegativeDuration("RIVER_DB_MAX_CONN_LIFETIME", maxConnLifetime, 0); err != nil {
+		return nil, err
+	}
+
+	return poolOpts, nil
+}
+
+// Parses rate limit settings from their environment variables, returning nil
+// if none are set.
+func parseRateLimitOpts(rps, burst, maxConcurrent string) (*riverui.RateLimitOpts, error) {
+	if rps == "" && burst == "" && maxConcurrent == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	rateLimit := &riverui.RateLimitOpts{}
+
+	if rps != "" {
+		var err error
+		if rateLimit.RequestsPerSecond, err = strconv.ParseFloat(rps, 64); err != nil || rateLimit.RequestsPerSecond < 0 {
+			return nil, fmt.Errorf("invalid RIVER_RATE_LIMIT_RPS %q: must be a non-negative number", rps)
+		}
+	}
+	if burst != "" {
+		var err error
+		if rateLimit.Burst, err = strconv.Atoi(burst); err != nil || rateLimit.Burst < 0 {
+			return nil, fmt.Errorf("invalid RIVER_RATE_LIMIT_BURST %q: must be a non-negative integer", burst)
+		}
+	}
+	if maxConcurrent != "" {
+		var err error
+		if rateLimit.MaxConcurrent, err = strconv.Atoi(maxConcurrent); err != nil || rateLimit.MaxConcurrent < 0 {
+			return nil, fmt.Errorf("invalid RIVER_RATE_LIMIT_MAX_CONCURRENT %q: must be a non-negative integer", maxConcurrent)
+		}
+	}
+
+	return rateLimit, nil
+}
+
+// Returns the CORS origins whose cross-origin requests should also pass CSRF
+// protection. Wildcards aren't meaningful as trusted origins, so are skipped.
+func csrfTrustedOrigins(corsOrigins []string) []string {
+	var origins []string
+	for _, origin := range corsOrigins {
+		if origin = strings.TrimSpace(origin); origin != "" && !strings.Contains(origin, "*") {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// Parses a comma-separated list of CIDRs like "10.0.0.0/8,127.0.0.1". Bare
+// addresses are treated as a network containing only that address.
+func parseTrustedProxies(s string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for value := range strings.SplitSeq(s, ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		if !strings.Contains(value, "/") {
+			addr, err := netip.ParseAddr(value)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	if len(prefixes) < 1 {
+		return nil, errors.New("at least one CIDR is required")
+	}
+
+	return prefixes, nil
+}
+
 type initServerResult struct {
-	dbPool     *pgxpool.Pool    // database pool; close must be deferred by caller!
-	httpServer *http.Server     // HTTP server wrapping the UI handler
-	logger     *slog.Logger     // application logger (also internalized in UI handler)
-	uiHandler  *riverui.Handler // River UI handler
+	db                        *dbConn          // database connection; close must be deferred by caller!
+	environmentDBs            []*dbConn        // database connections of additional environments
+	environmentReplicaDBPools []*pgxpool.Pool  // read replica pools of additional environments that configure one
+	httpServer                *http.Server     // HTTP server wrapping the UI handler
+	listenOpts                *listenOpts      // what httpServer listens on
+	logger                    *slog.Logger     // application logger (also internalized in UI handler)
+	replicaDBPool             *pgxpool.Pool    // read replica database pool, or nil if no replica is configured
+	shutdownDelay             time.Duration    // time to fail health checks before draining requests
+	shutdownTimeout           time.Duration    // maximum time to wait for in-flight requests to finish
+	uiHandler                 *riverui.Handler // River UI handler
 }
 
 type initServerOpts struct {
+	config             *serverConfig // settings; loaded from env vars if nil
 	logger             *slog.Logger
 	pathPrefix         string
 	schema             string
 	silentHealthChecks bool
 }
 
-func initServer[TClient any](ctx context.Context, opts *initServerOpts, createClient func(*pgxpool.Pool, *ClientOpts) (TClient, error), createBundle func(TClient) uiendpoints.Bundle) (*initServerResult, error) {
+func initServer[TClient any](ctx context.Context, opts *initServerOpts, createClient func(*ClientOpts) (TClient, error), createBundle func(TClient, *BundleOpts) uiendpoints.Bundle) (*initServerResult, error) {
 	if opts == nil {
 		return nil, errors.New("opts is required")
 	}
@@ -166,45 +578,307 @@ func initServer[TClient any](ctx context.Context, opts *initServerOpts, createCl
 
 	opts.pathPrefix = riverui.NormalizePathPrefix(opts.pathPrefix)
 
+	config := opts.config
+	if config == nil {
+		var err error
+		if config, err = loadServerConfig("", os.LookupEnv, nil); err != nil {
+			return nil, err
+		}
+	}
+
 	var (
-		basicAuthUsername        = os.Getenv("RIVER_BASIC_AUTH_USER")
-		basicAuthPassword        = os.Getenv("RIVER_BASIC_AUTH_PASS")
-		corsOrigins              = strings.Split(os.Getenv("CORS_ORIGINS"), ",")
-		databaseURL              = os.Getenv("DATABASE_URL")
-		devMode                  = envBooleanTrue(os.Getenv("DEV"))
-		jobListHideArgsByDefault = envBooleanTrue(os.Getenv("RIVER_JOB_LIST_HIDE_ARGS_BY_DEFAULT"))
-		host                     = os.Getenv("RIVER_HOST") // may be left empty to bind to all local interfaces
-		liveFS                   = envBooleanTrue(os.Getenv("LIVE_FS"))
-		otelEnabled              = envBooleanTrue(os.Getenv("OTEL_ENABLED"))
-		port                     = cmp.Or(os.Getenv("PORT"), "8080")
+		anonymousRole            = config.Get("RIVER_ANONYMOUS_ROLE")
+		apiTokensEnabled         = envBooleanTrue(config.Get("RIVER_API_TOKENS_ENABLED"))
+		argsSchemaFile           = config.Get("RIVER_ARGS_SCHEMA_FILE")
+		auditSinkName            = config.Get("RIVER_AUDIT_SINK")
+		authorizationPolicyFile  = config.Get("RIVER_AUTHORIZATION_POLICY_FILE")
+		basicAuthFile            = config.Get("RIVER_BASIC_AUTH_FILE")
+		basicAuthUsername        = config.Get("RIVER_BASIC_AUTH_USER")
+		basicAuthPassword        = config.Get("RIVER_BASIC_AUTH_PASS")
+		basicAuthRole            = config.Get("RIVER_BASIC_AUTH_ROLE")
+		corsOrigins              = strings.Split(config.Get("CORS_ORIGINS"), ",")
+		csrfProtection           = envBooleanTrue(config.Get("RIVER_CSRF_PROTECTION"))
+		databaseReplicaURL       = config.Get("DATABASE_REPLICA_URL")
+		databaseURL              = config.Get("DATABASE_URL")
+		dbApplicationName        = config.Get("RIVER_DB_APPLICATION_NAME")
+		dbMaxConnLifetime        = config.Get("RIVER_DB_MAX_CONN_LIFETIME")
+		dbMaxConns               = config.Get("RIVER_DB_MAX_CONNS")
+		dbMinConns               = config.Get("RIVER_DB_MIN_CONNS")
+		dbStatementTimeout       = config.Get("RIVER_DB_STATEMENT_TIMEOUT")
+		devMode                  = envBooleanTrue(config.Get("DEV"))
+		jobListHideArgsByDefault = envBooleanTrue(config.Get("RIVER_JOB_LIST_HIDE_ARGS_BY_DEFAULT"))
+		kindRegistryEnabled      = envBooleanTrue(config.Get("RIVER_KIND_REGISTRY_ENABLED"))
+		host                     = config.Get("RIVER_HOST") // may be left empty to bind to all local interfaces
+		listenSocket             = config.Get("RIVER_LISTEN_SOCKET")
+		listenSocketMode         = config.Get("RIVER_LISTEN_SOCKET_MODE")
+		listenSystemd            = config.Get("RIVER_LISTEN_SYSTEMD")
+		liveFS                   = envBooleanTrue(config.Get("LIVE_FS"))
+		otelEnabled              = envBooleanTrue(config.Get("OTEL_ENABLED"))
+		port                     = cmp.Or(config.Get("PORT"), "8080")
+		proxyAuthEmailHeader     = config.Get("RIVER_PROXY_AUTH_EMAIL_HEADER")
+		proxyAuthGroupsHeader    = config.Get("RIVER_PROXY_AUTH_GROUPS_HEADER")
+		proxyAuthRole            = config.Get("RIVER_PROXY_AUTH_ROLE")
+		proxyAuthTrustedCIDRs    = config.Get("RIVER_PROXY_AUTH_TRUSTED_CIDRS")
+		proxyAuthTrustUnixSocket = envBooleanTrue(config.Get("RIVER_PROXY_AUTH_TRUST_UNIX_SOCKET"))
+		proxyAuthUserHeader      = config.Get("RIVER_PROXY_AUTH_USER_HEADER")
+		rateLimitBurst           = config.Get("RIVER_RATE_LIMIT_BURST")
+		rateLimitMaxConcurrent   = config.Get("RIVER_RATE_LIMIT_MAX_CONCURRENT")
+		rateLimitRPS             = config.Get("RIVER_RATE_LIMIT_RPS")
+		redactionFile            = config.Get("RIVER_REDACTION_FILE")
+		shutdownDelay            = config.Get("RIVER_SHUTDOWN_DELAY")
+		shutdownTimeout          = config.Get("RIVER_SHUTDOWN_TIMEOUT")
+		tlsCert                  = config.Get("RIVER_TLS_CERT")
+		tlsClientCA              = config.Get("RIVER_TLS_CLIENT_CA")
+		tlsClientRole            = config.Get("RIVER_TLS_CLIENT_ROLE")
+		tlsKey                   = config.Get("RIVER_TLS_KEY")
 	)
 
-	if databaseURL == "" && os.Getenv("PGDATABASE") == "" {
-		return nil, errors.New("expect to have DATABASE_URL or database configuration in standard PG* env vars like PGDATABASE/PGHOST/PGPORT/PGUSER/PGPASSWORD")
+	if anonymousRole != "" {
+		if _, err := uiauth.ParseRole(anonymousRole); err != nil {
+			return nil, fmt.Errorf("error parsing RIVER_ANONYMOUS_ROLE: %w", err)
+		}
+	}
+	if basicAuthRole != "" {
+		if _, err := uiauth.ParseRole(basicAuthRole); err != nil {
+			return nil, fmt.Errorf("error parsing RIVER_BASIC_AUTH_ROLE: %w", err)
+		}
+	}
+	if proxyAuthRole != "" {
+		if _, err := uiauth.ParseRole(proxyAuthRole); err != nil {
+			return nil, fmt.Errorf("error parsing RIVER_PROXY_AUTH_ROLE: %w", err)
+		}
+	}
+	if tlsClientRole != "" {
+		if _, err := uiauth.ParseRole(tlsClientRole); err != nil {
+			return nil, fmt.Errorf("error parsing RIVER_TLS_CLIENT_ROLE: %w", err)
+		}
 	}
 
-	poolConfig, err := pgxpool.ParseConfig(databaseURL)
+	if (tlsCert == "") != (tlsKey == "") {
+		return nil, errors.New("RIVER_TLS_CERT and RIVER_TLS_KEY must be set together")
+	}
+
+	proxyAuthEnabled := proxyAuthTrustedCIDRs != "" || proxyAuthTrustUnixSocket
+
+	if tlsClientCA != "" {
+		if tlsCert == "" {
+			return nil, errors.New("RIVER_TLS_CLIENT_CA requires RIVER_TLS_CERT and RIVER_TLS_KEY")
+		}
+		if basicAuthUsername != "" || basicAuthPassword != "" || basicAuthFile != "" || proxyAuthEnabled {
+			return nil, errors.New("RIVER_TLS_CLIENT_CA can't be combined with basic auth or proxy auth")
+		}
+	}
+
+	if proxyAuthEnabled && (basicAuthUsername != "" || basicAuthPassword != "" || basicAuthFile != "") {
+		return nil, errors.New("proxy auth can't be combined with basic auth")
+	}
+
+	var proxyAuthTrustedProxies []netip.Prefix
+	if proxyAuthTrustedCIDRs != "" {
+		var err error
+		if proxyAuthTrustedProxies, err = parseTrustedProxies(proxyAuthTrustedCIDRs); err != nil {
+			return nil, fmt.Errorf("error parsing RIVER_PROXY_AUTH_TRUSTED_CIDRS: %w", err)
+		}
+	}
+
+	poolOpts, err := parseDBPoolOpts(dbMaxConns, dbMinConns, dbMaxConnLifetime, dbApplicationName)
 	if err != nil {
-		return nil, fmt.Errorf("error parsing db config: %w", err)
+		return nil, err
+	}
+	statementTimeout, err := parseNonNegativeDuration("RIVER_DB_STATEMENT_TIMEOUT", dbStatementTimeout, 0)
+	if err != nil {
+		return nil, err
 	}
 
-	dbPool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	rateLimit, err := parseRateLimitOpts(rateLimitRPS, rateLimitBurst, rateLimitMaxConcurrent)
 	if err != nil {
-		return nil, fmt.Errorf("error connecting to db: %w", err)
+		return nil, err
+	}
+
+	shutdownDelayDuration, err := parseNonNegativeDuration("RIVER_SHUTDOWN_DELAY", shutdownDelay, 0)
+	if err != nil {
+		return nil, err
+	}
+	shutdownTimeoutDuration, err := parseNonNegativeDuration("RIVER_SHUTDOWN_TIMEOUT", shutdownTimeout, defaultShutdownTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var tlsConfig *tls.Config
+	if tlsCert != "" {
+		certReloader, err := newCertificateReloader(opts.logger, tlsCert, tlsKey)
+		if err != nil {
+			return nil, err
+		}
+
+		if tlsConfig, err = newServerTLSConfig(certReloader, tlsClientCA); err != nil {
+			return nil, err
+		}
+
+		go certReloader.Watch(ctx)
+	}
+
+	var htpasswdFile *authmiddleware.HtpasswdFile
+	if basicAuthFile != "" {
+		if basicAuthUsername != "" || basicAuthPassword != "" {
+			return nil, errors.New("RIVER_BASIC_AUTH_FILE can't be combined with RIVER_BASIC_AUTH_USER and RIVER_BASIC_AUTH_PASS")
+		}
+
+		if htpasswdFile, err = authmiddleware.LoadHtpasswdFile(basicAuthFile, &authmiddleware.HtpasswdFileOpts{
+			DefaultRole: uiauth.Role(basicAuthRole),
+			Logger:      opts.logger,
+		}); err != nil {
+			return nil, err
+		}
+
+		reloadSignal := make(chan os.Signal, 1)
+		signal.Notify(reloadSignal, syscall.SIGHUP)
+		go htpasswdFile.Watch(ctx, reloadSignal)
 	}
 
-	client, err := createClient(dbPool, &ClientOpts{Schema: opts.schema})
+	switch auditSinkName {
+	case "", auditSinkPostgres, auditSinkSlog:
+	default:
+		return nil, fmt.Errorf("invalid RIVER_AUDIT_SINK %q; must be one of: %s, %s", auditSinkName, auditSinkPostgres, auditSinkSlog)
+	}
+
+	listenerOpts, err := parseListenOpts(listenSocket, listenSocketMode, listenSystemd)
 	if err != nil {
 		return nil, err
 	}
+	if proxyAuthTrustUnixSocket && listenerOpts.socketPath == "" && !listenerOpts.systemd {
+		return nil, errors.New("RIVER_PROXY_AUTH_TRUST_UNIX_SOCKET requires RIVER_LISTEN_SOCKET or RIVER_LISTEN_SYSTEMD")
+	}
+
+	var authorizationPolicy *uiauth.Policy
+	if authorizationPolicyFile != "" {
+		var err error
+		if authorizationPolicy, err = loadAuthorizationPolicy(authorizationPolicyFile); err != nil {
+			return nil, err
+		}
+	}
+
+	var argsSchemas *uischema.Registry
+	if argsSchemaFile != "" {
+		var err error
+		if argsSchemas, err = loadArgsSchemas(argsSchemaFile); err != nil {
+			return nil, err
+		}
+	}
+
+	var redactor uiredact.Redactor
+	if redactionFile != "" {
+		var err error
+		if redactor, err = loadRedactor(redactionFile); err != nil {
+			return nil, err
+		}
+	}
+
+	// Features that store their own state in Postgres or depend on Postgres
+	// replication aren't available with SQLite.
+	if _, isSQLite := sqlitePath(databaseURL); isSQLite {
+		switch {
+		case apiTokensEnabled:
+			return nil, errors.New("RIVER_API_TOKENS_ENABLED requires a Postgres database")
+		case auditSinkName == auditSinkPostgres:
+			return nil, fmt.Errorf("RIVER_AUDIT_SINK=%s requires a Postgres database", auditSinkPostgres)
+		case databaseReplicaURL != "":
+			return nil, errors.New("DATABASE_REPLICA_URL requires a Postgres database")
+		case kindRegistryEnabled:
+			return nil, errors.New("RIVER_KIND_REGISTRY_ENABLED requires a Postgres database")
+		}
+	}
+
+	db, err := openDB(ctx, databaseURL, poolOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var replicaDBPool *pgxpool.Pool
+	if databaseReplicaURL != "" {
+		if replicaDBPool, err = connectDB(ctx, databaseReplicaURL, poolOpts); err != nil {
+			return nil, fmt.Errorf("error connecting to DATABASE_REPLICA_URL: %w", err)
+		}
+	}
+
+	client, err := createClient(db.clientOpts(opts.schema))
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		environmentDBs            []*dbConn
+		environmentReplicaDBPools []*pgxpool.Pool
+		environments              map[string]uiendpoints.Bundle
+	)
+	for _, name := range config.Environments() {
+		settings := environmentSettings(name)
+		databaseReplicaURLSetting, databaseURLSetting, schemaSetting := settings[0].Env, settings[1].Env, settings[2].Env
+
+		environmentDatabaseURL := config.Get(databaseURLSetting)
+		if environmentDatabaseURL == "" {
+			return nil, fmt.Errorf("%s is required for environment %q", databaseURLSetting, name)
+		}
+
+		environmentDB, err := openDB(ctx, environmentDatabaseURL, poolOpts)
+		if err != nil {
+			return nil, fmt.Errorf("error connecting to %s: %w", databaseURLSetting, err)
+		}
+		environmentDBs = append(environmentDBs, environmentDB)
+
+		var environmentReplicaDBPool *pgxpool.Pool
+		if environmentDatabaseReplicaURL := config.Get(databaseReplicaURLSetting); environmentDatabaseReplicaURL != "" {
+			if environmentDB.pool == nil {
+				return nil, fmt.Errorf("%s requires a Postgres database", databaseReplicaURLSetting)
+			}
+			if environmentReplicaDBPool, err = connectDB(ctx, environmentDatabaseReplicaURL, poolOpts); err != nil {
+				return nil, fmt.Errorf("error connecting to %s: %w", databaseReplicaURLSetting, err)
+			}
+			environmentReplicaDBPools = append(environmentReplicaDBPools, environmentReplicaDBPool)
+		}
+
+		environmentClient, err := createClient(environmentDB.clientOpts(config.Get(schemaSetting)))
+		if err != nil {
+			return nil, err
+		}
+
+		if environments == nil {
+			environments = make(map[string]uiendpoints.Bundle)
+		}
+		environments[name] = createBundle(environmentClient, &BundleOpts{JobListHideArgsByDefault: jobListHideArgsByDefault, ReplicaDBPool: environmentReplicaDBPool})
+	}
+
+	auditSink, err := newAuditSink(ctx, auditSinkName, db, opts.schema, opts.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	var kindRegistry uikinds.Registry
+	if kindRegistryEnabled {
+		postgresRegistry := uikinds.NewPostgresRegistry(riverpgxv5.New(db.pool).GetExecutor(), &uikinds.PostgresRegistryOpts{Schema: opts.schema})
+		if err := postgresRegistry.Migrate(ctx); err != nil {
+			return nil, err
+		}
+		kindRegistry = postgresRegistry
+	}
 
 	uiHandler, err := riverui.NewHandler(&riverui.HandlerOpts{
+		AnonymousRole:            uiauth.Role(anonymousRole),
+		ArgsSchemas:              argsSchemas,
+		AuditSink:                auditSink,
+		AuthorizationPolicy:      authorizationPolicy,
+		CSRFProtection:           csrfProtection,
+		CSRFTrustedOrigins:       csrfTrustedOrigins(corsOrigins),
 		DevMode:                  devMode,
-		Endpoints:                createBundle(client),
+		Endpoints:                createBundle(client, &BundleOpts{JobListHideArgsByDefault: jobListHideArgsByDefault, KindRegistry: kindRegistry, ReplicaDBPool: replicaDBPool}),
+		Environments:             environments,
 		JobListHideArgsByDefault: jobListHideArgsByDefault,
 		LiveFS:                   liveFS,
 		Logger:                   opts.logger,
 		Prefix:                   opts.pathPrefix,
+		RateLimit:                rateLimit,
+		Redactor:                 redactor,
+		StatementTimeout:         statementTimeout,
 	})
 	if err != nil {
 		return nil, err
@@ -230,35 +904,148 @@ func initServer[TClient any](ctx context.Context, opts *initServerOpts, createCl
 		apimiddleware.MiddlewareFunc(corsHandler.Handler),
 		apimiddleware.MiddlewareFunc(logHandler),
 	)
+	if apiTokensEnabled {
+		tokenStore := apitoken.NewStore(db.pool, opts.schema)
+		if err := tokenStore.Migrate(ctx); err != nil {
+			return nil, err
+		}
+		// When tokens are the only authentication, a request without one
+		// would otherwise fall through as anonymous (an admin by default), so
+		// require a token unless an anonymous role was configured explicitly.
+		tokensOnly := (basicAuthUsername == "" || basicAuthPassword == "") &&
+			htpasswdFile == nil &&
+			!proxyAuthEnabled &&
+			tlsClientCA == ""
+		middlewareStack.Use(&authmiddleware.APITokenAuth{
+			Authenticator: tokenStore,
+			Logger:        opts.logger,
+			Required:      tokensOnly && anonymousRole == "",
+		})
+	}
 	if basicAuthUsername != "" && basicAuthPassword != "" {
-		middlewareStack.Use(&authmiddleware.BasicAuth{Username: basicAuthUsername, Password: basicAuthPassword})
+		middlewareStack.Use(&authmiddleware.BasicAuth{Username: basicAuthUsername, Password: basicAuthPassword, Role: uiauth.Role(basicAuthRole)})
+	}
+	if htpasswdFile != nil {
+		middlewareStack.Use(&authmiddleware.HtpasswdAuth{File: htpasswdFile})
+	}
+	if proxyAuthEnabled {
+		middlewareStack.Use(&authmiddleware.ProxyAuth{
+			EmailHeader:     proxyAuthEmailHeader,
+			GroupsHeader:    proxyAuthGroupsHeader,
+			Role:            uiauth.Role(proxyAuthRole),
+			TrustedProxies:  proxyAuthTrustedProxies,
+			TrustUnixSocket: proxyAuthTrustUnixSocket,
+			UserHeader:      proxyAuthUserHeader,
+		})
+	}
+	if tlsClientCA != "" {
+		middlewareStack.Use(&authmiddleware.ClientCertAuth{Role: uiauth.Role(tlsClientRole)})
 	}
 
 	return &initServerResult{
-		dbPool: dbPool,
+		db:                        db,
+		environmentDBs:            environmentDBs,
+		environmentReplicaDBPools: environmentReplicaDBPools,
 		httpServer: &http.Server{
 			Addr:              host + ":" + port,
 			Handler:           middlewareStack.Mount(uiHandler),
 			ReadHeaderTimeout: 5 * time.Second,
+			TLSConfig:         tlsConfig,
 		},
-		logger:    opts.logger,
-		uiHandler: uiHandler,
+		listenOpts:      listenerOpts,
+		logger:          opts.logger,
+		replicaDBPool:   replicaDBPool,
+		shutdownDelay:   shutdownDelayDuration,
+		shutdownTimeout: shutdownTimeoutDuration,
+		uiHandler:       uiHandler,
 	}, nil
 }
 
-func startAndListen(ctx context.Context, logger *slog.Logger, initRes *initServerResult) error {
-	defer initRes.dbPool.Close()
+// Starts the UI handler and serves HTTP until the server fails or a value is
+// received on shutdownSignal. On shutdown, health checks are failed for
+// shutdownDelay so that load balancers stop routing new requests, in-flight
+// requests are given up to shutdownTimeout to finish, and the handler's
+// background services are stopped.
+func startAndListen(ctx context.Context, logger *slog.Logger, initRes *initServerResult, shutdownSignal <-chan os.Signal) error {
+	defer initRes.db.Close()
+	if initRes.replicaDBPool != nil {
+		defer initRes.replicaDBPool.Close()
+	}
+	for _, environmentDB := range initRes.environmentDBs {
+		defer environmentDB.Close()
+	}
+	for _, environmentReplicaDBPool := range initRes.environmentReplicaDBPools {
+		defer environmentReplicaDBPool.Close()
+	}
 
-	if err := initRes.uiHandler.Start(ctx); err != nil {
+	handlerCtx, cancelHandler := context.WithCancel(ctx)
+	defer cancelHandler()
+
+	if err := initRes.uiHandler.Start(handlerCtx); err != nil {
 		return err
 	}
+	handlerStopped := initRes.uiHandler.Stopped()
 
-	logger.InfoContext(ctx, "Starting server", slog.String("addr", initRes.httpServer.Addr))
+	listener, err := initRes.listenOpts.listen(ctx, initRes.httpServer.Addr)
+	if err != nil {
+		cancelHandler()
+		<-handlerStopped
+		return fmt.Errorf("error listening: %w", err)
+	}
 
-	err := initRes.httpServer.ListenAndServe()
-	if err != nil && !errors.Is(err, http.ErrServerClosed) {
-		return err
+	logger.InfoContext(ctx, "Starting server",
+		slog.String("addr", listener.Addr().String()),
+		slog.String("network", listener.Addr().Network()),
+		slog.Bool("tls", initRes.httpServer.TLSConfig != nil),
+	)
+
+	listenErrChan := make(chan error, 1)
+	go func() {
+		if initRes.httpServer.TLSConfig != nil {
+			// Certificates are served by TLSConfig.GetCertificate.
+			listenErrChan <- initRes.httpServer.ServeTLS(listener, "", "")
+			return
+		}
+		listenErrChan <- initRes.httpServer.Serve(listener)
+	}()
+
+	select {
+	case err := <-listenErrChan:
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+
+	case sig := <-shutdownSignal:
+		logger.InfoContext(ctx, "Received signal; shutting down",
+			slog.String("signal", sig.String()),
+			slog.Duration("shutdown_delay", initRes.shutdownDelay),
+			slog.Duration("shutdown_timeout", initRes.shutdownTimeout),
+		)
+	}
+
+	initRes.uiHandler.Drain()
+
+	if initRes.shutdownDelay > 0 {
+		select {
+		case <-time.After(initRes.shutdownDelay):
+		case <-shutdownSignal:
+			logger.InfoContext(ctx, "Received second signal; skipping shutdown delay")
+		}
+	}
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.WithoutCancel(ctx), initRes.shutdownTimeout)
+	defer cancelShutdown()
+
+	shutdownErr := initRes.httpServer.Shutdown(shutdownCtx)
+
+	cancelHandler()
+	<-handlerStopped
+
+	if shutdownErr != nil {
+		return fmt.Errorf("error waiting for in-flight requests to finish: %w", shutdownErr)
 	}
 
+	logger.InfoContext(ctx, "Server shut down")
 	return nil
 }
@@ -0,0 +1,59 @@
+//go:build sqlite
+
+// Like SQLite support in the riverui command, these tests need a SQLite
+// driver that isn't a dependency of this module, so they're only built with
+// the `sqlite` tag after adding it:
+//
+//	go get modernc.org/sqlite github.com/riverqueue/river/riverdriver/riversqlite
+//	go test -tags sqlite -run SQLite ./internal/riveruicmd
+
+package riveruicmd
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+
+	"github.com/riverqueue/river"
+	"github.com/riverqueue/river/riverdriver/riversqlite"
+	"github.com/riverqueue/river/rivershared/riversharedtest"
+
+	"riverqueue.com/riverui"
+	"riverqueue.com/riverui/uiendpoints"
+)
+
+func TestInitServerSQLite(t *testing.T) { //nolint:tparallel
+	// Cannot be parallelized because of Setenv calls.
+	ctx := context.Background()
+
+	t.Setenv("DEV", "true")
+	t.Setenv("DATABASE_URL", "sqlite://"+filepath.Join(t.TempDir(), "river.sqlite3"))
+
+	t.Run("PassesClientOptions", func(t *testing.T) {
+		const schema = "river_custom"
+
+		var receivedOpts *ClientOpts
+		initRes, err := initServer(ctx, &initServerOpts{
+			logger:     riversharedtest.Logger(t),
+			pathPrefix: "/",
+			schema:     schema,
+		},
+			func(opts *ClientOpts) (*river.Client[*sql.Tx], error) {
+				receivedOpts = opts
+				return river.NewClient(riversqlite.New(opts.SQLiteDB), &river.Config{})
+			},
+			func(client *river.Client[*sql.Tx], _ *BundleOpts) uiendpoints.Bundle {
+				return riverui.NewEndpoints(client, nil)
+			},
+		)
+		require.NoError(t, err)
+		t.Cleanup(initRes.db.Close)
+		require.Nil(t, receivedOpts.DBPool)
+		require.Equal(t, schema, receivedOpts.Schema)
+		require.NotNil(t, receivedOpts.SQLiteDB)
+	})
+}
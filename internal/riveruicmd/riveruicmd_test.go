@@ -5,22 +5,31 @@ import (
 	"context"
 	"encoding/json"
 	"log/slog"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
 	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/require"
 
 	"github.com/riverqueue/river"
 	"github.com/riverqueue/river/riverdriver/riverpgxv5"
 	"github.com/riverqueue/river/rivershared/riversharedtest"
+	"github.com/riverqueue/river/rivertype"
 
 	"riverqueue.com/riverui"
+	"riverqueue.com/riverui/uiaudit"
+	"riverqueue.com/riverui/uiauth"
 	"riverqueue.com/riverui/uiendpoints"
+	"riverqueue.com/riverui/uiredact"
 )
 
 func TestInitServer(t *testing.T) { //nolint:tparallel
@@ -42,15 +51,15 @@ func TestInitServer(t *testing.T) { //nolint:tparallel
 			logger:     riversharedtest.Logger(t),
 			pathPrefix: "/",
 		},
-			func(dbPool *pgxpool.Pool, opts *ClientOpts) (*river.Client[pgx.Tx], error) {
-				return river.NewClient(riverpgxv5.New(dbPool), &river.Config{Schema: opts.Schema})
+			func(opts *ClientOpts) (*river.Client[pgx.Tx], error) {
+				return river.NewClient(riverpgxv5.New(opts.DBPool), &river.Config{Schema: opts.Schema})
 			},
-			func(client *river.Client[pgx.Tx]) uiendpoints.Bundle {
+			func(client *river.Client[pgx.Tx], _ *BundleOpts) uiendpoints.Bundle {
 				return riverui.NewEndpoints(client, nil)
 			},
 		)
 		require.NoError(t, err)
-		t.Cleanup(initRes.dbPool.Close)
+		t.Cleanup(initRes.db.Close)
 
 		return initRes, &testBundle{}
 	}
@@ -59,7 +68,7 @@ func TestInitServer(t *testing.T) { //nolint:tparallel
 		t.Parallel()
 		initRes, _ := setup(t)
 
-		_, err := initRes.dbPool.Exec(ctx, "SELECT 1")
+		_, err := initRes.db.pool.Exec(ctx, "SELECT 1")
 		require.NoError(t, err)
 	})
 
@@ -80,7 +89,7 @@ func TestInitServer(t *testing.T) { //nolint:tparallel
 
 		initRes, _ := setup(t)
 
-		_, err = initRes.dbPool.Exec(ctx, "SELECT 1")
+		_, err = initRes.db.pool.Exec(ctx, "SELECT 1")
 		require.NoError(t, err)
 	})
 
@@ -95,17 +104,19 @@ func TestInitServer(t *testing.T) { //nolint:tparallel
 			pathPrefix: "/",
 			schema:     schema,
 		},
-			func(dbPool *pgxpool.Pool, opts *ClientOpts) (*river.Client[pgx.Tx], error) {
+			func(opts *ClientOpts) (*river.Client[pgx.Tx], error) {
 				receivedOpts = opts
-				return river.NewClient(riverpgxv5.New(dbPool), &river.Config{})
+				return river.NewClient(riverpgxv5.New(opts.DBPool), &river.Config{})
 			},
-			func(client *river.Client[pgx.Tx]) uiendpoints.Bundle {
+			func(client *river.Client[pgx.Tx], _ *BundleOpts) uiendpoints.Bundle {
 				return riverui.NewEndpoints(client, nil)
 			},
 		)
 		require.NoError(t, err)
-		t.Cleanup(initRes.dbPool.Close)
-		require.Equal(t, &ClientOpts{Schema: schema}, receivedOpts)
+		t.Cleanup(initRes.db.Close)
+		require.NotNil(t, receivedOpts.DBPool)
+		require.Equal(t, schema, receivedOpts.Schema)
+		require.Nil(t, receivedOpts.SQLiteDB)
 	})
 
 	t.Run("JobListHideArgsByDefault", func(t *testing.T) {
@@ -206,15 +217,15 @@ func TestSilentHealthchecks_SuppressesLogs(t *testing.T) {
 			pathPrefix:         prefix,
 			silentHealthChecks: silent,
 		},
-			func(dbPool *pgxpool.Pool, opts *ClientOpts) (*river.Client[pgx.Tx], error) {
-				return river.NewClient(riverpgxv5.New(dbPool), &river.Config{Schema: opts.Schema})
+			func(opts *ClientOpts) (*river.Client[pgx.Tx], error) {
+				return river.NewClient(riverpgxv5.New(opts.DBPool), &river.Config{Schema: opts.Schema})
 			},
-			func(client *river.Client[pgx.Tx]) uiendpoints.Bundle {
+			func(client *river.Client[pgx.Tx], _ *BundleOpts) uiendpoints.Bundle {
 				return riverui.NewEndpoints(client, nil)
 			},
 		)
 		require.NoError(t, err)
-		t.Cleanup(initRes.dbPool.Close)
+		t.Cleanup(initRes.db.Close)
 		return initRes
 	}
 
@@ -258,3 +269,419 @@ func TestSilentHealthchecks_SuppressesLogs(t *testing.T) {
 	require.Equal(t, http.StatusOK, recorder.Code)
 	require.NotEmpty(t, memoryHandler.records)
 }
+
+func TestStartAndListen_GracefulShutdown(t *testing.T) {
+	// Cannot be parallelized because of Setenv calls.
+	var (
+		ctx         = context.Background()
+		databaseURL = cmp.Or(os.Getenv("TEST_DATABASE_URL"), "postgres://localhost/river_test")
+	)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port) //nolint:forcetypeassert
+	require.NoError(t, listener.Close())
+
+	t.Setenv("DEV", "true")
+	t.Setenv("DATABASE_URL", databaseURL)
+	t.Setenv("PORT", port)
+	t.Setenv("RIVER_HOST", "127.0.0.1")
+	t.Setenv("RIVER_SHUTDOWN_DELAY", "1m")
+
+	initRes, err := initServer(ctx, &initServerOpts{
+		logger:     riversharedtest.Logger(t),
+		pathPrefix: "/",
+	},
+		func(opts *ClientOpts) (*river.Client[pgx.Tx], error) {
+			return river.NewClient(riverpgxv5.New(opts.DBPool), &river.Config{Schema: opts.Schema})
+		},
+		func(client *river.Client[pgx.Tx], _ *BundleOpts) uiendpoints.Bundle {
+			return riverui.NewEndpoints(client, nil)
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, initRes.shutdownDelay)
+	require.Equal(t, defaultShutdownTimeout, initRes.shutdownTimeout)
+
+	var (
+		errChan        = make(chan error, 1)
+		shutdownSignal = make(chan os.Signal, 1)
+	)
+	go func() {
+		errChan <- startAndListen(ctx, riversharedtest.Logger(t), initRes, shutdownSignal)
+	}()
+
+	healthCheckStatus := func() int {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://127.0.0.1:"+port+"/api/health-checks/minimal", nil)
+		require.NoError(t, err)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return 0
+		}
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	require.Eventually(t, func() bool { return healthCheckStatus() == http.StatusOK }, 5*time.Second, 10*time.Millisecond)
+
+	// Health checks fail while the server keeps serving during the shutdown
+	// delay.
+	shutdownSignal <- syscall.SIGTERM
+	require.Eventually(t, func() bool { return healthCheckStatus() == http.StatusServiceUnavailable }, 5*time.Second, 10*time.Millisecond)
+
+	// A second signal skips the rest of the delay.
+	shutdownSignal <- syscall.SIGTERM
+	select {
+	case err := <-errChan:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "Timed out waiting for server to shut down")
+	}
+
+	select {
+	case <-initRes.uiHandler.Stopped():
+	default:
+		require.FailNow(t, "Expected UI handler to be stopped")
+	}
+}
+
+func TestParseNonNegativeDuration(t *testing.T) {
+	t.Parallel()
+
+	duration, err := parseNonNegativeDuration("RIVER_SHUTDOWN_TIMEOUT", "", 5*time.Second)
+	require.NoError(t, err)
+	require.Equal(t, 5*time.Second, duration)
+
+	duration, err = parseNonNegativeDuration("RIVER_SHUTDOWN_TIMEOUT", "1m30s", 5*time.Second)
+	require.NoError(t, err)
+	require.Equal(t, 90*time.Second, duration)
+
+	_, err = parseNonNegativeDuration("RIVER_SHUTDOWN_TIMEOUT", "-1s", 0)
+	require.EqualError(t, err, `invalid RIVER_SHUTDOWN_TIMEOUT "-1s": must be a non-negative duration like 10s`)
+
+	_, err = parseNonNegativeDuration("RIVER_SHUTDOWN_TIMEOUT", "soon", 0)
+	require.EqualError(t, err, `invalid RIVER_SHUTDOWN_TIMEOUT "soon": must be a non-negative duration like 10s`)
+}
+
+func TestParseDBPoolOpts(t *testing.T) {
+	t.Parallel()
+
+	poolOpts, err := parseDBPoolOpts("", "", "", "")
+	require.NoError(t, err)
+	require.Equal(t, &dbPoolOpts{}, poolOpts)
+
+	poolOpts, err = parseDBPoolOpts("10", "2", "30m", "riverui-prod")
+	require.NoError(t, err)
+	require.Equal(t, &dbPoolOpts{
+		applicationName: "riverui-prod",
+		maxConnLifetime: 30 * time.Minute,
+		maxConns:        10,
+		minConns:        2,
+	}, poolOpts)
+
+	_, err = parseDBPoolOpts("0", "", "", "")
+	require.EqualError(t, err, `invalid RIVER_DB_MAX_CONNS "0": must be a positive integer`)
+
+	_, err = parseDBPoolOpts("", "-1", "", "")
+	require.EqualError(t, err, `invalid RIVER_DB_MIN_CONNS "-1": must be a non-negative integer`)
+
+	_, err = parseDBPoolOpts("2", "5", "", "")
+	require.EqualError(t, err, "RIVER_DB_MIN_CONNS (5) can't be greater than RIVER_DB_MAX_CONNS (2)")
+
+	_, err = parseDBPoolOpts("", "", "forever", "")
+	require.EqualError(t, err, `invalid RIVER_DB_MAX_CONN_LIFETIME "forever": must be a non-negative duration like 10s`)
+}
+
+func TestLoadAuthorizationPolicy(t *testing.T) {
+	t.Parallel()
+
+	writeFile := func(t *testing.T, contents string) string {
+		t.Helper()
+
+		path := filepath.Join(t.TempDir(), "policy.json")
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+		return path
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		t.Parallel()
+
+		policy, err := loadAuthorizationPolicy(writeFile(t, `{
+			"filter_lists": true,
+			"rules": [
+				{"subjects": ["team-billing"], "actions": ["job:cancel", "job:retry"], "queues": ["billing_*"], "kinds": ["invoice.*"]}
+			]
+		}`))
+		require.NoError(t, err)
+		require.Equal(t, &uiauth.Policy{
+			FilterLists: true,
+			Rules: []*uiauth.Rule{
+				{
+					Actions:  []uiauth.Action{uiauth.ActionJobCancel, uiauth.ActionJobRetry},
+					Kinds:    []string{"invoice.*"},
+					Queues:   []string{"billing_*"},
+					Subjects: []string{"team-billing"},
+				},
+			},
+		}, policy)
+	})
+
+	t.Run("UnknownField", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := loadAuthorizationPolicy(writeFile(t, `{"rulez": []}`))
+		require.ErrorContains(t, err, `unknown field "rulez"`)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := loadAuthorizationPolicy(writeFile(t, `{"rules": [{"actions": ["job:cancel"]}]}`))
+		require.ErrorContains(t, err, "rule 0: at least one subject is required")
+	})
+}
+
+func TestLoadRedactor(t *testing.T) {
+	t.Parallel()
+
+	writeFile := func(t *testing.T, contents string) string {
+		t.Helper()
+
+		path := filepath.Join(t.TempDir(), "redaction.json")
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+		return path
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		t.Parallel()
+
+		redactor, err := loadRedactor(writeFile(t, `{
+			"args": ["$..password"],
+			"kinds": {"send_email": {"args": ["$.to"], "errors": true}}
+		}`))
+		require.NoError(t, err)
+
+		job, err := uiredact.Apply(t.Context(), redactor, &rivertype.JobRow{
+			EncodedArgs: []byte(`{"password":"secret","to":"a@example.com"}`),
+			Kind:        "send_email",
+		})
+		require.NoError(t, err)
+		require.JSONEq(t, `{"password":"[REDACTED]","to":"[REDACTED]"}`, string(job.EncodedArgs))
+	})
+
+	t.Run("UnknownField", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := loadRedactor(writeFile(t, `{"argz": []}`))
+		require.ErrorContains(t, err, `unknown field "argz"`)
+	})
+
+	t.Run("InvalidPath", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := loadRedactor(writeFile(t, `{"args": ["password"]}`))
+		require.ErrorContains(t, err, `invalid args path "password": must start with`)
+	})
+}
+
+func TestNewAuditSink(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("None", func(t *testing.T) {
+		t.Parallel()
+
+		auditSink, err := newAuditSink(ctx, "", &dbConn{}, "", riversharedtest.Logger(t))
+		require.NoError(t, err)
+		require.Nil(t, auditSink)
+	})
+
+	t.Run("Slog", func(t *testing.T) {
+		t.Parallel()
+
+		auditSink, err := newAuditSink(ctx, auditSinkSlog, &dbConn{}, "", riversharedtest.Logger(t))
+		require.NoError(t, err)
+		require.IsType(t, &uiaudit.SlogSink{}, auditSink)
+	})
+
+	t.Run("PostgresWithoutPostgres", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := newAuditSink(ctx, auditSinkPostgres, &dbConn{}, "", riversharedtest.Logger(t))
+		require.EqualError(t, err, "RIVER_AUDIT_SINK=postgres requires a Postgres database")
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := newAuditSink(ctx, "kafka", &dbConn{}, "", riversharedtest.Logger(t))
+		require.EqualError(t, err, `invalid RIVER_AUDIT_SINK "kafka"; must be one of: postgres, slog`)
+	})
+}
+
+func TestLoadArgsSchemas(t *testing.T) {
+	t.Parallel()
+
+	writeFile := func(t *testing.T, contents string) string {
+		t.Helper()
+
+		path := filepath.Join(t.TempDir(), "args_schemas.json")
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+		return path
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		t.Parallel()
+
+		registry, err := loadArgsSchemas(writeFile(t, `{
+			"send_email": {"type": "object", "required": ["to"]},
+			"sync_all": true
+		}`))
+		require.NoError(t, err)
+		require.Equal(t, []string{"send_email", "sync_all"}, registry.Kinds())
+
+		require.NoError(t, registry.Validate("send_email", []byte(`{"to":"a@example.com"}`)))
+		require.EqualError(t, registry.Validate("send_email", []byte(`{}`)), "args don't match schema: $.to: is required")
+	})
+
+	t.Run("NotObject", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := loadArgsSchemas(writeFile(t, `[]`))
+		require.ErrorContains(t, err, "error parsing args schema file")
+	})
+
+	t.Run("InvalidSchema", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := loadArgsSchemas(writeFile(t, `{"send_email": {"type": "text"}}`))
+		require.ErrorContains(t, err, `kind "send_email": invalid schema at $: invalid type "text"`)
+	})
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Success", func(t *testing.T) {
+		t.Parallel()
+
+		prefixes, err := parseTrustedProxies("10.0.0.0/8, 127.0.0.1,::1,192.168.1.7/24,")
+		require.NoError(t, err)
+		require.Equal(t, []netip.Prefix{
+			netip.MustParsePrefix("10.0.0.0/8"),
+			netip.MustParsePrefix("127.0.0.1/32"),
+			netip.MustParsePrefix("::1/128"),
+			netip.MustParsePrefix("192.168.1.0/24"),
+		}, prefixes)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := parseTrustedProxies("10.0.0.0/8,not-an-ip")
+		require.ErrorContains(t, err, `ParseAddr("not-an-ip")`)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := parseTrustedProxies(" , ")
+		require.EqualError(t, err, "at least one CIDR is required")
+	})
+}
+
+func TestInitServerProxyAuthTrustUnixSocket(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	initServerWithEnv := func(t *testing.T, env map[string]string) error {
+		t.Helper()
+
+		config, err := loadServerConfig("", func(key string) (string, bool) {
+			val, ok := env[key]
+			return val, ok
+		}, nil)
+		require.NoError(t, err)
+
+		_, err = initServer(ctx, &initServerOpts{
+			config:     config,
+			logger:     riversharedtest.Logger(t),
+			pathPrefix: "/",
+		},
+			func(opts *ClientOpts) (*river.Client[pgx.Tx], error) {
+				return river.NewClient(riverpgxv5.New(opts.DBPool), &river.Config{Schema: opts.Schema})
+			},
+			func(client *river.Client[pgx.Tx], _ *BundleOpts) uiendpoints.Bundle {
+				return riverui.NewEndpoints(client, nil)
+			},
+		)
+		return err
+	}
+
+	t.Run("RequiresUnixSocket", func(t *testing.T) {
+		t.Parallel()
+
+		err := initServerWithEnv(t, map[string]string{
+			"RIVER_PROXY_AUTH_TRUST_UNIX_SOCKET": "true",
+		})
+		require.EqualError(t, err, "RIVER_PROXY_AUTH_TRUST_UNIX_SOCKET requires RIVER_LISTEN_SOCKET or RIVER_LISTEN_SYSTEMD")
+	})
+
+	t.Run("CantBeCombinedWithBasicAuth", func(t *testing.T) {
+		t.Parallel()
+
+		err := initServerWithEnv(t, map[string]string{
+			"RIVER_BASIC_AUTH_PASS":              "pass",
+			"RIVER_BASIC_AUTH_USER":              "user",
+			"RIVER_LISTEN_SOCKET":                "/run/riverui.sock",
+			"RIVER_PROXY_AUTH_TRUST_UNIX_SOCKET": "true",
+		})
+		require.EqualError(t, err, "proxy auth can't be combined with basic auth")
+	})
+}
+
+func TestCSRFTrustedOrigins(t *testing.T) {
+	t.Parallel()
+
+	require.Nil(t, csrfTrustedOrigins([]string{""}))
+	require.Equal(t,
+		[]string{"https://a.example.com", "https://b.example.com"},
+		csrfTrustedOrigins([]string{"https://a.example.com", " https://b.example.com ", "*", "https://*.example.com"}),
+	)
+}
+
+func TestParseRateLimitOpts(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Unset", func(t *testing.T) {
+		t.Parallel()
+
+		rateLimit, err := parseRateLimitOpts("", "", "")
+		require.NoError(t, err)
+		require.Nil(t, rateLimit)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		t.Parallel()
+
+		rateLimit, err := parseRateLimitOpts("2.5", "10", "20")
+		require.NoError(t, err)
+		require.Equal(t, &riverui.RateLimitOpts{Burst: 10, MaxConcurrent: 20, RequestsPerSecond: 2.5}, rateLimit)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := parseRateLimitOpts("fast", "", "")
+		require.EqualError(t, err, `invalid RIVER_RATE_LIMIT_RPS "fast": must be a non-negative number`)
+
+		_, err = parseRateLimitOpts("", "-1", "")
+		require.EqualError(t, err, `invalid RIVER_RATE_LIMIT_BURST "-1": must be a non-negative integer`)
+
+		_, err = parseRateLimitOpts("", "", "1.5")
+		require.EqualError(t, err, `invalid RIVER_RATE_LIMIT_MAX_CONCURRENT "1.5": must be a non-negative integer`)
+	})
+}
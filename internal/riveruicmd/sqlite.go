@@ -0,0 +1,66 @@
+package riveruicmd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	// Name under which a database/sql driver for SQLite must be registered
+	// for the binary to open SQLite databases, like the one from
+	// modernc.org/sqlite.
+	sqliteDriverName = "sqlite"
+
+	// Prefix of a DATABASE_URL that refers to a SQLite database file rather
+	// than Postgres, like `sqlite://river.sqlite3` for a relative path or
+	// `sqlite:///var/lib/river.sqlite3` for an absolute one.
+	sqliteURLPrefix = "sqlite://"
+)
+
+// Returns the path of the SQLite database in a database URL like
+// `sqlite://river.sqlite3`, and false if it's not a SQLite URL. Anything after
+// the path like `?_pragma=busy_timeout(5000)` is passed on to the driver.
+func sqlitePath(databaseURL string) (string, bool) {
+	return strings.CutPrefix(databaseURL, sqliteURLPrefix)
+}
+
+// Opens the SQLite database at path, applying any pool settings from poolOpts
+// that are meaningful for SQLite.
+func openSQLite(ctx context.Context, path string, poolOpts *dbPoolOpts) (*sql.DB, error) {
+	if path == "" {
+		return nil, fmt.Errorf("expect a path to a SQLite database file after %q", sqliteURLPrefix)
+	}
+
+	if !slices.Contains(sql.Drivers(), sqliteDriverName) {
+		return nil, errors.New("this build of riverui doesn't support SQLite databases")
+	}
+
+	db, err := sql.Open(sqliteDriverName, path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening SQLite database: %w", err)
+	}
+
+	// SQLite allows only one writer at a time, so concurrent connections
+	// mostly produce busy errors. Use one unless configured otherwise.
+	db.SetMaxOpenConns(1)
+	if poolOpts.maxConns > 0 {
+		db.SetMaxOpenConns(int(poolOpts.maxConns))
+	}
+	if poolOpts.minConns > 0 {
+		db.SetMaxIdleConns(int(poolOpts.minConns))
+	}
+	if poolOpts.maxConnLifetime > 0 {
+		db.SetConnMaxLifetime(poolOpts.maxConnLifetime)
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("error opening SQLite database: %w", err)
+	}
+
+	return db, nil
+}
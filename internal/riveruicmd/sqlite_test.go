@@ -0,0 +1,50 @@
+package riveruicmd
+
+import (
+	"database/sql"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLitePath(t *testing.T) {
+	t.Parallel()
+
+	path, ok := sqlitePath("sqlite://river.sqlite3")
+	require.True(t, ok)
+	require.Equal(t, "river.sqlite3", path)
+
+	path, ok = sqlitePath("sqlite:///var/lib/river.sqlite3?_pragma=busy_timeout(5000)")
+	require.True(t, ok)
+	require.Equal(t, "/var/lib/river.sqlite3?_pragma=busy_timeout(5000)", path)
+
+	_, ok = sqlitePath("postgres://localhost/river")
+	require.False(t, ok)
+}
+
+func TestOpenSQLite(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+
+	t.Run("MissingPath", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := openSQLite(ctx, "", &dbPoolOpts{})
+		require.EqualError(t, err, `expect a path to a SQLite database file after "sqlite://"`)
+	})
+
+	t.Run("DriverNotRegistered", func(t *testing.T) {
+		t.Parallel()
+
+		// The riveruicmd package doesn't register a SQLite driver itself, but
+		// its tests built with the `sqlite` tag do.
+		if slices.Contains(sql.Drivers(), sqliteDriverName) {
+			t.Skip("SQLite driver is registered")
+		}
+
+		_, err := openSQLite(ctx, "river.sqlite3", &dbPoolOpts{})
+		require.EqualError(t, err, "this build of riverui doesn't support SQLite databases")
+	})
+}
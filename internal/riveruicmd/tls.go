@@ -0,0 +1,185 @@
+package riveruicmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const defaultCertificatePollInterval = 10 * time.Second
+
+// certificateReloader serves a TLS certificate and key loaded from files,
+// reloading them when they change on disk so that rotated certificates (e.g.
+// from cert-manager) are picked up without a restart.
+type certificateReloader struct {
+	certPath     string
+	keyPath      string
+	logger       *slog.Logger
+	pollInterval time.Duration
+
+	mu          sync.RWMutex
+	cert        *tls.Certificate
+	certModTime time.Time
+	keyModTime  time.Time
+}
+
+func newCertificateReloader(logger *slog.Logger, certPath, keyPath string) (*certificateReloader, error) {
+	reloader := &certificateReloader{
+		certPath:     certPath,
+		keyPath:      keyPath,
+		logger:       logger,
+		pollInterval: defaultCertificatePollInterval,
+	}
+	if err := reloader.Reload(); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+// GetCertificate returns the current certificate. It's suitable for use as
+// tls.Config.GetCertificate.
+func (r *certificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert, nil
+}
+
+// Reload reloads the certificate and key. If they can't be loaded, an error is
+// returned and the previously loaded certificate is kept.
+func (r *certificateReloader) Reload() error {
+	certStat, err := os.Stat(r.certPath)
+	if err != nil {
+		return fmt.Errorf("error reading TLS certificate: %w", err)
+	}
+	keyStat, err := os.Stat(r.keyPath)
+	if err != nil {
+		return fmt.Errorf("error reading TLS key: %w", err)
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certPath, r.keyPath)
+	if err != nil {
+		return fmt.Errorf("error loading TLS certificate and key: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cert = &cert
+	r.certModTime = certStat.ModTime()
+	r.keyModTime = keyStat.ModTime()
+	return nil
+}
+
+// Watch reloads the certificate whenever its files change on disk until ctx is
+// done. Errors reloading are logged and the previous certificate is kept. A
+// certificate and key that are mid-rotation and don't match fail to load, but
+// are retried on the next poll.
+func (r *certificateReloader) Watch(ctx context.Context) {
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			certStat, certErr := os.Stat(r.certPath)
+			keyStat, keyErr := os.Stat(r.keyPath)
+			if err := errors.Join(certErr, keyErr); err != nil {
+				r.logger.ErrorContext(ctx, "Error checking TLS certificate for changes", slog.String("error", err.Error()))
+				continue
+			}
+
+			r.mu.RLock()
+			changed := !certStat.ModTime().Equal(r.certModTime) || !keyStat.ModTime().Equal(r.keyModTime)
+			r.mu.RUnlock()
+
+			if !changed {
+				continue
+			}
+
+			if err := r.Reload(); err != nil {
+				r.logger.ErrorContext(ctx, "Error reloading TLS certificate; keeping previously loaded certificate", slog.String("error", err.Error()))
+				continue
+			}
+			r.logger.InfoContext(ctx, "Reloaded TLS certificate", slog.String("path", r.certPath))
+		}
+	}
+}
+
+// Builds the server's TLS config. If clientCAPath is set, client certificates
+// are verified against the CA bundle it contains. Clients that don't present a
+// certificate are still allowed to connect so that health check probes work,
+// and are rejected by authmiddleware.ClientCertAuth instead.
+func newServerTLSConfig(reloader *certificateReloader, clientCAPath string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		GetCertificate: reloader.GetCertificate,
+		MinVersion:     tls.VersionTLS12,
+	}
+
+	if clientCAPath != "" {
+		caPEM, err := os.ReadFile(clientCAPath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading TLS client CA bundle: %w", err)
+		}
+
+		clientCAs := x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in TLS client CA bundle %q", clientCAPath)
+		}
+
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		tlsConfig.ClientCAs = clientCAs
+	}
+
+	return tlsConfig, nil
+}
+
+// Returns an HTTP client for the `-healthcheck` probe of a server using the
+// given certificate. The probe connects to the server by address rather than
+// the name the certificate was issued for, so instead of verifying the usual
+// way, the server must present exactly this certificate.
+func newHealthCheckTLSClient(certPath string) (*http.Client, error) {
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading TLS certificate: %w", err)
+	}
+
+	var expectedLeaf []byte
+	for rest := certPEM; expectedLeaf == nil; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			return nil, fmt.Errorf("no certificates found in %q", certPath)
+		}
+		if block.Type == "CERTIFICATE" {
+			expectedLeaf = block.Bytes
+		}
+	}
+
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true, //nolint:gosec // verified by pinning in VerifyConnection instead
+				MinVersion:         tls.VersionTLS12,
+				VerifyConnection: func(state tls.ConnectionState) error {
+					if len(state.PeerCertificates) < 1 || !bytes.Equal(state.PeerCertificates[0].Raw, expectedLeaf) {
+						return errors.New("server presented a certificate other than the one in RIVER_TLS_CERT")
+					}
+					return nil
+				},
+			},
+		},
+	}, nil
+}
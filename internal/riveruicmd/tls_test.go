@@ -0,0 +1,311 @@
+package riveruicmd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/riverqueue/river/rivershared/riversharedtest"
+
+	"riverqueue.com/riverui/internal/authmiddleware"
+	"riverqueue.com/riverui/uiauth"
+)
+
+func TestCertificateReloader(t *testing.T) {
+	t.Parallel()
+
+	writeCert := func(t *testing.T, dir, commonName string) (string, string) {
+		t.Helper()
+
+		certPEM, keyPEM := generateTestCertificate(t, commonName, nil)
+
+		certPath, keyPath := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+		require.NoError(t, os.WriteFile(certPath, certPEM, 0o600))
+		require.NoError(t, os.WriteFile(keyPath, keyPEM, 0o600))
+		return certPath, keyPath
+	}
+
+	leafCommonName := func(t *testing.T, reloader *certificateReloader) string {
+		t.Helper()
+
+		cert, err := reloader.GetCertificate(nil)
+		require.NoError(t, err)
+
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		require.NoError(t, err)
+		return leaf.Subject.CommonName
+	}
+
+	t.Run("ReloadsOnChange", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		certPath, keyPath := writeCert(t, dir, "first")
+
+		reloader, err := newCertificateReloader(riversharedtest.Logger(t), certPath, keyPath)
+		require.NoError(t, err)
+		reloader.pollInterval = 10 * time.Millisecond
+		require.Equal(t, "first", leafCommonName(t, reloader))
+
+		go reloader.Watch(t.Context())
+
+		writeCert(t, dir, "second")
+		future := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(certPath, future, future))
+		require.NoError(t, os.Chtimes(keyPath, future, future))
+
+		require.Eventually(t, func() bool { return leafCommonName(t, reloader) == "second" }, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("KeepsCertificateOnInvalidReload", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		certPath, keyPath := writeCert(t, dir, "first")
+
+		reloader, err := newCertificateReloader(riversharedtest.Logger(t), certPath, keyPath)
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(keyPath, []byte("not a key"), 0o600))
+		require.ErrorContains(t, reloader.Reload(), "error loading TLS certificate and key")
+		require.Equal(t, "first", leafCommonName(t, reloader))
+	})
+
+	t.Run("MissingFiles", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := newCertificateReloader(riversharedtest.Logger(t), filepath.Join(t.TempDir(), "missing.crt"), "missing.key")
+		require.ErrorContains(t, err, "error reading TLS certificate")
+	})
+}
+
+func TestNewServerTLSConfig(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	certPEM, keyPEM := generateTestCertificate(t, "server", nil)
+	certPath, keyPath := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	require.NoError(t, os.WriteFile(certPath, certPEM, 0o600))
+	require.NoError(t, os.WriteFile(keyPath, keyPEM, 0o600))
+
+	reloader, err := newCertificateReloader(riversharedtest.Logger(t), certPath, keyPath)
+	require.NoError(t, err)
+
+	t.Run("WithoutClientCA", func(t *testing.T) {
+		t.Parallel()
+
+		tlsConfig, err := newServerTLSConfig(reloader, "")
+		require.NoError(t, err)
+		require.Equal(t, tls.NoClientCert, tlsConfig.ClientAuth)
+	})
+
+	t.Run("WithClientCA", func(t *testing.T) {
+		t.Parallel()
+
+		caPath := filepath.Join(t.TempDir(), "ca.crt")
+		require.NoError(t, os.WriteFile(caPath, certPEM, 0o600))
+
+		tlsConfig, err := newServerTLSConfig(reloader, caPath)
+		require.NoError(t, err)
+		require.Equal(t, tls.VerifyClientCertIfGiven, tlsConfig.ClientAuth)
+		require.NotNil(t, tlsConfig.ClientCAs)
+	})
+
+	t.Run("ClientCertificateIdentity", func(t *testing.T) {
+		t.Parallel()
+
+		caCertPEM, caKeyPEM := generateTestCertificate(t, "Test CA", nil)
+		caCert, err := tls.X509KeyPair(caCertPEM, caKeyPEM)
+		require.NoError(t, err)
+
+		caPath := filepath.Join(t.TempDir(), "ca.crt")
+		require.NoError(t, os.WriteFile(caPath, caCertPEM, 0o600))
+
+		tlsConfig, err := newServerTLSConfig(reloader, caPath)
+		require.NoError(t, err)
+
+		server := httptest.NewUnstartedServer((&authmiddleware.ClientCertAuth{}).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(uiauth.IdentityFromContext(r.Context()).Name))
+		})))
+		server.TLS = tlsConfig
+		server.StartTLS()
+		t.Cleanup(server.Close)
+
+		request := func(t *testing.T, clientCert *tls.Certificate) (*http.Response, error) {
+			t.Helper()
+
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+				// Always send the certificate, even if it's not from a CA the
+				// server asked for.
+				GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+					if clientCert == nil {
+						return &tls.Certificate{}, nil
+					}
+					return clientCert, nil
+				},
+				InsecureSkipVerify: true, //nolint:gosec
+				MinVersion:         tls.VersionTLS12,
+			}}}
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL+"/api/jobs", nil)
+			require.NoError(t, err)
+			return client.Do(req)
+		}
+
+		clientCertPEM, clientKeyPEM := generateTestCertificate(t, "deploy-bot", &caCert)
+		clientCert, err := tls.X509KeyPair(clientCertPEM, clientKeyPEM)
+		require.NoError(t, err)
+
+		resp, err := request(t, &clientCert)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "deploy-bot", string(body))
+
+		resp, err = request(t, nil)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		// Certificates from other CAs are rejected during the handshake.
+		untrustedCertPEM, untrustedKeyPEM := generateTestCertificate(t, "intruder", nil)
+		untrustedCert, err := tls.X509KeyPair(untrustedCertPEM, untrustedKeyPEM)
+		require.NoError(t, err)
+
+		_, err = request(t, &untrustedCert) //nolint:bodyclose
+		require.Error(t, err)
+	})
+
+	t.Run("InvalidClientCA", func(t *testing.T) {
+		t.Parallel()
+
+		caPath := filepath.Join(t.TempDir(), "ca.crt")
+		require.NoError(t, os.WriteFile(caPath, []byte("not a certificate"), 0o600))
+
+		_, err := newServerTLSConfig(reloader, caPath)
+		require.ErrorContains(t, err, "no certificates found in TLS client CA bundle")
+	})
+}
+
+func TestNewHealthCheckTLSClient(t *testing.T) {
+	t.Parallel()
+
+	startServer := func(t *testing.T, certPEM, keyPEM []byte) *httptest.Server {
+		t.Helper()
+
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		require.NoError(t, err)
+
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+		server.StartTLS()
+		t.Cleanup(server.Close)
+		return server
+	}
+
+	certPEM, keyPEM := generateTestCertificate(t, "riverui.example.com", nil)
+	certPath := filepath.Join(t.TempDir(), "tls.crt")
+	require.NoError(t, os.WriteFile(certPath, certPEM, 0o600))
+
+	client, err := newHealthCheckTLSClient(certPath)
+	require.NoError(t, err)
+
+	t.Run("MatchingCertificate", func(t *testing.T) {
+		t.Parallel()
+
+		server := startServer(t, certPEM, keyPEM)
+
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("OtherCertificate", func(t *testing.T) {
+		t.Parallel()
+
+		otherCertPEM, otherKeyPEM := generateTestCertificate(t, "riverui.example.com", nil)
+		server := startServer(t, otherCertPEM, otherKeyPEM)
+
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+
+		_, err = client.Do(req) //nolint:bodyclose
+		require.ErrorContains(t, err, "server presented a certificate other than the one in RIVER_TLS_CERT")
+	})
+
+	t.Run("NoCertificateInFile", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "tls.crt")
+		require.NoError(t, os.WriteFile(path, []byte("nothing here"), 0o600))
+
+		_, err := newHealthCheckTLSClient(path)
+		require.ErrorContains(t, err, "no certificates found")
+	})
+}
+
+// Generates a self-signed certificate and key in PEM format for commonName,
+// valid for 127.0.0.1 and localhost. If parent is non-nil, its certificate
+// and key sign the certificate instead.
+func generateTestCertificate(t *testing.T, commonName string, parent *tls.Certificate) ([]byte, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serialNumber, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"localhost"},
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		IsCA:                  parent == nil,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		NotAfter:              time.Now().Add(time.Hour),
+		NotBefore:             time.Now().Add(-time.Hour),
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: commonName},
+	}
+
+	var (
+		parentTemplate     = template
+		signer         any = key
+	)
+	if parent != nil {
+		parentTemplate, err = x509.ParseCertificate(parent.Certificate[0])
+		require.NoError(t, err)
+		signer = parent.PrivateKey
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, parentTemplate, &key.PublicKey, signer)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
@@ -0,0 +1,167 @@
+package riveruicmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"riverqueue.com/riverui/internal/apitoken"
+	"riverqueue.com/riverui/uiauth"
+)
+
+const tokensUsage = `usage: riverui tokens <command> [flags]
+
+Manage API tokens accepted when RIVER_API_TOKENS_ENABLED is set.
+
+commands:
+  create -name NAME [-role ROLE] [-expires-in DURATION]
+  list
+  revoke ID
+
+All commands accept -config to read DATABASE_URL and RIVER_SCHEMA from a
+config file, and -schema to use a non-default database schema.`
+
+// Runs the `tokens` subcommand with the arguments following it, writing
+// output to out. Settings are read from lookupEnv and any config file.
+func runTokensCommand(ctx context.Context, args []string, out io.Writer, lookupEnv func(string) (string, bool)) error {
+	if len(args) < 1 {
+		return errors.New(tokensUsage)
+	}
+
+	flagSet := flag.NewFlagSet("riverui tokens "+args[0], flag.ContinueOnError)
+	flagSet.SetOutput(out)
+
+	var (
+		configPath string
+		schema     string
+	)
+	flagSet.StringVar(&configPath, "config", "", "path to a YAML or TOML config file (defaults to RIVER_CONFIG)")
+	flagSet.StringVar(&schema, "schema", "", "name of non-default database schema where River tables are located (defaults to RIVER_SCHEMA)")
+
+	var run func(ctx context.Context, store *apitoken.Store) error
+
+	switch args[0] {
+	case "create":
+		var (
+			expiresIn time.Duration
+			name      string
+			role      string
+		)
+		flagSet.DurationVar(&expiresIn, "expires-in", 0, "duration after which the token expires, like 720h (never expires if zero)")
+		flagSet.StringVar(&name, "name", "", "name describing the token's use")
+		flagSet.StringVar(&role, "role", string(uiauth.RoleViewer), "role granted to the token: viewer, operator, or admin")
+		if err := flagSet.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		if name == "" {
+			return errors.New("-name is required")
+		}
+		if _, err := uiauth.ParseRole(role); err != nil {
+			return err
+		}
+		if expiresIn < 0 {
+			return errors.New("-expires-in must not be negative")
+		}
+
+		run = func(ctx context.Context, store *apitoken.Store) error {
+			params := &apitoken.CreateParams{Name: name, Role: uiauth.Role(role)}
+			if expiresIn > 0 {
+				expiresAt := time.Now().Add(expiresIn)
+				params.ExpiresAt = &expiresAt
+			}
+
+			token, secret, err := store.Create(ctx, params)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(out, "Created token %d (%s) with role %s. It won't be shown again:\n\n%s\n", token.ID, token.Name, token.Role, secret)
+			return nil
+		}
+
+	case "list":
+		if err := flagSet.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		run = func(ctx context.Context, store *apitoken.Store) error {
+			tokens, err := store.List(ctx)
+			if err != nil {
+				return err
+			}
+
+			writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(writer, "ID\tNAME\tROLE\tCREATED\tEXPIRES\tLAST USED\tREVOKED")
+			for _, token := range tokens {
+				fmt.Fprintf(writer, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+					token.ID, token.Name, token.Role, formatTime(&token.CreatedAt), formatTime(token.ExpiresAt), formatTime(token.LastUsedAt), formatTime(token.RevokedAt))
+			}
+			return writer.Flush()
+		}
+
+	case "revoke":
+		if err := flagSet.Parse(args[1:]); err != nil {
+			return err
+		}
+		if flagSet.NArg() != 1 {
+			return errors.New("usage: riverui tokens revoke [-schema SCHEMA] ID")
+		}
+
+		id, err := strconv.ParseInt(flagSet.Arg(0), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid token ID %q: %w", flagSet.Arg(0), err)
+		}
+
+		run = func(ctx context.Context, store *apitoken.Store) error {
+			token, err := store.Revoke(ctx, id)
+			if err != nil {
+				if errors.Is(err, apitoken.ErrNotFound) {
+					return fmt.Errorf("token %d not found or already revoked", id)
+				}
+				return err
+			}
+
+			fmt.Fprintf(out, "Revoked token %d (%s).\n", token.ID, token.Name)
+			return nil
+		}
+
+	default:
+		return fmt.Errorf("unknown tokens command %q\n\n%s", args[0], tokensUsage)
+	}
+
+	config, err := loadCommandConfig(configPath, lookupEnv, schema)
+	if err != nil {
+		return err
+	}
+
+	databaseURL := config.Get("DATABASE_URL")
+	if _, isSQLite := sqlitePath(databaseURL); isSQLite {
+		return errors.New("API tokens require a Postgres database")
+	}
+
+	dbPool, err := connectDB(ctx, databaseURL, &dbPoolOpts{})
+	if err != nil {
+		return err
+	}
+	defer dbPool.Close()
+
+	store := apitoken.NewStore(dbPool, config.Get("RIVER_SCHEMA"))
+	if err := store.Migrate(ctx); err != nil {
+		return err
+	}
+
+	return run(ctx, store)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
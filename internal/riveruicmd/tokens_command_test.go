@@ -0,0 +1,56 @@
+package riveruicmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunTokensCommand(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	// No environment variables are set.
+	lookupEnvNone := func(string) (string, bool) { return "", false }
+
+	// Only argument validation is tested here because it happens before
+	// connecting to the database.
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"NoCommand", nil, tokensUsage},
+		{"UnknownCommand", []string{"rotate"}, "unknown tokens command \"rotate\"\n\n" + tokensUsage},
+		{"CreateWithoutName", []string{"create"}, "-name is required"},
+		{"CreateWithInvalidRole", []string{"create", "-name", "runbook", "-role", "superuser"}, `unknown role "superuser"; must be one of: viewer, operator, admin`},
+		{"CreateWithNegativeExpiry", []string{"create", "-name", "runbook", "-expires-in", "-1h"}, "-expires-in must not be negative"},
+		{"RevokeWithoutID", []string{"revoke"}, "usage: riverui tokens revoke [-schema SCHEMA] ID"},
+		{"RevokeWithInvalidID", []string{"revoke", "abc"}, `invalid token ID "abc": strconv.ParseInt: parsing "abc": invalid syntax`},
+	}
+
+	t.Run("InvalidConfigFile", func(t *testing.T) {
+		t.Parallel()
+
+		configPath := filepath.Join(t.TempDir(), "riverui.yaml")
+		require.NoError(t, os.WriteFile(configPath, []byte("databse_url: postgres://localhost/river\n"), 0o600))
+
+		var out bytes.Buffer
+		err := runTokensCommand(ctx, []string{"list", "-config", configPath}, &out, lookupEnvNone)
+		require.ErrorContains(t, err, `unknown setting "databse_url"`)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+			require.EqualError(t, runTokensCommand(ctx, tt.args, &out, lookupEnvNone), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,649 @@
+package riverui
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/riverqueue/apiframe/apiendpoint"
+	"github.com/riverqueue/apiframe/apierror"
+	"github.com/riverqueue/apiframe/apitype"
+	"github.com/riverqueue/river/rivertype"
+)
+
+const (
+	openAPIGetPattern = "GET /api/openapi.json"
+
+	// openAPIVersion is the version of the API described in the OpenAPI
+	// document. It's distinct from River UI's release version, and should be
+	// incremented on incompatible API changes.
+	openAPIVersion = "1.0.0"
+)
+
+//
+// openAPIGetEndpoint
+//
+
+// openAPIGetEndpoint serves an OpenAPI document describing every endpoint
+// mounted on the handler. The document is generated once when the handler is
+// created because it can't change afterwards.
+type openAPIGetEndpoint struct {
+	apiendpoint.Endpoint[openAPIGetRequest, openAPIGetResponse]
+
+	document []byte
+}
+
+func newOpenAPIGetEndpoint() *openAPIGetEndpoint {
+	return &openAPIGetEndpoint{}
+}
+
+func (*openAPIGetEndpoint) Meta() *apiendpoint.EndpointMeta {
+	return &apiendpoint.EndpointMeta{
+		Pattern:    openAPIGetPattern,
+		StatusCode: http.StatusOK,
+	}
+}
+
+type openAPIGetRequest struct{}
+
+// openAPIGetResponse responds with a prerendered OpenAPI document rather than
+// one marshaled from a struct.
+type openAPIGetResponse struct {
+	document []byte
+}
+
+func (resp *openAPIGetResponse) RespondRaw(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, err := w.Write(resp.document)
+	return err
+}
+
+func (a *openAPIGetEndpoint) Execute(_ context.Context, _ *openAPIGetRequest) (*openAPIGetResponse, error) {
+	return &openAPIGetResponse{document: a.document}, nil
+}
+
+//
+// OpenAPI document generation
+//
+
+type openAPIDocument struct {
+	Components openAPIComponents                       `json:"components"`
+	Info       openAPIInfo                             `json:"info"`
+	OpenAPI    string                                  `json:"openapi"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Servers    []openAPIServer                         `json:"servers,omitempty"`
+}
+
+type openAPIComponents struct {
+	Schemas map[string]*openAPISchema `json:"schemas"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIServer struct {
+	URL string `json:"url"`
+}
+
+type openAPIOperation struct { //nolint:tagliatelle // names are defined by the OpenAPI specification
+	OperationID string                      `json:"operationId"`
+	Parameters  []*openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*openAPIResponse `json:"responses"`
+	Tags        []string                    `json:"tags,omitempty"`
+
+	// XRiverAccess is the level of access an identity needs to call the
+	// operation, one of read, mutate, or admin.
+	XRiverAccess string `json:"x-river-access"`
+}
+
+type openAPIParameter struct {
+	Explode  *bool          `json:"explode,omitempty"`
+	In       string         `json:"in"`
+	Name     string         `json:"name"`
+	Required bool           `json:"required,omitempty"`
+	Schema   *openAPISchema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Content  map[string]*openAPIMediaType `json:"content"`
+	Required bool                         `json:"required,omitempty"`
+}
+
+type openAPIResponse struct {
+	Content     map[string]*openAPIMediaType `json:"content,omitempty"`
+	Description string                       `json:"description"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema"`
+}
+
+// openAPISchema is the subset of the OpenAPI 3.0 schema object needed to
+// describe River UI's requests and responses.
+type openAPISchema struct { //nolint:tagliatelle // names are defined by the OpenAPI specification
+	AdditionalProperties *openAPISchema            `json:"additionalProperties,omitempty"`
+	AllOf                []*openAPISchema          `json:"allOf,omitempty"`
+	Enum                 []string                  `json:"enum,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Items                *openAPISchema            `json:"items,omitempty"`
+	MaxItems             *int                      `json:"maxItems,omitempty"`
+	MaxLength            *int                      `json:"maxLength,omitempty"`
+	Maximum              *float64                  `json:"maximum,omitempty"`
+	MinItems             *int                      `json:"minItems,omitempty"`
+	MinLength            *int                      `json:"minLength,omitempty"`
+	Minimum              *float64                  `json:"minimum,omitempty"`
+	Nullable             bool                      `json:"nullable,omitempty"`
+	Pattern              string                    `json:"pattern,omitempty"`
+	Properties           map[string]*openAPISchema `json:"properties,omitempty"`
+	Ref                  string                    `json:"$ref,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+}
+
+type openAPIDocumentOpts struct {
+	// Environments are the names of environments that requests may select
+	// with the EnvironmentHeader, or empty if there's only the default one.
+	Environments []string
+
+	// Prefix is the path prefix the handler is mounted at, if any.
+	Prefix string
+}
+
+// Builds an OpenAPI document describing endpoints from their patterns and the
+// request and response types of their Execute methods. Parameters are taken
+// from request struct fields tagged with `path` or `query` (see jobGetRequest
+// and jobListRequest), the request body from fields visible to JSON, and
+// constraints like enums and bounds from `validate` tags.
+//
+// Endpoints are described in order, and an endpoint whose pattern was already
+// described, like the same endpoint mounted for another environment, is
+// skipped.
+func buildOpenAPIDocument(endpoints []apiendpoint.EndpointInterface, opts *openAPIDocumentOpts) ([]byte, error) {
+	builder := &openAPISchemaBuilder{
+		names:   make(map[reflect.Type]string),
+		schemas: make(map[string]*openAPISchema),
+	}
+
+	document := &openAPIDocument{
+		Components: openAPIComponents{Schemas: builder.schemas},
+		Info:       openAPIInfo{Title: "River UI API", Version: openAPIVersion},
+		OpenAPI:    "3.0.3",
+		Paths:      make(map[string]map[string]*openAPIOperation),
+	}
+	if opts.Prefix != "" {
+		document.Servers = []openAPIServer{{URL: opts.Prefix}}
+	}
+
+	errorSchema := builder.schemaFor(reflect.TypeFor[apierror.APIError]())
+
+	var (
+		operationIDs = make(map[string]struct{})
+		patterns     = make(map[string]struct{})
+	)
+	for _, endpoint := range endpoints {
+		meta := endpoint.Meta()
+		if _, ok := patterns[meta.Pattern]; ok {
+			continue
+		}
+		patterns[meta.Pattern] = struct{}{}
+
+		execute := reflect.ValueOf(endpoint).MethodByName("Execute")
+		if !execute.IsValid() {
+			return nil, fmt.Errorf("endpoint %q has no Execute method", meta.Pattern)
+		}
+		reqType := execute.Type().In(1).Elem()
+		respType := execute.Type().Out(0).Elem()
+
+		method, path, ok := strings.Cut(meta.Pattern, " ")
+		if !ok {
+			return nil, fmt.Errorf("endpoint pattern %q doesn't start with a method", meta.Pattern)
+		}
+		path = strings.TrimSuffix(path, "{$}")
+
+		operation := &openAPIOperation{
+			OperationID:  openAPIOperationID(endpoint, operationIDs),
+			Responses:    make(map[string]*openAPIResponse),
+			XRiverAccess: string(endpointRequiredAccess(endpoint, meta)),
+		}
+
+		// Operations are tagged with the first segment of their path, like
+		// `jobs` for /api/jobs/{job_id}, which client generators group by.
+		if tag, _, _ := strings.Cut(strings.TrimPrefix(path, "/api/"), "/"); tag != "" {
+			tag, _, _ = strings.Cut(tag, ".")
+			operation.Tags = []string{tag}
+		}
+
+		parameters, path, err := builder.parameters(reqType, path)
+		if err != nil {
+			return nil, fmt.Errorf("endpoint %q: %w", meta.Pattern, err)
+		}
+		operation.Parameters = parameters
+
+		if len(opts.Environments) > 0 {
+			operation.Parameters = append(operation.Parameters, &openAPIParameter{
+				In:     "header",
+				Name:   EnvironmentHeader,
+				Schema: &openAPISchema{Type: "string", Enum: opts.Environments},
+			})
+		}
+
+		if method != http.MethodGet && method != http.MethodHead && len(openAPIStructFields(reqType)) > 0 {
+			schema, required := builder.requestSchemaFor(reqType)
+			operation.RequestBody = &openAPIRequestBody{
+				Content:  map[string]*openAPIMediaType{"application/json": {Schema: schema}},
+				Required: required,
+			}
+		}
+
+		response := &openAPIResponse{Description: http.StatusText(meta.StatusCode)}
+		if reflect.PointerTo(respType).Implements(reflect.TypeFor[apiendpoint.RawResponder]()) {
+			response.Content = map[string]*openAPIMediaType{"application/json": {Schema: &openAPISchema{}}}
+		} else {
+			response.Content = map[string]*openAPIMediaType{"application/json": {Schema: builder.schemaFor(respType)}}
+		}
+		operation.Responses[strconv.Itoa(meta.StatusCode)] = response
+		operation.Responses["default"] = &openAPIResponse{
+			Content:     map[string]*openAPIMediaType{"application/json": {Schema: errorSchema}},
+			Description: "Error",
+		}
+
+		if document.Paths[path] == nil {
+			document.Paths[path] = make(map[string]*openAPIOperation)
+		}
+		document.Paths[path][strings.ToLower(method)] = operation
+	}
+
+	data, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling OpenAPI document: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// Derives an operation ID from an endpoint's type name, like `jobList` for
+// jobListEndpoint, disambiguating it with a number if it's already taken.
+func openAPIOperationID(endpoint apiendpoint.EndpointInterface, operationIDs map[string]struct{}) string {
+	typ := reflect.TypeOf(endpoint)
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	name, _, _ := strings.Cut(typ.Name(), "[")
+	name = strings.TrimSuffix(name, "Endpoint")
+	if name == "" {
+		name = "operation"
+	}
+	name = string(unicode.ToLower(rune(name[0]))) + name[1:]
+
+	operationID := name
+	for i := 2; ; i++ {
+		if _, ok := operationIDs[operationID]; !ok {
+			break
+		}
+		operationID = name + strconv.Itoa(i)
+	}
+	operationIDs[operationID] = struct{}{}
+	return operationID
+}
+
+// openAPISchemaBuilder builds schemas for Go types, collecting those of named
+// struct types as components that are referenced by name.
+type openAPISchemaBuilder struct {
+	names   map[reflect.Type]string
+	schemas map[string]*openAPISchema
+}
+
+// Builds the path and query parameters of a request type. Path parameters are
+// taken from the pattern's wildcards so that they're described even if no
+// field is tagged for them, and the path is returned with wildcards like
+// `{name...}` converted to OpenAPI's syntax.
+func (b *openAPISchemaBuilder) parameters(reqType reflect.Type, path string) ([]*openAPIParameter, string, error) {
+	var (
+		parameters  []*openAPIParameter
+		pathFields  = make(map[string]reflect.StructField)
+		queryFields []reflect.StructField
+	)
+	for _, field := range openAPITaggedFields(reqType) {
+		if name := field.Tag.Get("path"); name != "" {
+			pathFields[name] = field
+		}
+		if name := field.Tag.Get("query"); name != "" {
+			queryFields = append(queryFields, field)
+		}
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(segment, "{"), "}"), "...")
+		segments[i] = "{" + name + "}"
+
+		schema := &openAPISchema{Type: "string"}
+		if field, ok := pathFields[name]; ok {
+			schema = b.parameterSchemaFor(field)
+			delete(pathFields, name)
+		}
+		parameters = append(parameters, &openAPIParameter{
+			In:       "path",
+			Name:     name,
+			Required: true,
+			Schema:   schema,
+		})
+	}
+	for name := range pathFields {
+		return nil, "", fmt.Errorf("field tagged with path %q, but the pattern has no such wildcard", name)
+	}
+
+	for _, field := range queryFields {
+		parameter := &openAPIParameter{
+			In:       "query",
+			Name:     field.Tag.Get("query"),
+			Required: openAPIValidateRequired(field),
+			Schema:   b.parameterSchemaFor(field),
+		}
+		if parameter.Schema.Type == "array" {
+			explode := true
+			parameter.Explode = &explode
+		}
+		parameters = append(parameters, parameter)
+	}
+
+	return parameters, strings.Join(segments, "/"), nil
+}
+
+// Builds the schema of a parameter field. Parameters that are omitted are
+// indistinguishable from null ones, so pointers aren't marked nullable.
+func (b *openAPISchemaBuilder) parameterSchemaFor(field reflect.StructField) *openAPISchema {
+	typ := field.Type
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	schema := b.schemaFor(typ)
+	openAPIApplyValidate(schema, field.Tag.Get("validate"))
+	return schema
+}
+
+// Builds the schema of a request body type, returning whether the body is
+// required. Unlike other structs, its fields are only required if they have a
+// `validate:"required"` tag.
+func (b *openAPISchemaBuilder) requestSchemaFor(reqType reflect.Type) (*openAPISchema, bool) {
+	ref := b.schemaFor(reqType)
+
+	schema := b.schemas[b.names[reqType]]
+	schema.Required = nil
+	for _, field := range openAPIStructFields(reqType) {
+		if openAPIValidateRequired(field.StructField) {
+			schema.Required = append(schema.Required, field.name)
+		}
+	}
+	slices.Sort(schema.Required)
+
+	return ref, len(schema.Required) > 0
+}
+
+// Builds the schema of a type, which is a reference to a component for named
+// struct types.
+func (b *openAPISchemaBuilder) schemaFor(typ reflect.Type) *openAPISchema {
+	if typ.Kind() == reflect.Pointer {
+		return openAPINullable(b.schemaFor(typ.Elem()))
+	}
+
+	switch typ {
+	case reflect.TypeFor[int64String]():
+		return &openAPISchema{Type: "string", Pattern: "^-?[0-9]+$"}
+	case reflect.TypeFor[json.RawMessage]():
+		return &openAPISchema{}
+	case reflect.TypeFor[rivertype.JobState]():
+		schema := &openAPISchema{Type: "string"}
+		for _, state := range rivertype.JobStates() {
+			schema.Enum = append(schema.Enum, string(state))
+		}
+		return schema
+	case reflect.TypeFor[time.Duration]():
+		// Durations are only parsed from parameters, in Go's format like "10m".
+		return &openAPISchema{Type: "string"}
+	case reflect.TypeFor[time.Time]():
+		return &openAPISchema{Type: "string", Format: "date-time"}
+	}
+
+	// apitype.ExplicitNullable distinguishes omitted fields from null ones, but
+	// on the wire it's the same as the pointer it wraps.
+	if typ.PkgPath() == reflect.TypeFor[apitype.ExplicitNullable[struct{}]]().PkgPath() && strings.HasPrefix(typ.Name(), "ExplicitNullable[") {
+		valueField, _ := typ.FieldByName("Value")
+		return b.schemaFor(valueField.Type)
+	}
+
+	switch typ.Kind() { //nolint:exhaustive
+	case reflect.Bool:
+		return &openAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &openAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Int32, reflect.Uint32:
+		return &openAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int8, reflect.Int16, reflect.Uint8, reflect.Uint16:
+		return &openAPISchema{Type: "integer"}
+	case reflect.Float32:
+		return &openAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &openAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &openAPISchema{Type: "string"}
+	case reflect.Array, reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return &openAPISchema{Type: "string", Format: "byte"}
+		}
+		return &openAPISchema{Type: "array", Items: b.schemaFor(typ.Elem())}
+	case reflect.Map:
+		return &openAPISchema{Type: "object", AdditionalProperties: b.schemaFor(typ.Elem())}
+	case reflect.Struct:
+		if typ.Name() == "" {
+			return b.structSchemaFor(typ)
+		}
+
+		name, ok := b.names[typ]
+		if !ok {
+			name = b.componentName(typ)
+			b.names[typ] = name
+			b.schemas[name] = &openAPISchema{} // placeholder in case of recursion
+			*b.schemas[name] = *b.structSchemaFor(typ)
+		}
+		return &openAPISchema{Ref: "#/components/schemas/" + name}
+	default:
+		// Interfaces and anything else could be any JSON value.
+		return &openAPISchema{}
+	}
+}
+
+// Builds the schema of a struct's JSON fields. Fields are required unless
+// they're omitted when empty.
+func (b *openAPISchemaBuilder) structSchemaFor(typ reflect.Type) *openAPISchema {
+	schema := &openAPISchema{
+		Properties: make(map[string]*openAPISchema),
+		Type:       "object",
+	}
+
+	for _, field := range openAPIStructFields(typ) {
+		fieldSchema := b.schemaFor(field.Type)
+		if field.asString {
+			fieldSchema = &openAPISchema{Type: "string"}
+		}
+		if fieldSchema.Ref == "" {
+			openAPIApplyValidate(fieldSchema, field.Tag.Get("validate"))
+		}
+		schema.Properties[field.name] = fieldSchema
+
+		if !field.omitEmpty {
+			schema.Required = append(schema.Required, field.name)
+		}
+	}
+	slices.Sort(schema.Required)
+
+	return schema
+}
+
+// Names a component after its Go type, capitalized and with type arguments
+// appended, like ListResponseRiverJob for listResponse[RiverJob].
+func (b *openAPISchemaBuilder) componentName(typ reflect.Type) string {
+	baseName, typeArgs, _ := strings.Cut(typ.Name(), "[")
+
+	var name strings.Builder
+	for _, part := range append([]string{baseName}, strings.FieldsFunc(typeArgs, func(r rune) bool {
+		return r == '[' || r == ']' || r == ','
+	})...) {
+		part = part[strings.LastIndex(part, "/")+1:]
+		part = part[strings.LastIndex(part, ".")+1:]
+		if part == "" {
+			continue
+		}
+		name.WriteString(string(unicode.ToUpper(rune(part[0]))) + part[1:])
+	}
+
+	componentName := name.String()
+	for i := 2; ; i++ {
+		if _, ok := b.schemas[componentName]; !ok {
+			return componentName
+		}
+		componentName = name.String() + strconv.Itoa(i)
+	}
+}
+
+// openAPIField is a struct field as it's marshaled to JSON.
+type openAPIField struct {
+	reflect.StructField
+
+	asString  bool
+	name      string
+	omitEmpty bool
+}
+
+// Returns a struct's fields as encoding/json sees them, with the fields of
+// embedded structs promoted and fields tagged `json:"-"` left out.
+func openAPIStructFields(typ reflect.Type) []openAPIField {
+	var fields []openAPIField
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embeddedType := field.Type
+			if embeddedType.Kind() == reflect.Pointer {
+				embeddedType = embeddedType.Elem()
+			}
+			if embeddedType.Kind() == reflect.Struct {
+				fields = append(fields, openAPIStructFields(embeddedType)...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		optionList := strings.Split(options, ",")
+		fields = append(fields, openAPIField{
+			StructField: field,
+			asString:    slices.Contains(optionList, "string"),
+			name:        cmp.Or(name, field.Name),
+			omitEmpty:   slices.Contains(optionList, "omitempty") || slices.Contains(optionList, "omitzero"),
+		})
+	}
+	return fields
+}
+
+// Returns the fields of a request struct that are tagged as path or query
+// parameters.
+func openAPITaggedFields(typ reflect.Type) []reflect.StructField {
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fields []reflect.StructField
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		if field.Tag.Get("path") != "" || field.Tag.Get("query") != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// Applies the constraints of a `validate` tag that have an equivalent in a
+// schema, like `oneof` and `min`/`max`, whose meaning depends on the type.
+func openAPIApplyValidate(schema *openAPISchema, validateTag string) {
+	if validateTag == "" {
+		return
+	}
+
+	for rule := range strings.SplitSeq(validateTag, ",") {
+		// Rules after `dive` apply to the elements of a slice or map.
+		if rule == "dive" {
+			return
+		}
+
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "oneof":
+			if schema.Type == "string" {
+				schema.Enum = strings.Fields(value)
+			}
+		case "min", "max":
+			bound, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			switch schema.Type {
+			case "array":
+				openAPISetBound(key, int(bound), &schema.MinItems, &schema.MaxItems)
+			case "string":
+				openAPISetBound(key, int(bound), &schema.MinLength, &schema.MaxLength)
+			case "integer", "number":
+				if key == "min" {
+					schema.Minimum = &bound
+				} else {
+					schema.Maximum = &bound
+				}
+			}
+		}
+	}
+}
+
+func openAPISetBound(key string, bound int, minPtr, maxPtr **int) {
+	if key == "min" {
+		*minPtr = &bound
+	} else {
+		*maxPtr = &bound
+	}
+}
+
+// Returns whether a field has a `validate:"required"` tag.
+func openAPIValidateRequired(field reflect.StructField) bool {
+	return slices.Contains(strings.Split(field.Tag.Get("validate"), ","), "required")
+}
+
+// Marks a schema nullable. OpenAPI 3.0 ignores siblings of $ref, so references
+// are wrapped in allOf.
+func openAPINullable(schema *openAPISchema) *openAPISchema {
+	if schema.Ref != "" {
+		return &openAPISchema{AllOf: []*openAPISchema{schema}, Nullable: true}
+	}
+	schema.Nullable = true
+	return schema
+}
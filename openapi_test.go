@@ -0,0 +1,141 @@
+package riverui
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/riverqueue/apiframe/apiendpoint"
+	"github.com/riverqueue/river"
+	"github.com/riverqueue/river/riverdriver/riverpgxv5"
+	"github.com/riverqueue/river/rivershared/riversharedtest"
+)
+
+// Path of the checked in OpenAPI document, which TestOpenAPIDocument keeps in
+// sync with the API.
+const openAPIDocumentPath = "docs/openapi.json"
+
+var updateOpenAPI = flag.Bool("update-openapi", false, "rewrite "+openAPIDocumentPath+" from the API's endpoints") //nolint:gochecknoglobals
+
+func TestOpenAPIDocument(t *testing.T) {
+	t.Parallel()
+
+	// The document only depends on endpoint types, so no database is needed.
+	client, err := river.NewClient(riverpgxv5.New(nil), &river.Config{})
+	require.NoError(t, err)
+
+	handler, err := NewHandler(&HandlerOpts{
+		DevMode:     true,
+		Endpoints:   NewEndpoints(client, nil),
+		LiveFS:      true,
+		Logger:      riversharedtest.Logger(t),
+		projectRoot: "./",
+	})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/openapi.json", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "application/json; charset=utf-8", recorder.Header().Get("Content-Type"))
+
+	if *updateOpenAPI {
+		require.NoError(t, os.WriteFile(openAPIDocumentPath, recorder.Body.Bytes(), 0o600))
+		return
+	}
+
+	expected, err := os.ReadFile(openAPIDocumentPath)
+	require.NoError(t, err)
+	require.Equal(t, string(expected), recorder.Body.String(),
+		"%s is out of date with the API's endpoints; regenerate it with `go test -run TestOpenAPIDocument . -update-openapi`", openAPIDocumentPath)
+}
+
+type openAPITestEndpoint struct {
+	apiendpoint.Endpoint[openAPITestRequest, statusResponse]
+
+	pattern string
+}
+
+func (a *openAPITestEndpoint) Meta() *apiendpoint.EndpointMeta {
+	return &apiendpoint.EndpointMeta{Pattern: a.pattern, StatusCode: http.StatusOK}
+}
+
+func (a *openAPITestEndpoint) Execute(_ context.Context, _ *openAPITestRequest) (*statusResponse, error) {
+	return statusResponseOK, nil
+}
+
+type openAPITestRequest struct {
+	ID string `json:"-" path:"id"` // from ExtractRaw
+}
+
+func TestBuildOpenAPIDocument(t *testing.T) {
+	t.Parallel()
+
+	build := func(t *testing.T, opts *openAPIDocumentOpts, patterns ...string) map[string]any {
+		t.Helper()
+
+		endpoints := make([]apiendpoint.EndpointInterface, len(patterns))
+		for i, pattern := range patterns {
+			endpoints[i] = &openAPITestEndpoint{pattern: pattern}
+		}
+
+		data, err := buildOpenAPIDocument(endpoints, opts)
+		require.NoError(t, err)
+
+		var document map[string]any
+		require.NoError(t, json.Unmarshal(data, &document))
+		return document
+	}
+
+	t.Run("PathWildcards", func(t *testing.T) {
+		t.Parallel()
+
+		document := build(t, &openAPIDocumentOpts{}, "GET /api/things/{id}/files/{path...}")
+
+		operation := document["paths"].(map[string]any)["/api/things/{id}/files/{path}"].(map[string]any)["get"].(map[string]any) //nolint:forcetypeassert
+		require.Equal(t, "openAPITest", operation["operationId"])
+		require.Equal(t, []any{"things"}, operation["tags"])
+		require.Equal(t, []any{
+			map[string]any{"in": "path", "name": "id", "required": true, "schema": map[string]any{"type": "string"}},
+			map[string]any{"in": "path", "name": "path", "required": true, "schema": map[string]any{"type": "string"}},
+		}, operation["parameters"])
+	})
+
+	t.Run("DuplicatePatternsAndOperationIDs", func(t *testing.T) {
+		t.Parallel()
+
+		document := build(t, &openAPIDocumentOpts{}, "GET /api/things/{id}", "GET /api/things/{id}", "PUT /api/things/{id}")
+
+		path := document["paths"].(map[string]any)["/api/things/{id}"].(map[string]any) //nolint:forcetypeassert
+		require.Len(t, path, 2)
+		require.Equal(t, "openAPITest", path["get"].(map[string]any)["operationId"])  //nolint:forcetypeassert
+		require.Equal(t, "openAPITest2", path["put"].(map[string]any)["operationId"]) //nolint:forcetypeassert
+		require.Equal(t, "mutate", path["put"].(map[string]any)["x-river-access"])    //nolint:forcetypeassert
+	})
+
+	t.Run("PrefixAndEnvironments", func(t *testing.T) {
+		t.Parallel()
+
+		document := build(t, &openAPIDocumentOpts{Environments: []string{DefaultEnvironment, "eu"}, Prefix: "/river"}, "GET /api/things/{id}")
+		require.Equal(t, []any{map[string]any{"url": "/river"}}, document["servers"])
+
+		operation := document["paths"].(map[string]any)["/api/things/{id}"].(map[string]any)["get"].(map[string]any) //nolint:forcetypeassert
+		require.Contains(t, operation["parameters"], map[string]any{
+			"in":     "header",
+			"name":   EnvironmentHeader,
+			"schema": map[string]any{"type": "string", "enum": []any{DefaultEnvironment, "eu"}},
+		})
+	})
+
+	t.Run("PathTagWithoutWildcard", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := buildOpenAPIDocument([]apiendpoint.EndpointInterface{&openAPITestEndpoint{pattern: "GET /api/things"}}, &openAPIDocumentOpts{})
+		require.EqualError(t, err, `endpoint "GET /api/things": field tagged with path "id", but the pattern has no such wildcard`)
+	})
+}
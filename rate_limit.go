@@ -0,0 +1,193 @@
+package riverui
+
+import (
+	"errors"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/riverqueue/apiframe/apierror"
+
+	"riverqueue.com/riverui/uiauth"
+)
+
+const (
+	// Identities whose limiters haven't been used for this long are forgotten
+	// so that the set of limiters doesn't grow without bound.
+	rateLimiterIdleTimeout = 10 * time.Minute
+
+	// Retry-After sent when requests are rejected because too many are already
+	// in flight. There's no way to know when a slot will free up, so this is a
+	// short, fixed interval.
+	concurrencyLimitRetryAfter = time.Second
+)
+
+// RateLimitOpts configure limits on API requests that protect the database from
+// being saturated by expensive queries, like a user repeatedly refreshing a
+// heavily filtered job list. Requests exceeding a limit are rejected with a 429
+// and a `Retry-After` header. Health checks are never limited.
+type RateLimitOpts struct {
+	// Burst is the number of requests an identity may make in quick succession
+	// before being limited to RequestsPerSecond. Defaults to
+	// RequestsPerSecond rounded up, or 1, whichever is larger.
+	Burst int
+
+	// MaxConcurrent caps the number of API requests executing at once across
+	// all identities. Unlimited if zero.
+	MaxConcurrent int
+
+	// RequestsPerSecond is the sustained rate of API requests allowed for each
+	// identity. Requests from anonymous identities are limited by remote
+	// address instead. Unlimited if zero.
+	RequestsPerSecond float64
+}
+
+func (opts *RateLimitOpts) validate() error {
+	if opts.Burst < 0 {
+		return errors.New("burst must not be negative")
+	}
+	if opts.MaxConcurrent < 0 {
+		return errors.New("max concurrent must not be negative")
+	}
+	if opts.RequestsPerSecond < 0 {
+		return errors.New("requests per second must not be negative")
+	}
+	return nil
+}
+
+// rateLimitMiddleware applies RateLimitOpts to requests routed to API
+// endpoints. It must run after authorizationMiddleware so that every request
+// carries an identity.
+type rateLimitMiddleware struct {
+	apiPatterns map[string]struct{}
+	burst       int
+	limit       rate.Limit
+	logger      *slog.Logger
+	mux         routeMatcher
+	semaphore   chan struct{}
+	timeNowFunc func() time.Time
+
+	mu        sync.Mutex
+	lastPrune time.Time
+	limiters  map[string]*identityLimiter
+}
+
+type identityLimiter struct {
+	lastUsed time.Time
+	limiter  *rate.Limiter
+}
+
+func newRateLimitMiddleware(logger *slog.Logger, mux routeMatcher, apiPatterns map[string]struct{}, opts *RateLimitOpts) *rateLimitMiddleware {
+	middleware := &rateLimitMiddleware{
+		apiPatterns: apiPatterns,
+		limit:       rate.Limit(opts.RequestsPerSecond),
+		logger:      logger,
+		mux:         mux,
+		timeNowFunc: time.Now,
+		limiters:    make(map[string]*identityLimiter),
+	}
+
+	if opts.RequestsPerSecond > 0 {
+		middleware.burst = opts.Burst
+		if middleware.burst == 0 {
+			middleware.burst = max(1, int(math.Ceil(opts.RequestsPerSecond)))
+		}
+	}
+
+	if opts.MaxConcurrent > 0 {
+		middleware.semaphore = make(chan struct{}, opts.MaxConcurrent)
+	}
+
+	return middleware
+}
+
+func (m *rateLimitMiddleware) Middleware(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := m.mux.Handler(r)
+		if _, ok := m.apiPatterns[pattern]; !ok {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		if m.limit > 0 {
+			if delay := m.reserve(m.limiterKey(r)); delay > 0 {
+				m.writeTooManyRequests(w, r, delay, "Rate limit exceeded. Try again later.")
+				return
+			}
+		}
+
+		if m.semaphore != nil {
+			select {
+			case m.semaphore <- struct{}{}:
+				defer func() { <-m.semaphore }()
+			default:
+				m.writeTooManyRequests(w, r, concurrencyLimitRetryAfter, "Too many requests in progress. Try again later.")
+				return
+			}
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// Identifies the limiter for a request. Named identities share a limiter
+// regardless of where their requests come from, while anonymous ones are
+// limited per remote address.
+func (m *rateLimitMiddleware) limiterKey(r *http.Request) string {
+	if identity := uiauth.IdentityFromContext(r.Context()); identity != nil && identity.Name != "" {
+		return "identity:" + identity.Name
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "addr:" + host
+}
+
+// Takes a token from the limiter for key, returning zero if the request may
+// proceed or how long to wait before retrying if it may not.
+func (m *rateLimitMiddleware) reserve(key string) time.Duration {
+	now := m.timeNowFunc()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.lastPrune) > rateLimiterIdleTimeout {
+		for limiterKey, limiter := range m.limiters {
+			if now.Sub(limiter.lastUsed) > rateLimiterIdleTimeout {
+				delete(m.limiters, limiterKey)
+			}
+		}
+		m.lastPrune = now
+	}
+
+	limiter, ok := m.limiters[key]
+	if !ok {
+		limiter = &identityLimiter{limiter: rate.NewLimiter(m.limit, m.burst)}
+		m.limiters[key] = limiter
+	}
+	limiter.lastUsed = now
+
+	reservation := limiter.limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		// Rejected requests don't count against the limit.
+		reservation.CancelAt(now)
+		return delay
+	}
+	return 0
+}
+
+func (m *rateLimitMiddleware) writeTooManyRequests(w http.ResponseWriter, r *http.Request, retryAfter time.Duration, message string) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	(&apierror.APIError{
+		Message:    message,
+		StatusCode: http.StatusTooManyRequests,
+	}).Write(r.Context(), m.logger, w)
+}
@@ -0,0 +1,165 @@
+package riverui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/riverqueue/river/rivershared/riversharedtest"
+
+	"riverqueue.com/riverui/uiauth"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	t.Parallel()
+
+	type testBundle struct {
+		now     time.Time
+		release chan struct{}
+		started chan struct{}
+	}
+
+	setup := func(t *testing.T, opts *RateLimitOpts) (http.Handler, *testBundle) {
+		t.Helper()
+
+		var (
+			bundle = &testBundle{
+				now:     time.Now(),
+				release: make(chan struct{}),
+				started: make(chan struct{}, 10),
+			}
+			mux = http.NewServeMux()
+		)
+
+		okHandler := func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}
+		mux.HandleFunc("GET /api/jobs", okHandler)
+		mux.HandleFunc("GET /api/slow", func(w http.ResponseWriter, r *http.Request) {
+			bundle.started <- struct{}{}
+			<-bundle.release
+			w.WriteHeader(http.StatusOK)
+		})
+		mux.HandleFunc(healthCheckGetPattern, okHandler)
+		mux.HandleFunc("GET /robots.txt", okHandler)
+
+		middleware := newRateLimitMiddleware(riversharedtest.Logger(t), mux, map[string]struct{}{
+			"GET /api/jobs": {},
+			"GET /api/slow": {},
+		}, opts)
+		middleware.timeNowFunc = func() time.Time { return bundle.now }
+
+		return middleware.Middleware(mux), bundle
+	}
+
+	serve := func(t *testing.T, handler http.Handler, path, remoteAddr string, identity *uiauth.Identity) *httptest.ResponseRecorder {
+		t.Helper()
+
+		ctx := t.Context()
+		if identity != nil {
+			ctx = uiauth.WithIdentity(ctx, identity)
+		}
+
+		req := httptest.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	var (
+		alice = &uiauth.Identity{Name: "alice", Roles: []uiauth.Role{uiauth.RoleViewer}}
+		bob   = &uiauth.Identity{Name: "bob", Roles: []uiauth.Role{uiauth.RoleViewer}}
+	)
+
+	t.Run("LimitsRatePerIdentity", func(t *testing.T) {
+		t.Parallel()
+
+		handler, bundle := setup(t, &RateLimitOpts{Burst: 2, RequestsPerSecond: 0.5})
+
+		require.Equal(t, http.StatusOK, serve(t, handler, "/api/jobs", "10.0.0.1:1234", alice).Code)
+		require.Equal(t, http.StatusOK, serve(t, handler, "/api/jobs", "10.0.0.2:1234", alice).Code)
+
+		recorder := serve(t, handler, "/api/jobs", "10.0.0.1:1234", alice)
+		require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+		require.Equal(t, "2", recorder.Header().Get("Retry-After"))
+		require.JSONEq(t, `{"message":"Rate limit exceeded. Try again later."}`, recorder.Body.String())
+
+		// Other identities have their own limit.
+		require.Equal(t, http.StatusOK, serve(t, handler, "/api/jobs", "10.0.0.1:1234", bob).Code)
+
+		// Rejected requests don't count against the limit, so a single token
+		// accrues after two seconds.
+		bundle.now = bundle.now.Add(2 * time.Second)
+		require.Equal(t, http.StatusOK, serve(t, handler, "/api/jobs", "10.0.0.1:1234", alice).Code)
+		require.Equal(t, http.StatusTooManyRequests, serve(t, handler, "/api/jobs", "10.0.0.1:1234", alice).Code)
+	})
+
+	t.Run("AnonymousLimitedByRemoteAddr", func(t *testing.T) {
+		t.Parallel()
+
+		handler, _ := setup(t, &RateLimitOpts{RequestsPerSecond: 1})
+
+		anonymous := &uiauth.Identity{Roles: []uiauth.Role{uiauth.RoleAdmin}}
+
+		require.Equal(t, http.StatusOK, serve(t, handler, "/api/jobs", "10.0.0.1:1234", anonymous).Code)
+		require.Equal(t, http.StatusTooManyRequests, serve(t, handler, "/api/jobs", "10.0.0.1:5678", anonymous).Code)
+		require.Equal(t, http.StatusOK, serve(t, handler, "/api/jobs", "10.0.0.2:1234", anonymous).Code)
+	})
+
+	t.Run("LimitsConcurrency", func(t *testing.T) {
+		t.Parallel()
+
+		handler, bundle := setup(t, &RateLimitOpts{MaxConcurrent: 1})
+
+		done := make(chan int)
+		go func() {
+			done <- serve(t, handler, "/api/slow", "10.0.0.1:1234", alice).Code
+		}()
+		<-bundle.started
+
+		recorder := serve(t, handler, "/api/jobs", "10.0.0.2:1234", bob)
+		require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+		require.Equal(t, "1", recorder.Header().Get("Retry-After"))
+
+		close(bundle.release)
+		require.Equal(t, http.StatusOK, <-done)
+
+		require.Equal(t, http.StatusOK, serve(t, handler, "/api/jobs", "10.0.0.2:1234", bob).Code)
+	})
+
+	t.Run("HealthChecksAndNonEndpointRoutesExempt", func(t *testing.T) {
+		t.Parallel()
+
+		handler, bundle := setup(t, &RateLimitOpts{MaxConcurrent: 1, RequestsPerSecond: 1})
+
+		go serve(t, handler, "/api/slow", "10.0.0.1:1234", alice)
+		<-bundle.started
+		t.Cleanup(func() { close(bundle.release) })
+
+		for range 3 {
+			require.Equal(t, http.StatusOK, serve(t, handler, "/api/health-checks/complete", "10.0.0.1:1234", alice).Code)
+			require.Equal(t, http.StatusOK, serve(t, handler, "/robots.txt", "10.0.0.1:1234", alice).Code)
+		}
+	})
+
+	t.Run("PrunesIdleLimiters", func(t *testing.T) {
+		t.Parallel()
+
+		middleware := newRateLimitMiddleware(riversharedtest.Logger(t), http.NewServeMux(), nil, &RateLimitOpts{RequestsPerSecond: 1})
+		now := time.Now()
+		middleware.timeNowFunc = func() time.Time { return now }
+
+		require.Zero(t, middleware.reserve("identity:alice"))
+		require.Len(t, middleware.limiters, 1)
+
+		now = now.Add(rateLimiterIdleTimeout + time.Second)
+		require.Zero(t, middleware.reserve("identity:bob"))
+		require.Len(t, middleware.limiters, 1)
+		require.Contains(t, middleware.limiters, "identity:bob")
+	})
+}
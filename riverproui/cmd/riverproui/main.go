@@ -1,8 +1,9 @@
 package main
 
 import (
+	"errors"
+
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/riverqueue/river"
 
@@ -16,13 +17,21 @@ import (
 
 func main() {
 	riveruicmd.Run(
-		func(dbPool *pgxpool.Pool, opts *riveruicmd.ClientOpts) (*riverpro.Client[pgx.Tx], error) {
-			return riverpro.NewClient(riverpropgxv5.New(dbPool), &riverpro.Config{
+		func(opts *riveruicmd.ClientOpts) (*riverpro.Client[pgx.Tx], error) {
+			if opts.SQLiteDB != nil {
+				return nil, errors.New("riverproui requires a Postgres database")
+			}
+
+			return riverpro.NewClient(riverpropgxv5.New(opts.DBPool), &riverpro.Config{
 				Config: river.Config{Schema: opts.Schema},
 			})
 		},
-		func(client *riverpro.Client[pgx.Tx]) uiendpoints.Bundle {
-			return riverproui.NewEndpoints(client, nil)
+		func(client *riverpro.Client[pgx.Tx], opts *riveruicmd.BundleOpts) uiendpoints.Bundle {
+			endpointsOpts := &riverproui.EndpointsOpts[pgx.Tx]{KindRegistry: opts.KindRegistry}
+			if opts.ReplicaDBPool != nil {
+				endpointsOpts.ReplicaExecutor = riverpropgxv5.New(opts.ReplicaDBPool).GetExecutor()
+			}
+			return riverproui.NewEndpoints(client, endpointsOpts)
 		},
 	)
 }
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/riverqueue/apiframe/apiendpoint"
 	"github.com/riverqueue/river/riverdriver"
@@ -17,9 +18,24 @@ import (
 	"riverqueue.com/riverui/internal/apibundle"
 	"riverqueue.com/riverui/riverproui/internal/prohandler"
 	"riverqueue.com/riverui/uiendpoints"
+	"riverqueue.com/riverui/uikinds"
 )
 
 type EndpointsOpts[TTx any] struct {
+	// Extensions are optional extensions that mount custom endpoints
+	// alongside the built-in ones and add custom feature flags to
+	// `/api/features`. See uiendpoints.Extension.
+	Extensions []uiendpoints.Extension[TTx]
+
+	// KindRegistry is an optional registry of the job kinds that live clients
+	// have registered workers for. See riverui.EndpointsOpts.KindRegistry.
+	KindRegistry uikinds.Registry
+
+	// ReplicaExecutor is an optional executor for a streaming read replica of
+	// the client's database, which read-only endpoints query instead of the
+	// primary. See riverui.EndpointsOpts.ReplicaExecutor.
+	ReplicaExecutor riverdriver.Executor
+
 	// Tx is an optional transaction to wrap all database operations. It's mainly
 	// used for testing.
 	Tx *TTx
@@ -30,7 +46,10 @@ func NewEndpoints[TTx any](client *riverpro.Client[TTx], opts *EndpointsOpts[TTx
 		opts = &EndpointsOpts[TTx]{}
 	}
 	ossEndpoints := riverui.NewEndpoints(client.Client, &riverui.EndpointsOpts[TTx]{
-		Tx: opts.Tx,
+		Extensions:      opts.Extensions,
+		KindRegistry:    opts.KindRegistry,
+		ReplicaExecutor: opts.ReplicaExecutor,
+		Tx:              opts.Tx,
 	})
 
 	return &endpoints[TTx]{
@@ -141,18 +160,31 @@ func (e *endpoints[TTx]) MountEndpoints(archetype *baseservice.Archetype, logger
 	} else {
 		executor = driver.UnwrapProExecutor(*e.proOpts.Tx)
 	}
+	replicaExecutor := e.proOpts.ReplicaExecutor
+	if e.bundleOpts.StatementTimeout > 0 && driver.DatabaseName() == riverdriver.DatabaseNamePostgres {
+		executor = &statementTimeoutProExecutor{ProExecutor: executor, timeout: e.bundleOpts.StatementTimeout}
+		if replicaExecutor != nil {
+			replicaExecutor = &apibundle.StatementTimeoutExecutor{Executor: replicaExecutor, Timeout: e.bundleOpts.StatementTimeout}
+		}
+	}
 	bundle := prohandler.ProAPIBundle[TTx]{
 		APIBundle: apibundle.APIBundle[TTx]{
 			Archetype:                archetype,
+			AuditSink:                e.bundleOpts.AuditSink,
+			AuthorizationPolicy:      e.bundleOpts.AuthorizationPolicy,
 			Client:                   e.client.Client,
 			DB:                       executor,
 			Driver:                   driver,
 			Extensions:               e.Extensions,
 			JobListHideArgsByDefault: e.bundleOpts.JobListHideArgsByDefault,
+			KindRegistry:             e.proOpts.KindRegistry,
 			Logger:                   logger,
+			Redactor:                 e.bundleOpts.Redactor,
+			ReplicaDB:                replicaExecutor,
 		},
-		Client: e.client,
-		DB:     executor,
+		Client:    e.client,
+		DB:        executor,
+		ProDriver: driver,
 	}
 
 	endpoints := e.ossEndpoints.MountEndpoints(archetype, logger, mux, mountOpts)
@@ -169,3 +201,15 @@ func (e *endpoints[TTx]) MountEndpoints(archetype *baseservice.Archetype, logger
 
 	return endpoints
 }
+
+// statementTimeoutProExecutor is apibundle.StatementTimeoutExecutor for River
+// Pro's executor, which Pro endpoints need for its additional queries.
+type statementTimeoutProExecutor struct {
+	prodriver.ProExecutor
+
+	timeout time.Duration
+}
+
+func (e *statementTimeoutProExecutor) Begin(ctx context.Context) (riverdriver.ExecutorTx, error) {
+	return apibundle.BeginWithStatementTimeout(ctx, e.ProExecutor, e.timeout)
+}
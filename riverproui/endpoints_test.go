@@ -4,12 +4,14 @@ import (
 	"context"
 	"log/slog"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/stretchr/testify/require"
 
 	"github.com/riverqueue/river"
 	"github.com/riverqueue/river/riverdbtest"
+	"github.com/riverqueue/river/riverdriver"
 	"github.com/riverqueue/river/rivershared/riversharedtest"
 
 	"riverqueue.com/riverpro"
@@ -17,6 +19,7 @@ import (
 
 	"riverqueue.com/riverui/internal/uicommontest"
 	"riverqueue.com/riverui/riverproui/internal/prohandler"
+	"riverqueue.com/riverui/uiendpoints"
 )
 
 func TestProEndpointsExtensions(t *testing.T) {
@@ -223,3 +226,25 @@ func TestProEndpointsExtensions(t *testing.T) {
 		require.True(t, ext["workflow_queries"])
 	})
 }
+
+func TestStatementTimeoutProExecutor(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var (
+		driver = riverpropgxv5.New(riversharedtest.DBPool(ctx, t))
+		tx, _  = riverdbtest.TestTxPgxDriver(ctx, t, driver, nil)
+		exec   = &statementTimeoutProExecutor{ProExecutor: driver.UnwrapProExecutor(tx), timeout: 1500 * time.Millisecond}
+	)
+
+	timeout, err := uiendpoints.WithTxV(ctx, exec, func(ctx context.Context, execTx riverdriver.ExecutorTx) (string, error) {
+		var timeout string
+		if err := execTx.QueryRow(ctx, "SHOW statement_timeout").Scan(&timeout); err != nil {
+			return "", err
+		}
+		return timeout, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "1500ms", timeout)
+}
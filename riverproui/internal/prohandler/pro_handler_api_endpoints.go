@@ -13,7 +13,6 @@ import (
 	"github.com/riverqueue/apiframe/apiendpoint"
 	"github.com/riverqueue/apiframe/apierror"
 	"github.com/riverqueue/river/riverdriver"
-	"github.com/riverqueue/river/rivershared/util/dbutil"
 	"github.com/riverqueue/river/rivershared/util/ptrutil"
 	"github.com/riverqueue/river/rivershared/util/sliceutil"
 	"github.com/riverqueue/river/rivertype"
@@ -24,13 +23,18 @@ import (
 
 	"riverqueue.com/riverui/internal/apibundle"
 	"riverqueue.com/riverui/riverproui/internal/uitype"
+	"riverqueue.com/riverui/uiaudit"
+	"riverqueue.com/riverui/uiauth"
+	"riverqueue.com/riverui/uiendpoints"
+	"riverqueue.com/riverui/uiredact"
 )
 
 type ProAPIBundle[TTx any] struct {
 	apibundle.APIBundle[TTx]
 
-	Client *riverpro.Client[TTx]
-	DB     riverprodriver.ProExecutor
+	Client    *riverpro.Client[TTx]
+	DB        riverprodriver.ProExecutor
+	ProDriver riverprodriver.ProDriver[TTx]
 }
 
 var WorkflowV2TableNames = []string{ //nolint:gochecknoglobals
@@ -58,6 +62,14 @@ func HasWorkflowV2Tables(ctx context.Context, exec riverdriver.Executor, schema
 	return true, nil
 }
 
+// proExecutorTx returns a Pro executor for a transaction started with
+// uiendpoints.WithTxV on DB or ReadDB so that Pro queries run with its
+// statement timeout, and on the read replica if there is one, like the
+// queries of built-in endpoints.
+func (a *ProAPIBundle[TTx]) proExecutorTx(execTx riverdriver.ExecutorTx) riverprodriver.ProExecutor {
+	return a.ProDriver.UnwrapProExecutor(a.Driver.UnwrapTx(execTx))
+}
+
 func (a *ProAPIBundle[TTx]) requireWorkflowQueries(ctx context.Context) error {
 	hasWorkflowV2Tables, err := HasWorkflowV2Tables(ctx, a.DB, a.Client.Schema())
 	if err != nil {
@@ -97,8 +109,10 @@ func (*periodicJobListEndpoint[TTx]) Meta() *apiendpoint.EndpointMeta {
 	}
 }
 
+func (*periodicJobListEndpoint[TTx]) Access() uiauth.Access { return uiauth.AccessRead }
+
 type periodicJobListRequest struct {
-	Limit *int `json:"-" validate:"omitempty,min=0,max=1000"` // from ExtractRaw
+	Limit *int `json:"-" query:"limit" validate:"omitempty,min=0,max=1000"` // from ExtractRaw
 }
 
 func (req *periodicJobListRequest) ExtractRaw(r *http.Request) error {
@@ -115,10 +129,12 @@ func (req *periodicJobListRequest) ExtractRaw(r *http.Request) error {
 }
 
 func (a *periodicJobListEndpoint[TTx]) Execute(ctx context.Context, req *periodicJobListRequest) (*listResponse[uitype.RiverPeriodicJob], error) {
-	result, err := a.DB.PeriodicJobGetAll(ctx, &riverprodriver.PeriodicJobGetAllParams{
-		Max:                   ptrutil.ValOrDefault(req.Limit, 100),
-		Schema:                a.Client.Schema(),
-		StaleUpdatedAtHorizon: time.Now().Add(-24 * time.Hour),
+	result, err := uiendpoints.WithTxV(ctx, a.ReadDB(), func(ctx context.Context, execTx riverdriver.ExecutorTx) ([]*riverprodriver.PeriodicJob, error) {
+		return a.proExecutorTx(execTx).PeriodicJobGetAll(ctx, &riverprodriver.PeriodicJobGetAllParams{
+			Max:                   ptrutil.ValOrDefault(req.Limit, 100),
+			Schema:                a.Client.Schema(),
+			StaleUpdatedAtHorizon: time.Now().Add(-24 * time.Hour),
+		})
 	})
 	if err != nil {
 		return nil, fmt.Errorf("error listing periodic jobs: %w", err)
@@ -151,8 +167,10 @@ func (*producerListEndpoint[TTx]) Meta() *apiendpoint.EndpointMeta {
 	}
 }
 
+func (*producerListEndpoint[TTx]) Access() uiauth.Access { return uiauth.AccessRead }
+
 type producerListRequest struct {
-	QueueName string `json:"-" validate:"required"` // from ExtractRaw
+	QueueName string `json:"-" query:"queue_name" validate:"required"` // from ExtractRaw
 }
 
 func (req *producerListRequest) ExtractRaw(r *http.Request) error {
@@ -164,9 +182,11 @@ func (req *producerListRequest) ExtractRaw(r *http.Request) error {
 }
 
 func (a *producerListEndpoint[TTx]) Execute(ctx context.Context, req *producerListRequest) (*listResponse[uitype.RiverProducer], error) {
-	result, err := a.DB.ProducerListByQueue(ctx, &riverprodriver.ProducerListByQueueParams{
-		QueueName: req.QueueName,
-		Schema:    a.Client.Schema(),
+	result, err := uiendpoints.WithTxV(ctx, a.ReadDB(), func(ctx context.Context, execTx riverdriver.ExecutorTx) ([]*riverprodriver.ProducerListByQueueResult, error) {
+		return a.proExecutorTx(execTx).ProducerListByQueue(ctx, &riverprodriver.ProducerListByQueueParams{
+			QueueName: req.QueueName,
+			Schema:    a.Client.Schema(),
+		})
 	})
 	if err != nil {
 		return nil, fmt.Errorf("error listing producers: %w", err)
@@ -218,8 +238,11 @@ func (*workflowCancelEndpoint[TTx]) Meta() *apiendpoint.EndpointMeta {
 	}
 }
 
+func (*workflowCancelEndpoint[TTx]) Access() uiauth.Access { return uiauth.AccessMutate }
+func (*workflowCancelEndpoint[TTx]) AuditAction() string   { return string(uiauth.ActionWorkflowCancel) }
+
 type workflowCancelRequest struct {
-	ID string `json:"-" validate:"required"` // from ExtractRaw
+	ID string `json:"-" path:"id" validate:"required"` // from ExtractRaw
 }
 
 func (req *workflowCancelRequest) ExtractRaw(r *http.Request) error {
@@ -236,22 +259,39 @@ func (a *workflowCancelEndpoint[TTx]) Execute(ctx context.Context, req *workflow
 		return nil, err
 	}
 
-	return dbutil.WithTxV(ctx, a.DB, func(ctx context.Context, execTx riverdriver.ExecutorTx) (*workflowCancelResponse, error) {
-		tx := a.Driver.UnwrapTx(execTx)
+	auditEntry := &uiaudit.Entry{
+		Action:     a.AuditAction(),
+		Endpoint:   a.Meta().Pattern,
+		WorkflowID: req.ID,
+	}
 
-		result, err := a.Client.WorkflowCancelTx(ctx, tx, req.ID)
-		if err != nil {
-			return nil, fmt.Errorf("error cancelling workflow: %w", err)
-		}
+	return uiendpoints.WithAudit(ctx, a.APIBundle, auditEntry, req, func() (*workflowCancelResponse, error) {
+		resp, err := uiendpoints.WithTxV(ctx, a.DB, func(ctx context.Context, execTx riverdriver.ExecutorTx) (*workflowCancelResponse, error) {
+			tx := a.Driver.UnwrapTx(execTx)
+
+			result, err := a.Client.WorkflowCancelTx(ctx, tx, req.ID)
+			if err != nil {
+				return nil, fmt.Errorf("error cancelling workflow: %w", err)
+			}
+
+			// consistent ordering
+			slices.SortFunc(result.CancelledJobs, func(a, b *rivertype.JobRow) int {
+				return int(a.ID - b.ID)
+			})
 
-		// consistent ordering
-		slices.SortFunc(result.CancelledJobs, func(a, b *rivertype.JobRow) int {
-			return int(a.ID - b.ID)
+			cancelledJobs, err := internalJobsToJobsMinimal(ctx, a.APIBundle, result.CancelledJobs)
+			if err != nil {
+				return nil, err
+			}
+
+			return &workflowCancelResponse{CancelledJobs: cancelledJobs}, nil
 		})
+		if err != nil {
+			return nil, err
+		}
 
-		return &workflowCancelResponse{
-			CancelledJobs: sliceutil.Map(result.CancelledJobs, internalJobToJobMinimal),
-		}, nil
+		auditEntry.JobIDs = sliceutil.Map(resp.CancelledJobs, func(job *riverJobMinimal) int64 { return job.ID })
+		return resp, nil
 	})
 }
 
@@ -275,8 +315,10 @@ func (*workflowGetEndpoint[TTx]) Meta() *apiendpoint.EndpointMeta {
 	}
 }
 
+func (*workflowGetEndpoint[TTx]) Access() uiauth.Access { return uiauth.AccessRead }
+
 type workflowGetRequest struct {
-	ID string `json:"-" validate:"required"` // from ExtractRaw
+	ID string `json:"-" path:"id" validate:"required"` // from ExtractRaw
 }
 
 func (req *workflowGetRequest) ExtractRaw(r *http.Request) error {
@@ -316,6 +358,19 @@ func (a *workflowGetEndpoint[TTx]) Execute(ctx context.Context, req *workflowGet
 		if serializedTask == nil {
 			continue
 		}
+
+		// Decoded and redacted after serialization so that values derived from
+		// the job's metadata like its staged time are unaffected. Args are
+		// decoded before they're redacted so that redaction applies to the
+		// decoded form.
+		job, argsDecoded, argsDecodeErr := a.DecodeJobArgs(ctx, task.Job)
+
+		if job, err = uiredact.Apply(ctx, a.Redactor, job); err != nil {
+			return nil, fmt.Errorf("error redacting workflow task: %w", err)
+		}
+		serializedTask.riverJobSerializable = *internalJobToSerializableJob(job)
+		serializedTask.setArgsDecoded(argsDecoded, argsDecodeErr)
+
 		tasks = append(tasks, serializedTask)
 	}
 
@@ -346,6 +401,8 @@ func (*workflowTaskSignalsEndpoint[TTx]) Meta() *apiendpoint.EndpointMeta {
 	}
 }
 
+func (*workflowTaskSignalsEndpoint[TTx]) Access() uiauth.Access { return uiauth.AccessRead }
+
 const (
 	workflowTaskSignalsLimitDefault = 20
 	workflowTaskSignalsLimitMax     = 100
@@ -355,15 +412,15 @@ const (
 )
 
 type workflowTaskSignalsRequest struct {
-	CursorID        *int64 `json:"-" validate:"omitempty"`       // from ExtractRaw
-	Desc            *bool  `json:"-" validate:"omitempty"`       // from ExtractRaw
-	ID              string `json:"-" validate:"required"`        // from ExtractRaw
-	Key             string `json:"-" validate:"omitempty"`       // from ExtractRaw
-	Limit           *int   `json:"-" validate:"omitempty,min=1"` // from ExtractRaw
-	Scope           string `json:"-" validate:"omitempty"`       // from ExtractRaw
-	TaskName        string `json:"-" validate:"required"`        // from ExtractRaw
-	TermName        string `json:"-" validate:"omitempty"`       // from ExtractRaw
-	WorkflowAttempt *int   `json:"-" validate:"omitempty,min=1"` // from ExtractRaw
+	CursorID        *int64 `json:"-" query:"cursor_id"        validate:"omitempty"`       // from ExtractRaw
+	Desc            *bool  `json:"-" query:"desc"             validate:"omitempty"`       // from ExtractRaw
+	ID              string `json:"-" path:"id"                validate:"required"`        // from ExtractRaw
+	Key             string `json:"-" query:"key"              validate:"omitempty"`       // from ExtractRaw
+	Limit           *int   `json:"-" query:"limit"            validate:"omitempty,min=1"` // from ExtractRaw
+	Scope           string `json:"-" query:"scope"            validate:"omitempty"`       // from ExtractRaw
+	TaskName        string `json:"-" query:"task_name"        validate:"required"`        // from ExtractRaw
+	TermName        string `json:"-" query:"term_name"        validate:"omitempty"`       // from ExtractRaw
+	WorkflowAttempt *int   `json:"-" query:"workflow_attempt" validate:"omitempty,min=1"` // from ExtractRaw
 }
 
 func (req *workflowTaskSignalsRequest) ExtractRaw(r *http.Request) error {
@@ -582,9 +639,11 @@ func (*workflowTaskWaitDiagnosticsEndpoint[TTx]) Meta() *apiendpoint.EndpointMet
 	}
 }
 
+func (*workflowTaskWaitDiagnosticsEndpoint[TTx]) Access() uiauth.Access { return uiauth.AccessRead }
+
 type workflowTaskWaitDiagnosticsRequest struct {
-	ID       string `json:"-" validate:"required"` // from ExtractRaw
-	TaskName string `json:"-" validate:"required"` // from ExtractRaw
+	ID       string `json:"-" path:"id"         validate:"required"` // from ExtractRaw
+	TaskName string `json:"-" query:"task_name" validate:"required"` // from ExtractRaw
 }
 
 func (req *workflowTaskWaitDiagnosticsRequest) ExtractRaw(r *http.Request) error {
@@ -685,10 +744,12 @@ func (*workflowListEndpoint[TTx]) Meta() *apiendpoint.EndpointMeta {
 	}
 }
 
+func (*workflowListEndpoint[TTx]) Access() uiauth.Access { return uiauth.AccessRead }
+
 type workflowListRequest struct {
-	After *string `json:"-" validate:"omitempty"`                       // from ExtractRaw
-	Limit *int    `json:"-" validate:"omitempty,min=0,max=1000"`        // from ExtractRaw
-	State string  `json:"-" validate:"omitempty,oneof=active inactive"` // from ExtractRaw
+	After *string `json:"-" query:"after" validate:"omitempty"`                       // from ExtractRaw
+	Limit *int    `json:"-" query:"limit" validate:"omitempty,min=0,max=1000"`        // from ExtractRaw
+	State string  `json:"-" query:"state" validate:"omitempty,oneof=active inactive"` // from ExtractRaw
 }
 
 func (req *workflowListRequest) ExtractRaw(r *http.Request) error {
@@ -717,39 +778,28 @@ func (a *workflowListEndpoint[TTx]) Execute(ctx context.Context, req *workflowLi
 		return nil, err
 	}
 
-	switch req.State {
-	case "active":
-		workflows, err := a.DB.WorkflowListActive(ctx, &riverprodriver.WorkflowListParams{
-			After:           ptrutil.ValOrDefault(req.After, ""),
-			PaginationLimit: min(ptrutil.ValOrDefault(req.Limit, 100), 1000),
-			Schema:          a.Client.Schema(),
-		})
-		if err != nil {
-			return nil, fmt.Errorf("error listing workflows: %w", err)
-		}
+	params := &riverprodriver.WorkflowListParams{
+		After:           ptrutil.ValOrDefault(req.After, ""),
+		PaginationLimit: min(ptrutil.ValOrDefault(req.Limit, 100), 1000),
+		Schema:          a.Client.Schema(),
+	}
 
-		return listResponseFrom(sliceutil.Map(workflows, workflowListItemFromInternal)), nil
-	case "inactive":
-		workflows, err := a.DB.WorkflowListInactive(ctx, &riverprodriver.WorkflowListParams{
-			After:           ptrutil.ValOrDefault(req.After, ""),
-			PaginationLimit: min(ptrutil.ValOrDefault(req.Limit, 100), 1000),
-			Schema:          a.Client.Schema(),
-		})
-		if err != nil {
-			return nil, fmt.Errorf("error listing workflows: %w", err)
-		}
-		return listResponseFrom(sliceutil.Map(workflows, workflowListItemFromInternal)), nil
-	default:
-		workflows, err := a.DB.WorkflowListAll(ctx, &riverprodriver.WorkflowListParams{
-			After:           ptrutil.ValOrDefault(req.After, ""),
-			PaginationLimit: min(ptrutil.ValOrDefault(req.Limit, 100), 1000),
-			Schema:          a.Client.Schema(),
-		})
-		if err != nil {
-			return nil, fmt.Errorf("error listing workflows: %w", err)
+	workflows, err := uiendpoints.WithTxV(ctx, a.ReadDB(), func(ctx context.Context, execTx riverdriver.ExecutorTx) ([]*riverprodriver.WorkflowListItem, error) {
+		exec := a.proExecutorTx(execTx)
+		switch req.State {
+		case "active":
+			return exec.WorkflowListActive(ctx, params)
+		case "inactive":
+			return exec.WorkflowListInactive(ctx, params)
+		default:
+			return exec.WorkflowListAll(ctx, params)
 		}
-		return listResponseFrom(sliceutil.Map(workflows, workflowListItemFromInternal)), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing workflows: %w", err)
 	}
+
+	return listResponseFrom(sliceutil.Map(workflows, workflowListItemFromInternal)), nil
 }
 
 //
@@ -772,8 +822,11 @@ func (*workflowRetryEndpoint[TTx]) Meta() *apiendpoint.EndpointMeta {
 	}
 }
 
+func (*workflowRetryEndpoint[TTx]) Access() uiauth.Access { return uiauth.AccessMutate }
+func (*workflowRetryEndpoint[TTx]) AuditAction() string   { return string(uiauth.ActionWorkflowRetry) }
+
 type workflowRetryRequest struct {
-	ID           string `json:"-"             validate:"required"` // from ExtractRaw
+	ID           string `json:"-"             path:"id" validate:"required"` // from ExtractRaw
 	Mode         string `json:"mode"          validate:"omitempty,oneof=all failed_only failed_and_downstream"`
 	ResetHistory bool   `json:"reset_history"`
 }
@@ -792,44 +845,72 @@ func (a *workflowRetryEndpoint[TTx]) Execute(ctx context.Context, req *workflowR
 		return nil, err
 	}
 
-	return dbutil.WithTxV(ctx, a.DB, func(ctx context.Context, execTx riverdriver.ExecutorTx) (*workflowRetryResponse, error) {
-		tx := a.Driver.UnwrapTx(execTx)
+	auditEntry := &uiaudit.Entry{
+		Action:     a.AuditAction(),
+		Endpoint:   a.Meta().Pattern,
+		WorkflowID: req.ID,
+	}
 
-		// Build workflow wrapper from existing workflow ID
-		workflow := a.Client.NewWorkflow(&riverpro.WorkflowOpts{ID: req.ID})
+	return uiendpoints.WithAudit(ctx, a.APIBundle, auditEntry, req, func() (*workflowRetryResponse, error) {
+		resp, err := uiendpoints.WithTxV(ctx, a.DB, func(ctx context.Context, execTx riverdriver.ExecutorTx) (*workflowRetryResponse, error) {
+			tx := a.Driver.UnwrapTx(execTx)
 
-		// Determine retry mode (defaults to "all")
-		var mode riverpro.WorkflowRetryMode
-		switch req.Mode {
-		case "failed_only":
-			mode = riverpro.WorkflowRetryModeFailedOnly
-		case "failed_and_downstream":
-			mode = riverpro.WorkflowRetryModeFailedAndDownstream
-		case "", "all":
-			mode = riverpro.WorkflowRetryModeAll
-		default:
-			// validator should prevent this path; keep safe default
-			mode = riverpro.WorkflowRetryModeAll
-		}
+			// Build workflow wrapper from existing workflow ID
+			workflow := a.Client.NewWorkflow(&riverpro.WorkflowOpts{ID: req.ID})
+
+			// Determine retry mode (defaults to "all")
+			var mode riverpro.WorkflowRetryMode
+			switch req.Mode {
+			case "failed_only":
+				mode = riverpro.WorkflowRetryModeFailedOnly
+			case "failed_and_downstream":
+				mode = riverpro.WorkflowRetryModeFailedAndDownstream
+			case "", "all":
+				mode = riverpro.WorkflowRetryModeAll
+			default:
+				// validator should prevent this path; keep safe default
+				mode = riverpro.WorkflowRetryModeAll
+			}
 
-		result, err := workflow.RetryTx(ctx, tx, &riverpro.WorkflowRetryOpts{
-			Mode:         mode,
-			ResetHistory: req.ResetHistory,
+			result, err := workflow.RetryTx(ctx, tx, &riverpro.WorkflowRetryOpts{
+				Mode:         mode,
+				ResetHistory: req.ResetHistory,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			// consistent ordering
+			slices.SortFunc(result.Jobs, func(a, b *rivertype.JobRow) int { return int(a.ID - b.ID) })
+
+			retriedJobs, err := internalJobsToJobsMinimal(ctx, a.APIBundle, result.Jobs)
+			if err != nil {
+				return nil, err
+			}
+
+			return &workflowRetryResponse{RetriedJobs: retriedJobs}, nil
 		})
 		if err != nil {
 			return nil, err
 		}
 
-		// consistent ordering
-		slices.SortFunc(result.Jobs, func(a, b *rivertype.JobRow) int { return int(a.ID - b.ID) })
-
-		return &workflowRetryResponse{RetriedJobs: sliceutil.Map(result.Jobs, internalJobToJobMinimal)}, nil
+		auditEntry.JobIDs = sliceutil.Map(resp.RetriedJobs, func(job *riverJobMinimal) int64 { return job.ID })
+		return resp, nil
 	})
 }
 
 type riverJobMinimal struct {
-	ID          int64      `json:"id"`
-	Args        string     `json:"args"`
+	ID   int64  `json:"id"`
+	Args string `json:"args"`
+
+	// ArgsDecodeError is the error that occurred decoding the job's args with
+	// the handler's args decoder, in which case Args are the raw args.
+	ArgsDecodeError string `json:"args_decode_error,omitempty"`
+
+	// ArgsDecoded is whether Args are a form decoded by the handler's args
+	// decoder rather than the raw args stored with the job.
+	ArgsDecoded bool `json:"args_decoded"`
+
 	Attempt     int        `json:"attempt"`
 	AttemptedAt *time.Time `json:"attempted_at"`
 	AttemptedBy []string   `json:"attempted_by"`
@@ -868,6 +949,38 @@ func internalJobToJobMinimal(internal *rivertype.JobRow) *riverJobMinimal {
 	}
 }
 
+// Decodes the args of jobs and redacts them, then serializes them along with
+// whether each one's args were decoded and any error decoding them.
+func internalJobsToJobsMinimal[TTx any](ctx context.Context, bundle apibundle.APIBundle[TTx], jobs []*rivertype.JobRow) ([]*riverJobMinimal, error) {
+	var (
+		argsDecoded    = make([]bool, len(jobs))
+		argsDecodeErrs = make([]error, len(jobs))
+		decodedJobs    = make([]*rivertype.JobRow, len(jobs))
+	)
+	for i, job := range jobs {
+		decodedJobs[i], argsDecoded[i], argsDecodeErrs[i] = bundle.DecodeJobArgs(ctx, job)
+	}
+
+	redactedJobs, err := uiredact.ApplyAll(ctx, bundle.Redactor, decodedJobs)
+	if err != nil {
+		return nil, fmt.Errorf("error redacting jobs: %w", err)
+	}
+
+	minimalJobs := make([]*riverJobMinimal, len(redactedJobs))
+	for i, job := range redactedJobs {
+		minimalJobs[i] = internalJobToJobMinimal(job)
+		minimalJobs[i].setArgsDecoded(argsDecoded[i], argsDecodeErrs[i])
+	}
+	return minimalJobs, nil
+}
+
+func (j *riverJobMinimal) setArgsDecoded(decoded bool, decodeErr error) {
+	j.ArgsDecoded = decoded
+	if decodeErr != nil {
+		j.ArgsDecodeError = decodeErr.Error()
+	}
+}
+
 type riverJobSerializable struct {
 	riverJobMinimal
 
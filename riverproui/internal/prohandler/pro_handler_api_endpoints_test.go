@@ -3,6 +3,7 @@ package prohandler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
@@ -32,6 +33,8 @@ import (
 	"riverqueue.com/riverui/internal/riverinternaltest/testfactory"
 	"riverqueue.com/riverui/internal/uicommontest"
 	"riverqueue.com/riverui/riverproui/internal/protestfactory"
+	"riverqueue.com/riverui/uiauth"
+	"riverqueue.com/riverui/uidecode"
 )
 
 type setupEndpointTestBundle struct {
@@ -69,8 +72,9 @@ func setupEndpoint[TEndpoint any](ctx context.Context, t *testing.T, initFunc fu
 			Extensions: func(_ context.Context) (map[string]bool, error) { return map[string]bool{}, nil },
 			Logger:     logger,
 		},
-		Client: client,
-		DB:     exec,
+		Client:    client,
+		DB:        exec,
+		ProDriver: proDriver,
 	})
 
 	if service, ok := any(endpoint).(startstop.Service); ok {
@@ -266,6 +270,54 @@ func TestProAPIHandlerWorkflowGet(t *testing.T) {
 		require.Nil(t, timer.Result)
 	})
 
+	t.Run("DecodedArgs", func(t *testing.T) {
+		t.Parallel()
+
+		endpoint, bundle := setupEndpoint(ctx, t, NewWorkflowGetEndpoint)
+		endpoint.ArgsDecoder = uidecode.ArgsDecoderFunc(func(_ context.Context, kind string, args []byte) ([]byte, error) {
+			if kind == "undecodable" {
+				return nil, errors.New("key not found")
+			}
+			return []byte(`{"email":"a@example.com"}`), nil
+		})
+		endpoint.ArgsDecoderAccess = uiauth.AccessAdmin
+
+		require.NoError(t, bundle.exec.WorkflowInsertMany(ctx, &driver.WorkflowInsertManyParams{
+			IDs:    []string{"wf_decode"},
+			Names:  []string{"wf_decode"},
+			Schema: bundle.schema,
+		}))
+
+		decodableJob := jobWithSchema(ctx, t, bundle.exec, bundle.schema, &testfactory.JobOpts{
+			EncodedArgs: []byte(`"ciphertext1"`),
+			Kind:        ptrutil.Ptr("decodable"),
+			Metadata:    workflowMetadata("wf_decode", "decodable", nil),
+		})
+		undecodableJob := jobWithSchema(ctx, t, bundle.exec, bundle.schema, &testfactory.JobOpts{
+			EncodedArgs: []byte(`"ciphertext2"`),
+			Kind:        ptrutil.Ptr("undecodable"),
+			Metadata:    workflowMetadata("wf_decode", "undecodable", nil),
+		})
+
+		adminCtx := uiauth.WithIdentity(ctx, &uiauth.Identity{Name: "admin", Roles: []uiauth.Role{uiauth.RoleAdmin}})
+		resp, err := apitest.InvokeHandler(adminCtx, endpoint.Execute, testMountOpts(t), &workflowGetRequest{ID: "wf_decode"})
+		require.NoError(t, err)
+		require.Len(t, resp.Tasks, 2)
+
+		taskByID := map[int64]*workflowTaskSerializable{}
+		for _, task := range resp.Tasks {
+			taskByID[task.ID] = task
+		}
+
+		require.True(t, taskByID[decodableJob.ID].ArgsDecoded)
+		require.Empty(t, taskByID[decodableJob.ID].ArgsDecodeError)
+		require.JSONEq(t, `{"email":"a@example.com"}`, taskByID[decodableJob.ID].Args)
+
+		require.False(t, taskByID[undecodableJob.ID].ArgsDecoded)
+		require.Equal(t, "key not found", taskByID[undecodableJob.ID].ArgsDecodeError)
+		require.Equal(t, `"ciphertext2"`, taskByID[undecodableJob.ID].Args)
+	})
+
 	t.Run("NotFound", func(t *testing.T) {
 		t.Parallel()
 
@@ -0,0 +1,46 @@
+package riverproui
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"riverqueue.com/riverpro"
+	"riverqueue.com/riverpro/driver/riverpropgxv5"
+)
+
+// Path of the checked in OpenAPI document including Pro endpoints, which
+// TestOpenAPIDocument keeps in sync with the API.
+const openAPIDocumentPath = "docs/openapi.json"
+
+var updateOpenAPI = flag.Bool("update-openapi", false, "rewrite "+openAPIDocumentPath+" from the API's endpoints") //nolint:gochecknoglobals
+
+func TestOpenAPIDocument(t *testing.T) {
+	t.Parallel()
+
+	// The document only depends on endpoint types, so no database is needed.
+	client, err := riverpro.NewClient(riverpropgxv5.New(nil), &riverpro.Config{})
+	require.NoError(t, err)
+
+	handler := newProHandler(t, NewEndpoints(client, nil))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/openapi.json", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "application/json; charset=utf-8", recorder.Header().Get("Content-Type"))
+
+	if *updateOpenAPI {
+		require.NoError(t, os.WriteFile(openAPIDocumentPath, recorder.Body.Bytes(), 0o600))
+		return
+	}
+
+	expected, err := os.ReadFile(openAPIDocumentPath)
+	require.NoError(t, err)
+	require.Equal(t, string(expected), recorder.Body.String(),
+		"%s is out of date with the API's endpoints; regenerate it with `go test -run TestOpenAPIDocument . -update-openapi` from riverproui", openAPIDocumentPath)
+}
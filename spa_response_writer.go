@@ -30,7 +30,10 @@ func intercept404(handler, on404 http.Handler) http.Handler {
 	})
 }
 
-func serveIndexHTML(devMode bool, manifest map[string]any, pathPrefix string, files http.FileSystem) http.HandlerFunc {
+// serveIndexHTML serves the SPA's index.html. When csrf is non-nil, a CSRF
+// token is injected into the page's config for the frontend to send with
+// state changing requests.
+func serveIndexHTML(devMode bool, manifest map[string]any, pathPrefix string, files http.FileSystem, csrf *csrfProtection) http.HandlerFunc {
 	cachedIndex := indexTemplateResult{}
 	if !devMode {
 		cachedIndex = loadIndexTemplate(files)
@@ -65,6 +68,21 @@ func serveIndexHTML(devMode bool, manifest map[string]any, pathPrefix string, fi
 			Base:   pathPrefix,
 		}
 
+		modTime := indexTemplate.modTime
+		if csrf != nil {
+			csrfToken, err := csrf.ensureToken(rw, req)
+			if err != nil {
+				http.Error(rw, "could not generate CSRF token", http.StatusInternalServerError)
+				return
+			}
+			config.CSRFToken = csrfToken
+
+			// The page is specific to the CSRF cookie, so it mustn't be served
+			// from a cache that may predate it.
+			rw.Header().Set("Cache-Control", "no-store")
+			modTime = time.Time{}
+		}
+
 		templateData := indexTemplateData{
 			Config:   config,
 			Dev:      devMode,
@@ -81,7 +99,7 @@ func serveIndexHTML(devMode bool, manifest map[string]any, pathPrefix string, fi
 		indexReader := bytes.NewReader(output.Bytes())
 
 		rw.Header().Set("Content-Type", "text/html; charset=utf-8")
-		http.ServeContent(rw, req, indexTemplate.name, indexTemplate.modTime, indexReader)
+		http.ServeContent(rw, req, indexTemplate.name, modTime, indexReader)
 	}
 }
 
@@ -146,8 +164,9 @@ func addVaryHeader(headers http.Header, value string) {
 }
 
 type indexTemplateConfig struct {
-	APIURL string `json:"apiUrl"` //nolint:tagliatelle
-	Base   string `json:"base"`
+	APIURL    string `json:"apiUrl"` //nolint:tagliatelle
+	Base      string `json:"base"`
+	CSRFToken string `json:"csrfToken,omitempty"` //nolint:tagliatelle
 }
 
 type indexTemplateData struct {
@@ -2,6 +2,7 @@ package riverui
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -15,7 +16,7 @@ import (
 func TestServeIndexHTMLAcceptNegotiation(t *testing.T) {
 	t.Parallel()
 
-	handler := serveIndexHTML(false, map[string]any{}, "/riverui", newIndexFileSystem())
+	handler := serveIndexHTML(false, map[string]any{}, "/riverui", newIndexFileSystem(), nil)
 
 	tests := []struct {
 		name          string
@@ -81,7 +82,7 @@ func TestServeIndexHTMLAcceptNegotiation(t *testing.T) {
 func TestServeIndexHTMLVaryHeader(t *testing.T) {
 	t.Parallel()
 
-	handler := serveIndexHTML(false, map[string]any{}, "/riverui", newIndexFileSystem())
+	handler := serveIndexHTML(false, map[string]any{}, "/riverui", newIndexFileSystem(), nil)
 
 	recorder := performRequest(handler, http.MethodGet, []string{"application/json"})
 	require.Equal(t, http.StatusNotAcceptable, recorder.Result().StatusCode)
@@ -91,7 +92,7 @@ func TestServeIndexHTMLVaryHeader(t *testing.T) {
 func TestServeIndexHTMLMethodNotAllowed(t *testing.T) {
 	t.Parallel()
 
-	handler := serveIndexHTML(false, map[string]any{}, "/riverui", newIndexFileSystem())
+	handler := serveIndexHTML(false, map[string]any{}, "/riverui", newIndexFileSystem(), nil)
 
 	recorder := performRequest(handler, http.MethodPost, []string{"text/html"})
 	require.Equal(t, http.StatusMethodNotAllowed, recorder.Result().StatusCode)
@@ -102,7 +103,7 @@ func TestServeIndexHTMLMethodNotAllowed(t *testing.T) {
 func TestServeIndexHTMLHead(t *testing.T) {
 	t.Parallel()
 
-	handler := serveIndexHTML(false, map[string]any{}, "/riverui", newIndexFileSystem())
+	handler := serveIndexHTML(false, map[string]any{}, "/riverui", newIndexFileSystem(), nil)
 
 	recorder := performRequest(handler, http.MethodHead, []string{"text/html"})
 	require.Equal(t, http.StatusOK, recorder.Result().StatusCode)
@@ -116,7 +117,7 @@ func TestServeIndexHTMLTemplateCaching(t *testing.T) {
 		t.Parallel()
 
 		counting := &countingFS{fs: newIndexFileSystem()}
-		handler := serveIndexHTML(false, map[string]any{}, "/riverui", counting)
+		handler := serveIndexHTML(false, map[string]any{}, "/riverui", counting, nil)
 
 		require.Equal(t, http.StatusOK, performRequest(handler, http.MethodGet, []string{"text/html"}).Result().StatusCode)
 		require.Equal(t, http.StatusOK, performRequest(handler, http.MethodGet, []string{"text/html"}).Result().StatusCode)
@@ -128,7 +129,7 @@ func TestServeIndexHTMLTemplateCaching(t *testing.T) {
 		t.Parallel()
 
 		counting := &countingFS{fs: newIndexFileSystem()}
-		handler := serveIndexHTML(true, map[string]any{}, "/riverui", counting)
+		handler := serveIndexHTML(true, map[string]any{}, "/riverui", counting, nil)
 
 		require.Equal(t, http.StatusOK, performRequest(handler, http.MethodGet, []string{"text/html"}).Result().StatusCode)
 		require.Equal(t, http.StatusOK, performRequest(handler, http.MethodGet, []string{"text/html"}).Result().StatusCode)
@@ -137,6 +138,37 @@ func TestServeIndexHTMLTemplateCaching(t *testing.T) {
 	})
 }
 
+func TestServeIndexHTMLCSRFToken(t *testing.T) {
+	t.Parallel()
+
+	csrf, err := newCSRFProtection(slog.New(slog.DiscardHandler), "/riverui", nil)
+	require.NoError(t, err)
+
+	files := http.FS(fstest.MapFS{
+		"index.html": &fstest.MapFile{Data: []byte(`<script id="config__json">{{marshal .Config}}</script>`)},
+	})
+	handler := serveIndexHTML(false, map[string]any{}, "/riverui", files, csrf)
+
+	recorder := performRequest(handler, http.MethodGet, []string{"text/html"})
+	require.Equal(t, http.StatusOK, recorder.Result().StatusCode)
+	require.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+
+	cookies := recorder.Result().Cookies()
+	require.Len(t, cookies, 1)
+	require.Equal(t, csrfCookieName, cookies[0].Name)
+	require.Equal(t, "/riverui", cookies[0].Path)
+	require.Contains(t, recorder.Body.String(), `"csrfToken":"`+cookies[0].Value+`"`)
+
+	// A request carrying a valid cookie reuses its token.
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/", nil)
+	req.AddCookie(cookies[0])
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Result().StatusCode)
+	require.Empty(t, recorder.Result().Cookies())
+	require.Contains(t, recorder.Body.String(), `"csrfToken":"`+cookies[0].Value+`"`)
+}
+
 func performRequest(handler http.Handler, method string, acceptHeaders []string) *httptest.ResponseRecorder {
 	req := httptest.NewRequestWithContext(context.Background(), method, "/", nil)
 	for _, header := range acceptHeaders {
@@ -1,5 +1,5 @@
 import { getWorkflow, getWorkflowKey } from "@services/workflows";
-import { NotFoundError } from "@utils/api";
+import { API, NotFoundError } from "@utils/api";
 import { afterEach, describe, expect, it, vi } from "vitest";
 
 describe("API 404 handling", () => {
@@ -65,3 +65,40 @@ describe("API 404 handling", () => {
     });
   });
 });
+
+describe("API CSRF token", () => {
+  afterEach(() => {
+    vi.restoreAllMocks();
+    document.body.innerHTML = "";
+  });
+
+  const mockFetch = () =>
+    vi.spyOn(globalThis, "fetch").mockResolvedValue(
+      new Response("{}", {
+        headers: { "Content-Type": "application/json" },
+        status: 200,
+      }),
+    );
+
+  it("sends the CSRF token from the page config with mutating requests", async () => {
+    document.body.innerHTML =
+      '<script id="config__json">{"apiUrl":"http://example.test/api","csrfToken":"token123"}</script>';
+    const fetchMock = mockFetch();
+
+    await API.post("/jobs/cancel", "{}");
+
+    const headers = new Headers(fetchMock.mock.calls[0][1]?.headers);
+    expect(headers.get("X-CSRF-Token")).toBe("token123");
+  });
+
+  it("doesn't send the CSRF token with GET requests", async () => {
+    document.body.innerHTML =
+      '<script id="config__json">{"apiUrl":"http://example.test/api","csrfToken":"token123"}</script>';
+    const fetchMock = mockFetch();
+
+    await API.get({ path: "/jobs" });
+
+    const headers = new Headers(fetchMock.mock.calls[0][1]?.headers);
+    expect(headers.has("X-CSRF-Token")).toBe(false);
+  });
+});
@@ -48,10 +48,13 @@ export class APIResponseError extends Error {
 
 export class NotFoundError extends Error {}
 
+type PageConfig = {
+  apiUrl?: string;
+  csrfToken?: string;
+};
+
 export function APIUrl(path: string, query?: URLSearchParams) {
-  const configText =
-    document.querySelector("script#config__json")?.textContent || "{}";
-  const pageConfig = JSON.parse(configText);
+  const pageConfig = readPageConfig();
 
   const riverApiBaseUrl =
     pageConfig.apiUrl || import.meta.env.VITE_RIVER_API_BASE_URL;
@@ -76,6 +79,12 @@ async function parseJSONResponse<TResponse>(
   return (await response.json()) as TResponse;
 }
 
+function readPageConfig(): PageConfig {
+  const configText =
+    document.querySelector("script#config__json")?.textContent || "{}";
+  return JSON.parse(configText) as PageConfig;
+}
+
 async function request<TResponse>(
   url: string,
   config: RequestInit,
@@ -85,6 +94,16 @@ async function request<TResponse>(
     headers.set("Accept", "application/json");
   }
 
+  // When CSRF protection is enabled, the server injects a token into the page
+  // config that must accompany state changing requests.
+  const method = (config.method || "GET").toUpperCase();
+  if (method !== "GET" && method !== "HEAD") {
+    const { csrfToken } = readPageConfig();
+    if (csrfToken && !headers.has("X-CSRF-Token")) {
+      headers.set("X-CSRF-Token", csrfToken);
+    }
+  }
+
   const response = await fetch(url, { ...config, headers });
   if (response.ok) {
     return await parseJSONResponse<TResponse>(response, url);
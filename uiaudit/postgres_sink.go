@@ -0,0 +1,195 @@
+package uiaudit
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/riverqueue/river/riverdriver"
+	"github.com/riverqueue/river/rivershared/util/ptrutil"
+)
+
+// DefaultPostgresTable is the name of the table used by PostgresSink unless
+// another is configured.
+const DefaultPostgresTable = "river_ui_audit"
+
+// PostgresSinkOpts are options for NewPostgresSink.
+type PostgresSinkOpts struct {
+	// Schema is the schema containing the audit table. Defaults to the
+	// connection's search path when empty.
+	Schema string
+
+	// Table is the name of the audit table. Defaults to DefaultPostgresTable.
+	Table string
+}
+
+// PostgresSink is a Sink that writes entries to a Postgres table. It
+// implements Lister so that its entries can be browsed through the API.
+//
+// The table must be created before entries are recorded, either with Migrate
+// or by running the SQL returned by MigrateSQL through an application's own
+// migration framework.
+type PostgresSink struct {
+	exec      riverdriver.Executor
+	table     string // quoted and possibly schema qualified
+	tableName string
+}
+
+// NewPostgresSink returns a new PostgresSink that writes entries using the
+// given executor, like one returned by riverpgxv5.New(dbPool).GetExecutor().
+func NewPostgresSink(exec riverdriver.Executor, opts *PostgresSinkOpts) *PostgresSink {
+	if opts == nil {
+		opts = &PostgresSinkOpts{}
+	}
+
+	tableName := cmp.Or(opts.Table, DefaultPostgresTable)
+	table := quoteIdentifier(tableName)
+	if opts.Schema != "" {
+		table = quoteIdentifier(opts.Schema) + "." + table
+	}
+
+	return &PostgresSink{exec: exec, table: table, tableName: tableName}
+}
+
+// MigrateSQL returns the statements that create the audit table and its
+// indexes. They're idempotent.
+func (s *PostgresSink) MigrateSQL() []string {
+	return []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id bigserial PRIMARY KEY,
+	action text NOT NULL,
+	actor text NOT NULL,
+	endpoint text NOT NULL,
+	environment text,
+	error text,
+	job_ids jsonb NOT NULL DEFAULT '[]',
+	occurred_at timestamptz NOT NULL DEFAULT now(),
+	outcome text NOT NULL,
+	params jsonb NOT NULL DEFAULT '{}',
+	queue text,
+	workflow_id text
+)`, s.table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s (actor, id)`,
+			quoteIdentifier(s.tableName+"_actor_id"), s.table),
+	}
+}
+
+// Migrate creates the audit table if it doesn't already exist.
+func (s *PostgresSink) Migrate(ctx context.Context) error {
+	for _, sql := range s.MigrateSQL() {
+		if err := s.exec.Exec(ctx, sql); err != nil {
+			return fmt.Errorf("error migrating audit table: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *PostgresSink) Record(ctx context.Context, entry *Entry) error {
+	jobIDs := entry.JobIDs
+	if jobIDs == nil {
+		jobIDs = []int64{}
+	}
+	jobIDsJSON, err := json.Marshal(jobIDs)
+	if err != nil {
+		return fmt.Errorf("error marshaling job IDs: %w", err)
+	}
+
+	params := entry.Params
+	if len(params) < 1 {
+		params = json.RawMessage("{}")
+	}
+
+	if err := s.exec.QueryRow(ctx, fmt.Sprintf(`
+		INSERT INTO %s (action, actor, endpoint, environment, error, job_ids, occurred_at, outcome, params, queue, workflow_id)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6::jsonb, $7, $8, $9::jsonb, NULLIF($10, ''), NULLIF($11, ''))
+		RETURNING id`, s.table),
+		entry.Action,
+		entry.Actor,
+		entry.Endpoint,
+		entry.Environment,
+		entry.Error,
+		string(jobIDsJSON),
+		entry.OccurredAt,
+		string(entry.Outcome),
+		string(params),
+		entry.Queue,
+		entry.WorkflowID,
+	).Scan(&entry.ID); err != nil {
+		return fmt.Errorf("error inserting audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// postgresEntry is an audit row as encoded by Postgres' json_agg.
+type postgresEntry struct {
+	ID          int64           `json:"id"`
+	Action      string          `json:"action"`
+	Actor       string          `json:"actor"`
+	Endpoint    string          `json:"endpoint"`
+	Environment *string         `json:"environment"`
+	Error       *string         `json:"error"`
+	JobIDs      []int64         `json:"job_ids"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Outcome     string          `json:"outcome"`
+	Params      json.RawMessage `json:"params"`
+	Queue       *string         `json:"queue"`
+	WorkflowID  *string         `json:"workflow_id"`
+}
+
+func (s *PostgresSink) List(ctx context.Context, params *ListParams) ([]*Entry, error) {
+	// Rows are aggregated into a single JSON array because riverdriver.Executor
+	// only exposes single row queries.
+	var rowsJSON []byte
+	if err := s.exec.QueryRow(ctx, fmt.Sprintf(`
+		SELECT coalesce(json_agg(entry ORDER BY entry.id DESC), '[]')
+		FROM (
+			SELECT id, action, actor, endpoint, environment, error, job_ids, occurred_at, outcome, params, queue, workflow_id
+			FROM %s
+			WHERE ($1 = '' OR action = $1)
+				AND ($2 = '' OR actor = $2)
+				AND ($3::bigint = 0 OR id < $3::bigint)
+				AND ($4 = '' OR environment = $4)
+			ORDER BY id DESC
+			LIMIT $5
+		) entry`, s.table),
+		params.Action,
+		params.Actor,
+		params.BeforeID,
+		params.Environment,
+		params.Limit,
+	).Scan(&rowsJSON); err != nil {
+		return nil, fmt.Errorf("error listing audit entries: %w", err)
+	}
+
+	var rows []*postgresEntry
+	if err := json.Unmarshal(rowsJSON, &rows); err != nil {
+		return nil, fmt.Errorf("error unmarshaling audit entries: %w", err)
+	}
+
+	entries := make([]*Entry, len(rows))
+	for i, row := range rows {
+		entries[i] = &Entry{
+			ID:          row.ID,
+			Action:      row.Action,
+			Actor:       row.Actor,
+			Endpoint:    row.Endpoint,
+			Environment: ptrutil.ValOrDefault(row.Environment, ""),
+			Error:       ptrutil.ValOrDefault(row.Error, ""),
+			JobIDs:      row.JobIDs,
+			OccurredAt:  row.OccurredAt,
+			Outcome:     Outcome(row.Outcome),
+			Params:      row.Params,
+			Queue:       ptrutil.ValOrDefault(row.Queue, ""),
+			WorkflowID:  ptrutil.ValOrDefault(row.WorkflowID, ""),
+		}
+	}
+	return entries, nil
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,46 @@
+package uiaudit
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogSink is a Sink that writes entries to an slog logger. Its entries can't
+// be browsed through the API.
+type SlogSink struct {
+	logger *slog.Logger
+}
+
+// NewSlogSink returns a new SlogSink that writes entries to the given logger.
+func NewSlogSink(logger *slog.Logger) *SlogSink {
+	return &SlogSink{logger: logger}
+}
+
+func (s *SlogSink) Record(ctx context.Context, entry *Entry) error {
+	attrs := []slog.Attr{
+		slog.String("action", entry.Action),
+		slog.String("actor", entry.Actor),
+		slog.String("endpoint", entry.Endpoint),
+		slog.Time("occurred_at", entry.OccurredAt),
+		slog.String("outcome", string(entry.Outcome)),
+		slog.String("params", string(entry.Params)),
+	}
+	if entry.Environment != "" {
+		attrs = append(attrs, slog.String("environment", entry.Environment))
+	}
+	if entry.Error != "" {
+		attrs = append(attrs, slog.String("error", entry.Error))
+	}
+	if len(entry.JobIDs) > 0 {
+		attrs = append(attrs, slog.Any("job_ids", entry.JobIDs))
+	}
+	if entry.Queue != "" {
+		attrs = append(attrs, slog.String("queue", entry.Queue))
+	}
+	if entry.WorkflowID != "" {
+		attrs = append(attrs, slog.String("workflow_id", entry.WorkflowID))
+	}
+
+	s.logger.LogAttrs(ctx, slog.LevelInfo, "River UI audit", attrs...)
+	return nil
+}
@@ -0,0 +1,44 @@
+package uiaudit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlogSink(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	sink := NewSlogSink(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	require.NoError(t, sink.Record(ctx, &Entry{
+		Action:     "job:cancel",
+		Actor:      "alice",
+		Endpoint:   "POST /api/jobs/cancel",
+		JobIDs:     []int64{1, 2},
+		OccurredAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Outcome:    OutcomeSuccess,
+		Params:     json.RawMessage(`{"ids":["1","2"]}`),
+	}))
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	require.Equal(t, "River UI audit", record["msg"])
+	require.Equal(t, "job:cancel", record["action"])
+	require.Equal(t, "alice", record["actor"])
+	require.Equal(t, "POST /api/jobs/cancel", record["endpoint"])
+	require.Equal(t, []any{1.0, 2.0}, record["job_ids"])
+	require.Equal(t, "2026-01-02T03:04:05Z", record["occurred_at"])
+	require.Equal(t, "success", record["outcome"])
+	require.JSONEq(t, `{"ids":["1","2"]}`, record["params"].(string)) //nolint:forcetypeassert
+	require.NotContains(t, record, "error")
+	require.NotContains(t, record, "queue")
+}
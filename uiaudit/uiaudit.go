@@ -0,0 +1,116 @@
+// Package uiaudit contains an audit subsystem that records every mutating
+// action taken through River UI's API, like cancelling jobs or pausing queues.
+// Entries are written to a Sink, which may be configured on a riverui.Handler
+// with HandlerOpts.AuditSink. Two sinks are provided: one that writes entries
+// to a Postgres table and can be browsed through the API, and one that writes
+// them to an slog logger.
+package uiaudit
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Outcome is the result of an audited action.
+type Outcome string
+
+const (
+	// OutcomeDenied indicates that the actor wasn't permitted to take the
+	// action and nothing was changed.
+	OutcomeDenied Outcome = "denied"
+
+	// OutcomeFailure indicates that the action failed and its transaction was
+	// rolled back.
+	OutcomeFailure Outcome = "failure"
+
+	// OutcomeSuccess indicates that the action succeeded.
+	OutcomeSuccess Outcome = "success"
+)
+
+// Entry is a single audited action.
+type Entry struct {
+	// ID uniquely identifies the entry. It's assigned by sinks that persist
+	// entries and is zero otherwise.
+	ID int64
+
+	// Action is the action taken, like "job:cancel" or "queue:pause".
+	Action string
+
+	// Actor is the name of the identity that took the action. It's empty for
+	// anonymous requests.
+	Actor string
+
+	// Endpoint is the API endpoint invoked, like "POST /api/jobs/cancel".
+	Endpoint string
+
+	// Environment is the name of the environment whose database the action
+	// was taken against, like "default". Environments served by one handler
+	// share its sink, so it tells apart actions on different databases.
+	Environment string
+
+	// Error is a description of the error that caused the action to fail when
+	// Outcome isn't OutcomeSuccess.
+	Error string
+
+	// JobIDs are the IDs of the jobs affected by the action, if any.
+	JobIDs []int64
+
+	// OccurredAt is the time at which the action was taken.
+	OccurredAt time.Time
+
+	// Outcome is the result of the action.
+	Outcome Outcome
+
+	// Params are the JSON-encoded parameters the endpoint was invoked with.
+	Params json.RawMessage
+
+	// Queue is the name of the queue affected by the action, if any.
+	Queue string
+
+	// WorkflowID is the ID of the workflow affected by the action, if any.
+	WorkflowID string
+}
+
+// Sink records audit entries. Implementations must be safe for concurrent use.
+//
+// Entries are recorded after an action's transaction has completed so that
+// failed and denied actions are recorded too. An error from Record is logged,
+// but doesn't fail the action, which has already been committed.
+type Sink interface {
+	Record(ctx context.Context, entry *Entry) error
+}
+
+// ActionDeclarer is implemented by API endpoints that record audit entries to
+// declare the action they're recorded under. It lets requests that are denied
+// before they reach an endpoint, like those from an identity whose role
+// doesn't grant sufficient access, be recorded as well.
+type ActionDeclarer interface {
+	AuditAction() string
+}
+
+// Lister is implemented by sinks whose entries can be browsed through the
+// `GET /api/audit` endpoint.
+type Lister interface {
+	List(ctx context.Context, params *ListParams) ([]*Entry, error)
+}
+
+// ListParams are parameters for Lister.List.
+type ListParams struct {
+	// Action optionally limits results to entries for the given action.
+	Action string
+
+	// Actor optionally limits results to entries for the given actor.
+	Actor string
+
+	// BeforeID optionally limits results to entries with an ID lower than the
+	// given one. Used to page through results.
+	BeforeID int64
+
+	// Environment optionally limits results to entries for the given
+	// environment.
+	Environment string
+
+	// Limit is the maximum number of entries to return.
+	Limit int
+}
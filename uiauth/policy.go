@@ -0,0 +1,234 @@
+package uiauth
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Action is a mutating operation that can be scoped to particular queues and
+// job kinds with a Policy.
+type Action string
+
+const (
+	ActionJobCancel   Action = "job:cancel"
+	ActionJobDelete   Action = "job:delete"
+	ActionJobRetry    Action = "job:retry"
+	ActionQueuePause  Action = "queue:pause"
+	ActionQueueResume Action = "queue:resume"
+	ActionQueueUpdate Action = "queue:update"
+)
+
+// Actions on River Pro workflows. They're recorded in the audit log, but can't
+// be scoped with a Policy, which only applies to jobs and queues.
+const (
+	ActionWorkflowCancel Action = "workflow:cancel"
+	ActionWorkflowRetry  Action = "workflow:retry"
+)
+
+func (a Action) isQueueAction() bool {
+	return strings.HasPrefix(string(a), "queue:")
+}
+
+func (a Action) validate() error {
+	switch a {
+	case ActionJobCancel, ActionJobDelete, ActionJobRetry, ActionQueuePause, ActionQueueResume, ActionQueueUpdate:
+		return nil
+	}
+	return fmt.Errorf("unknown action %q", a)
+}
+
+// Policy scopes the actions that identities may take to particular queues and
+// job kinds. It's layered on top of roles: an identity must still have a role
+// granting AccessMutate to take any action, and admins are never restricted
+// by a policy.
+//
+// A policy without rules restricts nothing. Once any rule is present, all
+// non-admin identities are limited to the actions granted to them by rules.
+type Policy struct {
+	// FilterLists causes list endpoints to only return jobs and queues that
+	// an identity is covered by a rule for. When false, all jobs and queues are
+	// visible, but actions on them are still restricted.
+	FilterLists bool `json:"filter_lists"`
+
+	// Rules are the rules granting actions to identities.
+	Rules []*Rule `json:"rules"`
+}
+
+// Rule grants actions on matching queues and job kinds to subjects. Queue and
+// kind patterns may contain `*` wildcards that match any sequence of
+// characters, like `billing_*` or `invoice.*`.
+type Rule struct {
+	// Actions are the actions granted. A rule without actions only grants
+	// visibility when Policy.FilterLists is enabled.
+	Actions []Action `json:"actions"`
+
+	// Kinds are patterns for the job kinds the rule applies to. Empty matches
+	// any kind. Not considered for queue actions.
+	Kinds []string `json:"kinds"`
+
+	// Queues are patterns for the queues the rule applies to. Empty matches
+	// any queue.
+	Queues []string `json:"queues"`
+
+	// Subjects are the identities that the rule applies to, matched against
+	// an identity's name or any of its groups.
+	Subjects []string `json:"subjects"`
+}
+
+// Validate checks that the policy is well formed.
+func (p *Policy) Validate() error {
+	if p == nil {
+		return nil
+	}
+
+	for i, rule := range p.Rules {
+		if len(rule.Subjects) < 1 {
+			return fmt.Errorf("rule %d: at least one subject is required", i)
+		}
+		for _, action := range rule.Actions {
+			if err := action.validate(); err != nil {
+				return fmt.Errorf("rule %d: %w", i, err)
+			}
+		}
+		for _, pattern := range slices.Concat(rule.Kinds, rule.Queues) {
+			if pattern == "" {
+				return fmt.Errorf("rule %d: patterns may not be empty", i)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Restricts returns true if the policy restricts the given identity, which is
+// the case when the policy has at least one rule and the identity isn't an
+// admin. When false, the identity may take any action its roles allow on any
+// queue or kind.
+func (p *Policy) Restricts(identity *Identity) bool {
+	if p == nil || len(p.Rules) < 1 {
+		return false
+	}
+	return !identity.Allows(AccessAdmin)
+}
+
+// RulesFor returns the rules whose subjects match the given identity.
+func (p *Policy) RulesFor(identity *Identity) []*Rule {
+	if p == nil || identity == nil {
+		return nil
+	}
+
+	var rules []*Rule
+	for _, rule := range p.Rules {
+		if slices.ContainsFunc(rule.Subjects, identity.hasSubject) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// AllowsJobAction returns true if the identity may take the given action on a
+// job in the given queue and of the given kind.
+func (p *Policy) AllowsJobAction(identity *Identity, action Action, queue, kind string) bool {
+	if !p.Restricts(identity) {
+		return true
+	}
+
+	return slices.ContainsFunc(p.RulesFor(identity), func(rule *Rule) bool {
+		return slices.Contains(rule.Actions, action) && rule.MatchesQueue(queue) && rule.MatchesKind(kind)
+	})
+}
+
+// AllowsQueueAction returns true if the identity may take the given action on
+// the given queue.
+func (p *Policy) AllowsQueueAction(identity *Identity, action Action, queue string) bool {
+	if !p.Restricts(identity) {
+		return true
+	}
+
+	return slices.ContainsFunc(p.RulesFor(identity), func(rule *Rule) bool {
+		return slices.Contains(rule.Actions, action) && action.isQueueAction() && rule.MatchesQueue(queue)
+	})
+}
+
+// FiltersLists returns true if list results should be filtered to what the
+// given identity may see.
+func (p *Policy) FiltersLists(identity *Identity) bool {
+	return p.Restricts(identity) && p.FilterLists
+}
+
+// CanSeeJob returns true if the identity may see a job in the given queue and
+// of the given kind. Always true unless FilterLists is enabled.
+func (p *Policy) CanSeeJob(identity *Identity, queue, kind string) bool {
+	if !p.FiltersLists(identity) {
+		return true
+	}
+
+	return slices.ContainsFunc(p.RulesFor(identity), func(rule *Rule) bool {
+		return rule.MatchesQueue(queue) && rule.MatchesKind(kind)
+	})
+}
+
+// CanSeeKind returns true if the identity may see the given job kind. Always
+// true unless FilterLists is enabled.
+func (p *Policy) CanSeeKind(identity *Identity, kind string) bool {
+	if !p.FiltersLists(identity) {
+		return true
+	}
+
+	return slices.ContainsFunc(p.RulesFor(identity), func(rule *Rule) bool {
+		return rule.MatchesKind(kind)
+	})
+}
+
+// CanSeeQueue returns true if the identity may see the given queue. Always
+// true unless FilterLists is enabled.
+func (p *Policy) CanSeeQueue(identity *Identity, queue string) bool {
+	if !p.FiltersLists(identity) {
+		return true
+	}
+
+	return slices.ContainsFunc(p.RulesFor(identity), func(rule *Rule) bool {
+		return rule.MatchesQueue(queue)
+	})
+}
+
+// MatchesKind returns true if the rule applies to the given job kind.
+func (r *Rule) MatchesKind(kind string) bool {
+	return len(r.Kinds) < 1 || slices.ContainsFunc(r.Kinds, func(pattern string) bool { return MatchPattern(pattern, kind) })
+}
+
+// MatchesQueue returns true if the rule applies to the given queue.
+func (r *Rule) MatchesQueue(queue string) bool {
+	return len(r.Queues) < 1 || slices.ContainsFunc(r.Queues, func(pattern string) bool { return MatchPattern(pattern, queue) })
+}
+
+// MatchPattern matches a value against a pattern where `*` matches any
+// sequence of characters and all other characters match literally.
+func MatchPattern(pattern, value string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == value
+	}
+
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+
+	// Match each part between wildcards at its earliest possible position,
+	// leaving as much of the value as possible for the parts that follow.
+	for _, part := range parts[1 : len(parts)-1] {
+		index := strings.Index(value, part)
+		if index < 0 {
+			return false
+		}
+		value = value[index+len(part):]
+	}
+
+	return strings.HasSuffix(value, parts[len(parts)-1])
+}
+
+func (i *Identity) hasSubject(subject string) bool {
+	return i.Name == subject || slices.Contains(i.Groups, subject)
+}
@@ -0,0 +1,141 @@
+package uiauth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchPattern(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern string
+		value   string
+		want    bool
+	}{
+		{"billing", "billing", true},
+		{"billing", "billing_high", false},
+		{"billing_*", "billing_high", true},
+		{"billing_*", "billing_", true},
+		{"billing_*", "billing", false},
+		{"*", "anything", true},
+		{"*", "", true},
+		{"invoice.*", "invoice.send", true},
+		{"invoice.*", "invoicexsend", false},
+		{"*.send", "invoice.send", true},
+		{"*.send", "invoice.sender", false},
+		{"a*b*c", "aXbYc", true},
+		{"a*b*c", "abc", true},
+		{"a*b*c", "acb", false},
+		{"a*a", "a", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"/"+tt.value, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, MatchPattern(tt.pattern, tt.value))
+		})
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	t.Parallel()
+
+	var (
+		admin    = &Identity{Name: "root", Roles: []Role{RoleAdmin}}
+		billing  = &Identity{Groups: []string{"team-billing"}, Name: "alice", Roles: []Role{RoleOperator}}
+		stranger = &Identity{Name: "bob", Roles: []Role{RoleOperator}}
+	)
+
+	policy := &Policy{
+		FilterLists: true,
+		Rules: []*Rule{
+			{
+				Actions:  []Action{ActionJobCancel, ActionJobRetry, ActionQueuePause},
+				Kinds:    []string{"invoice.*"},
+				Queues:   []string{"billing_*"},
+				Subjects: []string{"team-billing"},
+			},
+		},
+	}
+
+	t.Run("NilPolicyRestrictsNothing", func(t *testing.T) {
+		t.Parallel()
+
+		var policy *Policy
+		require.False(t, policy.Restricts(stranger))
+		require.True(t, policy.AllowsJobAction(stranger, ActionJobDelete, "mailers", "email.send"))
+		require.True(t, policy.AllowsQueueAction(stranger, ActionQueuePause, "mailers"))
+		require.True(t, policy.CanSeeJob(stranger, "mailers", "email.send"))
+	})
+
+	t.Run("PolicyWithoutRulesRestrictsNothing", func(t *testing.T) {
+		t.Parallel()
+
+		require.False(t, (&Policy{FilterLists: true}).Restricts(stranger))
+	})
+
+	t.Run("AdminsUnrestricted", func(t *testing.T) {
+		t.Parallel()
+
+		require.False(t, policy.Restricts(admin))
+		require.True(t, policy.AllowsJobAction(admin, ActionJobDelete, "mailers", "email.send"))
+		require.True(t, policy.CanSeeQueue(admin, "mailers"))
+	})
+
+	t.Run("JobActions", func(t *testing.T) {
+		t.Parallel()
+
+		require.True(t, policy.AllowsJobAction(billing, ActionJobCancel, "billing_high", "invoice.send"))
+		require.True(t, policy.AllowsJobAction(billing, ActionJobRetry, "billing_low", "invoice.void"))
+		require.False(t, policy.AllowsJobAction(billing, ActionJobDelete, "billing_high", "invoice.send"))
+		require.False(t, policy.AllowsJobAction(billing, ActionJobCancel, "mailers", "invoice.send"))
+		require.False(t, policy.AllowsJobAction(billing, ActionJobCancel, "billing_high", "email.send"))
+		require.False(t, policy.AllowsJobAction(stranger, ActionJobCancel, "billing_high", "invoice.send"))
+	})
+
+	t.Run("QueueActions", func(t *testing.T) {
+		t.Parallel()
+
+		require.True(t, policy.AllowsQueueAction(billing, ActionQueuePause, "billing_high"))
+		require.False(t, policy.AllowsQueueAction(billing, ActionQueueResume, "billing_high"))
+		require.False(t, policy.AllowsQueueAction(billing, ActionQueuePause, "mailers"))
+		require.False(t, policy.AllowsQueueAction(billing, ActionQueuePause, "*"))
+		require.False(t, policy.AllowsQueueAction(stranger, ActionQueuePause, "billing_high"))
+	})
+
+	t.Run("Visibility", func(t *testing.T) {
+		t.Parallel()
+
+		require.True(t, policy.CanSeeJob(billing, "billing_high", "invoice.send"))
+		require.False(t, policy.CanSeeJob(billing, "mailers", "invoice.send"))
+		require.True(t, policy.CanSeeKind(billing, "invoice.send"))
+		require.False(t, policy.CanSeeKind(billing, "email.send"))
+		require.True(t, policy.CanSeeQueue(billing, "billing_high"))
+		require.False(t, policy.CanSeeQueue(billing, "mailers"))
+		require.False(t, policy.CanSeeQueue(stranger, "billing_high"))
+	})
+
+	t.Run("VisibilityUnfilteredWithoutFilterLists", func(t *testing.T) {
+		t.Parallel()
+
+		policy := &Policy{Rules: policy.Rules}
+		require.True(t, policy.CanSeeQueue(stranger, "mailers"))
+		require.False(t, policy.AllowsQueueAction(stranger, ActionQueuePause, "mailers"))
+	})
+}
+
+func TestPolicyValidate(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, (*Policy)(nil).Validate())
+	require.NoError(t, (&Policy{Rules: []*Rule{{Actions: []Action{ActionJobCancel}, Subjects: []string{"alice"}}}}).Validate())
+
+	require.EqualError(t, (&Policy{Rules: []*Rule{{Actions: []Action{ActionJobCancel}}}}).Validate(),
+		"rule 0: at least one subject is required")
+	require.EqualError(t, (&Policy{Rules: []*Rule{{Actions: []Action{"job:explode"}, Subjects: []string{"alice"}}}}).Validate(),
+		`rule 0: unknown action "job:explode"`)
+	require.EqualError(t, (&Policy{Rules: []*Rule{{Queues: []string{""}, Subjects: []string{"alice"}}}}).Validate(),
+		"rule 0: patterns may not be empty")
+}
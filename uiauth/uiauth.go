@@ -0,0 +1,133 @@
+// Package uiauth contains types used to authorize requests made to River UI's
+// API. Authentication is handled by middleware in front of a riverui.Handler,
+// which places an Identity carrying one or more roles into the request context
+// with WithIdentity. The Handler then checks that identity against the access
+// level declared by each API endpoint before the endpoint is executed.
+package uiauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/riverqueue/apiframe/apierror"
+)
+
+// Access is the level of access required to invoke an API endpoint.
+type Access string
+
+const (
+	// AccessRead is required by endpoints that only read state, like listing
+	// jobs or getting a queue.
+	AccessRead Access = "read"
+
+	// AccessMutate is required by endpoints that change state, like
+	// cancelling jobs or pausing queues.
+	AccessMutate Access = "mutate"
+
+	// AccessAdmin is required by endpoints that are only for administrators.
+	AccessAdmin Access = "admin"
+)
+
+// AccessDeclarer is implemented by API endpoints to declare the level of
+// access they require. Endpoints that don't implement it are assumed to
+// require AccessRead for GET and HEAD requests and AccessMutate otherwise.
+type AccessDeclarer interface {
+	Access() Access
+}
+
+// Role is a role granted to an identity. Each role grants all the access of
+// the roles before it.
+type Role string
+
+const (
+	// RoleViewer may use read-only endpoints.
+	RoleViewer Role = "viewer"
+
+	// RoleOperator may use read-only endpoints and may also mutate state, like
+	// cancelling, retrying, or deleting jobs and pausing queues.
+	RoleOperator Role = "operator"
+
+	// RoleAdmin has unrestricted access.
+	RoleAdmin Role = "admin"
+)
+
+// ParseRole parses a role from a string like "viewer", returning an error if
+// the role isn't known.
+func ParseRole(s string) (Role, error) {
+	role := Role(s)
+	switch role {
+	case RoleAdmin, RoleOperator, RoleViewer:
+		return role, nil
+	}
+	return "", fmt.Errorf("unknown role %q; must be one of: %s, %s, %s", s, RoleViewer, RoleOperator, RoleAdmin)
+}
+
+// Allows returns true if the role grants the given access.
+func (r Role) Allows(access Access) bool {
+	switch access {
+	case AccessRead:
+		return r == RoleViewer || r == RoleOperator || r == RoleAdmin
+	case AccessMutate:
+		return r == RoleOperator || r == RoleAdmin
+	case AccessAdmin:
+		return r == RoleAdmin
+	}
+	return false
+}
+
+// Identity is an authenticated principal making a request to River UI.
+type Identity struct {
+	// Groups are groups or teams the principal belongs to. They're matched
+	// against the subjects of Policy rules.
+	Groups []string
+
+	// Name identifies the principal, like a username or email address. It's
+	// empty for anonymous requests.
+	Name string
+
+	// Roles are the roles granted to the principal.
+	Roles []Role
+}
+
+// Allows returns true if any of the identity's roles grant the given access.
+// A nil identity is allowed nothing.
+func (i *Identity) Allows(access Access) bool {
+	if i == nil {
+		return false
+	}
+	return slices.ContainsFunc(i.Roles, func(role Role) bool { return role.Allows(access) })
+}
+
+type identityContextKey struct{}
+
+// IdentityFromContext returns the identity stored in context by WithIdentity,
+// or nil if there isn't one.
+func IdentityFromContext(ctx context.Context) *Identity {
+	identity, _ := ctx.Value(identityContextKey{}).(*Identity)
+	return identity
+}
+
+// WithIdentity returns a copy of ctx containing the given identity. It should
+// be invoked by authentication middleware placed in front of a
+// riverui.Handler.
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityContextKey{}, identity)
+}
+
+// Forbidden is an API error returned when an identity isn't allowed to
+// perform an operation.
+type Forbidden struct { //nolint:errname
+	apierror.APIError
+}
+
+// NewForbiddenf returns a new Forbidden API error with a formatted message.
+func NewForbiddenf(format string, a ...any) *Forbidden {
+	return &Forbidden{
+		APIError: apierror.APIError{
+			Message:    fmt.Sprintf(format, a...),
+			StatusCode: http.StatusForbidden,
+		},
+	}
+}
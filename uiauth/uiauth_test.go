@@ -0,0 +1,82 @@
+package uiauth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRole(t *testing.T) {
+	t.Parallel()
+
+	for _, role := range []Role{RoleAdmin, RoleOperator, RoleViewer} {
+		parsed, err := ParseRole(string(role))
+		require.NoError(t, err)
+		require.Equal(t, role, parsed)
+	}
+
+	_, err := ParseRole("superuser")
+	require.EqualError(t, err, `unknown role "superuser"; must be one of: viewer, operator, admin`)
+}
+
+func TestRoleAllows(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		role       Role
+		wantRead   bool
+		wantMutate bool
+		wantAdmin  bool
+	}{
+		{RoleViewer, true, false, false},
+		{RoleOperator, true, true, false},
+		{RoleAdmin, true, true, true},
+		{Role("unknown"), false, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.role), func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.wantRead, tt.role.Allows(AccessRead))
+			require.Equal(t, tt.wantMutate, tt.role.Allows(AccessMutate))
+			require.Equal(t, tt.wantAdmin, tt.role.Allows(AccessAdmin))
+		})
+	}
+}
+
+func TestIdentityAllows(t *testing.T) {
+	t.Parallel()
+
+	t.Run("AnyRoleAllows", func(t *testing.T) {
+		t.Parallel()
+
+		identity := &Identity{Name: "alice", Roles: []Role{RoleViewer, RoleOperator}}
+		require.True(t, identity.Allows(AccessMutate))
+		require.False(t, identity.Allows(AccessAdmin))
+	})
+
+	t.Run("NoRoles", func(t *testing.T) {
+		t.Parallel()
+
+		require.False(t, (&Identity{Name: "alice"}).Allows(AccessRead))
+	})
+
+	t.Run("NilIdentity", func(t *testing.T) {
+		t.Parallel()
+
+		var identity *Identity
+		require.False(t, identity.Allows(AccessRead))
+	})
+}
+
+func TestIdentityContext(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	require.Nil(t, IdentityFromContext(ctx))
+
+	identity := &Identity{Name: "alice", Roles: []Role{RoleAdmin}}
+	require.Equal(t, identity, IdentityFromContext(WithIdentity(ctx, identity)))
+}
@@ -0,0 +1,561 @@
+// Package uiclient provides a typed client for River UI's HTTP API, for use
+// in scripts, bots, and other tooling that operates on jobs and queues
+// through a running River UI rather than directly against the database.
+//
+// Responses reuse riverui's API types like riverui.RiverJob and
+// riverui.RiverQueue. Errors returned by the API are *apierror.APIError values
+// carrying the response's status code and message:
+//
+//	job, err := client.JobGet(ctx, 123)
+//	if err != nil {
+//		var apiErr *apierror.APIError
+//		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+//			// handle missing job
+//		}
+//		return err
+//	}
+package uiclient
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/riverqueue/apiframe/apierror"
+	"github.com/riverqueue/river/rivertype"
+
+	"riverqueue.com/riverui"
+	"riverqueue.com/riverui/uischema"
+)
+
+// ClientOpts are options for a Client.
+type ClientOpts struct {
+	// BasicAuthPassword and BasicAuthUser are credentials for a River UI
+	// protected with basic auth, like the riverui executable's
+	// RIVER_BASIC_AUTH_USER and RIVER_BASIC_AUTH_PASS. Ignored if Token is set.
+	BasicAuthPassword string
+	BasicAuthUser     string
+
+	// Environment optionally selects one of the named River environments
+	// served by the UI. Defaults to the default environment.
+	Environment string
+
+	// HTTPClient is the client used to make requests. Defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+
+	// Token is an API token sent as a bearer token, like one created with
+	// `riverui tokens create`.
+	Token string
+}
+
+// Client calls the API of a River UI. It's safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	opts       *ClientOpts
+}
+
+// NewClient returns a client for the River UI at baseURL, which includes any
+// path prefix the UI is mounted at, like `https://example.com/riverui`.
+func NewClient(baseURL string, opts *ClientOpts) (*Client, error) {
+	if opts == nil {
+		opts = &ClientOpts{}
+	}
+
+	parsedURL, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base URL %q: must be an http or https URL", baseURL)
+	}
+	parsedURL.Path = strings.TrimSuffix(parsedURL.Path, "/")
+
+	return &Client{
+		baseURL:    parsedURL,
+		httpClient: cmp.Or(opts.HTTPClient, http.DefaultClient),
+		opts:       opts,
+	}, nil
+}
+
+//
+// Audit log
+//
+
+// AuditListParams are parameters for AuditList.
+type AuditListParams struct {
+	// Action optionally limits results to entries for an action, like
+	// `job_cancel`.
+	Action string
+
+	// Actor optionally limits results to entries for an actor.
+	Actor string
+
+	// BeforeID optionally limits results to entries with an ID lower than the
+	// given one. Entries are returned newest first, so pages are fetched by
+	// setting it to the ID of the last entry of the previous page.
+	BeforeID int64
+
+	// Environment optionally limits results to entries for actions taken in
+	// an environment. Environments share an audit log, so entries of all of
+	// them are listed regardless of the environment the client selects.
+	Environment string
+
+	// Limit is the maximum number of entries to return. Defaults to the API's
+	// default of 100.
+	Limit int
+}
+
+// AuditList lists audit log entries, newest first. It requires admin access
+// and a browsable audit log.
+func (c *Client) AuditList(ctx context.Context, params *AuditListParams) ([]*riverui.AuditEntry, error) {
+	if params == nil {
+		params = &AuditListParams{}
+	}
+
+	query := url.Values{}
+	setQueryString(query, "action", params.Action)
+	setQueryString(query, "actor", params.Actor)
+	setQueryInt(query, "before_id", params.BeforeID)
+	setQueryString(query, "environment", params.Environment)
+	setQueryInt(query, "limit", int64(params.Limit))
+
+	var resp listResponse[riverui.AuditEntry]
+	if err := c.do(ctx, http.MethodGet, "/api/audit", query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// AuditEntries iterates through all audit log entries matching params, newest
+// first, fetching pages of params.Limit entries as needed. Iteration stops
+// after the first error.
+func (c *Client) AuditEntries(ctx context.Context, params *AuditListParams) iter.Seq2[*riverui.AuditEntry, error] {
+	pageParams := AuditListParams{}
+	if params != nil {
+		pageParams = *params
+	}
+
+	return paginate(func() ([]*riverui.AuditEntry, error) {
+		entries, err := c.AuditList(ctx, &pageParams)
+		if len(entries) > 0 {
+			pageParams.BeforeID = entries[len(entries)-1].ID
+		}
+		return entries, err
+	})
+}
+
+//
+// Health checks
+//
+
+// HealthCheckName is the name of a health check.
+type HealthCheckName string
+
+const (
+	// HealthCheckComplete checks that the UI is up and can query its
+	// database.
+	HealthCheckComplete HealthCheckName = "complete"
+
+	// HealthCheckMinimal checks only that the UI is up.
+	HealthCheckMinimal HealthCheckName = "minimal"
+)
+
+// HealthCheck runs a health check, returning an error if it fails.
+func (c *Client) HealthCheck(ctx context.Context, name HealthCheckName) error {
+	return c.do(ctx, http.MethodGet, "/api/health-checks/"+url.PathEscape(string(name)), nil, nil, nil)
+}
+
+//
+// Jobs
+//
+
+// JobListParams are parameters for JobList, which filter jobs like the UI's
+// job list.
+type JobListParams struct {
+	// IDs optionally limits results to jobs with the given IDs.
+	IDs []int64
+
+	// Kinds optionally limits results to jobs of the given kinds.
+	Kinds []string
+
+	// Limit is the maximum number of jobs to return. Defaults to the API's
+	// default of 20.
+	Limit int
+
+	// Priorities optionally limits results to jobs with the given priorities.
+	Priorities []int16
+
+	// Queues optionally limits results to jobs in the given queues.
+	Queues []string
+
+	// State optionally limits results to jobs in the given state.
+	State rivertype.JobState
+
+	// Tags optionally limits results to jobs with the given tags.
+	Tags []string
+}
+
+// JobList lists jobs matching params.
+func (c *Client) JobList(ctx context.Context, params *JobListParams) ([]*riverui.RiverJobMinimal, error) {
+	if params == nil {
+		params = &JobListParams{}
+	}
+
+	query := url.Values{}
+	for _, id := range params.IDs {
+		query.Add("ids", strconv.FormatInt(id, 10))
+	}
+	setQueryStrings(query, "kinds", params.Kinds)
+	setQueryInt(query, "limit", int64(params.Limit))
+	for _, priority := range params.Priorities {
+		query.Add("priorities", strconv.Itoa(int(priority)))
+	}
+	setQueryStrings(query, "queues", params.Queues)
+	setQueryString(query, "state", string(params.State))
+	setQueryStrings(query, "tags", params.Tags)
+
+	var resp listResponse[riverui.RiverJobMinimal]
+	if err := c.do(ctx, http.MethodGet, "/api/jobs", query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// JobGet gets a job by ID.
+func (c *Client) JobGet(ctx context.Context, id int64) (*riverui.RiverJob, error) {
+	var job riverui.RiverJob
+	if err := c.do(ctx, http.MethodGet, "/api/jobs/"+strconv.FormatInt(id, 10), nil, nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// JobCancel cancels jobs by ID. Jobs that are already finalized are left
+// unchanged.
+func (c *Client) JobCancel(ctx context.Context, ids ...int64) error {
+	return c.do(ctx, http.MethodPost, "/api/jobs/cancel", nil, newJobIDsRequest(ids), nil)
+}
+
+// JobDelete deletes jobs by ID. Running jobs can't be deleted.
+func (c *Client) JobDelete(ctx context.Context, ids ...int64) error {
+	return c.do(ctx, http.MethodPost, "/api/jobs/delete", nil, newJobIDsRequest(ids), nil)
+}
+
+// JobRetry makes jobs available to be worked again immediately, including
+// ones that are finalized. Retrying a job that conflicts with an active unique
+// job fails with a 409.
+func (c *Client) JobRetry(ctx context.Context, ids ...int64) error {
+	return c.do(ctx, http.MethodPost, "/api/jobs/retry", nil, newJobIDsRequest(ids), nil)
+}
+
+// jobIDsRequest is the body of requests that act on jobs by ID. IDs are sent
+// as strings, as the API expects, so that they round trip through JavaScript
+// clients without losing precision.
+type jobIDsRequest struct {
+	IDs []string `json:"ids"`
+}
+
+func newJobIDsRequest(ids []int64) *jobIDsRequest {
+	req := &jobIDsRequest{IDs: make([]string, len(ids))}
+	for i, id := range ids {
+		req.IDs[i] = strconv.FormatInt(id, 10)
+	}
+	return req
+}
+
+// JobStateCounts returns the number of jobs in each state. Counts may be
+// cached by the UI for large job tables.
+func (c *Client) JobStateCounts(ctx context.Context) (map[rivertype.JobState]int, error) {
+	var counts map[rivertype.JobState]int
+	if err := c.do(ctx, http.MethodGet, "/api/states", nil, nil, &counts); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+//
+// Kinds
+//
+
+// KindListParams are parameters for KindList.
+type KindListParams struct {
+	// StaleAfter is how long after its last report a kind registration stops
+	// counting as live. Defaults to the API's default of
+	// uikinds.DefaultStaleAfter.
+	StaleAfter time.Duration
+}
+
+// KindListResult is the result of KindList.
+type KindListResult struct {
+	// Kinds are the kinds found in the database or the UI's kind registry.
+	Kinds []*riverui.RiverKind
+
+	// RegistryConfigured is whether the UI has a kind registry configured.
+	// Without one, no kinds are registered and none are flagged as unhandled
+	// or stale.
+	RegistryConfigured bool
+}
+
+// KindList lists job kinds along with whether live clients have registered
+// workers for them, flagging kinds with pending jobs but no live worker.
+func (c *Client) KindList(ctx context.Context, params *KindListParams) (*KindListResult, error) {
+	if params == nil {
+		params = &KindListParams{}
+	}
+
+	query := url.Values{}
+	if params.StaleAfter > 0 {
+		query.Set("stale_after", params.StaleAfter.String())
+	}
+
+	var resp struct {
+		Data               []*riverui.RiverKind `json:"data"`
+		RegistryConfigured bool                 `json:"registry_configured"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/kinds", query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &KindListResult{Kinds: resp.Data, RegistryConfigured: resp.RegistryConfigured}, nil
+}
+
+// KindSchema gets the JSON Schema describing the args of a job kind. It
+// returns nil for kinds without a schema, whose args may be any JSON object.
+func (c *Client) KindSchema(ctx context.Context, kind string) (json.RawMessage, error) {
+	var resp struct {
+		Schema json.RawMessage `json:"schema"`
+	}
+	if err := c.do(ctx, http.MethodGet, kindPath(kind)+"/schema", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	if string(resp.Schema) == "null" {
+		return nil, nil
+	}
+	return resp.Schema, nil
+}
+
+// KindValidate validates args against the JSON Schema of a job kind, returning
+// the ways in which they don't match it. Args are valid if no errors are
+// returned.
+func (c *Client) KindValidate(ctx context.Context, kind string, args json.RawMessage) ([]*uischema.FieldError, error) {
+	body := struct {
+		Args json.RawMessage `json:"args"`
+	}{Args: args}
+
+	var resp struct {
+		Errors []*uischema.FieldError `json:"errors"`
+	}
+	if err := c.do(ctx, http.MethodPost, kindPath(kind)+"/validate", nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Errors, nil
+}
+
+func kindPath(kind string) string {
+	return "/api/kinds/" + url.PathEscape(kind)
+}
+
+//
+// Queues
+//
+
+// QueueListParams are parameters for QueueList.
+type QueueListParams struct {
+	// Limit is the maximum number of queues to return. Defaults to the API's
+	// default of 100.
+	Limit int
+}
+
+// QueueList lists queues along with counts of their available and running
+// jobs.
+func (c *Client) QueueList(ctx context.Context, params *QueueListParams) ([]*riverui.RiverQueue, error) {
+	if params == nil {
+		params = &QueueListParams{}
+	}
+
+	query := url.Values{}
+	setQueryInt(query, "limit", int64(params.Limit))
+
+	var resp listResponse[riverui.RiverQueue]
+	if err := c.do(ctx, http.MethodGet, "/api/queues", query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// QueueGet gets a queue by name.
+func (c *Client) QueueGet(ctx context.Context, name string) (*riverui.RiverQueue, error) {
+	var queue riverui.RiverQueue
+	if err := c.do(ctx, http.MethodGet, queuePath(name), nil, nil, &queue); err != nil {
+		return nil, err
+	}
+	return &queue, nil
+}
+
+// QueuePause pauses a queue so that no new jobs are worked from it.
+func (c *Client) QueuePause(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodPut, queuePath(name)+"/pause", nil, nil, nil)
+}
+
+// QueueResume resumes a paused queue.
+func (c *Client) QueueResume(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodPut, queuePath(name)+"/resume", nil, nil, nil)
+}
+
+// QueueUpdateParams are parameters for QueueUpdate.
+type QueueUpdateParams struct {
+	// Concurrency is the queue's new concurrency config. Nil removes any
+	// existing config. Concurrency limits require River Pro.
+	Concurrency *riverui.ConcurrencyConfig
+}
+
+// QueueUpdate updates a queue's settings, returning the updated queue.
+func (c *Client) QueueUpdate(ctx context.Context, name string, params *QueueUpdateParams) (*riverui.RiverQueue, error) {
+	if params == nil {
+		params = &QueueUpdateParams{}
+	}
+
+	// Concurrency is always sent, including as null, which the API
+	// distinguishes from an omitted field.
+	body := struct {
+		Concurrency *riverui.ConcurrencyConfig `json:"concurrency"`
+	}{Concurrency: params.Concurrency}
+
+	var queue riverui.RiverQueue
+	if err := c.do(ctx, http.MethodPatch, queuePath(name), nil, body, &queue); err != nil {
+		return nil, err
+	}
+	return &queue, nil
+}
+
+func queuePath(name string) string {
+	return "/api/queues/" + url.PathEscape(name)
+}
+
+//
+// Requests
+//
+
+type listResponse[T any] struct {
+	Data []*T `json:"data"`
+}
+
+// Makes a request to the API, encoding reqBody as JSON if non-nil and decoding
+// a successful response into respBody if non-nil. Unsuccessful responses are
+// returned as *apierror.APIError.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, reqBody, respBody any) error {
+	reqURL := c.baseURL.JoinPath(path)
+	reqURL.RawQuery = query.Encode()
+
+	var bodyReader io.Reader
+	if reqBody != nil {
+		data, err := json.Marshal(reqBody)
+		if err != nil {
+			return fmt.Errorf("error marshaling request: %w", err)
+		}
+		bodyReader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL.String(), bodyReader)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if reqBody != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.opts.Environment != "" {
+		req.Header.Set(riverui.EnvironmentHeader, c.opts.Environment)
+	}
+	switch {
+	case c.opts.Token != "":
+		req.Header.Set("Authorization", "Bearer "+c.opts.Token)
+	case c.opts.BasicAuthUser != "" || c.opts.BasicAuthPassword != "":
+		req.SetBasicAuth(c.opts.BasicAuthUser, c.opts.BasicAuthPassword)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error requesting %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newAPIError(resp.StatusCode, data)
+	}
+
+	if respBody != nil {
+		if err := json.Unmarshal(data, respBody); err != nil {
+			return fmt.Errorf("error unmarshaling response: %w", err)
+		}
+	}
+	return nil
+}
+
+// Builds an API error from an unsuccessful response. Responses that didn't
+// come from the API, like a proxy's error page, get a generic message.
+func newAPIError(statusCode int, body []byte) *apierror.APIError {
+	apiErr := &apierror.APIError{StatusCode: statusCode}
+	if err := json.Unmarshal(body, apiErr); err != nil || apiErr.Message == "" {
+		apiErr.Message = fmt.Sprintf("Unexpected response with status %d %s.", statusCode, http.StatusText(statusCode))
+		if err != nil {
+			apiErr.InternalError = err
+		}
+	}
+	return apiErr
+}
+
+// Iterates through the items of pages returned by fetchPage until it returns
+// an empty page or an error.
+func paginate[T any](fetchPage func() ([]*T, error)) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		for {
+			items, err := fetchPage()
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if len(items) == 0 {
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func setQueryInt(query url.Values, key string, value int64) {
+	if value != 0 {
+		query.Set(key, strconv.FormatInt(value, 10))
+	}
+}
+
+func setQueryString(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
+
+func setQueryStrings(query url.Values, key string, values []string) {
+	for _, value := range values {
+		query.Add(key, value)
+	}
+}
@@ -0,0 +1,369 @@
+package uiclient
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/riverqueue/apiframe/apierror"
+	"github.com/riverqueue/river/rivertype"
+
+	"riverqueue.com/riverui"
+	"riverqueue.com/riverui/uischema"
+)
+
+// testRequest is a request received by the test server.
+type testRequest struct {
+	authorization string
+	body          string
+	environment   string
+	uri           string
+}
+
+// testServer responds to requests with canned responses by pattern and
+// records the requests it receives.
+type testServer struct {
+	mu       sync.Mutex
+	requests []*testRequest
+}
+
+func (s *testServer) lastRequest() *testRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[len(s.requests)-1]
+}
+
+func setup(t *testing.T, opts *ClientOpts, responses map[string]func(r *http.Request) (int, string)) (*Client, *testServer) {
+	t.Helper()
+
+	server := &testServer{}
+
+	mux := http.NewServeMux()
+	for pattern, respond := range responses {
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+
+			server.mu.Lock()
+			server.requests = append(server.requests, &testRequest{
+				authorization: r.Header.Get("Authorization"),
+				body:          string(body),
+				environment:   r.Header.Get(riverui.EnvironmentHeader),
+				uri:           r.RequestURI,
+			})
+			server.mu.Unlock()
+
+			statusCode, respBody := respond(r)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(statusCode)
+			_, _ = w.Write([]byte(respBody))
+		})
+	}
+
+	httpServer := httptest.NewServer(http.StripPrefix("/riverui", mux))
+	t.Cleanup(httpServer.Close)
+
+	client, err := NewClient(httpServer.URL+"/riverui/", opts)
+	require.NoError(t, err)
+
+	return client, server
+}
+
+func respond(statusCode int, body string) func(r *http.Request) (int, string) {
+	return func(r *http.Request) (int, string) { return statusCode, body }
+}
+
+func TestNewClient(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewClient("localhost:8080", nil)
+	require.EqualError(t, err, `invalid base URL "localhost:8080": must be an http or https URL`)
+}
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("JobList", func(t *testing.T) {
+		t.Parallel()
+
+		client, server := setup(t, nil, map[string]func(r *http.Request) (int, string){
+			"GET /api/jobs": respond(http.StatusOK, `{"data":[{"id":123,"kind":"email","state":"available"}]}`),
+		})
+
+		jobs, err := client.JobList(ctx, &JobListParams{
+			IDs:        []int64{123, 9007199254740993},
+			Kinds:      []string{"email", "sms"},
+			Limit:      5,
+			Priorities: []int16{1, 2},
+			State:      rivertype.JobStateAvailable,
+		})
+		require.NoError(t, err)
+		require.Len(t, jobs, 1)
+		require.Equal(t, int64(123), jobs[0].ID)
+		require.Equal(t, "email", jobs[0].Kind)
+		require.Equal(t, "/riverui/api/jobs?ids=123&ids=9007199254740993&kinds=email&kinds=sms&limit=5&priorities=1&priorities=2&state=available", server.lastRequest().uri)
+	})
+
+	t.Run("JobGet", func(t *testing.T) {
+		t.Parallel()
+
+		client, server := setup(t, nil, map[string]func(r *http.Request) (int, string){
+			"GET /api/jobs/{job_id}": respond(http.StatusOK, `{"id":123,"kind":"email","errors":[{"attempt":1,"error":"boom"}],"metadata":{"a":1}}`),
+		})
+
+		job, err := client.JobGet(ctx, 123)
+		require.NoError(t, err)
+		require.Equal(t, int64(123), job.ID)
+		require.Equal(t, "boom", job.Errors[0].Error)
+		require.JSONEq(t, `{"a":1}`, string(job.Metadata))
+		require.Equal(t, "/riverui/api/jobs/123", server.lastRequest().uri)
+	})
+
+	t.Run("JobRetry", func(t *testing.T) {
+		t.Parallel()
+
+		client, server := setup(t, nil, map[string]func(r *http.Request) (int, string){
+			"POST /api/jobs/retry": respond(http.StatusOK, `{"status":"ok"}`),
+		})
+
+		require.NoError(t, client.JobRetry(ctx, 123, 9007199254740993))
+		require.JSONEq(t, `{"ids":["123","9007199254740993"]}`, server.lastRequest().body)
+	})
+
+	t.Run("JobStateCounts", func(t *testing.T) {
+		t.Parallel()
+
+		client, _ := setup(t, nil, map[string]func(r *http.Request) (int, string){
+			"GET /api/states": respond(http.StatusOK, `{"available":3,"completed":10}`),
+		})
+
+		counts, err := client.JobStateCounts(ctx)
+		require.NoError(t, err)
+		require.Equal(t, map[rivertype.JobState]int{rivertype.JobStateAvailable: 3, rivertype.JobStateCompleted: 10}, counts)
+	})
+
+	t.Run("KindList", func(t *testing.T) {
+		t.Parallel()
+
+		client, server := setup(t, nil, map[string]func(r *http.Request) (int, string){
+			"GET /api/kinds": respond(http.StatusOK, `{"data":[{"client_ids":[],"kind":"email","oldest_pending_at":"2025-01-02T03:04:05Z","registered":false,"unhandled":true}],"registry_configured":true}`),
+		})
+
+		result, err := client.KindList(ctx, &KindListParams{StaleAfter: 10 * time.Minute})
+		require.NoError(t, err)
+		require.True(t, result.RegistryConfigured)
+		require.Len(t, result.Kinds, 1)
+		require.Equal(t, "email", result.Kinds[0].Kind)
+		require.True(t, result.Kinds[0].Unhandled)
+		require.Equal(t, "/riverui/api/kinds?stale_after=10m0s", server.lastRequest().uri)
+	})
+
+	t.Run("KindSchema", func(t *testing.T) {
+		t.Parallel()
+
+		client, server := setup(t, nil, map[string]func(r *http.Request) (int, string){
+			"GET /api/kinds/{kind}/schema": func(r *http.Request) (int, string) {
+				if r.PathValue("kind") == "email" {
+					return http.StatusOK, `{"kind":"email","schema":{"type":"object"}}`
+				}
+				return http.StatusOK, `{"kind":"other","schema":null}`
+			},
+		})
+
+		schema, err := client.KindSchema(ctx, "email")
+		require.NoError(t, err)
+		require.JSONEq(t, `{"type":"object"}`, string(schema))
+
+		schema, err = client.KindSchema(ctx, "other/kind")
+		require.NoError(t, err)
+		require.Nil(t, schema)
+		require.Equal(t, "/riverui/api/kinds/other%2Fkind/schema", server.lastRequest().uri)
+	})
+
+	t.Run("KindValidate", func(t *testing.T) {
+		t.Parallel()
+
+		client, server := setup(t, nil, map[string]func(r *http.Request) (int, string){
+			"POST /api/kinds/{kind}/validate": respond(http.StatusOK, `{"errors":[{"path":"$.to","message":"is required"}],"valid":false}`),
+		})
+
+		fieldErrs, err := client.KindValidate(ctx, "email", json.RawMessage(`{}`))
+		require.NoError(t, err)
+		require.Equal(t, []*uischema.FieldError{{Path: "$.to", Message: "is required"}}, fieldErrs)
+		require.JSONEq(t, `{"args":{}}`, server.lastRequest().body)
+	})
+
+	t.Run("QueuePause", func(t *testing.T) {
+		t.Parallel()
+
+		client, server := setup(t, nil, map[string]func(r *http.Request) (int, string){
+			"PUT /api/queues/{name}/pause": respond(http.StatusOK, `{"status":"ok"}`),
+		})
+
+		require.NoError(t, client.QueuePause(ctx, "a/b"))
+		require.Equal(t, "/riverui/api/queues/a%2Fb/pause", server.lastRequest().uri)
+	})
+
+	t.Run("QueueUpdate", func(t *testing.T) {
+		t.Parallel()
+
+		client, server := setup(t, nil, map[string]func(r *http.Request) (int, string){
+			"PATCH /api/queues/{name}": respond(http.StatusOK, `{"name":"default","concurrency":{"global_limit":10}}`),
+		})
+
+		queue, err := client.QueueUpdate(ctx, "default", &QueueUpdateParams{Concurrency: &riverui.ConcurrencyConfig{GlobalLimit: 10}})
+		require.NoError(t, err)
+		require.Equal(t, int32(10), queue.Concurrency.GlobalLimit)
+		require.JSONEq(t, `{"concurrency":{"global_limit":10,"local_limit":0,"partition":{"by_args":null,"by_kind":false}}}`, server.lastRequest().body)
+
+		// A nil config is sent as null to remove the queue's config.
+		_, err = client.QueueUpdate(ctx, "default", nil)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"concurrency":null}`, server.lastRequest().body)
+	})
+
+	t.Run("HealthCheckFailure", func(t *testing.T) {
+		t.Parallel()
+
+		client, _ := setup(t, nil, map[string]func(r *http.Request) (int, string){
+			"GET /api/health-checks/{name}": respond(http.StatusServiceUnavailable, `{"message":"Unable to query database. Check logs for details."}`),
+		})
+
+		err := client.HealthCheck(ctx, HealthCheckComplete)
+
+		var apiErr *apierror.APIError
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+		require.Equal(t, "Unable to query database. Check logs for details.", apiErr.Message)
+	})
+
+	t.Run("NonAPIError", func(t *testing.T) {
+		t.Parallel()
+
+		client, _ := setup(t, nil, map[string]func(r *http.Request) (int, string){
+			"GET /api/queues": respond(http.StatusBadGateway, `<html>Bad Gateway</html>`),
+		})
+
+		_, err := client.QueueList(ctx, nil)
+		require.EqualError(t, err, "Unexpected response with status 502 Bad Gateway.")
+	})
+
+	t.Run("TokenAndEnvironment", func(t *testing.T) {
+		t.Parallel()
+
+		client, server := setup(t, &ClientOpts{Environment: "eu", Token: "riverui_secret"}, map[string]func(r *http.Request) (int, string){
+			"GET /api/queues": respond(http.StatusOK, `{"data":[]}`),
+		})
+
+		_, err := client.QueueList(ctx, nil)
+		require.NoError(t, err)
+		require.Equal(t, "Bearer riverui_secret", server.lastRequest().authorization)
+		require.Equal(t, "eu", server.lastRequest().environment)
+	})
+
+	t.Run("BasicAuth", func(t *testing.T) {
+		t.Parallel()
+
+		client, server := setup(t, &ClientOpts{BasicAuthPassword: "pass", BasicAuthUser: "user"}, map[string]func(r *http.Request) (int, string){
+			"GET /api/queues": respond(http.StatusOK, `{"data":[]}`),
+		})
+
+		_, err := client.QueueList(ctx, nil)
+		require.NoError(t, err)
+		require.Equal(t, "Basic dXNlcjpwYXNz", server.lastRequest().authorization)
+	})
+
+	t.Run("AuditEntries", func(t *testing.T) {
+		t.Parallel()
+
+		// Serves entries 5 through 1, newest first, in pages of two.
+		client, server := setup(t, nil, map[string]func(r *http.Request) (int, string){
+			"GET /api/audit": func(r *http.Request) (int, string) {
+				beforeID, _ := strconv.Atoi(r.URL.Query().Get("before_id"))
+				if beforeID == 0 {
+					beforeID = 6
+				}
+
+				body := `{"data":[`
+				for id := beforeID - 1; id > max(beforeID-3, 0); id-- {
+					if id != beforeID-1 {
+						body += ","
+					}
+					body += `{"id":` + strconv.Itoa(id) + `}`
+				}
+				return http.StatusOK, body + `]}`
+			},
+		})
+
+		var ids []int64
+		for entry, err := range client.AuditEntries(ctx, &AuditListParams{Actor: "admin", Limit: 2}) {
+			require.NoError(t, err)
+			ids = append(ids, entry.ID)
+		}
+		require.Equal(t, []int64{5, 4, 3, 2, 1}, ids)
+		require.Equal(t, "/riverui/api/audit?actor=admin&before_id=1&limit=2", server.lastRequest().uri)
+	})
+
+	t.Run("PaginationError", func(t *testing.T) {
+		t.Parallel()
+
+		client, _ := setup(t, nil, map[string]func(r *http.Request) (int, string){
+			"GET /api/audit": respond(http.StatusForbidden, `{"message":"Insufficient permissions: this operation requires admin access."}`),
+		})
+
+		var errs []error
+		for entry, err := range client.AuditEntries(ctx, nil) {
+			require.Nil(t, entry)
+			errs = append(errs, err)
+		}
+		require.Len(t, errs, 1)
+
+		var apiErr *apierror.APIError
+		require.ErrorAs(t, errs[0], &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+	})
+
+	t.Run("Workflows", func(t *testing.T) {
+		t.Parallel()
+
+		client, server := setup(t, nil, map[string]func(r *http.Request) (int, string){
+			"GET /api/pro/workflows": func(r *http.Request) (int, string) {
+				if r.URL.Query().Get("after") == "" {
+					return http.StatusOK, `{"data":[{"id":"wf1"},{"id":"wf2"}]}`
+				}
+				return http.StatusOK, `{"data":[]}`
+			},
+		})
+
+		var ids []string
+		for workflow, err := range client.Workflows(ctx, &WorkflowListParams{State: WorkflowStateActive}) {
+			require.NoError(t, err)
+			ids = append(ids, workflow.ID)
+		}
+		require.Equal(t, []string{"wf1", "wf2"}, ids)
+		require.Equal(t, "/riverui/api/pro/workflows?after=wf2&state=active", server.lastRequest().uri)
+	})
+
+	t.Run("WorkflowRetry", func(t *testing.T) {
+		t.Parallel()
+
+		client, server := setup(t, nil, map[string]func(r *http.Request) (int, string){
+			"POST /api/pro/workflows/{id}/retry": respond(http.StatusOK, `{"retried_jobs":[{"id":1},{"id":2}]}`),
+		})
+
+		jobs, err := client.WorkflowRetry(ctx, "wf1", &WorkflowRetryParams{Mode: WorkflowRetryModeFailedOnly})
+		require.NoError(t, err)
+		require.Len(t, jobs, 2)
+		require.Equal(t, "/riverui/api/pro/workflows/wf1/retry", server.lastRequest().uri)
+		require.JSONEq(t, `{"mode":"failed_only","reset_history":false}`, server.lastRequest().body)
+	})
+}
@@ -0,0 +1,230 @@
+package uiclient
+
+import (
+	"context"
+	"encoding/json"
+	"iter"
+	"net/http"
+	"net/url"
+	"time"
+
+	"riverqueue.com/riverui"
+)
+
+// The methods in this file call endpoints only served by the River Pro bundle
+// from riverproui. Against a UI without it, they fail with a 404.
+
+//
+// Periodic jobs
+//
+
+// PeriodicJob is a durable periodic job tracked by River Pro.
+type PeriodicJob struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	NextRunAt time.Time `json:"next_run_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PeriodicJobListParams are parameters for PeriodicJobList.
+type PeriodicJobListParams struct {
+	// Limit is the maximum number of periodic jobs to return. Defaults to the
+	// API's default of 100.
+	Limit int
+}
+
+// PeriodicJobList lists durable periodic jobs.
+func (c *Client) PeriodicJobList(ctx context.Context, params *PeriodicJobListParams) ([]*PeriodicJob, error) {
+	if params == nil {
+		params = &PeriodicJobListParams{}
+	}
+
+	query := url.Values{}
+	setQueryInt(query, "limit", int64(params.Limit))
+
+	var resp listResponse[PeriodicJob]
+	if err := c.do(ctx, http.MethodGet, "/api/pro/periodic-jobs", query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+//
+// Workflows
+//
+
+// Workflow is a workflow in a workflow list, with counts of its tasks by
+// state.
+type Workflow struct {
+	CountAvailable  int       `json:"count_available"`
+	CountCancelled  int       `json:"count_cancelled"`
+	CountCompleted  int       `json:"count_completed"`
+	CountDiscarded  int       `json:"count_discarded"`
+	CountFailedDeps int       `json:"count_failed_deps"`
+	CountPending    int       `json:"count_pending"`
+	CountRetryable  int       `json:"count_retryable"`
+	CountRunning    int       `json:"count_running"`
+	CountScheduled  int       `json:"count_scheduled"`
+	CreatedAt       time.Time `json:"created_at"`
+	ID              string    `json:"id"`
+	Name            *string   `json:"name"`
+}
+
+// WorkflowDetail is a workflow along with all of its tasks.
+type WorkflowDetail struct {
+	ID    string          `json:"id"`
+	Name  string          `json:"name"`
+	Tasks []*WorkflowTask `json:"tasks"`
+}
+
+// WorkflowTask is a task in a workflow, which is a job along with its place
+// in the workflow.
+type WorkflowTask struct {
+	riverui.RiverJob
+
+	Deps                []string   `json:"deps"`
+	IgnoreCancelledDeps bool       `json:"ignore_cancelled_deps"`
+	IgnoreDeletedDeps   bool       `json:"ignore_deleted_deps"`
+	IgnoreDiscardedDeps bool       `json:"ignore_discarded_deps"`
+	Name                string     `json:"name"`
+	StagedAt            *time.Time `json:"staged_at,omitempty"`
+
+	// Wait describes the wait condition of a task that waits on signals or
+	// timers, if any. It's left as raw JSON because its shape is specific to
+	// the River Pro version serving it.
+	Wait json.RawMessage `json:"wait,omitempty"`
+
+	WaitReason string `json:"wait_reason"`
+	WorkflowID string `json:"workflow_id"`
+}
+
+// WorkflowState filters workflows by whether they have unfinished tasks.
+type WorkflowState string
+
+const (
+	// WorkflowStateActive matches workflows with unfinished tasks.
+	WorkflowStateActive WorkflowState = "active"
+
+	// WorkflowStateInactive matches workflows whose tasks are all finished.
+	WorkflowStateInactive WorkflowState = "inactive"
+)
+
+// WorkflowListParams are parameters for WorkflowList.
+type WorkflowListParams struct {
+	// After optionally lists only workflows after the one with this ID, so
+	// that pages are fetched by setting it to the ID of the last workflow of
+	// the previous page.
+	After string
+
+	// Limit is the maximum number of workflows to return. Defaults to the
+	// API's default of 100.
+	Limit int
+
+	// State optionally limits results to active or inactive workflows.
+	State WorkflowState
+}
+
+// WorkflowList lists workflows.
+func (c *Client) WorkflowList(ctx context.Context, params *WorkflowListParams) ([]*Workflow, error) {
+	if params == nil {
+		params = &WorkflowListParams{}
+	}
+
+	query := url.Values{}
+	setQueryString(query, "after", params.After)
+	setQueryInt(query, "limit", int64(params.Limit))
+	setQueryString(query, "state", string(params.State))
+
+	var resp listResponse[Workflow]
+	if err := c.do(ctx, http.MethodGet, "/api/pro/workflows", query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// Workflows iterates through all workflows matching params, fetching pages of
+// params.Limit workflows as needed. Iteration stops after the first error.
+func (c *Client) Workflows(ctx context.Context, params *WorkflowListParams) iter.Seq2[*Workflow, error] {
+	pageParams := WorkflowListParams{}
+	if params != nil {
+		pageParams = *params
+	}
+
+	return paginate(func() ([]*Workflow, error) {
+		workflows, err := c.WorkflowList(ctx, &pageParams)
+		if len(workflows) > 0 {
+			pageParams.After = workflows[len(workflows)-1].ID
+		}
+		return workflows, err
+	})
+}
+
+// WorkflowGet gets a workflow and its tasks by ID.
+func (c *Client) WorkflowGet(ctx context.Context, id string) (*WorkflowDetail, error) {
+	var workflow WorkflowDetail
+	if err := c.do(ctx, http.MethodGet, workflowPath(id), nil, nil, &workflow); err != nil {
+		return nil, err
+	}
+	return &workflow, nil
+}
+
+// WorkflowCancel cancels a workflow's unfinished tasks, returning the jobs
+// that were cancelled.
+func (c *Client) WorkflowCancel(ctx context.Context, id string) ([]*riverui.RiverJobMinimal, error) {
+	var resp struct {
+		CancelledJobs []*riverui.RiverJobMinimal `json:"cancelled_jobs"`
+	}
+	if err := c.do(ctx, http.MethodPost, workflowPath(id)+"/cancel", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.CancelledJobs, nil
+}
+
+// WorkflowRetryMode selects which of a workflow's tasks WorkflowRetry retries.
+type WorkflowRetryMode string
+
+const (
+	// WorkflowRetryModeAll retries all tasks.
+	WorkflowRetryModeAll WorkflowRetryMode = "all"
+
+	// WorkflowRetryModeFailedAndDownstream retries failed tasks and the tasks
+	// that depend on them.
+	WorkflowRetryModeFailedAndDownstream WorkflowRetryMode = "failed_and_downstream"
+
+	// WorkflowRetryModeFailedOnly retries only failed tasks.
+	WorkflowRetryModeFailedOnly WorkflowRetryMode = "failed_only"
+)
+
+// WorkflowRetryParams are parameters for WorkflowRetry.
+type WorkflowRetryParams struct {
+	// Mode selects which tasks to retry. Defaults to the API's default.
+	Mode WorkflowRetryMode
+
+	// ResetHistory resets the attempt history of retried tasks.
+	ResetHistory bool
+}
+
+// WorkflowRetry retries a workflow's tasks, returning the jobs that were
+// retried.
+func (c *Client) WorkflowRetry(ctx context.Context, id string, params *WorkflowRetryParams) ([]*riverui.RiverJobMinimal, error) {
+	if params == nil {
+		params = &WorkflowRetryParams{}
+	}
+
+	body := struct {
+		Mode         WorkflowRetryMode `json:"mode,omitempty"`
+		ResetHistory bool              `json:"reset_history"`
+	}{Mode: params.Mode, ResetHistory: params.ResetHistory}
+
+	var resp struct {
+		RetriedJobs []*riverui.RiverJobMinimal `json:"retried_jobs"`
+	}
+	if err := c.do(ctx, http.MethodPost, workflowPath(id)+"/retry", nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return resp.RetriedJobs, nil
+}
+
+func workflowPath(id string) string {
+	return "/api/pro/workflows/" + url.PathEscape(id)
+}
@@ -0,0 +1,43 @@
+// Package uidecode contains a hook that decodes job args that are encrypted or
+// otherwise encoded at the application layer so that River UI can display
+// them instead of opaque ciphertext. An ArgsDecoder may be configured on a
+// riverui.Handler with HandlerOpts.ArgsDecoder, and decoded args are only
+// served to identities with the access set in HandlerOpts.ArgsDecoderAccess.
+package uidecode
+
+import (
+	"context"
+)
+
+// ArgsDecoder decodes the args of jobs into a displayable JSON form.
+// Implementations must be safe for concurrent use.
+type ArgsDecoder interface {
+	// DecodeArgs returns a displayable JSON form of args, which are the raw
+	// encoded args of a job of the given kind. It returns nil without an
+	// error for jobs whose args aren't encoded and should be displayed as
+	// they are.
+	//
+	// An error doesn't fail the request. Instead, the job is served with its
+	// raw args and the error's message so that it can be displayed alongside
+	// them, so errors shouldn't contain anything sensitive.
+	DecodeArgs(ctx context.Context, kind string, args []byte) ([]byte, error)
+}
+
+// ArgsDecoderFunc is a function that implements ArgsDecoder.
+type ArgsDecoderFunc func(ctx context.Context, kind string, args []byte) ([]byte, error)
+
+// DecodeArgs invokes f.
+func (f ArgsDecoderFunc) DecodeArgs(ctx context.Context, kind string, args []byte) ([]byte, error) {
+	return f(ctx, kind, args)
+}
+
+// ByKind returns an ArgsDecoder that decodes the args of each job with the
+// decoder for its kind in decoders, leaving jobs of other kinds undecoded.
+func ByKind(decoders map[string]ArgsDecoder) ArgsDecoder {
+	return ArgsDecoderFunc(func(ctx context.Context, kind string, args []byte) ([]byte, error) {
+		if decoder, ok := decoders[kind]; ok && decoder != nil {
+			return decoder.DecodeArgs(ctx, kind, args)
+		}
+		return nil, nil
+	})
+}
@@ -0,0 +1,28 @@
+package uidecode
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestByKind(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	decoder := ByKind(map[string]ArgsDecoder{
+		"encrypted": ArgsDecoderFunc(func(ctx context.Context, kind string, args []byte) ([]byte, error) {
+			return []byte(`{"decoded":true}`), nil
+		}),
+	})
+
+	decoded, err := decoder.DecodeArgs(ctx, "encrypted", []byte(`"ciphertext"`))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"decoded":true}`, string(decoded))
+
+	decoded, err = decoder.DecodeArgs(ctx, "plain", []byte(`{}`))
+	require.NoError(t, err)
+	require.Nil(t, decoded)
+}
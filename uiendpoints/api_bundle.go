@@ -0,0 +1,110 @@
+package uiendpoints
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+
+	"github.com/riverqueue/river"
+	"github.com/riverqueue/river/riverdriver"
+	"github.com/riverqueue/river/rivershared/baseservice"
+	"github.com/riverqueue/river/rivertype"
+
+	"riverqueue.com/riverui/uiaudit"
+	"riverqueue.com/riverui/uiauth"
+	"riverqueue.com/riverui/uidecode"
+	"riverqueue.com/riverui/uikinds"
+	"riverqueue.com/riverui/uiredact"
+	"riverqueue.com/riverui/uischema"
+)
+
+// APIBundle is a bundle of common types needed for many API endpoints. It's
+// shared by a Bundle's built-in endpoints and the endpoints of its extensions.
+type APIBundle[TTx any] struct {
+	Archetype *baseservice.Archetype
+
+	// ArgsDecoder optionally decodes job args that are encoded at the
+	// application layer. Decoded args are only served to identities with
+	// ArgsDecoderAccess. Endpoints that serve jobs should decode their args
+	// with DecodeJobArgs before redacting them.
+	ArgsDecoder       uidecode.ArgsDecoder
+	ArgsDecoderAccess uiauth.Access
+
+	// ArgsSchemas optionally holds JSON Schemas for the args of job kinds.
+	// Endpoints that insert or edit jobs should validate args with its
+	// Validate, which falls back to requiring a JSON object for kinds without
+	// a schema.
+	ArgsSchemas *uischema.Registry
+
+	AuditSink           uiaudit.Sink
+	AuthorizationPolicy *uiauth.Policy
+	Client              *river.Client[TTx]
+
+	// DB is the executor for the bundle's database, which is bound to the
+	// bundle's transaction if one was configured with its `Tx` option.
+	DB riverdriver.Executor
+
+	Driver       riverdriver.Driver[TTx]
+	Environment  string
+	Environments []string
+
+	// Extensions returns the feature flags served in the `extensions` of
+	// `/api/features`, including those of the bundle's extensions.
+	Extensions func(ctx context.Context) (map[string]bool, error)
+
+	JobListHideArgsByDefault bool
+
+	// KindRegistry optionally lists the job kinds that live clients have
+	// registered workers for.
+	KindRegistry uikinds.Registry
+
+	Logger *slog.Logger
+
+	// Redactor optionally redacts the args, metadata, and attempt errors of
+	// jobs before they're served. Endpoints that serve jobs should pass them
+	// through uiredact.Apply or uiredact.ApplyAll.
+	Redactor uiredact.Redactor
+
+	// ReplicaDB is an optional executor for a read replica. Read-only
+	// endpoints that can tolerate replication lag should query ReadDB rather
+	// than DB so that they use it when it's configured.
+	ReplicaDB riverdriver.Executor
+}
+
+// ReadDB returns the executor for read-only queries that can tolerate
+// replication lag, which is ReplicaDB if one is configured, and DB otherwise.
+func (b APIBundle[TTx]) ReadDB() riverdriver.Executor {
+	if b.ReplicaDB != nil {
+		return b.ReplicaDB
+	}
+	return b.DB
+}
+
+// DecodeJobArgs decodes a job's args with ArgsDecoder if one is configured and
+// the identity in context has ArgsDecoderAccess, returning a copy of the job
+// with decoded args and whether they were decoded. An error decoding args is
+// returned along with the job unchanged, but is meant to be served with it
+// rather than fail the request.
+func (b APIBundle[TTx]) DecodeJobArgs(ctx context.Context, job *rivertype.JobRow) (*rivertype.JobRow, bool, error) {
+	if b.ArgsDecoder == nil || !uiauth.IdentityFromContext(ctx).Allows(b.ArgsDecoderAccess) {
+		return job, false, nil
+	}
+
+	decodedArgs, err := b.ArgsDecoder.DecodeArgs(ctx, job.Kind, job.EncodedArgs)
+	if err == nil && decodedArgs != nil && !json.Valid(decodedArgs) {
+		err = errors.New("decoded args aren't valid JSON")
+	}
+	if err != nil {
+		b.Logger.WarnContext(ctx, "Error decoding job args",
+			slog.Int64("job_id", job.ID), slog.String("kind", job.Kind), slog.String("error", err.Error()))
+		return job, false, err
+	}
+	if decodedArgs == nil {
+		return job, false, nil
+	}
+
+	decodedJob := *job
+	decodedJob.EncodedArgs = decodedArgs
+	return &decodedJob, true, nil
+}
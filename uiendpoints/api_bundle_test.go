@@ -0,0 +1,92 @@
+package uiendpoints
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/riverqueue/river/rivershared/riversharedtest"
+	"github.com/riverqueue/river/rivertype"
+
+	"riverqueue.com/riverui/uiauth"
+	"riverqueue.com/riverui/uidecode"
+)
+
+func TestAPIBundleDecodeJobArgs(t *testing.T) {
+	t.Parallel()
+
+	var (
+		adminCtx = uiauth.WithIdentity(context.Background(), &uiauth.Identity{Roles: []uiauth.Role{uiauth.RoleAdmin}})
+		job      = &rivertype.JobRow{EncodedArgs: []byte(`"ciphertext"`), ID: 123, Kind: "encrypted"}
+	)
+
+	newBundle := func(t *testing.T, decode func(kind string, args []byte) ([]byte, error)) APIBundle[pgx.Tx] {
+		t.Helper()
+
+		return APIBundle[pgx.Tx]{
+			ArgsDecoder: uidecode.ArgsDecoderFunc(func(_ context.Context, kind string, args []byte) ([]byte, error) {
+				return decode(kind, args)
+			}),
+			ArgsDecoderAccess: uiauth.AccessAdmin,
+			Logger:            riversharedtest.Logger(t),
+		}
+	}
+
+	t.Run("Decoded", func(t *testing.T) {
+		t.Parallel()
+
+		bundle := newBundle(t, func(kind string, args []byte) ([]byte, error) {
+			require.Equal(t, "encrypted", kind)
+			require.Equal(t, `"ciphertext"`, string(args))
+			return []byte(`{"a":1}`), nil
+		})
+
+		decodedJob, decoded, err := bundle.DecodeJobArgs(adminCtx, job)
+		require.NoError(t, err)
+		require.True(t, decoded)
+		require.JSONEq(t, `{"a":1}`, string(decodedJob.EncodedArgs))
+		require.Equal(t, `"ciphertext"`, string(job.EncodedArgs))
+	})
+
+	t.Run("NotEncoded", func(t *testing.T) {
+		t.Parallel()
+
+		bundle := newBundle(t, func(kind string, args []byte) ([]byte, error) { return nil, nil })
+
+		decodedJob, decoded, err := bundle.DecodeJobArgs(adminCtx, job)
+		require.NoError(t, err)
+		require.False(t, decoded)
+		require.Same(t, job, decodedJob)
+	})
+
+	t.Run("InsufficientAccess", func(t *testing.T) {
+		t.Parallel()
+
+		bundle := newBundle(t, func(kind string, args []byte) ([]byte, error) {
+			require.FailNow(t, "decoder shouldn't be invoked")
+			return nil, nil
+		})
+
+		ctx := uiauth.WithIdentity(context.Background(), &uiauth.Identity{Roles: []uiauth.Role{uiauth.RoleOperator}})
+		_, decoded, err := bundle.DecodeJobArgs(ctx, job)
+		require.NoError(t, err)
+		require.False(t, decoded)
+
+		_, decoded, err = bundle.DecodeJobArgs(context.Background(), job)
+		require.NoError(t, err)
+		require.False(t, decoded)
+	})
+
+	t.Run("InvalidJSON", func(t *testing.T) {
+		t.Parallel()
+
+		bundle := newBundle(t, func(kind string, args []byte) ([]byte, error) { return []byte(`not json`), nil })
+
+		decodedJob, decoded, err := bundle.DecodeJobArgs(adminCtx, job)
+		require.EqualError(t, err, "decoded args aren't valid JSON")
+		require.False(t, decoded)
+		require.Same(t, job, decodedJob)
+	})
+}
@@ -0,0 +1,60 @@
+package uiendpoints
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"riverqueue.com/riverui/uiaudit"
+	"riverqueue.com/riverui/uiauth"
+)
+
+// WithAudit invokes fn and records its outcome as an audit entry to the
+// bundle's audit sink, if one is configured. The caller fills in the entry's
+// action, endpoint, and affected job IDs, queue, or workflow, and fn may add
+// to them (e.g. with the IDs of jobs a workflow operation touched). Actor,
+// environment, outcome, parameters, and timestamp are filled in here.
+//
+// Built-in endpoints that take a mutating action record it with WithAudit, and
+// endpoints of extensions should too so that their actions are audited like
+// built-in ones.
+//
+// Failure to record an entry is logged, but doesn't fail the operation.
+func WithAudit[TTx, TResp any](ctx context.Context, bundle APIBundle[TTx], entry *uiaudit.Entry, params any, fn func() (TResp, error)) (TResp, error) {
+	resp, err := fn()
+
+	if bundle.AuditSink == nil {
+		return resp, err
+	}
+
+	if identity := uiauth.IdentityFromContext(ctx); identity != nil {
+		entry.Actor = identity.Name
+	}
+	entry.Environment = bundle.Environment
+	entry.OccurredAt = bundle.Archetype.Time.Now().UTC()
+
+	if paramsJSON, marshalErr := json.Marshal(params); marshalErr == nil {
+		entry.Params = paramsJSON
+	}
+
+	var forbiddenErr *uiauth.Forbidden
+	switch {
+	case err == nil:
+		entry.Outcome = uiaudit.OutcomeSuccess
+	case errors.As(err, &forbiddenErr):
+		entry.Outcome = uiaudit.OutcomeDenied
+		entry.Error = err.Error()
+	default:
+		entry.Outcome = uiaudit.OutcomeFailure
+		entry.Error = err.Error()
+	}
+
+	// Record even if the request was cancelled so that an action whose
+	// transaction committed just before the client disconnected isn't lost.
+	if recordErr := bundle.AuditSink.Record(context.WithoutCancel(ctx), entry); recordErr != nil {
+		bundle.Logger.ErrorContext(ctx, "Error recording audit entry",
+			"action", entry.Action, "actor", entry.Actor, "error", recordErr)
+	}
+
+	return resp, err
+}
@@ -0,0 +1,127 @@
+package uiendpoints
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/riverqueue/river/rivershared/riversharedtest"
+
+	"riverqueue.com/riverui/uiaudit"
+	"riverqueue.com/riverui/uiauth"
+)
+
+type recordingSink struct {
+	entries []*uiaudit.Entry
+	err     error
+}
+
+func (s *recordingSink) Record(ctx context.Context, entry *uiaudit.Entry) error {
+	s.entries = append(s.entries, entry)
+	return s.err
+}
+
+func TestWithAudit(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	type testParams struct {
+		IDs []int64 `json:"ids"`
+	}
+
+	setup := func(t *testing.T) (APIBundle[pgx.Tx], *recordingSink) {
+		t.Helper()
+
+		sink := &recordingSink{}
+		return APIBundle[pgx.Tx]{
+			Archetype:   riversharedtest.BaseServiceArchetype(t),
+			AuditSink:   sink,
+			Environment: "eu",
+			Logger:      riversharedtest.Logger(t),
+		}, sink
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		t.Parallel()
+
+		bundle, sink := setup(t)
+
+		ctx := uiauth.WithIdentity(ctx, &uiauth.Identity{Name: "alice"})
+
+		resp, err := WithAudit(ctx, bundle, &uiaudit.Entry{Action: "job:cancel", JobIDs: []int64{1}}, &testParams{IDs: []int64{1}}, func() (string, error) {
+			return "ok", nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, "ok", resp)
+
+		require.Len(t, sink.entries, 1)
+		require.Equal(t, "alice", sink.entries[0].Actor)
+		require.Equal(t, "eu", sink.entries[0].Environment)
+		require.Equal(t, []int64{1}, sink.entries[0].JobIDs)
+		require.NotZero(t, sink.entries[0].OccurredAt)
+		require.Equal(t, uiaudit.OutcomeSuccess, sink.entries[0].Outcome)
+		require.JSONEq(t, `{"ids":[1]}`, string(sink.entries[0].Params))
+		require.Empty(t, sink.entries[0].Error)
+	})
+
+	t.Run("Denied", func(t *testing.T) {
+		t.Parallel()
+
+		bundle, sink := setup(t)
+
+		_, err := WithAudit(ctx, bundle, &uiaudit.Entry{Action: "job:cancel"}, nil, func() (string, error) {
+			return "", uiauth.NewForbiddenf("Not permitted.")
+		})
+		require.Error(t, err)
+
+		require.Len(t, sink.entries, 1)
+		require.Empty(t, sink.entries[0].Actor)
+		require.Equal(t, uiaudit.OutcomeDenied, sink.entries[0].Outcome)
+		require.Equal(t, "Not permitted.", sink.entries[0].Error)
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		t.Parallel()
+
+		bundle, sink := setup(t)
+
+		_, err := WithAudit(ctx, bundle, &uiaudit.Entry{Action: "job:cancel"}, nil, func() (string, error) {
+			return "", errors.New("database exploded")
+		})
+		require.EqualError(t, err, "database exploded")
+
+		require.Len(t, sink.entries, 1)
+		require.Equal(t, uiaudit.OutcomeFailure, sink.entries[0].Outcome)
+		require.Equal(t, "database exploded", sink.entries[0].Error)
+	})
+
+	t.Run("SinkErrorDoesNotFailOperation", func(t *testing.T) {
+		t.Parallel()
+
+		bundle, sink := setup(t)
+		sink.err = errors.New("sink unavailable")
+
+		resp, err := WithAudit(ctx, bundle, &uiaudit.Entry{Action: "job:cancel"}, nil, func() (string, error) {
+			return "ok", nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, "ok", resp)
+	})
+
+	t.Run("NoSink", func(t *testing.T) {
+		t.Parallel()
+
+		bundle, _ := setup(t)
+		bundle.AuditSink = nil
+
+		resp, err := WithAudit(ctx, bundle, &uiaudit.Entry{Action: "job:cancel"}, nil, func() (string, error) {
+			return "ok", nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, "ok", resp)
+	})
+}
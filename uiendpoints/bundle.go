@@ -3,13 +3,32 @@ package uiendpoints
 import (
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/riverqueue/apiframe/apiendpoint"
 	"github.com/riverqueue/river/rivershared/baseservice"
+
+	"riverqueue.com/riverui/uiaudit"
+	"riverqueue.com/riverui/uiauth"
+	"riverqueue.com/riverui/uidecode"
+	"riverqueue.com/riverui/uiredact"
+	"riverqueue.com/riverui/uischema"
 )
 
 type BundleOpts struct {
+	ArgsDecoder         uidecode.ArgsDecoder
+	ArgsDecoderAccess   uiauth.Access
+	ArgsSchemas         *uischema.Registry
+	AuditSink           uiaudit.Sink
+	AuthorizationPolicy *uiauth.Policy
+	// Environment is the name of the environment the bundle serves, and
+	// Environments are the names of all environments served by the handler,
+	// starting with riverui.DefaultEnvironment.
+	Environment              string
+	Environments             []string
 	JobListHideArgsByDefault bool
+	Redactor                 uiredact.Redactor
+	StatementTimeout         time.Duration
 }
 
 // Bundle is a collection of API endpoints and features for a riverui.Handler.
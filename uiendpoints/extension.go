@@ -0,0 +1,31 @@
+package uiendpoints
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/riverqueue/apiframe/apiendpoint"
+)
+
+// Extension adds custom API endpoints and feature flags to a Bundle, like an
+// endpoint that moves a job to a dead letter queue specific to the embedding
+// application. Extensions are registered with the `Extensions` option of
+// `riverui.EndpointsOpts` or `riverproui.EndpointsOpts`.
+//
+// Endpoints are authorized like built-in ones: an endpoint may declare the
+// access it requires by implementing uiauth.AccessDeclarer, and otherwise
+// requires read access for GET requests and mutate access for all others.
+// They're also described in the handler's OpenAPI document.
+type Extension[TTx any] interface {
+	// Features returns custom feature flags that are merged into the
+	// `extensions` of `/api/features` so that a customized frontend can
+	// discover them. Built-in flags take precedence over custom flags of the
+	// same name.
+	Features(ctx context.Context) (map[string]bool, error)
+
+	// MountEndpoints mounts the extension's endpoints on mux with
+	// apiendpoint.Mount and returns them. It's invoked once for each
+	// environment the bundle serves, after the bundle's built-in endpoints
+	// have been mounted, so patterns must not collide with theirs.
+	MountEndpoints(bundle APIBundle[TTx], mux *http.ServeMux, mountOpts *apiendpoint.MountOpts) []apiendpoint.EndpointInterface
+}
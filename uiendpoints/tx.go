@@ -0,0 +1,42 @@
+package uiendpoints
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/riverqueue/apiframe/apierror"
+	"github.com/riverqueue/river/riverdriver"
+	"github.com/riverqueue/river/rivershared/util/dbutil"
+
+	"riverqueue.com/riverui/internal/pgerror"
+)
+
+// StatementTimeoutMessage is the message of the API error returned by WithTxV
+// when a statement exceeds its statement timeout.
+const StatementTimeoutMessage = "The database took too long to respond and the query was cancelled. Try again, or narrow the request with filters."
+
+const notImplementedMessage = "This feature isn't supported by the database that River is using."
+
+// WithTxV runs innerFunc in a transaction on exec, which is usually the DB or
+// ReadDB of an APIBundle, committing it if innerFunc succeeds. It's how
+// built-in endpoints query the database, and endpoints of extensions should
+// use it too so that they respond to errors the same way: statements that
+// exceed HandlerOpts.StatementTimeout produce a 503 API error instead of a
+// generic internal server error, and operations that the driver doesn't
+// implement for its database (like some on SQLite) produce a 501.
+func WithTxV[T any](ctx context.Context, exec riverdriver.Executor, innerFunc func(ctx context.Context, execTx riverdriver.ExecutorTx) (T, error)) (T, error) {
+	res, err := dbutil.WithTxV(ctx, exec, innerFunc)
+	switch {
+	case err == nil:
+	case pgerror.IsStatementTimeout(err):
+		return res, apierror.WithInternalError(apierror.NewServiceUnavailable(StatementTimeoutMessage), err)
+	case errors.Is(err, riverdriver.ErrNotImplemented):
+		return res, &apierror.APIError{
+			InternalError: err,
+			Message:       notImplementedMessage,
+			StatusCode:    http.StatusNotImplemented,
+		}
+	}
+	return res, err
+}
@@ -0,0 +1,143 @@
+package uikinds
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/riverqueue/river/riverdriver"
+)
+
+// DefaultPostgresTable is the name of the table used by PostgresRegistry
+// unless another is configured.
+const DefaultPostgresTable = "river_ui_kind_registration"
+
+// PostgresRegistryOpts are options for NewPostgresRegistry.
+type PostgresRegistryOpts struct {
+	// Schema is the schema containing the registration table. Defaults to the
+	// connection's search path when empty.
+	Schema string
+
+	// Table is the name of the registration table. Defaults to
+	// DefaultPostgresTable.
+	Table string
+}
+
+// PostgresRegistry is a Registry that stores registrations in a Postgres
+// table, so that clients in other processes can report their kinds to the UI.
+//
+// The table must be created before kinds are registered, either with Migrate
+// or by running the SQL returned by MigrateSQL through an application's own
+// migration framework.
+type PostgresRegistry struct {
+	exec  riverdriver.Executor
+	table string // quoted and possibly schema qualified
+}
+
+// NewPostgresRegistry returns a new PostgresRegistry that stores
+// registrations using the given executor, like one returned by
+// riverpgxv5.New(dbPool).GetExecutor().
+func NewPostgresRegistry(exec riverdriver.Executor, opts *PostgresRegistryOpts) *PostgresRegistry {
+	if opts == nil {
+		opts = &PostgresRegistryOpts{}
+	}
+
+	table := quoteIdentifier(cmp.Or(opts.Table, DefaultPostgresTable))
+	if opts.Schema != "" {
+		table = quoteIdentifier(opts.Schema) + "." + table
+	}
+
+	return &PostgresRegistry{exec: exec, table: table}
+}
+
+// MigrateSQL returns the statements that create the registration table.
+// They're idempotent.
+func (r *PostgresRegistry) MigrateSQL() []string {
+	return []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	client_id text NOT NULL,
+	kind text NOT NULL,
+	seen_at timestamptz NOT NULL DEFAULT now(),
+	PRIMARY KEY (client_id, kind)
+)`, r.table),
+	}
+}
+
+// Migrate creates the registration table if it doesn't already exist.
+func (r *PostgresRegistry) Migrate(ctx context.Context) error {
+	for _, sql := range r.MigrateSQL() {
+		if err := r.exec.Exec(ctx, sql); err != nil {
+			return fmt.Errorf("error migrating kind registration table: %w", err)
+		}
+	}
+	return nil
+}
+
+// postgresRegistration is a registration row as encoded by Postgres'
+// json_agg.
+type postgresRegistration struct {
+	ClientID string    `json:"client_id"`
+	Kind     string    `json:"kind"`
+	SeenAt   time.Time `json:"seen_at"`
+}
+
+func (r *PostgresRegistry) List(ctx context.Context) ([]*Registration, error) {
+	// Rows are aggregated into a single JSON array because riverdriver.Executor
+	// only exposes single row queries.
+	var rowsJSON []byte
+	if err := r.exec.QueryRow(ctx, fmt.Sprintf(`
+		SELECT coalesce(json_agg(registration ORDER BY registration.kind, registration.client_id), '[]')
+		FROM (
+			SELECT client_id, kind, seen_at
+			FROM %s
+		) registration`, r.table),
+	).Scan(&rowsJSON); err != nil {
+		return nil, fmt.Errorf("error listing kind registrations: %w", err)
+	}
+
+	var rows []*postgresRegistration
+	if err := json.Unmarshal(rowsJSON, &rows); err != nil {
+		return nil, fmt.Errorf("error unmarshaling kind registrations: %w", err)
+	}
+
+	registrations := make([]*Registration, len(rows))
+	for i, row := range rows {
+		registrations[i] = &Registration{
+			ClientID: row.ClientID,
+			Kind:     row.Kind,
+			SeenAt:   row.SeenAt,
+		}
+	}
+	return registrations, nil
+}
+
+func (r *PostgresRegistry) Register(ctx context.Context, clientID string, kinds []string) error {
+	if len(kinds) < 1 {
+		return nil
+	}
+
+	kindsJSON, err := json.Marshal(kinds)
+	if err != nil {
+		return fmt.Errorf("error marshaling kinds: %w", err)
+	}
+
+	if err := r.exec.Exec(ctx, fmt.Sprintf(`
+		INSERT INTO %s (client_id, kind, seen_at)
+		SELECT DISTINCT $1::text, kind, now()
+		FROM jsonb_array_elements_text($2::jsonb) AS kind
+		ON CONFLICT (client_id, kind) DO UPDATE SET seen_at = EXCLUDED.seen_at`, r.table),
+		clientID,
+		string(kindsJSON),
+	); err != nil {
+		return fmt.Errorf("error registering kinds: %w", err)
+	}
+
+	return nil
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,155 @@
+// Package uikinds contains a registry of the job kinds that live River
+// clients are able to work. River doesn't persist which workers a client has
+// registered, so without one there's no way to tell that jobs of a kind whose
+// worker was removed or renamed will never be worked. Clients report their
+// kinds to a Registry periodically with Heartbeat, and a registry configured
+// on riverui.EndpointsOpts.KindRegistry lets the UI flag kinds with pending
+// jobs but no live worker, along with registered kinds that have stopped
+// reporting.
+package uikinds
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/riverqueue/river"
+)
+
+// DefaultHeartbeatInterval is the interval at which Heartbeat reports kinds
+// when no other interval is given.
+const DefaultHeartbeatInterval = 1 * time.Minute
+
+// DefaultStaleAfter is how long after its last report a registration is
+// considered stale unless another threshold is requested. It's a few multiples
+// of DefaultHeartbeatInterval so that a slow or missed heartbeat doesn't flag
+// a kind immediately.
+const DefaultStaleAfter = 5 * time.Minute
+
+// Registration records that a client was able to work a job kind.
+type Registration struct {
+	// ClientID identifies the client that reported the kind, like the ID
+	// returned by a River client's ID method.
+	ClientID string
+
+	// Kind is the job kind that the client has a registered worker for.
+	Kind string
+
+	// SeenAt is when the client last reported the kind.
+	SeenAt time.Time
+}
+
+// Registry stores the job kinds reported by clients.
+type Registry interface {
+	// List returns all registrations, including stale ones. Callers decide
+	// which are live based on SeenAt.
+	List(ctx context.Context) ([]*Registration, error)
+
+	// Register records that the given client is able to work the given kinds
+	// as of now, replacing the last report of each.
+	Register(ctx context.Context, clientID string, kinds []string) error
+}
+
+// Heartbeat registers kinds for clientID with registry immediately, and then
+// again on every interval until ctx is cancelled, at which point it returns
+// nil. An interval of zero uses DefaultHeartbeatInterval. Errors registering
+// after the first are passed to onError if it's non-nil and otherwise
+// ignored, so that a temporary database problem doesn't stop reporting.
+//
+// It's meant to be run in a goroutine alongside a River client:
+//
+//	go uikinds.Heartbeat(ctx, registry, riverClient.ID(), uikinds.KindsOf(SortArgs{}, EmailArgs{}), 0, nil)
+func Heartbeat(ctx context.Context, registry Registry, clientID string, kinds []string, interval time.Duration, onError func(err error)) error {
+	if registry == nil {
+		return errors.New("registry is required")
+	}
+	if clientID == "" {
+		return errors.New("client ID is required")
+	}
+	if interval <= 0 {
+		interval = DefaultHeartbeatInterval
+	}
+
+	if err := registry.Register(ctx, clientID, kinds); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := registry.Register(ctx, clientID, kinds); err != nil && onError != nil && ctx.Err() == nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// KindsOf returns the kinds of the given job args, which is convenient for
+// building the list of kinds to pass to Heartbeat from the same args types
+// that are registered with river.AddWorker.
+func KindsOf(args ...river.JobArgs) []string {
+	kinds := make([]string, len(args))
+	for i, arg := range args {
+		kinds[i] = arg.Kind()
+	}
+	return kinds
+}
+
+// MemoryRegistry is a Registry that keeps registrations in memory. It's
+// useful when the UI is embedded in the same process as the only River client
+// working jobs, and in tests.
+type MemoryRegistry struct {
+	mu            sync.Mutex
+	registrations map[memoryRegistryKey]time.Time
+	timeNow       func() time.Time // settable for testing
+}
+
+type memoryRegistryKey struct {
+	clientID string
+	kind     string
+}
+
+// NewMemoryRegistry returns a new, empty MemoryRegistry.
+func NewMemoryRegistry() *MemoryRegistry {
+	return &MemoryRegistry{
+		registrations: make(map[memoryRegistryKey]time.Time),
+		timeNow:       time.Now,
+	}
+}
+
+func (r *MemoryRegistry) List(_ context.Context) ([]*Registration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	registrations := make([]*Registration, 0, len(r.registrations))
+	for key, seenAt := range r.registrations {
+		registrations = append(registrations, &Registration{ClientID: key.clientID, Kind: key.kind, SeenAt: seenAt})
+	}
+
+	slices.SortFunc(registrations, compareRegistrations)
+	return registrations, nil
+}
+
+func (r *MemoryRegistry) Register(_ context.Context, clientID string, kinds []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.timeNow().UTC()
+	for _, kind := range kinds {
+		r.registrations[memoryRegistryKey{clientID: clientID, kind: kind}] = now
+	}
+	return nil
+}
+
+func compareRegistrations(a, b *Registration) int {
+	return cmp.Or(strings.Compare(a.Kind, b.Kind), strings.Compare(a.ClientID, b.ClientID))
+}
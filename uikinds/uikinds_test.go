@@ -0,0 +1,138 @@
+package uikinds
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testArgs struct{}
+
+func (testArgs) Kind() string { return "test" }
+
+type otherArgs struct{}
+
+func (otherArgs) Kind() string { return "other" }
+
+// countingRegistry is a Registry that counts registrations and optionally
+// fails them.
+type countingRegistry struct {
+	mu    sync.Mutex
+	count int
+	err   error
+}
+
+func (r *countingRegistry) List(_ context.Context) ([]*Registration, error) { return nil, nil }
+
+func (r *countingRegistry) Register(_ context.Context, _ string, _ []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.count++
+	return r.err
+}
+
+func (r *countingRegistry) registerCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.count
+}
+
+func TestHeartbeat(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("RegistersUntilCancelled", func(t *testing.T) {
+		t.Parallel()
+
+		registry := NewMemoryRegistry()
+
+		ctx, cancel := context.WithCancel(ctx)
+		errChan := make(chan error)
+		go func() { errChan <- Heartbeat(ctx, registry, "client_1", []string{"test"}, time.Millisecond, nil) }()
+
+		require.Eventually(t, func() bool {
+			registrations, err := registry.List(ctx)
+			require.NoError(t, err)
+			return len(registrations) == 1
+		}, time.Second, time.Millisecond)
+
+		cancel()
+		require.NoError(t, <-errChan)
+	})
+
+	t.Run("FirstErrorReturned", func(t *testing.T) {
+		t.Parallel()
+
+		registry := &countingRegistry{err: errors.New("db down")}
+
+		require.EqualError(t, Heartbeat(ctx, registry, "client_1", []string{"test"}, time.Millisecond, nil), "db down")
+		require.Equal(t, 1, registry.registerCount())
+	})
+
+	t.Run("LaterErrorsPassedToOnError", func(t *testing.T) {
+		t.Parallel()
+
+		registry := &countingRegistry{}
+
+		ctx, cancel := context.WithCancel(ctx)
+		errChan := make(chan error)
+		go func() {
+			errChan <- Heartbeat(ctx, registry, "client_1", []string{"test"}, time.Millisecond, func(err error) {
+				require.EqualError(t, err, "db down")
+				cancel()
+			})
+		}()
+
+		require.Eventually(t, func() bool { return registry.registerCount() > 0 }, time.Second, time.Millisecond)
+		registry.mu.Lock()
+		registry.err = errors.New("db down")
+		registry.mu.Unlock()
+
+		require.NoError(t, <-errChan)
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		t.Parallel()
+
+		require.EqualError(t, Heartbeat(ctx, nil, "client_1", nil, 0, nil), "registry is required")
+		require.EqualError(t, Heartbeat(ctx, NewMemoryRegistry(), "", nil, 0, nil), "client ID is required")
+	})
+}
+
+func TestKindsOf(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, []string{"test", "other"}, KindsOf(testArgs{}, otherArgs{}))
+	require.Empty(t, KindsOf())
+}
+
+func TestMemoryRegistry(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var (
+		registry = NewMemoryRegistry()
+		now      = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	)
+	registry.timeNow = func() time.Time { return now }
+
+	require.NoError(t, registry.Register(ctx, "client_2", []string{"test"}))
+	require.NoError(t, registry.Register(ctx, "client_1", []string{"test", "other"}))
+
+	now = now.Add(time.Minute)
+	require.NoError(t, registry.Register(ctx, "client_1", []string{"test"}))
+
+	registrations, err := registry.List(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []*Registration{
+		{ClientID: "client_1", Kind: "other", SeenAt: now.Add(-time.Minute)},
+		{ClientID: "client_1", Kind: "test", SeenAt: now},
+		{ClientID: "client_2", Kind: "test", SeenAt: now.Add(-time.Minute)},
+	}, registrations)
+}
@@ -0,0 +1,327 @@
+package uiredact
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/riverqueue/river/rivertype"
+)
+
+// DefaultReplacement is the string that JSONPathRedactor replaces redacted
+// values with unless another is configured.
+const DefaultReplacement = "[REDACTED]"
+
+// JSONPathRedactorOpts configures a JSONPathRedactor. It may be decoded from
+// JSON, like:
+//
+//	{
+//	  "args": ["$..password", "$.card.number"],
+//	  "kinds": {
+//	    "send_email": {"args": ["$.to", "$.cc[*]"], "errors": true}
+//	  }
+//	}
+type JSONPathRedactorOpts struct {
+	JSONPathRules
+
+	// Kinds are additional rules for jobs of particular kinds, which apply on
+	// top of the rules for all kinds.
+	Kinds map[string]*JSONPathRules `json:"kinds"`
+
+	// Replacement is the string that redacted values are replaced with.
+	// Defaults to DefaultReplacement.
+	Replacement string `json:"replacement"`
+}
+
+// JSONPathRules are the parts of a job that a JSONPathRedactor redacts.
+//
+// Paths are a subset of JSONPath: they start with `$` for the document's
+// root, followed by any number of `.name` or `['name']` for an object's field,
+// `[0]` for an array's element, `.*` or `[*]` for all of an object's fields or
+// an array's elements, and `..name` for a field at any depth. Values matched
+// by a path are replaced whole, whatever their type.
+type JSONPathRules struct {
+	// Args are paths to values in a job's args to redact.
+	Args []string `json:"args"`
+
+	// Errors redacts the messages and stack traces of a job's attempt errors,
+	// which may contain values from its args.
+	Errors bool `json:"errors"`
+
+	// Metadata are paths to values in a job's metadata to redact.
+	Metadata []string `json:"metadata"`
+}
+
+// JSONPathRedactor is a Redactor that replaces values at JSON paths in jobs'
+// args and metadata, and optionally their attempt errors.
+type JSONPathRedactor struct {
+	all             *jsonPathRuleSet
+	kinds           map[string]*jsonPathRuleSet
+	replacement     string
+	replacementJSON json.RawMessage
+}
+
+// NewJSONPathRedactor returns a new JSONPathRedactor, or an error if any of
+// its paths are invalid.
+func NewJSONPathRedactor(opts *JSONPathRedactorOpts) (*JSONPathRedactor, error) {
+	if opts == nil {
+		opts = &JSONPathRedactorOpts{}
+	}
+
+	all, err := parseJSONPathRules(&opts.JSONPathRules)
+	if err != nil {
+		return nil, err
+	}
+
+	kinds := make(map[string]*jsonPathRuleSet, len(opts.Kinds))
+	for kind, rules := range opts.Kinds {
+		if rules == nil {
+			continue
+		}
+		if kinds[kind], err = parseJSONPathRules(rules); err != nil {
+			return nil, fmt.Errorf("kind %q: %w", kind, err)
+		}
+	}
+
+	replacement := cmp.Or(opts.Replacement, DefaultReplacement)
+	replacementJSON, err := json.Marshal(replacement)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JSONPathRedactor{all: all, kinds: kinds, replacement: replacement, replacementJSON: replacementJSON}, nil
+}
+
+// Redact redacts job's args, metadata, and attempt errors according to the
+// redactor's rules for all kinds and for job's kind.
+func (r *JSONPathRedactor) Redact(_ context.Context, job *rivertype.JobRow) error {
+	var (
+		argsPaths     = r.all.args
+		metadataPaths = r.all.metadata
+		redactErrors  = r.all.errors
+	)
+	if kindRules, ok := r.kinds[job.Kind]; ok {
+		argsPaths = slices.Concat(argsPaths, kindRules.args)
+		metadataPaths = slices.Concat(metadataPaths, kindRules.metadata)
+		redactErrors = redactErrors || kindRules.errors
+	}
+
+	var err error
+	if job.EncodedArgs, err = r.redactJSON(job.EncodedArgs, argsPaths); err != nil {
+		return fmt.Errorf("error redacting args of job %d: %w", job.ID, err)
+	}
+	if job.Metadata, err = r.redactJSON(job.Metadata, metadataPaths); err != nil {
+		return fmt.Errorf("error redacting metadata of job %d: %w", job.ID, err)
+	}
+
+	if redactErrors {
+		for i := range job.Errors {
+			job.Errors[i].Error = r.replacement
+			if job.Errors[i].Trace != "" {
+				job.Errors[i].Trace = r.replacement
+			}
+		}
+	}
+
+	return nil
+}
+
+// Redacts the values at paths in the JSON document data, returning data
+// unchanged if no path matched anything.
+func (r *JSONPathRedactor) redactJSON(data []byte, paths []jsonPath) ([]byte, error) {
+	if len(paths) < 1 || len(data) < 1 {
+		return data, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // preserve large numbers exactly
+
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+
+	var changed bool
+	for _, path := range paths {
+		var pathChanged bool
+		document, pathChanged = path.redact(document, r.replacementJSON)
+		changed = changed || pathChanged
+	}
+	if !changed {
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(document); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+type jsonPathRuleSet struct {
+	args     []jsonPath
+	errors   bool
+	metadata []jsonPath
+}
+
+func parseJSONPathRules(rules *JSONPathRules) (*jsonPathRuleSet, error) {
+	ruleSet := &jsonPathRuleSet{errors: rules.Errors}
+
+	for _, path := range rules.Args {
+		parsed, err := parseJSONPath(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid args path %q: %w", path, err)
+		}
+		ruleSet.args = append(ruleSet.args, parsed)
+	}
+
+	for _, path := range rules.Metadata {
+		parsed, err := parseJSONPath(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid metadata path %q: %w", path, err)
+		}
+		ruleSet.metadata = append(ruleSet.metadata, parsed)
+	}
+
+	return ruleSet, nil
+}
+
+// jsonPath is a parsed JSON path, which is a sequence of segments each
+// matching children of the values matched by the previous one.
+type jsonPath []jsonPathSegment
+
+type jsonPathSegment struct {
+	index     int
+	isIndex   bool
+	name      string
+	recursive bool // matches descendants at any depth rather than children
+	wildcard  bool
+}
+
+func (s *jsonPathSegment) matchesIndex(index int) bool {
+	return s.wildcard || (s.isIndex && s.index == index)
+}
+
+func (s *jsonPathSegment) matchesName(name string) bool {
+	return s.wildcard || (!s.isIndex && s.name == name)
+}
+
+func parseJSONPath(path string) (jsonPath, error) {
+	rest, ok := strings.CutPrefix(path, "$")
+	if !ok {
+		return nil, errors.New("must start with `$`")
+	}
+
+	var parsed jsonPath
+	for rest != "" {
+		var segment jsonPathSegment
+
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			segment.recursive = true
+			rest = rest[2:]
+			if strings.HasPrefix(rest, "[") {
+				break
+			}
+			rest = "." + rest
+			fallthrough
+
+		case strings.HasPrefix(rest, "."):
+			end := strings.IndexAny(rest[1:], ".[")
+			if end == -1 {
+				end = len(rest) - 1
+			}
+			name := rest[1 : end+1]
+			rest = rest[end+1:]
+
+			switch name {
+			case "":
+				return nil, errors.New("empty field name")
+			case "*":
+				segment.wildcard = true
+			default:
+				segment.name = name
+			}
+			parsed = append(parsed, segment)
+			continue
+
+		case !strings.HasPrefix(rest, "["):
+			return nil, fmt.Errorf("unexpected %q", rest[:1])
+		}
+
+		// A bracketed segment like `[0]`, `[*]`, or `['name']`.
+		end := strings.Index(rest, "]")
+		if end == -1 {
+			return nil, errors.New("unterminated `[`")
+		}
+		inner := rest[1:end]
+		rest = rest[end+1:]
+
+		switch {
+		case inner == "*":
+			segment.wildcard = true
+		case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+			segment.name = inner[1 : len(inner)-1]
+		default:
+			index, err := strconv.Atoi(inner)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid index %q", inner)
+			}
+			segment.index = index
+			segment.isIndex = true
+		}
+		parsed = append(parsed, segment)
+	}
+
+	return parsed, nil
+}
+
+// Replaces the values in node matched by the path with replacement, returning
+// the resulting node and whether anything was replaced. Objects and arrays
+// are modified in place.
+func (p jsonPath) redact(node any, replacement json.RawMessage) (any, bool) {
+	if len(p) < 1 {
+		return replacement, true
+	}
+
+	var (
+		changed bool
+		segment = p[0]
+	)
+
+	// Descends into child with the remainder of the path if segment matches
+	// it, and with the whole path again if segment matches at any depth.
+	redactChild := func(child any, matches bool) any {
+		var childChanged bool
+		if segment.recursive {
+			child, childChanged = p.redact(child, replacement)
+			changed = changed || childChanged
+		}
+		if matches {
+			child, childChanged = p[1:].redact(child, replacement)
+			changed = changed || childChanged
+		}
+		return child
+	}
+
+	switch node := node.(type) {
+	case map[string]any:
+		for name, child := range node {
+			node[name] = redactChild(child, segment.matchesName(name))
+		}
+	case []any:
+		for i, child := range node {
+			node[i] = redactChild(child, segment.matchesIndex(i))
+		}
+	}
+
+	return node, changed
+}
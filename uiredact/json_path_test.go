@@ -0,0 +1,158 @@
+package uiredact
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/riverqueue/river/rivertype"
+)
+
+func TestParseJSONPath(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Valid", func(t *testing.T) {
+		t.Parallel()
+
+		path, err := parseJSONPath(`$.card.numbers[0]['full name'][*]..token.*`)
+		require.NoError(t, err)
+		require.Equal(t, jsonPath{
+			{name: "card"},
+			{name: "numbers"},
+			{index: 0, isIndex: true},
+			{name: "full name"},
+			{wildcard: true},
+			{name: "token", recursive: true},
+			{wildcard: true},
+		}, path)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+
+		for path, expectedErr := range map[string]string{
+			"card":        "must start with `$`",
+			"$card":       `unexpected "c"`,
+			"$.":          "empty field name",
+			"$..":         "empty field name",
+			"$.cards[0":   "unterminated `[`",
+			"$.cards[-1]": `invalid index "-1"`,
+		} {
+			_, err := parseJSONPath(path)
+			require.EqualError(t, err, expectedErr, "path %q", path)
+		}
+	})
+}
+
+func TestJSONPathRedactor(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	redact := func(t *testing.T, opts *JSONPathRedactorOpts, job *rivertype.JobRow) *rivertype.JobRow {
+		t.Helper()
+
+		redactor, err := NewJSONPathRedactor(opts)
+		require.NoError(t, err)
+
+		redacted, err := Apply(ctx, redactor, job)
+		require.NoError(t, err)
+		return redacted
+	}
+
+	t.Run("Args", func(t *testing.T) {
+		t.Parallel()
+
+		job := &rivertype.JobRow{
+			EncodedArgs: []byte(`{"user":{"email":"a@example.com","id":9007199254740993},"cards":[{"number":"4242"},{"number":"4000"}],"note":"<b>"}`),
+			Kind:        "charge",
+		}
+
+		redacted := redact(t, &JSONPathRedactorOpts{
+			JSONPathRules: JSONPathRules{Args: []string{"$.user.email", "$.cards[*].number"}},
+		}, job)
+		require.JSONEq(t,
+			`{"user":{"email":"[REDACTED]","id":9007199254740993},"cards":[{"number":"[REDACTED]"},{"number":"[REDACTED]"}],"note":"<b>"}`,
+			string(redacted.EncodedArgs))
+		require.Contains(t, string(redacted.EncodedArgs), `"<b>"`)
+		require.Contains(t, string(redacted.EncodedArgs), `9007199254740993`)
+
+		// The original job is left unchanged.
+		require.Contains(t, string(job.EncodedArgs), "a@example.com")
+	})
+
+	t.Run("RecursiveDescent", func(t *testing.T) {
+		t.Parallel()
+
+		redacted := redact(t, &JSONPathRedactorOpts{
+			JSONPathRules: JSONPathRules{Args: []string{"$..password"}},
+			Replacement:   "***",
+		}, &rivertype.JobRow{
+			EncodedArgs: []byte(`{"password":"a","nested":[{"password":"b"},{"other":{"password":{"c":1}}}]}`),
+		})
+		require.JSONEq(t, `{"password":"***","nested":[{"password":"***"},{"other":{"password":"***"}}]}`, string(redacted.EncodedArgs))
+	})
+
+	t.Run("UnmatchedLeftUnchanged", func(t *testing.T) {
+		t.Parallel()
+
+		args := []byte(`{ "b": 1, "a": 2 }`)
+		redacted := redact(t, &JSONPathRedactorOpts{
+			JSONPathRules: JSONPathRules{Args: []string{"$.password"}},
+		}, &rivertype.JobRow{EncodedArgs: args})
+		require.Equal(t, string(args), string(redacted.EncodedArgs))
+	})
+
+	t.Run("KindRulesAndMetadata", func(t *testing.T) {
+		t.Parallel()
+
+		opts := &JSONPathRedactorOpts{
+			JSONPathRules: JSONPathRules{Metadata: []string{"$.tenant"}},
+			Kinds: map[string]*JSONPathRules{
+				"send_email": {Args: []string{"$.to"}, Errors: true},
+			},
+		}
+
+		newJob := func(kind string) *rivertype.JobRow {
+			return &rivertype.JobRow{
+				EncodedArgs: []byte(`{"to":"a@example.com"}`),
+				Errors:      []rivertype.AttemptError{{Attempt: 1, Error: "bad address a@example.com", Trace: "trace"}},
+				Kind:        kind,
+				Metadata:    []byte(`{"tenant":"acme"}`),
+			}
+		}
+
+		redacted := redact(t, opts, newJob("send_email"))
+		require.JSONEq(t, `{"to":"[REDACTED]"}`, string(redacted.EncodedArgs))
+		require.JSONEq(t, `{"tenant":"[REDACTED]"}`, string(redacted.Metadata))
+		require.Equal(t, []rivertype.AttemptError{{Attempt: 1, Error: "[REDACTED]", Trace: "[REDACTED]"}}, redacted.Errors)
+
+		redacted = redact(t, opts, newJob("send_sms"))
+		require.JSONEq(t, `{"to":"a@example.com"}`, string(redacted.EncodedArgs))
+		require.JSONEq(t, `{"tenant":"[REDACTED]"}`, string(redacted.Metadata))
+		require.Equal(t, "bad address a@example.com", redacted.Errors[0].Error)
+	})
+
+	t.Run("DecodedFromJSON", func(t *testing.T) {
+		t.Parallel()
+
+		var opts JSONPathRedactorOpts
+		require.NoError(t, json.Unmarshal([]byte(`{"args":["$.secret"],"kinds":{"k":{"metadata":["$.m"]}},"replacement":"x"}`), &opts))
+		require.Equal(t, JSONPathRedactorOpts{
+			JSONPathRules: JSONPathRules{Args: []string{"$.secret"}},
+			Kinds:         map[string]*JSONPathRules{"k": {Metadata: []string{"$.m"}}},
+			Replacement:   "x",
+		}, opts)
+	})
+
+	t.Run("InvalidPath", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewJSONPathRedactor(&JSONPathRedactorOpts{
+			Kinds: map[string]*JSONPathRules{"k": {Metadata: []string{"m"}}},
+		})
+		require.EqualError(t, err, "kind \"k\": invalid metadata path \"m\": must start with `$`")
+	})
+}
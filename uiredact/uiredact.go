@@ -0,0 +1,103 @@
+// Package uiredact contains hooks that redact sensitive data like personal
+// information and secrets from jobs before River UI's API serves them. A
+// Redactor may be configured on a riverui.Handler with HandlerOpts.Redactor,
+// and is applied to the args, metadata, and attempt errors of every job the
+// API returns, in job lists and job details alike.
+//
+// Redactors can be written as a RedactorFunc, scoped to particular job kinds
+// with ByKind, and combined with Chain. JSONPathRedactor is a built-in
+// redactor that replaces values at JSON paths.
+package uiredact
+
+import (
+	"context"
+	"slices"
+
+	"github.com/riverqueue/river/rivertype"
+)
+
+// Redactor transforms or redacts a job's fields before they're served by the
+// API. Implementations must be safe for concurrent use.
+type Redactor interface {
+	// Redact modifies job in place. Only changes to its EncodedArgs, Metadata,
+	// and Errors are served, and changes to any other field are discarded.
+	// job is a copy, so its fields may be modified or replaced freely, but
+	// the contents of EncodedArgs and Metadata must be replaced rather than
+	// modified.
+	//
+	// An error fails the request serving the job rather than risk serving it
+	// unredacted.
+	Redact(ctx context.Context, job *rivertype.JobRow) error
+}
+
+// RedactorFunc is a function that implements Redactor.
+type RedactorFunc func(ctx context.Context, job *rivertype.JobRow) error
+
+// Redact invokes f.
+func (f RedactorFunc) Redact(ctx context.Context, job *rivertype.JobRow) error {
+	return f(ctx, job)
+}
+
+// ByKind returns a Redactor that redacts each job with the redactor for its
+// kind in redactors, leaving jobs of other kinds unchanged.
+func ByKind(redactors map[string]Redactor) Redactor {
+	return RedactorFunc(func(ctx context.Context, job *rivertype.JobRow) error {
+		if redactor, ok := redactors[job.Kind]; ok && redactor != nil {
+			return redactor.Redact(ctx, job)
+		}
+		return nil
+	})
+}
+
+// Chain returns a Redactor that redacts each job with each of redactors in
+// turn. Nil redactors are skipped.
+func Chain(redactors ...Redactor) Redactor {
+	return RedactorFunc(func(ctx context.Context, job *rivertype.JobRow) error {
+		for _, redactor := range redactors {
+			if redactor == nil {
+				continue
+			}
+			if err := redactor.Redact(ctx, job); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Apply returns a copy of job whose args, metadata, and errors have been
+// redacted by redactor, or job itself if redactor is nil. job isn't modified.
+func Apply(ctx context.Context, redactor Redactor, job *rivertype.JobRow) (*rivertype.JobRow, error) {
+	if redactor == nil {
+		return job, nil
+	}
+
+	redacted := *job
+	redacted.Errors = slices.Clone(job.Errors)
+	if err := redactor.Redact(ctx, &redacted); err != nil {
+		return nil, err
+	}
+
+	result := *job
+	result.EncodedArgs = redacted.EncodedArgs
+	result.Errors = redacted.Errors
+	result.Metadata = redacted.Metadata
+	return &result, nil
+}
+
+// ApplyAll applies redactor to each of jobs like Apply, returning a new slice
+// of redacted jobs.
+func ApplyAll(ctx context.Context, redactor Redactor, jobs []*rivertype.JobRow) ([]*rivertype.JobRow, error) {
+	if redactor == nil {
+		return jobs, nil
+	}
+
+	redacted := make([]*rivertype.JobRow, len(jobs))
+	for i, job := range jobs {
+		var err error
+		if redacted[i], err = Apply(ctx, redactor, job); err != nil {
+			return nil, err
+		}
+	}
+	return redacted, nil
+}
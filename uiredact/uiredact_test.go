@@ -0,0 +1,89 @@
+package uiredact
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/riverqueue/river/rivertype"
+)
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("NilRedactor", func(t *testing.T) {
+		t.Parallel()
+
+		job := &rivertype.JobRow{ID: 1}
+		redacted, err := Apply(ctx, nil, job)
+		require.NoError(t, err)
+		require.Same(t, job, redacted)
+	})
+
+	t.Run("OnlyRedactableFieldsKept", func(t *testing.T) {
+		t.Parallel()
+
+		job := &rivertype.JobRow{
+			EncodedArgs: []byte(`{"a":1}`),
+			Errors:      []rivertype.AttemptError{{Error: "secret"}},
+			ID:          1,
+			Kind:        "kind",
+		}
+
+		redacted, err := Apply(ctx, RedactorFunc(func(ctx context.Context, job *rivertype.JobRow) error {
+			job.EncodedArgs = []byte(`{}`)
+			job.Errors[0].Error = "redacted"
+			job.Kind = "other"
+			job.Metadata = []byte(`{"redacted":true}`)
+			return nil
+		}), job)
+		require.NoError(t, err)
+		require.Equal(t, `{}`, string(redacted.EncodedArgs))
+		require.Equal(t, "redacted", redacted.Errors[0].Error)
+		require.Equal(t, "kind", redacted.Kind)
+		require.JSONEq(t, `{"redacted":true}`, string(redacted.Metadata))
+
+		// The original job's errors are left unchanged.
+		require.Equal(t, "secret", job.Errors[0].Error)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Apply(ctx, RedactorFunc(func(ctx context.Context, job *rivertype.JobRow) error {
+			return errors.New("redaction failed")
+		}), &rivertype.JobRow{})
+		require.EqualError(t, err, "redaction failed")
+	})
+}
+
+func TestByKindAndChain(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	setArgs := func(args string) Redactor {
+		return RedactorFunc(func(ctx context.Context, job *rivertype.JobRow) error {
+			job.EncodedArgs = append(job.EncodedArgs, args...)
+			return nil
+		})
+	}
+
+	redactor := Chain(
+		setArgs("a"),
+		nil,
+		ByKind(map[string]Redactor{"email": setArgs("b")}),
+	)
+
+	redacted, err := Apply(ctx, redactor, &rivertype.JobRow{Kind: "email"})
+	require.NoError(t, err)
+	require.Equal(t, "ab", string(redacted.EncodedArgs))
+
+	redacted, err = Apply(ctx, redactor, &rivertype.JobRow{Kind: "sms"})
+	require.NoError(t, err)
+	require.Equal(t, "a", string(redacted.EncodedArgs))
+}
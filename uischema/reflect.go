@@ -0,0 +1,252 @@
+package uischema
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/riverqueue/river"
+)
+
+// SchemaDialect is the JSON Schema dialect of schemas derived by Reflect.
+const SchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// reflectedSchema is a schema derived from a Go type. Only the keywords that
+// Reflect produces are included.
+type reflectedSchema struct { //nolint:tagliatelle // names are defined by the JSON Schema specification
+	Schema               string                      `json:"$schema,omitempty"`
+	Title                string                      `json:"title,omitempty"`
+	AdditionalProperties any                         `json:"additionalProperties,omitempty"`
+	ContentEncoding      string                      `json:"contentEncoding,omitempty"`
+	Format               string                      `json:"format,omitempty"`
+	Items                *reflectedSchema            `json:"items,omitempty"`
+	MaxItems             *int                        `json:"maxItems,omitempty"`
+	MinItems             *int                        `json:"minItems,omitempty"`
+	Minimum              *float64                    `json:"minimum,omitempty"`
+	Properties           map[string]*reflectedSchema `json:"properties,omitempty"`
+	Required             []string                    `json:"required,omitempty"`
+	Type                 any                         `json:"type,omitempty"` // string or []string
+}
+
+// Reflect derives a JSON Schema from the Go type of args, describing the JSON
+// that encoding/json encodes it to. Struct fields are named by their `json`
+// tags and are required unless they're pointers or tagged `omitempty` or
+// `omitzero`, and properties that aren't fields aren't allowed. Pointers,
+// slices, and maps may be null. Types that implement json.Marshaler, other
+// than time.Time, may be any value, and types that implement
+// encoding.TextMarshaler are strings. Recursive types are described up to
+// the point they recur, after which they may be any value.
+//
+// For constraints that can't be derived from a Go type, like the allowed
+// values of a string, register a handwritten schema with Registry.Register
+// instead.
+func Reflect(args river.JobArgs) (json.RawMessage, error) {
+	typ := reflect.TypeOf(args)
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("args must be a struct, but got %s", typ)
+	}
+
+	schema, err := reflectSchema(typ, map[reflect.Type]bool{})
+	if err != nil {
+		return nil, err
+	}
+	schema.Schema = SchemaDialect
+	schema.Title = args.Kind()
+
+	return json.Marshal(schema)
+}
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()         //nolint:gochecknoglobals
+	rawMessageType    = reflect.TypeFor[json.RawMessage]()        //nolint:gochecknoglobals
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]() //nolint:gochecknoglobals
+	timeType          = reflect.TypeFor[time.Time]()              //nolint:gochecknoglobals
+)
+
+// implements returns whether typ or a pointer to it implements iface, since
+// encoding/json uses methods on pointer receivers of addressable values.
+func implements(typ, iface reflect.Type) bool {
+	return typ.Implements(iface) || reflect.PointerTo(typ).Implements(iface)
+}
+
+func reflectSchema(typ reflect.Type, visiting map[reflect.Type]bool) (*reflectedSchema, error) {
+	switch {
+	case typ == timeType:
+		return &reflectedSchema{Type: "string", Format: "date-time"}, nil
+	case typ == rawMessageType, typ.Kind() != reflect.Pointer && implements(typ, jsonMarshalerType):
+		return &reflectedSchema{}, nil
+	case typ.Kind() != reflect.Pointer && implements(typ, textMarshalerType):
+		return &reflectedSchema{Type: "string"}, nil
+	}
+
+	switch typ.Kind() { //nolint:exhaustive
+	case reflect.Bool:
+		return &reflectedSchema{Type: "boolean"}, nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &reflectedSchema{Type: "integer"}, nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		minimum := 0.0
+		return &reflectedSchema{Type: "integer", Minimum: &minimum}, nil
+
+	case reflect.Float32, reflect.Float64:
+		return &reflectedSchema{Type: "number"}, nil
+
+	case reflect.String:
+		return &reflectedSchema{Type: "string"}, nil
+
+	case reflect.Interface:
+		return &reflectedSchema{}, nil
+
+	case reflect.Pointer:
+		schema, err := reflectSchema(typ.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return nullable(schema), nil
+
+	case reflect.Array:
+		items, err := reflectSchema(typ.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		length := typ.Len()
+		return &reflectedSchema{Type: "array", Items: items, MaxItems: &length, MinItems: &length}, nil
+
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 && !implements(typ.Elem(), jsonMarshalerType) && !implements(typ.Elem(), textMarshalerType) {
+			return nullable(&reflectedSchema{Type: "string", ContentEncoding: "base64"}), nil
+		}
+
+		items, err := reflectSchema(typ.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return nullable(&reflectedSchema{Type: "array", Items: items}), nil
+
+	case reflect.Map:
+		// Like encoding/json, keys may be strings, text marshalers, or integers,
+		// which are all encoded as strings.
+		keyType := typ.Key()
+		if keyType.Kind() != reflect.String && !implements(keyType, textMarshalerType) && (keyType.Kind() < reflect.Int || keyType.Kind() > reflect.Uintptr) {
+			return nil, fmt.Errorf("unsupported map key type %s", keyType)
+		}
+
+		values, err := reflectSchema(typ.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return nullable(&reflectedSchema{Type: "object", AdditionalProperties: values}), nil
+
+	case reflect.Struct:
+		if visiting[typ] {
+			return &reflectedSchema{}, nil
+		}
+		visiting[typ] = true
+		defer delete(visiting, typ)
+
+		schema := &reflectedSchema{
+			AdditionalProperties: false,
+			Properties:           map[string]*reflectedSchema{},
+			Type:                 "object",
+		}
+		if err := reflectFields(typ, schema, visiting, map[string]int{}, 0); err != nil {
+			return nil, err
+		}
+		slices.Sort(schema.Required)
+		return schema, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported type %s", typ)
+	}
+}
+
+// reflectFields adds the JSON fields of a struct to schema, including those
+// promoted from embedded structs. As in encoding/json, a field at a shallower
+// depth takes precedence over one of the same name promoted from deeper.
+// depths tracks the depth each property was added at.
+func reflectFields(typ reflect.Type, schema *reflectedSchema, visiting map[reflect.Type]bool, depths map[string]int, depth int) error {
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		fieldType := field.Type
+		if field.Anonymous && name == "" {
+			embeddedType := fieldType
+			if embeddedType.Kind() == reflect.Pointer {
+				embeddedType = embeddedType.Elem()
+			}
+			if embeddedType.Kind() == reflect.Struct {
+				if visiting[embeddedType] {
+					continue
+				}
+				if err := reflectFields(embeddedType, schema, visiting, depths, depth+1); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		if existingDepth, ok := depths[name]; ok && existingDepth <= depth {
+			continue
+		}
+
+		fieldSchema, err := reflectSchema(fieldType, visiting)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		if hasTagOption(opts, "string") && slices.Contains([]string{"boolean", "integer", "number", "string"}, fmt.Sprint(fieldSchema.Type)) {
+			fieldSchema = &reflectedSchema{Type: "string"}
+		}
+
+		schema.Properties[name] = fieldSchema
+		depths[name] = depth
+
+		schema.Required = slices.DeleteFunc(schema.Required, func(required string) bool { return required == name })
+		if fieldType.Kind() != reflect.Pointer && !hasTagOption(opts, "omitempty") && !hasTagOption(opts, "omitzero") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	return nil
+}
+
+func hasTagOption(opts, option string) bool {
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// nullable returns schema modified to also allow null. Schemas without a type
+// already allow any value, including null, and others that aren't a single
+// type are already nullable.
+func nullable(schema *reflectedSchema) *reflectedSchema {
+	if typ, ok := schema.Type.(string); ok {
+		schema.Type = []string{typ, "null"}
+	}
+	return schema
+}
@@ -0,0 +1,146 @@
+package uischema
+
+import (
+	"encoding/json"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type reflectBaseArgs struct {
+	CustomerID int64 `json:"customer_id"`
+	Note       string
+}
+
+type reflectAddress struct {
+	City string `json:"city"`
+}
+
+type reflectNode struct {
+	Children []*reflectNode `json:"children"`
+	Name     string         `json:"name"`
+}
+
+type reflectArgs struct {
+	reflectBaseArgs
+
+	Address    reflectAddress    `json:"address"`
+	Amount     float64           `json:"amount"`
+	Attempts   uint8             `json:"attempts"`
+	Blob       []byte            `json:"blob,omitempty"`
+	Count      int               `json:"count,string"`
+	Extra      json.RawMessage   `json:"extra,omitempty"`
+	Hidden     string            `json:"-"`
+	IP         netip.Addr        `json:"ip"`
+	Labels     map[string]string `json:"labels"`
+	Note       string            `json:"note,omitzero"`
+	Pair       [2]int            `json:"pair"`
+	ReplyTo    *string           `json:"reply_to"`
+	SendAt     time.Time         `json:"send_at"`
+	Tags       []string          `json:"tags"`
+	Tree       reflectNode       `json:"tree"`
+	Value      any               `json:"value"`
+	unexported string
+}
+
+func (reflectArgs) Kind() string { return "reflect" }
+
+type reflectAddressArgs struct {
+	reflectAddress
+}
+
+func (*reflectAddressArgs) Kind() string { return "address" }
+
+type reflectScalarArgs string
+
+func (reflectScalarArgs) Kind() string { return "scalar" }
+
+type reflectChanArgs struct {
+	Done chan struct{} `json:"done"`
+}
+
+func (reflectChanArgs) Kind() string { return "chan" }
+
+func TestReflect(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Struct", func(t *testing.T) {
+		t.Parallel()
+
+		schema, err := Reflect(reflectArgs{unexported: "unused"})
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"title": "reflect",
+			"type": "object",
+			"additionalProperties": false,
+			"properties": {
+				"Note": {"type": "string"},
+				"address": {
+					"type": "object",
+					"additionalProperties": false,
+					"properties": {"city": {"type": "string"}},
+					"required": ["city"]
+				},
+				"amount": {"type": "number"},
+				"attempts": {"type": "integer", "minimum": 0},
+				"blob": {"type": ["string", "null"], "contentEncoding": "base64"},
+				"count": {"type": "string"},
+				"customer_id": {"type": "integer"},
+				"extra": {},
+				"ip": {"type": "string"},
+				"labels": {"type": ["object", "null"], "additionalProperties": {"type": "string"}},
+				"note": {"type": "string"},
+				"pair": {"type": "array", "items": {"type": "integer"}, "minItems": 2, "maxItems": 2},
+				"reply_to": {"type": ["string", "null"]},
+				"send_at": {"type": "string", "format": "date-time"},
+				"tags": {"type": ["array", "null"], "items": {"type": "string"}},
+				"tree": {
+					"type": "object",
+					"additionalProperties": false,
+					"properties": {
+						"children": {"type": ["array", "null"], "items": {}},
+						"name": {"type": "string"}
+					},
+					"required": ["children", "name"]
+				},
+				"value": {}
+			},
+			"required": ["Note", "address", "amount", "attempts", "count", "customer_id", "ip", "labels", "pair", "send_at", "tags", "tree", "value"]
+		}`, string(schema))
+
+		_, err = ParseSchema(schema)
+		require.NoError(t, err)
+	})
+
+	t.Run("Pointer", func(t *testing.T) {
+		t.Parallel()
+
+		schema, err := Reflect(&reflectAddressArgs{})
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"title": "address",
+			"type": "object",
+			"additionalProperties": false,
+			"properties": {"city": {"type": "string"}},
+			"required": ["city"]
+		}`, string(schema))
+	})
+
+	t.Run("NotStruct", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Reflect(reflectScalarArgs(""))
+		require.EqualError(t, err, "args must be a struct, but got uischema.reflectScalarArgs")
+	})
+
+	t.Run("UnsupportedType", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Reflect(reflectChanArgs{})
+		require.EqualError(t, err, "field Done: unsupported type chan struct {}")
+	})
+}
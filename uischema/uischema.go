@@ -0,0 +1,190 @@
+// Package uischema contains a registry of JSON Schemas describing the args of
+// job kinds, which serve as guard rails for inserting and editing jobs through
+// River UI. A Registry may be configured on a riverui.Handler with
+// HandlerOpts.ArgsSchemas, and its schemas are served from the API so that
+// the frontend can render forms for args and validate them server-side.
+//
+// Schemas can be registered by hand with Register, or derived from the Go
+// type of a job's args with RegisterArgs. Kinds without a schema fall back to
+// editing args as raw JSON, which only needs to be a JSON object.
+package uischema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/riverqueue/river"
+)
+
+// Registry holds the JSON Schemas of job args by kind. It's safe for
+// concurrent use.
+type Registry struct {
+	mu      sync.RWMutex
+	schemas map[string]*Schema
+}
+
+// NewRegistry returns a new, empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{schemas: make(map[string]*Schema)}
+}
+
+// Kinds returns the kinds with a registered schema, sorted by name.
+func (r *Registry) Kinds() []string {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	kinds := make([]string, 0, len(r.schemas))
+	for kind := range r.schemas {
+		kinds = append(kinds, kind)
+	}
+	slices.Sort(kinds)
+	return kinds
+}
+
+// Register registers a JSON Schema for the args of kind, replacing any
+// previously registered for it. It returns an error if the schema isn't
+// valid JSON, uses a keyword that isn't supported, or if a keyword that's
+// validated has an invalid value, like a pattern that isn't a valid regular
+// expression. See ParseSchema.
+func (r *Registry) Register(kind string, schema json.RawMessage) error {
+	if kind == "" {
+		return errors.New("kind is required")
+	}
+
+	parsedSchema, err := ParseSchema(schema)
+	if err != nil {
+		return fmt.Errorf("kind %q: %w", kind, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.schemas[kind] = parsedSchema
+	return nil
+}
+
+// RegisterArgs registers schemas for the kinds of the given job args, deriving
+// each from the args' Go type with Reflect.
+func (r *Registry) RegisterArgs(args ...river.JobArgs) error {
+	for _, arg := range args {
+		schema, err := Reflect(arg)
+		if err != nil {
+			return fmt.Errorf("kind %q: %w", arg.Kind(), err)
+		}
+		if err := r.Register(arg.Kind(), schema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Schema returns the schema registered for kind, or nil if there isn't one.
+// A nil Registry has no schemas.
+func (r *Registry) Schema(kind string) *Schema {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.schemas[kind]
+}
+
+// Validate checks args against the schema registered for kind, returning a
+// *ValidationError describing every violation if they don't match. Args of
+// kinds without a schema only need to be a JSON object. A nil Registry has no
+// schemas.
+func (r *Registry) Validate(kind string, args []byte) error {
+	schema := r.Schema(kind)
+	if schema == nil {
+		var object map[string]any
+		if err := json.Unmarshal(args, &object); err != nil || object == nil {
+			return &ValidationError{Errors: []*FieldError{{Path: "$", Message: "must be a JSON object"}}}
+		}
+		return nil
+	}
+
+	return schema.Validate(args)
+}
+
+// Schema is a parsed JSON Schema. It marshals to the JSON it was parsed from.
+type Schema struct {
+	raw  json.RawMessage
+	root *node
+}
+
+// ParseSchema parses a JSON Schema so that values can be validated against
+// it. Only a subset of JSON Schema keywords are validated (see
+// Schema.Validate), and a schema using any other keyword that constrains
+// values, like `$ref`, `oneOf`, or `patternProperties`, is rejected with an
+// error so that it can't accept values it's meant to disallow. Annotations
+// like `title`, `description`, `examples`, and `$schema` are allowed.
+//
+// A `pattern` is compiled as a Go regular expression (RE2 syntax) rather than
+// an ECMA-262 one, so patterns using features like lookarounds or
+// backreferences are rejected.
+func ParseSchema(schema json.RawMessage) (*Schema, error) {
+	root, err := parseNode(schema, "$")
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{raw: slices.Clone(schema), root: root}, nil
+}
+
+// MarshalJSON returns the JSON the schema was parsed from.
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	return s.raw, nil
+}
+
+// Validate checks that value is valid JSON that matches the schema, returning
+// a *ValidationError describing every violation if it doesn't.
+//
+// The keywords validated are `type`, `enum`, `const`, `properties`,
+// `required`, `additionalProperties`, `items`, `minItems`, `maxItems`,
+// `minLength`, `maxLength`, `pattern`, `minimum`, `maximum`,
+// `exclusiveMinimum`, `exclusiveMaximum`, and the `date-time` `format`, along
+// with boolean schemas. Annotations and formats other than `date-time` are
+// ignored.
+func (s *Schema) Validate(value []byte) error {
+	var decoded any
+	if err := json.Unmarshal(value, &decoded); err != nil {
+		return &ValidationError{Errors: []*FieldError{{Path: "$", Message: "must be valid JSON"}}}
+	}
+
+	var errs []*FieldError
+	s.root.validate("$", decoded, &errs)
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+// FieldError is a single violation of a schema.
+type FieldError struct {
+	// Path is the JSON path to the invalid value, like `$.customer.email`.
+	Path string `json:"path"`
+
+	// Message describes the violation, like "is required".
+	Message string `json:"message"`
+}
+
+// ValidationError is returned when a value doesn't match a schema. It lists
+// every violation found.
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, fieldErr := range e.Errors {
+		messages[i] = fieldErr.Path + ": " + fieldErr.Message
+	}
+	return "args don't match schema: " + strings.Join(messages, "; ")
+}
@@ -0,0 +1,76 @@
+package uischema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type registryArgs struct {
+	Email string `json:"email"`
+}
+
+func (registryArgs) Kind() string { return "registry" }
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	t.Run("RegisterArgs", func(t *testing.T) {
+		t.Parallel()
+
+		registry := NewRegistry()
+		require.NoError(t, registry.RegisterArgs(registryArgs{}))
+		require.Equal(t, []string{"registry"}, registry.Kinds())
+
+		schema := registry.Schema("registry")
+		require.NotNil(t, schema)
+
+		data, err := json.Marshal(schema)
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"title": "registry",
+			"type": "object",
+			"additionalProperties": false,
+			"properties": {"email": {"type": "string"}},
+			"required": ["email"]
+		}`, string(data))
+
+		require.NoError(t, registry.Validate("registry", []byte(`{"email": "a@example.com"}`)))
+		require.EqualError(t, registry.Validate("registry", []byte(`{"emial": "a@example.com"}`)),
+			"args don't match schema: $.email: is required; $.emial: isn't a known property")
+	})
+
+	t.Run("Register", func(t *testing.T) {
+		t.Parallel()
+
+		registry := NewRegistry()
+		require.NoError(t, registry.Register("custom", json.RawMessage(`{"type": "object", "required": ["id"]}`)))
+		require.NoError(t, registry.Register("custom", json.RawMessage(`{"type": "object", "required": ["uuid"]}`)))
+
+		require.EqualError(t, registry.Validate("custom", []byte(`{"id": 1}`)), "args don't match schema: $.uuid: is required")
+
+		require.EqualError(t, registry.Register("", json.RawMessage(`{}`)), "kind is required")
+		require.EqualError(t, registry.Register("bad", json.RawMessage(`[]`)), `kind "bad": invalid schema at $: must be an object or boolean`)
+	})
+
+	t.Run("RegisterArgsError", func(t *testing.T) {
+		t.Parallel()
+
+		require.EqualError(t, NewRegistry().RegisterArgs(reflectChanArgs{}), `kind "chan": field Done: unsupported type chan struct {}`)
+	})
+
+	t.Run("RawJSONFallback", func(t *testing.T) {
+		t.Parallel()
+
+		registry := NewRegistry()
+		require.Nil(t, registry.Schema("unknown"))
+		require.NoError(t, registry.Validate("unknown", []byte(`{"anything": [1, 2]}`)))
+		require.EqualError(t, registry.Validate("unknown", []byte(`[1, 2]`)), "args don't match schema: $: must be a JSON object")
+		require.EqualError(t, registry.Validate("unknown", []byte(`null`)), "args don't match schema: $: must be a JSON object")
+
+		var nilRegistry *Registry
+		require.NoError(t, nilRegistry.Validate("unknown", []byte(`{}`)))
+	})
+}
@@ -0,0 +1,342 @@
+package uischema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// node is a parsed schema or subschema, holding the keywords that are
+// validated.
+type node struct {
+	// never is set for the `false` boolean schema, which no value matches.
+	never bool
+
+	additionalProperties *node
+	constValue           *any
+	enum                 []any
+	exclusiveMaximum     *float64
+	exclusiveMinimum     *float64
+	format               string
+	items                *node
+	maxItems             *int
+	maxLength            *int
+	maximum              *float64
+	minItems             *int
+	minLength            *int
+	minimum              *float64
+	pattern              *regexp.Regexp
+	properties           map[string]*node
+	required             []string
+	types                []string
+}
+
+// rawNode is a schema as decoded from JSON. Keywords that hold subschemas are
+// kept raw so they can be parsed recursively, and because they may also be
+// booleans.
+type rawNode struct {
+	AdditionalProperties json.RawMessage            `json:"additionalProperties"`
+	Const                json.RawMessage            `json:"const"`
+	Enum                 []any                      `json:"enum"`
+	ExclusiveMaximum     *float64                   `json:"exclusiveMaximum"`
+	ExclusiveMinimum     *float64                   `json:"exclusiveMinimum"`
+	Format               string                     `json:"format"`
+	Items                json.RawMessage            `json:"items"`
+	MaxItems             *int                       `json:"maxItems"`
+	MaxLength            *int                       `json:"maxLength"`
+	Maximum              *float64                   `json:"maximum"`
+	MinItems             *int                       `json:"minItems"`
+	MinLength            *int                       `json:"minLength"`
+	Minimum              *float64                   `json:"minimum"`
+	Pattern              *string                    `json:"pattern"`
+	Properties           map[string]json.RawMessage `json:"properties"`
+	Required             []string                   `json:"required"`
+	Type                 json.RawMessage            `json:"type"`
+}
+
+var validTypes = []string{"array", "boolean", "integer", "null", "number", "object", "string"} //nolint:gochecknoglobals
+
+// unsupportedKeywords are JSON Schema keywords that constrain values but
+// aren't validated. Schemas using them are rejected rather than accepting
+// values they'd disallow. Keywords that are only annotations, like `title` or
+// `description`, are allowed and ignored.
+var unsupportedKeywords = []string{ //nolint:gochecknoglobals
+	"$defs",
+	"$dynamicRef",
+	"$recursiveRef",
+	"$ref",
+	"additionalItems",
+	"allOf",
+	"anyOf",
+	"contains",
+	"definitions",
+	"dependencies",
+	"dependentRequired",
+	"dependentSchemas",
+	"else",
+	"if",
+	"maxContains",
+	"maxProperties",
+	"minContains",
+	"minProperties",
+	"multipleOf",
+	"not",
+	"oneOf",
+	"patternProperties",
+	"prefixItems",
+	"propertyNames",
+	"then",
+	"unevaluatedItems",
+	"unevaluatedProperties",
+	"uniqueItems",
+}
+
+func parseNode(data json.RawMessage, path string) (*node, error) {
+	data = bytes.TrimSpace(data)
+	switch {
+	case string(data) == "true":
+		return &node{}, nil
+	case string(data) == "false":
+		return &node{never: true}, nil
+	case !bytes.HasPrefix(data, []byte("{")):
+		return nil, fmt.Errorf("invalid schema at %s: must be an object or boolean", path)
+	}
+
+	var raw rawNode
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid schema at %s: %w", path, err)
+	}
+
+	var keywords map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keywords); err != nil {
+		return nil, fmt.Errorf("invalid schema at %s: %w", path, err)
+	}
+	for _, keyword := range unsupportedKeywords {
+		if _, ok := keywords[keyword]; ok {
+			return nil, fmt.Errorf("invalid schema at %s: keyword %q isn't supported", path, keyword)
+		}
+	}
+
+	n := &node{
+		enum:             raw.Enum,
+		exclusiveMaximum: raw.ExclusiveMaximum,
+		exclusiveMinimum: raw.ExclusiveMinimum,
+		format:           raw.Format,
+		maxItems:         raw.MaxItems,
+		maxLength:        raw.MaxLength,
+		maximum:          raw.Maximum,
+		minItems:         raw.MinItems,
+		minLength:        raw.MinLength,
+		minimum:          raw.Minimum,
+		required:         raw.Required,
+	}
+
+	if len(raw.AdditionalProperties) > 0 {
+		var err error
+		if n.additionalProperties, err = parseNode(raw.AdditionalProperties, path+".additionalProperties"); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(raw.Const) > 0 {
+		var constValue any
+		if err := json.Unmarshal(raw.Const, &constValue); err != nil {
+			return nil, fmt.Errorf("invalid schema at %s: invalid const: %w", path, err)
+		}
+		n.constValue = &constValue
+	}
+
+	if len(raw.Items) > 0 {
+		var err error
+		if n.items, err = parseNode(raw.Items, path+".items"); err != nil {
+			return nil, err
+		}
+	}
+
+	if raw.Pattern != nil {
+		pattern, err := regexp.Compile(*raw.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema at %s: invalid pattern: %w", path, err)
+		}
+		n.pattern = pattern
+	}
+
+	if len(raw.Properties) > 0 {
+		n.properties = make(map[string]*node, len(raw.Properties))
+		for name, propertyData := range raw.Properties {
+			property, err := parseNode(propertyData, fieldPath(path+".properties", name))
+			if err != nil {
+				return nil, err
+			}
+			n.properties[name] = property
+		}
+	}
+
+	if len(raw.Type) > 0 {
+		var typ string
+		if err := json.Unmarshal(raw.Type, &typ); err == nil {
+			n.types = []string{typ}
+		} else if err := json.Unmarshal(raw.Type, &n.types); err != nil {
+			return nil, fmt.Errorf("invalid schema at %s: type must be a string or array of strings", path)
+		}
+		for _, typ := range n.types {
+			if !slices.Contains(validTypes, typ) {
+				return nil, fmt.Errorf("invalid schema at %s: invalid type %q", path, typ)
+			}
+		}
+	}
+
+	return n, nil
+}
+
+// validate appends an error to errs for every way in which value, a value
+// decoded from JSON, doesn't match the schema.
+func (n *node) validate(path string, value any, errs *[]*FieldError) {
+	addErr := func(format string, a ...any) {
+		*errs = append(*errs, &FieldError{Path: path, Message: fmt.Sprintf(format, a...)})
+	}
+
+	if n.never {
+		addErr("isn't allowed")
+		return
+	}
+
+	if len(n.types) > 0 && !slices.ContainsFunc(n.types, func(typ string) bool { return matchesType(typ, value) }) {
+		addErr("must be of type %s", strings.Join(n.types, " or "))
+		return
+	}
+
+	if n.constValue != nil && !reflect.DeepEqual(*n.constValue, value) {
+		addErr("must be %s", mustMarshal(*n.constValue))
+	}
+
+	if n.enum != nil && !slices.ContainsFunc(n.enum, func(enumValue any) bool { return reflect.DeepEqual(enumValue, value) }) {
+		enumValues := make([]string, len(n.enum))
+		for i, enumValue := range n.enum {
+			enumValues[i] = mustMarshal(enumValue)
+		}
+		addErr("must be one of %s", strings.Join(enumValues, ", "))
+	}
+
+	switch value := value.(type) {
+	case []any:
+		if n.minItems != nil && len(value) < *n.minItems {
+			addErr("must have at least %d items", *n.minItems)
+		}
+		if n.maxItems != nil && len(value) > *n.maxItems {
+			addErr("must have at most %d items", *n.maxItems)
+		}
+		if n.items != nil {
+			for i, item := range value {
+				n.items.validate(path+"["+strconv.Itoa(i)+"]", item, errs)
+			}
+		}
+
+	case float64:
+		if n.minimum != nil && value < *n.minimum {
+			addErr("must be at least %s", formatNumber(*n.minimum))
+		}
+		if n.maximum != nil && value > *n.maximum {
+			addErr("must be at most %s", formatNumber(*n.maximum))
+		}
+		if n.exclusiveMinimum != nil && value <= *n.exclusiveMinimum {
+			addErr("must be greater than %s", formatNumber(*n.exclusiveMinimum))
+		}
+		if n.exclusiveMaximum != nil && value >= *n.exclusiveMaximum {
+			addErr("must be less than %s", formatNumber(*n.exclusiveMaximum))
+		}
+
+	case map[string]any:
+		for _, name := range n.required {
+			if _, ok := value[name]; !ok {
+				*errs = append(*errs, &FieldError{Path: fieldPath(path, name), Message: "is required"})
+			}
+		}
+
+		// Properties are validated in order so that errors are deterministic.
+		for _, name := range slices.Sorted(maps.Keys(value)) {
+			if property, ok := n.properties[name]; ok {
+				property.validate(fieldPath(path, name), value[name], errs)
+			} else if n.additionalProperties != nil {
+				if n.additionalProperties.never {
+					*errs = append(*errs, &FieldError{Path: fieldPath(path, name), Message: "isn't a known property"})
+				} else {
+					n.additionalProperties.validate(fieldPath(path, name), value[name], errs)
+				}
+			}
+		}
+
+	case string:
+		if n.minLength != nil && utf8.RuneCountInString(value) < *n.minLength {
+			addErr("must be at least %d characters", *n.minLength)
+		}
+		if n.maxLength != nil && utf8.RuneCountInString(value) > *n.maxLength {
+			addErr("must be at most %d characters", *n.maxLength)
+		}
+		if n.pattern != nil && !n.pattern.MatchString(value) {
+			addErr("must match pattern %q", n.pattern.String())
+		}
+		if n.format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, value); err != nil {
+				addErr("must be an RFC 3339 date-time")
+			}
+		}
+	}
+}
+
+func matchesType(typ string, value any) bool {
+	switch typ {
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case "null":
+		return value == nil
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	}
+	return false
+}
+
+var identifierRE = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`) //nolint:gochecknoglobals
+
+// fieldPath appends a field name to a JSON path, like `$.name`, or
+// `$['first name']` for names that aren't identifiers.
+func fieldPath(path, name string) string {
+	if identifierRE.MatchString(name) {
+		return path + "." + name
+	}
+	return path + "['" + strings.ReplaceAll(name, "'", `\'`) + "']"
+}
+
+func formatNumber(number float64) string {
+	return strconv.FormatFloat(number, 'f', -1, 64)
+}
+
+// Marshals a value decoded from JSON back to JSON for use in messages. Values
+// decoded from JSON can always be encoded again.
+func mustMarshal(value any) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
@@ -0,0 +1,147 @@
+package uischema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSchema(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name    string
+		schema  string
+		wantErr string
+	}{
+		{name: "Object", schema: `{"type": "object"}`},
+		{name: "BooleanTrue", schema: `true`},
+		{name: "BooleanFalse", schema: ` false `},
+		{name: "AnnotationsAllowed", schema: `{"$schema": "https://json-schema.org/draft/2020-12/schema", "title": "Args", "description": "Args.", "examples": [{}], "x-anything": 1}`},
+		{name: "UnsupportedKeyword", schema: `{"oneOf": [{"type": "string"}, {"type": "integer"}]}`, wantErr: `invalid schema at $: keyword "oneOf" isn't supported`},
+		{name: "UnsupportedKeywordRef", schema: `{"$defs": {"a": {"type": "string"}}, "$ref": "#/$defs/a"}`, wantErr: `invalid schema at $: keyword "$defs" isn't supported`},
+		{name: "UnsupportedKeywordNested", schema: `{"properties": {"a": {"patternProperties": {"^x": false}}}}`, wantErr: `invalid schema at $.properties.a: keyword "patternProperties" isn't supported`},
+		{name: "NotObject", schema: `"object"`, wantErr: "invalid schema at $: must be an object or boolean"},
+		{name: "InvalidJSON", schema: `{`, wantErr: "invalid schema at $: unexpected end of JSON input"},
+		{name: "InvalidType", schema: `{"properties": {"a": {"type": "text"}}}`, wantErr: `invalid schema at $.properties.a: invalid type "text"`},
+		{name: "InvalidTypeValue", schema: `{"type": 1}`, wantErr: "invalid schema at $: type must be a string or array of strings"},
+		{name: "InvalidPattern", schema: `{"items": {"pattern": "("}}`, wantErr: "invalid schema at $.items: invalid pattern: error parsing regexp: missing closing ): `(`"},
+		{name: "InvalidAdditionalProperties", schema: `{"additionalProperties": 1}`, wantErr: "invalid schema at $.additionalProperties: must be an object or boolean"},
+		{name: "PropertyNamePath", schema: `{"properties": {"first name": 1}}`, wantErr: "invalid schema at $.properties['first name']: must be an object or boolean"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			schema, err := ParseSchema(json.RawMessage(tt.schema))
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			data, err := json.Marshal(schema)
+			require.NoError(t, err)
+			require.JSONEq(t, tt.schema, string(data))
+		})
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	t.Parallel()
+
+	schema, err := ParseSchema(json.RawMessage(`{
+		"type": "object",
+		"additionalProperties": false,
+		"properties": {
+			"amount": {"type": "number", "minimum": 1, "exclusiveMaximum": 100},
+			"count": {"type": "integer", "maximum": 10, "exclusiveMinimum": 0},
+			"email": {"type": "string", "pattern": "^[^@]+@[^@]+$", "maxLength": 20},
+			"labels": {"type": ["object", "null"], "additionalProperties": {"type": "string", "minLength": 1}},
+			"mode": {"enum": ["fast", "slow"]},
+			"send_at": {"type": "string", "format": "date-time"},
+			"tags": {"type": "array", "items": {"type": "string"}, "minItems": 1, "maxItems": 2},
+			"version": {"const": 2},
+			"forbidden": false
+		},
+		"required": ["email", "mode"]
+	}`))
+	require.NoError(t, err)
+
+	validate := func(t *testing.T, value string) []*FieldError {
+		t.Helper()
+
+		err := schema.Validate([]byte(value))
+		if err == nil {
+			return nil
+		}
+
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		return validationErr.Errors
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		t.Parallel()
+
+		require.Empty(t, validate(t, `{
+			"amount": 99.5,
+			"count": 10,
+			"email": "a@example.com",
+			"labels": {"team": "billing"},
+			"mode": "fast",
+			"send_at": "2025-01-02T03:04:05.123Z",
+			"tags": ["a", "b"],
+			"version": 2
+		}`))
+		require.Empty(t, validate(t, `{"email": "a@example.com", "labels": null, "mode": "slow", "count": 2.0}`))
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+
+		require.Equal(t, []*FieldError{
+			{Path: "$.mode", Message: "is required"},
+			{Path: "$.amount", Message: "must be at least 1"},
+			{Path: "$.count", Message: "must be of type integer"},
+			{Path: "$.email", Message: "must be at most 20 characters"},
+			{Path: "$.email", Message: `must match pattern "^[^@]+@[^@]+$"`},
+			{Path: "$.forbidden", Message: "isn't allowed"},
+			{Path: "$.labels.team", Message: "must be at least 1 characters"},
+			{Path: "$.send_at", Message: "must be an RFC 3339 date-time"},
+			{Path: "$.tags", Message: "must have at most 2 items"},
+			{Path: "$.tags[1]", Message: "must be of type string"},
+			{Path: "$['unknown field']", Message: "isn't a known property"},
+			{Path: "$.version", Message: "must be 2"},
+		}, validate(t, `{
+			"amount": 0.5,
+			"count": 1.5,
+			"email": "not-an-email-address-at-all",
+			"forbidden": 1,
+			"labels": {"team": ""},
+			"send_at": "tomorrow",
+			"tags": ["a", 1, "c"],
+			"unknown field": true,
+			"version": 3
+		}`))
+
+		require.Equal(t, []*FieldError{
+			{Path: "$.amount", Message: "must be less than 100"},
+			{Path: "$.count", Message: "must be greater than 0"},
+			{Path: "$.mode", Message: `must be one of "fast", "slow"`},
+			{Path: "$.tags", Message: "must have at least 1 items"},
+		}, validate(t, `{"amount": 100, "count": 0, "email": "a@b", "mode": "medium", "tags": []}`))
+	})
+
+	t.Run("WrongRootType", func(t *testing.T) {
+		t.Parallel()
+
+		require.Equal(t, []*FieldError{{Path: "$", Message: "must be of type object"}}, validate(t, `[]`))
+	})
+
+	t.Run("InvalidJSON", func(t *testing.T) {
+		t.Parallel()
+
+		require.Equal(t, []*FieldError{{Path: "$", Message: "must be valid JSON"}}, validate(t, `{`))
+	})
+}